@@ -16,6 +16,8 @@ import (
 	"github.com/zqdfound/go-uni-pay/internal/infrastructure/cache"
 	"github.com/zqdfound/go-uni-pay/internal/infrastructure/config"
 	"github.com/zqdfound/go-uni-pay/internal/infrastructure/database"
+	provider "github.com/zqdfound/go-uni-pay/internal/payment"
+	"github.com/zqdfound/go-uni-pay/internal/payment/mock"
 	"github.com/zqdfound/go-uni-pay/internal/service/admin"
 	"github.com/zqdfound/go-uni-pay/internal/service/auth"
 	"github.com/zqdfound/go-uni-pay/internal/service/notify"
@@ -24,9 +26,11 @@ import (
 	"go.uber.org/zap"
 
 	// 导入支付提供商，触发init注册
+	_ "github.com/zqdfound/go-uni-pay/internal/payment/adyen"
 	_ "github.com/zqdfound/go-uni-pay/internal/payment/alipay"
 	_ "github.com/zqdfound/go-uni-pay/internal/payment/paypal"
 	_ "github.com/zqdfound/go-uni-pay/internal/payment/stripe"
+	_ "github.com/zqdfound/go-uni-pay/internal/payment/unionpay"
 	_ "github.com/zqdfound/go-uni-pay/internal/payment/wechat"
 )
 
@@ -84,6 +88,12 @@ func main() {
 
 	logger.Info("redis connected")
 
+	// 沙箱提供商只用于本地开发和测试，release 模式下不注册
+	if config.Cfg.Server.Mode != gin.ReleaseMode {
+		provider.Register(mock.NewProvider())
+		logger.Info("mock provider registered")
+	}
+
 	// 创建仓储
 	db := database.GetDB()
 	userRepo := repository.NewMySQLUserRepository(db)
@@ -93,6 +103,17 @@ func main() {
 	apiLogRepo := repository.NewMySQLAPILogRepository(db)
 	notifyQueueRepo := repository.NewMySQLNotifyQueueRepository(db)
 	adminRepo := repository.NewMySQLAdminRepository(db)
+	refundRepo := repository.NewMySQLRefundRepository(db)
+	payoutRepo := repository.NewMySQLPayoutRepository(db)
+	profitSharingRepo := repository.NewMySQLProfitSharingRepository(db)
+	profitSharingReturnRepo := repository.NewMySQLProfitSharingReturnRepository(db)
+	planRepo := repository.NewMySQLPlanRepository(db)
+	subscriptionRepo := repository.NewMySQLSubscriptionRepository(db)
+	customerRepo := repository.NewMySQLCustomerRepository(db)
+	paymentMethodRepo := repository.NewMySQLPaymentMethodRepository(db)
+	checkoutRepo := repository.NewMySQLCheckoutRepository(db)
+	paymentLinkRepo := repository.NewMySQLPaymentLinkRepository(db)
+	disputeRepo := repository.NewMySQLDisputeRepository(db)
 
 	// 创建服务
 	authService := auth.NewService(userRepo)
@@ -107,14 +128,26 @@ func main() {
 	)
 
 	// 创建支付服务，注入通知服务
-	paymentService := payment.NewService(paymentOrderRepo, paymentConfigRepo, paymentLogRepo, notifyService)
+	paymentService := payment.NewService(paymentOrderRepo, paymentConfigRepo, paymentLogRepo, refundRepo, payoutRepo, profitSharingRepo, profitSharingReturnRepo, planRepo, subscriptionRepo, customerRepo, paymentMethodRepo, checkoutRepo, paymentLinkRepo, disputeRepo, userRepo, notifyService, config.Cfg.Server.BaseURL)
+
+	if err := paymentService.SetNotifySecret(config.Cfg.Server.NotifySecret, config.Cfg.Server.LegacyNotify); err != nil {
+		logger.Fatal("failed to init notify url signing, set server.notify_secret", zap.Error(err))
+	}
 
 	// 启动通知服务
 	notifyService.Start()
 	defer notifyService.Stop()
 
+	// 启动处理中退款、付款、分账的定时同步，以及订阅续费
+	paymentService.StartRefundSync(time.Duration(config.Cfg.Refund.SyncInterval) * time.Second)
+	paymentService.StartPayoutSync(time.Duration(config.Cfg.Payout.SyncInterval) * time.Second)
+	paymentService.StartProfitSharingSync(time.Duration(config.Cfg.ProfitSharing.SyncInterval) * time.Second)
+	paymentService.StartSubscriptionSync(time.Duration(config.Cfg.Subscription.SyncInterval)*time.Second, config.Cfg.Subscription.DunningDurations())
+	defer paymentService.StopSync()
+
 	// 创建处理器
 	paymentHandler := handler.NewPaymentHandler(paymentService)
+	paymentHandler.SetWriteTimeout(config.Cfg.Server.GetWriteTimeout())
 	adminHandler := handler.NewAdminHandler(adminService)
 	managementHandler := handler.NewManagementHandler(
 		userRepo,
@@ -123,6 +156,7 @@ func main() {
 		paymentLogRepo,
 		apiLogRepo,
 		notifyQueueRepo,
+		disputeRepo,
 	)
 
 	// 设置Gin模式
@@ -0,0 +1,6 @@
+-- 支付订单场景字段
+-- 版本: 002
+-- 描述: 支付订单增加支付场景字段（native/jsapi/mini_program/h5/app）
+
+ALTER TABLE `payment_orders`
+  ADD COLUMN `scene` varchar(20) DEFAULT NULL COMMENT '支付场景：native/jsapi/mini_program/h5/app' AFTER `currency`;
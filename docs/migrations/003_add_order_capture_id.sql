@@ -0,0 +1,7 @@
+-- 支付订单扣款ID字段
+-- 版本: 003
+-- 描述: 记录先授权后扣款类支付（如PayPal）的扣款ID，退款时使用
+
+ALTER TABLE `payment_orders`
+  ADD COLUMN `capture_id` varchar(64) DEFAULT NULL COMMENT '扣款ID' AFTER `trade_no`,
+  ADD INDEX `idx_capture_id` (`capture_id`);
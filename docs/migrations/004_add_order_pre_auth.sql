@@ -0,0 +1,8 @@
+-- 支付订单预授权字段
+-- 版本: 004
+-- 描述: 支持先预授权冻结资金、后扣款或撤销的订单
+
+ALTER TABLE `payment_orders`
+  ADD COLUMN `pre_auth` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否预授权订单' AFTER `capture_id`,
+  ADD COLUMN `authorization_id` varchar(64) DEFAULT NULL COMMENT '预授权ID' AFTER `pre_auth`,
+  ADD COLUMN `capture_amount` decimal(10,2) NOT NULL DEFAULT 0.00 COMMENT '预授权扣款金额' AFTER `amount`;
@@ -0,0 +1,26 @@
+-- 争议（拒付）表
+-- 版本: 005
+-- 描述: 记录 Stripe、PayPal 等提供商通过 Webhook 推送的争议和拒付
+
+CREATE TABLE IF NOT EXISTS `disputes` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '争议ID',
+  `order_id` bigint unsigned NOT NULL COMMENT '订单ID',
+  `order_no` varchar(64) NOT NULL COMMENT '订单号',
+  `user_id` bigint unsigned NOT NULL COMMENT '用户ID',
+  `provider` varchar(20) NOT NULL COMMENT '支付提供商',
+  `dispute_id` varchar(64) NOT NULL COMMENT '第三方争议ID',
+  `reason` varchar(64) DEFAULT NULL COMMENT '争议原因',
+  `amount` decimal(10,2) NOT NULL COMMENT '争议金额',
+  `currency` varchar(10) NOT NULL COMMENT '货币类型',
+  `status` varchar(20) NOT NULL COMMENT '状态：needs_response/under_review/won/lost/closed',
+  `evidence_due_by` datetime DEFAULT NULL COMMENT '提交证据截止时间',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_provider_dispute` (`provider`, `dispute_id`),
+  KEY `idx_order_id` (`order_id`),
+  KEY `idx_order_no` (`order_no`),
+  KEY `idx_user_id` (`user_id`),
+  KEY `idx_status` (`status`),
+  KEY `idx_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='争议表';
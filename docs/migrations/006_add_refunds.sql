@@ -0,0 +1,29 @@
+-- 退款表
+-- 版本: 006
+-- 描述: 记录退款及其状态，退款结果由第三方通知或主动查询更新
+
+CREATE TABLE IF NOT EXISTS `refunds` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '退款ID',
+  `refund_no` varchar(64) NOT NULL COMMENT '退款单号',
+  `order_id` bigint unsigned NOT NULL COMMENT '订单ID',
+  `order_no` varchar(64) NOT NULL COMMENT '订单号',
+  `user_id` bigint unsigned NOT NULL COMMENT '用户ID',
+  `provider` varchar(20) NOT NULL COMMENT '支付提供商',
+  `config_id` bigint unsigned NOT NULL COMMENT '支付配置ID',
+  `refund_id` varchar(64) DEFAULT NULL COMMENT '第三方退款单号',
+  `amount` decimal(10,2) NOT NULL COMMENT '退款金额',
+  `currency` varchar(10) NOT NULL COMMENT '货币类型',
+  `reason` varchar(256) DEFAULT NULL COMMENT '退款原因',
+  `status` varchar(20) NOT NULL DEFAULT 'processing' COMMENT '状态：processing/success/failed',
+  `refund_time` datetime DEFAULT NULL COMMENT '退款成功时间',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_refund_no` (`refund_no`),
+  KEY `idx_order_id` (`order_id`),
+  KEY `idx_order_no` (`order_no`),
+  KEY `idx_user_id` (`user_id`),
+  KEY `idx_refund_id` (`refund_id`),
+  KEY `idx_status` (`status`),
+  KEY `idx_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='退款表';
@@ -0,0 +1,33 @@
+-- 付款表
+-- 版本: 007
+-- 描述: 记录平台向收款方的付款（转账），付款结果由第三方通知或主动查询更新
+
+CREATE TABLE IF NOT EXISTS `payouts` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '付款ID',
+  `payout_no` varchar(64) NOT NULL COMMENT '付款单号',
+  `user_id` bigint unsigned NOT NULL COMMENT '用户ID',
+  `out_payout_no` varchar(64) NOT NULL COMMENT '商户付款单号',
+  `provider` varchar(20) NOT NULL COMMENT '支付提供商',
+  `config_id` bigint unsigned NOT NULL COMMENT '支付配置ID',
+  `payout_id` varchar(64) DEFAULT NULL COMMENT '第三方付款单号',
+  `amount` decimal(10,2) NOT NULL COMMENT '付款金额',
+  `currency` varchar(10) NOT NULL COMMENT '货币类型',
+  `payee_type` varchar(20) DEFAULT NULL COMMENT '收款方账户类型',
+  `payee_account` varchar(128) NOT NULL COMMENT '收款方账户',
+  `payee_name` varchar(64) DEFAULT NULL COMMENT '收款方姓名',
+  `remark` varchar(256) DEFAULT NULL COMMENT '付款备注',
+  `notify_url` varchar(512) DEFAULT NULL COMMENT '商户通知URL',
+  `status` varchar(20) NOT NULL DEFAULT 'processing' COMMENT '状态：processing/success/failed',
+  `fail_reason` varchar(256) DEFAULT NULL COMMENT '失败原因',
+  `finish_time` datetime DEFAULT NULL COMMENT '付款成功时间',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_payout_no` (`payout_no`),
+  UNIQUE KEY `idx_user_out_payout` (`user_id`, `out_payout_no`),
+  KEY `idx_user_id` (`user_id`),
+  KEY `idx_provider` (`provider`),
+  KEY `idx_payout_id` (`payout_id`),
+  KEY `idx_status` (`status`),
+  KEY `idx_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='付款表';
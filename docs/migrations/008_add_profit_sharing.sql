@@ -0,0 +1,63 @@
+-- 分账表
+-- 版本: 008
+-- 描述: 支持平台类商户将订单资金分给其他接收方，以及分账回退
+
+ALTER TABLE `payment_orders`
+  ADD COLUMN `profit_sharing` JSON DEFAULT NULL COMMENT '分账计划' AFTER `extra_data`;
+
+CREATE TABLE IF NOT EXISTS `profit_sharings` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '分账ID',
+  `sharing_no` varchar(64) NOT NULL COMMENT '分账单号',
+  `user_id` bigint unsigned NOT NULL COMMENT '用户ID',
+  `out_sharing_no` varchar(64) NOT NULL COMMENT '商户分账单号',
+  `order_id` bigint unsigned NOT NULL COMMENT '订单ID',
+  `order_no` varchar(64) NOT NULL COMMENT '订单号',
+  `provider` varchar(20) NOT NULL COMMENT '支付提供商',
+  `config_id` bigint unsigned NOT NULL COMMENT '支付配置ID',
+  `sharing_id` varchar(64) DEFAULT NULL COMMENT '第三方分账单号',
+  `amount` decimal(10,2) NOT NULL COMMENT '分账总金额',
+  `currency` varchar(10) NOT NULL COMMENT '货币类型',
+  `receivers` JSON NOT NULL COMMENT '分账接收方及结果',
+  `finish` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否完结分账（解冻剩余资金）',
+  `status` varchar(20) NOT NULL DEFAULT 'processing' COMMENT '状态：processing/success/failed',
+  `fail_reason` varchar(256) DEFAULT NULL COMMENT '失败原因',
+  `finish_time` datetime DEFAULT NULL COMMENT '分账完成时间',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_sharing_no` (`sharing_no`),
+  UNIQUE KEY `idx_user_out_sharing` (`user_id`, `out_sharing_no`),
+  KEY `idx_user_id` (`user_id`),
+  KEY `idx_order_id` (`order_id`),
+  KEY `idx_order_no` (`order_no`),
+  KEY `idx_status` (`status`),
+  KEY `idx_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='分账表';
+
+CREATE TABLE IF NOT EXISTS `profit_sharing_returns` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '分账回退ID',
+  `return_no` varchar(64) NOT NULL COMMENT '回退单号',
+  `user_id` bigint unsigned NOT NULL COMMENT '用户ID',
+  `out_return_no` varchar(64) NOT NULL COMMENT '商户回退单号',
+  `sharing_no` varchar(64) NOT NULL COMMENT '分账单号',
+  `order_no` varchar(64) NOT NULL COMMENT '订单号',
+  `provider` varchar(20) NOT NULL COMMENT '支付提供商',
+  `config_id` bigint unsigned NOT NULL COMMENT '支付配置ID',
+  `return_id` varchar(64) DEFAULT NULL COMMENT '第三方回退单号',
+  `account` varchar(128) NOT NULL COMMENT '回退的分账接收方账户',
+  `amount` decimal(10,2) NOT NULL COMMENT '回退金额',
+  `currency` varchar(10) NOT NULL COMMENT '货币类型',
+  `description` varchar(256) DEFAULT NULL COMMENT '回退描述',
+  `status` varchar(20) NOT NULL DEFAULT 'processing' COMMENT '状态：processing/success/failed',
+  `fail_reason` varchar(256) DEFAULT NULL COMMENT '失败原因',
+  `finish_time` datetime DEFAULT NULL COMMENT '回退完成时间',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_return_no` (`return_no`),
+  UNIQUE KEY `idx_user_out_return` (`user_id`, `out_return_no`),
+  KEY `idx_user_id` (`user_id`),
+  KEY `idx_sharing_no` (`sharing_no`),
+  KEY `idx_status` (`status`),
+  KEY `idx_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='分账回退表';
@@ -0,0 +1,64 @@
+-- 订阅计划和订阅表
+-- 版本: 009
+-- 描述: 支持按周期续费的订阅，续费由提供商自行扣款（Stripe、PayPal）或由本服务按账单日扣款（支付宝周期扣款）
+
+CREATE TABLE IF NOT EXISTS `plans` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '计划ID',
+  `plan_no` varchar(64) NOT NULL COMMENT '计划号',
+  `user_id` bigint unsigned NOT NULL COMMENT '用户ID',
+  `out_plan_no` varchar(64) NOT NULL COMMENT '商户计划号',
+  `name` varchar(128) NOT NULL COMMENT '计划名称',
+  `description` varchar(256) DEFAULT NULL COMMENT '计划描述',
+  `amount` decimal(10,2) NOT NULL COMMENT '每期金额',
+  `currency` varchar(10) NOT NULL COMMENT '货币类型',
+  `billing_interval` varchar(10) NOT NULL COMMENT '计费周期：day/week/month/year',
+  `interval_count` int NOT NULL DEFAULT 1 COMMENT '每期包含的周期数',
+  `trial_days` int NOT NULL DEFAULT 0 COMMENT '试用天数',
+  `status` varchar(20) NOT NULL DEFAULT 'active' COMMENT '状态：active/inactive',
+  `provider_plans` JSON DEFAULT NULL COMMENT '提供商侧计划ID，键为 provider:config_id',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_plan_no` (`plan_no`),
+  UNIQUE KEY `idx_user_out_plan` (`user_id`, `out_plan_no`),
+  KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='订阅计划表';
+
+CREATE TABLE IF NOT EXISTS `subscriptions` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '订阅ID',
+  `subscription_no` varchar(64) NOT NULL COMMENT '订阅单号',
+  `user_id` bigint unsigned NOT NULL COMMENT '用户ID',
+  `out_subscription_no` varchar(64) NOT NULL COMMENT '商户订阅号',
+  `plan_id` bigint unsigned NOT NULL COMMENT '计划ID',
+  `plan_no` varchar(64) NOT NULL COMMENT '计划号',
+  `provider` varchar(20) NOT NULL COMMENT '支付提供商',
+  `config_id` bigint unsigned NOT NULL COMMENT '支付配置ID',
+  `subscription_id` varchar(128) DEFAULT NULL COMMENT '第三方订阅ID（支付宝为签约协议号）',
+  `amount` decimal(10,2) NOT NULL COMMENT '每期金额',
+  `currency` varchar(10) NOT NULL COMMENT '货币类型',
+  `billing_interval` varchar(10) NOT NULL COMMENT '计费周期：day/week/month/year',
+  `interval_count` int NOT NULL DEFAULT 1 COMMENT '每期包含的周期数',
+  `trial_days` int NOT NULL DEFAULT 0 COMMENT '试用天数',
+  `status` varchar(20) NOT NULL DEFAULT 'pending' COMMENT '状态：pending/trialing/active/past_due/canceled',
+  `payment_url` text COMMENT '买家签约或授权页面',
+  `trial_end` datetime DEFAULT NULL COMMENT '试用结束时间',
+  `current_period_start` datetime DEFAULT NULL COMMENT '当前周期开始时间',
+  `current_period_end` datetime DEFAULT NULL COMMENT '当前周期结束时间',
+  `next_billing_time` datetime DEFAULT NULL COMMENT '下次处理时间（扣款、重试或同步）',
+  `cycles` int NOT NULL DEFAULT 0 COMMENT '已扣款期数',
+  `retry_count` int NOT NULL DEFAULT 0 COMMENT '本期扣款失败次数',
+  `last_order_no` varchar(64) DEFAULT NULL COMMENT '处理中的续费订单号',
+  `notify_url` varchar(512) DEFAULT NULL COMMENT '商户通知地址',
+  `return_url` varchar(512) DEFAULT NULL COMMENT '签约完成后的跳转地址',
+  `fail_reason` varchar(256) DEFAULT NULL COMMENT '失败或取消原因',
+  `canceled_at` datetime DEFAULT NULL COMMENT '取消时间',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_subscription_no` (`subscription_no`),
+  UNIQUE KEY `idx_user_out_subscription` (`user_id`, `out_subscription_no`),
+  KEY `idx_user_id` (`user_id`),
+  KEY `idx_plan_id` (`plan_id`),
+  KEY `idx_subscription_id` (`subscription_id`),
+  KEY `idx_status_next_billing` (`status`, `next_billing_time`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='订阅表';
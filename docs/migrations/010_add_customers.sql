@@ -0,0 +1,53 @@
+-- 客户和支付方式表
+-- 版本: 010
+-- 描述: 支持为商户的买家保存支付方式（Stripe 银行卡、PayPal 授权、支付宝代扣协议），并使用已保存的支付方式免密扣款
+
+ALTER TABLE `payment_orders`
+  ADD COLUMN `payment_method_no` varchar(64) DEFAULT NULL COMMENT '扣款使用的已保存支付方式单号' AFTER `profit_sharing`,
+  ADD KEY `idx_payment_method_no` (`payment_method_no`);
+
+CREATE TABLE IF NOT EXISTS `customers` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '客户ID',
+  `customer_no` varchar(64) NOT NULL COMMENT '客户单号',
+  `user_id` bigint unsigned NOT NULL COMMENT '用户ID',
+  `out_customer_no` varchar(64) NOT NULL COMMENT '商户客户号',
+  `name` varchar(128) DEFAULT NULL COMMENT '客户姓名',
+  `email` varchar(128) DEFAULT NULL COMMENT '客户邮箱',
+  `phone` varchar(32) DEFAULT NULL COMMENT '客户手机号',
+  `provider_customers` JSON DEFAULT NULL COMMENT '第三方客户ID，键为 provider:config_id',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_customer_no` (`customer_no`),
+  UNIQUE KEY `idx_user_out_customer` (`user_id`, `out_customer_no`),
+  KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='客户表';
+
+CREATE TABLE IF NOT EXISTS `payment_methods` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '支付方式ID',
+  `method_no` varchar(64) NOT NULL COMMENT '支付方式单号',
+  `user_id` bigint unsigned NOT NULL COMMENT '用户ID',
+  `customer_id` bigint unsigned NOT NULL COMMENT '客户ID',
+  `customer_no` varchar(64) NOT NULL COMMENT '客户单号',
+  `provider` varchar(20) NOT NULL COMMENT '支付提供商',
+  `config_id` bigint unsigned NOT NULL COMMENT '支付配置ID',
+  `setup_id` varchar(128) DEFAULT NULL COMMENT '第三方绑定流程ID',
+  `method_id` varchar(128) DEFAULT NULL COMMENT '第三方支付方式ID（支付宝为代扣协议号）',
+  `type` varchar(20) DEFAULT NULL COMMENT '类型：card/paypal/alipay',
+  `brand` varchar(20) DEFAULT NULL COMMENT '卡组织',
+  `last4` varchar(4) DEFAULT NULL COMMENT '卡号后四位',
+  `exp_month` int NOT NULL DEFAULT 0 COMMENT '有效期月',
+  `exp_year` int NOT NULL DEFAULT 0 COMMENT '有效期年',
+  `account` varchar(128) DEFAULT NULL COMMENT '账户标识（PayPal 邮箱、支付宝登录号）',
+  `status` varchar(20) NOT NULL DEFAULT 'pending' COMMENT '状态：pending/active/canceled',
+  `setup_url` text COMMENT '买家绑定页面',
+  `notify_url` varchar(512) DEFAULT NULL COMMENT '商户通知地址',
+  `return_url` varchar(512) DEFAULT NULL COMMENT '绑定完成后的跳转地址',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_method_no` (`method_no`),
+  KEY `idx_user_id` (`user_id`),
+  KEY `idx_customer_id` (`customer_id`),
+  KEY `idx_method_id` (`method_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='支付方式表';
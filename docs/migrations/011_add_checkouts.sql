@@ -0,0 +1,31 @@
+-- 托管收银台表
+-- 版本: 011
+-- 描述: 商户创建收银台后由买家在本服务的收银台页面选择提供商，选择后才创建支付订单
+
+CREATE TABLE IF NOT EXISTS `checkouts` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '收银台ID',
+  `checkout_no` varchar(64) NOT NULL COMMENT '收银台单号',
+  `user_id` bigint unsigned NOT NULL COMMENT '用户ID',
+  `out_trade_no` varchar(64) NOT NULL COMMENT '商户订单号，买家选择后创建的订单使用同一订单号',
+  `subject` varchar(256) NOT NULL COMMENT '订单标题',
+  `body` text COMMENT '订单描述',
+  `amount` decimal(10,2) NOT NULL COMMENT '金额',
+  `currency` varchar(10) NOT NULL DEFAULT 'CNY' COMMENT '货币',
+  `providers` JSON DEFAULT NULL COMMENT '允许的提供商，为空时为所有启用的提供商',
+  `provider` varchar(20) DEFAULT NULL COMMENT '买家选择的提供商',
+  `order_no` varchar(64) DEFAULT NULL COMMENT '买家选择后创建的订单号',
+  `status` varchar(20) NOT NULL DEFAULT 'open' COMMENT '状态：open/paying/expired',
+  `checkout_url` varchar(512) DEFAULT NULL COMMENT '收银台地址',
+  `payment_url` text COMMENT '所选提供商的支付链接',
+  `qr_code` text COMMENT '所选提供商的二维码内容',
+  `notify_url` varchar(512) DEFAULT NULL COMMENT '商户通知地址',
+  `return_url` varchar(512) DEFAULT NULL COMMENT '支付成功后的跳转地址',
+  `expire_at` datetime DEFAULT NULL COMMENT '过期时间',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_checkout_no` (`checkout_no`),
+  UNIQUE KEY `idx_user_out_checkout` (`user_id`, `out_trade_no`),
+  KEY `idx_user_id` (`user_id`),
+  KEY `idx_order_no` (`order_no`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='托管收银台表';
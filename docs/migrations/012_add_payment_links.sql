@@ -0,0 +1,32 @@
+-- 支付链接表
+-- 版本: 012
+-- 描述: 商户无需开发即可分享支付链接，买家每次通过链接支付都会创建一个订单，支付成功的订单汇总到链接
+
+ALTER TABLE `payment_orders`
+  ADD COLUMN `payment_link_no` varchar(64) DEFAULT NULL COMMENT '创建订单的支付链接单号' AFTER `payment_method_no`,
+  ADD KEY `idx_payment_link_no` (`payment_link_no`);
+
+CREATE TABLE IF NOT EXISTS `payment_links` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '支付链接ID',
+  `link_no` varchar(64) NOT NULL COMMENT '支付链接单号',
+  `user_id` bigint unsigned NOT NULL COMMENT '用户ID',
+  `subject` varchar(256) NOT NULL COMMENT '标题',
+  `description` text COMMENT '描述',
+  `amount` decimal(10,2) NOT NULL DEFAULT 0 COMMENT '固定金额，买家填写金额时为0',
+  `custom_amount` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否由买家填写金额',
+  `currency` varchar(10) NOT NULL DEFAULT 'CNY' COMMENT '货币',
+  `providers` JSON DEFAULT NULL COMMENT '允许的提供商，为空时为所有启用的提供商',
+  `expire_at` datetime DEFAULT NULL COMMENT '过期时间',
+  `max_uses` int NOT NULL DEFAULT 0 COMMENT '最大支付次数，0为不限',
+  `paid_count` int NOT NULL DEFAULT 0 COMMENT '支付成功次数',
+  `paid_amount` decimal(12,2) NOT NULL DEFAULT 0 COMMENT '支付成功总金额',
+  `status` varchar(20) NOT NULL DEFAULT 'active' COMMENT '状态：active/inactive/completed',
+  `link_url` varchar(512) DEFAULT NULL COMMENT '支付链接地址',
+  `notify_url` varchar(512) DEFAULT NULL COMMENT '商户通知地址',
+  `return_url` varchar(512) DEFAULT NULL COMMENT '支付完成后的跳转地址',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_link_no` (`link_no`),
+  KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='支付链接表';
@@ -0,0 +1,6 @@
+-- 订单二维码
+-- 版本: 013
+-- 描述: 保存扫码支付返回的二维码内容，用于生成二维码图片
+
+ALTER TABLE `payment_orders`
+  ADD COLUMN `qr_code` text DEFAULT NULL COMMENT '扫码支付的二维码内容' AFTER `client_ip`;
@@ -0,0 +1,7 @@
+-- 同步跳转签名密钥
+-- 版本: 014
+-- 描述: 买家支付后经本服务确认支付结果再跳转到商户页面，跳转参数使用商户的签名密钥签名
+-- 已有用户的密钥在首次使用时自动生成，也可通过 GET /api/v1/payment/sign-key 获取
+
+ALTER TABLE `users`
+  ADD COLUMN `sign_key` varchar(64) DEFAULT NULL COMMENT '跳转参数签名密钥' AFTER `api_secret`;
@@ -0,0 +1,8 @@
+-- 支付配置的提供商账号
+-- 版本: 015
+-- 描述: 不带配置令牌的通知地址 /api/v1/public/notify/:provider 按通知中的提供商账号（支付宝 app_id、Stripe account 等）识别支付配置
+-- 已有配置的账号在首次收到不带令牌的通知时根据 config_data 自动补齐
+
+ALTER TABLE `payment_configs`
+  ADD COLUMN `account_id` varchar(128) DEFAULT NULL COMMENT '提供商账号' AFTER `config_data`,
+  ADD INDEX `idx_provider_account` (`provider`, `account_id`);
@@ -0,0 +1,7 @@
+-- 订单支付信息
+-- 版本: 016
+-- 描述: 保存提供商返回的支付链接、表单、额外数据和过期时间，重复创建订单时返回已有的支付信息，过期后重新获取
+-- 已有订单没有支付信息，重复创建时查询订单状态后重新获取
+
+ALTER TABLE `payment_orders`
+  ADD COLUMN `payment_data` json DEFAULT NULL COMMENT '提供商返回的支付信息（支付链接、表单、额外数据、过期时间）' AFTER `qr_code`;
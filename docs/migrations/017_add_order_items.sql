@@ -0,0 +1,9 @@
+-- 订单商品明细
+-- 版本: 017
+-- 描述: 保存创建支付时传入的商品明细、买家和收货信息，传递给提供商用于风控、结账页展示和发货
+-- 已有订单没有商品明细，重新获取支付信息时不传递
+
+ALTER TABLE `payment_orders`
+  ADD COLUMN `items` json DEFAULT NULL COMMENT '商品明细' AFTER `extra_data`,
+  ADD COLUMN `buyer` json DEFAULT NULL COMMENT '买家信息' AFTER `items`,
+  ADD COLUMN `shipping` json DEFAULT NULL COMMENT '收货信息' AFTER `buyer`;
@@ -0,0 +1,7 @@
+-- 订阅账单日
+-- 版本: 018
+-- 描述: 记录按月、按年计费的账单日，短月取月末后下一期仍回到原账单日（如 1 月 31 日、2 月 28 日、3 月 31 日）
+-- 已有订阅为 0，在下一次续费时按当前周期结束日期设置
+
+ALTER TABLE `subscriptions`
+  ADD COLUMN `billing_day` int NOT NULL DEFAULT 0 COMMENT '按月、按年计费的账单日，0 表示尚未开始续费' AFTER `next_billing_time`;
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"html/template"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zqdfound/go-uni-pay/internal/domain/entity"
+	paymentService "github.com/zqdfound/go-uni-pay/internal/service/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+)
+
+// checkoutPage 托管收银台页面
+// 等待买家支付时每5秒刷新一次，展示最新的订单状态
+var checkoutPage = template.Must(template.New("checkout").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+{{if .Waiting}}<meta http-equiv="refresh" content="5">{{end}}
+<title>Checkout</title>
+</head>
+<body>
+<h2>{{.Checkout.Subject}}</h2>
+<table>
+<tr><td>Order</td><td>{{.Checkout.OutTradeNo}}</td></tr>
+<tr><td>Amount</td><td>{{printf "%.2f" .Checkout.Amount}} {{.Checkout.Currency}}</td></tr>
+</table>
+{{if .Error}}<p>{{.Error}}</p>{{end}}
+{{if .Order}}
+<p>Paying with {{.Checkout.Provider}}, status: {{.Order.Status}}</p>
+{{if .Waiting}}
+{{if .Checkout.QRCode}}<p>Scan the code with {{.Checkout.Provider}} to pay:</p>
+<p><img src="/api/v1/payment/qrcode/{{.Checkout.OrderNo}}.png" alt="QR code"></p>
+{{else if .Checkout.PaymentURL}}<p><a href="{{.Checkout.PaymentURL}}">Continue to pay</a></p>{{end}}
+{{end}}
+{{if .Checkout.ReturnURL}}<p><a href="{{.Checkout.ReturnURL}}">Back to merchant</a></p>{{end}}
+{{else if eq .Checkout.Status "expired"}}
+<p>This checkout has expired.</p>
+{{else if .Providers}}
+<form method="post">
+{{range .Providers}}<p><button type="submit" name="provider" value="{{.}}">{{.}}</button></p>
+{{end}}
+</form>
+{{else}}
+<p>No payment method is available.</p>
+{{end}}
+</body>
+</html>
+`))
+
+// checkoutPageData 收银台页面模板数据
+type checkoutPageData struct {
+	*paymentService.CheckoutPage
+	Waiting bool
+	Error   string
+}
+
+// CreateCheckoutRequest 创建收银台请求
+type CreateCheckoutRequest struct {
+	OutTradeNo    string   `json:"out_trade_no" binding:"required,max=64"`
+	Subject       string   `json:"subject" binding:"required,max=256"`
+	Body          string   `json:"body"`
+	Amount        float64  `json:"amount" binding:"required,gt=0"`
+	Currency      string   `json:"currency"`
+	Providers     []string `json:"providers"`
+	NotifyURL     string   `json:"notify_url"`
+	ReturnURL     string   `json:"return_url"`
+	ExpireMinutes int      `json:"expire_minutes" binding:"gte=0"`
+}
+
+// CreateCheckout 创建托管收银台，同一 out_trade_no 重复请求返回已有收银台
+func (h *PaymentHandler) CreateCheckout(c *gin.Context) {
+	var req CreateCheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"code":    apperrors.ErrInvalidParam,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if req.Currency == "" {
+		req.Currency = "CNY"
+	}
+
+	checkout, err := h.paymentService.CreateCheckout(c.Request.Context(), &paymentService.CreateCheckoutRequest{
+		UserID:        userID.(uint64),
+		OutTradeNo:    req.OutTradeNo,
+		Subject:       req.Subject,
+		Body:          req.Body,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		Providers:     req.Providers,
+		NotifyURL:     req.NotifyURL,
+		ReturnURL:     req.ReturnURL,
+		ExpireMinutes: req.ExpireMinutes,
+	})
+	h.respond(c, checkout, err)
+}
+
+// QueryCheckout 查询收银台
+func (h *PaymentHandler) QueryCheckout(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	checkout, err := h.paymentService.QueryCheckout(c.Request.Context(), userID.(uint64), c.Param("checkout_no"))
+	h.respond(c, checkout, err)
+}
+
+// CheckoutPage 展示托管收银台，支付成功后跳转到商户的 return_url
+func (h *PaymentHandler) CheckoutPage(c *gin.Context) {
+	page, err := h.paymentService.GetCheckoutPage(c.Request.Context(), c.Param("checkout_no"))
+	if err != nil {
+		c.String(404, "checkout not found")
+		return
+	}
+
+	if page.Order != nil && page.Order.Status == entity.OrderStatusSuccess && page.Checkout.ReturnURL != "" {
+		c.Redirect(302, page.Checkout.ReturnURL)
+		return
+	}
+
+	h.renderCheckout(c, page, "")
+}
+
+// PayCheckout 买家在收银台选择提供商，创建支付后跳转到提供商的支付页面
+func (h *PaymentHandler) PayCheckout(c *gin.Context) {
+	ctx := c.Request.Context()
+	checkoutNo := c.Param("checkout_no")
+
+	checkout, err := h.paymentService.PayCheckout(ctx, checkoutNo, c.PostForm("provider"), c.ClientIP(), isMobile(c.Request.UserAgent()))
+	if err == nil {
+		if checkout.PaymentURL != "" && checkout.QRCode == "" {
+			c.Redirect(302, checkout.PaymentURL)
+			return
+		}
+		c.Redirect(302, checkout.URL)
+		return
+	}
+
+	page, pageErr := h.paymentService.GetCheckoutPage(ctx, checkoutNo)
+	if pageErr != nil {
+		c.String(404, "checkout not found")
+		return
+	}
+
+	message := "failed to create payment, please retry"
+	if appErr, ok := err.(*apperrors.AppError); ok {
+		message = appErr.Message
+	}
+	h.renderCheckout(c, page, message)
+}
+
+// renderCheckout 渲染收银台页面
+func (h *PaymentHandler) renderCheckout(c *gin.Context, page *paymentService.CheckoutPage, message string) {
+	data := &checkoutPageData{
+		CheckoutPage: page,
+		Waiting: page.Order != nil &&
+			(page.Order.Status == entity.OrderStatusPending || page.Order.Status == entity.OrderStatusProcessing),
+		Error: message,
+	}
+
+	c.Status(200)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := checkoutPage.Execute(c.Writer, data); err != nil {
+		c.String(500, "error")
+	}
+}
+
+// isMobile 根据 User-Agent 判断买家是否使用手机访问
+func isMobile(userAgent string) bool {
+	for _, keyword := range []string{"Mobile", "Android", "iPhone", "iPad"} {
+		if strings.Contains(userAgent, keyword) {
+			return true
+		}
+	}
+	return false
+}
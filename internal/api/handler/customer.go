@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	paymentService "github.com/zqdfound/go-uni-pay/internal/service/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+)
+
+// CreateCustomerRequest 创建客户请求
+type CreateCustomerRequest struct {
+	OutCustomerNo string `json:"out_customer_no" binding:"required,max=64"`
+	Name          string `json:"name" binding:"max=128"`
+	Email         string `json:"email" binding:"omitempty,email,max=128"`
+	Phone         string `json:"phone" binding:"max=32"`
+}
+
+// CreateCustomer 创建客户，同一 out_customer_no 重复请求返回已有客户
+func (h *PaymentHandler) CreateCustomer(c *gin.Context) {
+	var req CreateCustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"code":    apperrors.ErrInvalidParam,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	customer, err := h.paymentService.CreateCustomer(c.Request.Context(), &paymentService.CreateCustomerRequest{
+		UserID:        userID.(uint64),
+		OutCustomerNo: req.OutCustomerNo,
+		Name:          req.Name,
+		Email:         req.Email,
+		Phone:         req.Phone,
+	})
+	h.respond(c, customer, err)
+}
+
+// QueryCustomer 查询客户
+func (h *PaymentHandler) QueryCustomer(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	customer, err := h.paymentService.QueryCustomer(c.Request.Context(), userID.(uint64), c.Param("customer_no"))
+	h.respond(c, customer, err)
+}
+
+// CreatePaymentMethodRequest 绑定支付方式请求
+type CreatePaymentMethodRequest struct {
+	CustomerNo string `json:"customer_no" binding:"required"`
+	Provider   string `json:"provider" binding:"required"`
+	NotifyURL  string `json:"notify_url"`
+	ReturnURL  string `json:"return_url"`
+}
+
+// CreatePaymentMethod 绑定支付方式，返回买家绑定页面
+func (h *PaymentHandler) CreatePaymentMethod(c *gin.Context) {
+	var req CreatePaymentMethodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"code":    apperrors.ErrInvalidParam,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	method, err := h.paymentService.CreatePaymentMethod(c.Request.Context(), &paymentService.CreatePaymentMethodRequest{
+		UserID:     userID.(uint64),
+		CustomerNo: req.CustomerNo,
+		Provider:   req.Provider,
+		NotifyURL:  req.NotifyURL,
+		ReturnURL:  req.ReturnURL,
+	})
+	h.respond(c, method, err)
+}
+
+// ListPaymentMethods 查询客户的支付方式
+func (h *PaymentHandler) ListPaymentMethods(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	methods, err := h.paymentService.ListPaymentMethods(c.Request.Context(), userID.(uint64), c.Param("customer_no"))
+	h.respond(c, methods, err)
+}
+
+// DeletePaymentMethodRequest 删除支付方式请求
+type DeletePaymentMethodRequest struct {
+	PaymentMethodNo string `json:"payment_method_no" binding:"required"`
+}
+
+// DeletePaymentMethod 删除支付方式
+func (h *PaymentHandler) DeletePaymentMethod(c *gin.Context) {
+	var req DeletePaymentMethodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"code":    apperrors.ErrInvalidParam,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	method, err := h.paymentService.DeletePaymentMethod(c.Request.Context(), userID.(uint64), req.PaymentMethodNo)
+	h.respond(c, method, err)
+}
@@ -16,6 +16,7 @@ type ManagementHandler struct {
 	paymentLogRepo  repository.PaymentLogRepository
 	apiLogRepo      repository.APILogRepository
 	notifyQueueRepo repository.NotifyQueueRepository
+	disputeRepo     repository.DisputeRepository
 }
 
 // NewManagementHandler 创建管理后台处理器
@@ -26,6 +27,7 @@ func NewManagementHandler(
 	paymentLogRepo repository.PaymentLogRepository,
 	apiLogRepo repository.APILogRepository,
 	notifyQueueRepo repository.NotifyQueueRepository,
+	disputeRepo repository.DisputeRepository,
 ) *ManagementHandler {
 	return &ManagementHandler{
 		userRepo:        userRepo,
@@ -34,6 +36,7 @@ func NewManagementHandler(
 		paymentLogRepo:  paymentLogRepo,
 		apiLogRepo:      apiLogRepo,
 		notifyQueueRepo: notifyQueueRepo,
+		disputeRepo:     disputeRepo,
 	}
 }
 
@@ -246,6 +249,77 @@ func (h *ManagementHandler) GetOrder(c *gin.Context) {
 	})
 }
 
+// ListDisputes 获取争议列表
+func (h *ManagementHandler) ListDisputes(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	userID, _ := strconv.ParseUint(c.DefaultQuery("user_id", "0"), 10, 64)
+	status := c.Query("status")
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	disputes, total, err := h.disputeRepo.List(c.Request.Context(), userID, status, page, pageSize)
+	if err != nil {
+		c.JSON(500, gin.H{
+			"code":    apperrors.ErrInternalServer,
+			"message": "failed to list disputes",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"code":    apperrors.ErrSuccess,
+		"message": "success",
+		"data": gin.H{
+			"list":      disputes,
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// GetDispute 获取争议详情
+func (h *ManagementHandler) GetDispute(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"code":    apperrors.ErrInvalidParam,
+			"message": "invalid id",
+		})
+		return
+	}
+
+	dispute, err := h.disputeRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		if appErr, ok := err.(*apperrors.AppError); ok {
+			c.JSON(400, gin.H{
+				"code":    appErr.Code,
+				"message": appErr.Message,
+			})
+			return
+		}
+
+		c.JSON(500, gin.H{
+			"code":    apperrors.ErrInternalServer,
+			"message": "internal server error",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"code":    apperrors.ErrSuccess,
+		"message": "success",
+		"data":    dispute,
+	})
+}
+
 // ListPaymentLogs 获取支付日志列表
 func (h *ManagementHandler) ListPaymentLogs(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zqdfound/go-uni-pay/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// orderEventsMaxDuration 单个订单状态推送连接的最长时间，超时后客户端（EventSource）会自动重连
+	orderEventsMaxDuration = 30 * time.Minute
+	// orderEventsHeartbeat 心跳间隔，避免代理因连接空闲而断开
+	orderEventsHeartbeat = 15 * time.Second
+)
+
+// OrderEvents 通过 Server-Sent Events 推送订单状态变更
+// 首个事件为订单当前状态，订单进入最终状态后关闭连接
+func (h *PaymentHandler) OrderEvents(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	// 长连接不受服务器写超时限制；无法取消写超时时在超时前结束推送，由客户端重连
+	maxDuration := orderEventsMaxDuration
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		logger.Warn("failed to clear write deadline for order events", zap.Error(err))
+		maxDuration = h.maxQueryWait
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), maxDuration)
+	defer cancel()
+
+	events, err := h.paymentService.WatchOrderStatus(ctx, userID.(uint64), c.Param("order_no"))
+	if err != nil {
+		h.respond(c, nil, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	heartbeat := time.NewTicker(orderEventsHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Status(200)
+	// 客户端断开时请求的 ctx 结束
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			c.SSEvent("status", event)
+		case <-heartbeat.C:
+			if _, err := io.WriteString(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+		c.Writer.Flush()
+	}
+}
@@ -3,8 +3,12 @@ package handler
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/zqdfound/go-uni-pay/internal/domain/entity"
 	"github.com/zqdfound/go-uni-pay/internal/payment"
 	paymentService "github.com/zqdfound/go-uni-pay/internal/service/payment"
 	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
@@ -14,37 +18,150 @@ import (
 type PaymentServiceInterface interface {
 	CreatePayment(ctx context.Context, req *paymentService.CreatePaymentRequest) (*paymentService.CreatePaymentResponse, error)
 	QueryPayment(ctx context.Context, userID uint64, orderNo string) (interface{}, error)
+	WaitPayment(ctx context.Context, userID uint64, orderNo string, wait time.Duration) (interface{}, error)
 	HandleNotify(ctx context.Context, provider string, req *payment.NotifyRequest) ([]byte, error)
-	GetConfigByID(ctx context.Context, configID uint64) (map[string]interface{}, error)
+	ResolveNotifyConfig(ctx context.Context, provider, token string) (map[string]interface{}, error)
+	ResolveProviderNotifyConfig(ctx context.Context, provider string, req *payment.NotifyRequest) (map[string]interface{}, error)
+	GetNotifyURL(ctx context.Context, userID uint64, provider string) (string, error)
+	HandleReturn(ctx context.Context, provider string, configID uint64, params map[string][]string) (*entity.PaymentOrder, string, error)
+	GetSignKey(ctx context.Context, userID uint64) (string, error)
+	Authorize(ctx context.Context, req *paymentService.CreatePaymentRequest) (*paymentService.CreatePaymentResponse, error)
+	Capture(ctx context.Context, userID uint64, orderNo string, amount float64) (*entity.PaymentOrder, error)
+	Void(ctx context.Context, userID uint64, orderNo string) (*entity.PaymentOrder, error)
+	ClosePayment(ctx context.Context, userID uint64, orderNo string) (*entity.PaymentOrder, error)
+	DownloadBill(ctx context.Context, userID uint64, provider, billDate, billType string) (*payment.DownloadBillResponse, error)
+	SimulationOrder(ctx context.Context, orderNo string) (*entity.PaymentOrder, error)
+	SimulatePayment(ctx context.Context, orderNo, action string) (*entity.PaymentOrder, error)
+	Refund(ctx context.Context, userID uint64, orderNo string, amount float64, reason string) (*entity.Refund, error)
+	QueryRefund(ctx context.Context, userID uint64, refundNo string) (*entity.Refund, error)
+	CreatePayout(ctx context.Context, req *paymentService.CreatePayoutRequest) (*entity.Payout, error)
+	QueryPayout(ctx context.Context, userID uint64, payoutNo string) (*entity.Payout, error)
+	ShareProfit(ctx context.Context, req *paymentService.ShareProfitRequest) (*entity.ProfitSharing, error)
+	QueryProfitSharing(ctx context.Context, userID uint64, sharingNo string) (*entity.ProfitSharing, error)
+	ReturnProfitSharing(ctx context.Context, req *paymentService.ReturnProfitSharingRequest) (*entity.ProfitSharingReturn, error)
+	QueryProfitSharingReturn(ctx context.Context, userID uint64, returnNo string) (*entity.ProfitSharingReturn, error)
+	CreatePlan(ctx context.Context, req *paymentService.CreatePlanRequest) (*entity.Plan, error)
+	QueryPlan(ctx context.Context, userID uint64, planNo string) (*entity.Plan, error)
+	CreateSubscription(ctx context.Context, req *paymentService.CreateSubscriptionRequest) (*entity.Subscription, error)
+	QuerySubscription(ctx context.Context, userID uint64, subscriptionNo string) (*entity.Subscription, error)
+	CancelSubscription(ctx context.Context, userID uint64, subscriptionNo, reason string) (*entity.Subscription, error)
+	CreateCustomer(ctx context.Context, req *paymentService.CreateCustomerRequest) (*entity.Customer, error)
+	QueryCustomer(ctx context.Context, userID uint64, customerNo string) (*entity.Customer, error)
+	CreatePaymentMethod(ctx context.Context, req *paymentService.CreatePaymentMethodRequest) (*entity.PaymentMethod, error)
+	ListPaymentMethods(ctx context.Context, userID uint64, customerNo string) ([]*entity.PaymentMethod, error)
+	DeletePaymentMethod(ctx context.Context, userID uint64, methodNo string) (*entity.PaymentMethod, error)
+	CreateCheckout(ctx context.Context, req *paymentService.CreateCheckoutRequest) (*entity.Checkout, error)
+	QueryCheckout(ctx context.Context, userID uint64, checkoutNo string) (*entity.Checkout, error)
+	GetCheckoutPage(ctx context.Context, checkoutNo string) (*paymentService.CheckoutPage, error)
+	PayCheckout(ctx context.Context, checkoutNo, provider, clientIP string, mobile bool) (*entity.Checkout, error)
+	CreatePaymentLink(ctx context.Context, req *paymentService.CreatePaymentLinkRequest) (*entity.PaymentLink, error)
+	QueryPaymentLink(ctx context.Context, userID uint64, linkNo string) (*entity.PaymentLink, error)
+	ListPaymentLinks(ctx context.Context, userID uint64, page, pageSize int) ([]*entity.PaymentLink, int64, error)
+	UpdatePaymentLink(ctx context.Context, req *paymentService.UpdatePaymentLinkRequest) (*entity.PaymentLink, error)
+	DeletePaymentLink(ctx context.Context, userID uint64, linkNo string) error
+	ListPaymentLinkOrders(ctx context.Context, userID uint64, linkNo string, page, pageSize int) ([]*entity.PaymentOrder, int64, error)
+	GetPaymentLinkPage(ctx context.Context, linkNo string) (*paymentService.PaymentLinkPage, error)
+	PayPaymentLink(ctx context.Context, linkNo, provider string, amount float64, clientIP string, mobile bool) (*paymentService.CreatePaymentResponse, error)
+	GetQRCode(ctx context.Context, orderNo string) (string, error)
+	WatchOrderStatus(ctx context.Context, userID uint64, orderNo string) (<-chan *paymentService.OrderStatusEvent, error)
 }
 
+// 查询支付长轮询的等待时间
+const (
+	// defaultMaxQueryWait 未配置服务器写超时时的最长等待时间
+	defaultMaxQueryWait = 60 * time.Second
+	// queryWaitMargin 为写入响应预留的时间，保证在服务器写超时前返回
+	queryWaitMargin = 5 * time.Second
+)
+
 // PaymentHandler 支付处理器
 type PaymentHandler struct {
 	paymentService PaymentServiceInterface
+	maxQueryWait   time.Duration
 }
 
 // NewPaymentHandler 创建支付处理器
 func NewPaymentHandler(paymentService PaymentServiceInterface) *PaymentHandler {
 	return &PaymentHandler{
 		paymentService: paymentService,
+		maxQueryWait:   defaultMaxQueryWait,
+	}
+}
+
+// SetWriteTimeout 根据服务器写超时设置查询支付的最长等待时间
+func (h *PaymentHandler) SetWriteTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		h.maxQueryWait = defaultMaxQueryWait
+		return
+	}
+	h.maxQueryWait = timeout - queryWaitMargin
+	if h.maxQueryWait < 0 {
+		h.maxQueryWait = 0
 	}
 }
 
 // CreatePaymentRequest 创建支付请求
+// 传入 customer_no 和 payment_method_no 时使用客户已保存的支付方式直接扣款
 type CreatePaymentRequest struct {
-	Provider    string                 `json:"provider" binding:"required"`
-	OutTradeNo  string                 `json:"out_trade_no" binding:"required"`
-	Subject     string                 `json:"subject" binding:"required"`
-	Body        string                 `json:"body"`
-	Amount      float64                `json:"amount" binding:"required,gt=0"`
-	Currency    string                 `json:"currency"`
-	NotifyURL   string                 `json:"notify_url"`
-	ReturnURL   string                 `json:"return_url"`
-	ExtraParams map[string]interface{} `json:"extra_params"`
+	Provider        string                    `json:"provider" binding:"required"`
+	OutTradeNo      string                    `json:"out_trade_no" binding:"required"`
+	Subject         string                    `json:"subject" binding:"required"`
+	Body            string                    `json:"body"`
+	Amount          float64                   `json:"amount" binding:"required,gt=0"`
+	Currency        string                    `json:"currency"`
+	Scene           string                    `json:"scene"`
+	NotifyURL       string                    `json:"notify_url"`
+	ReturnURL       string                    `json:"return_url"`
+	ExtraParams     map[string]interface{}    `json:"extra_params"`
+	Items           []OrderItemRequest        `json:"items" binding:"omitempty,dive"`
+	Buyer           *BuyerRequest             `json:"buyer"`
+	Shipping        *ShippingRequest          `json:"shipping"`
+	ProfitSharing   *ProfitSharingPlanRequest `json:"profit_sharing"`
+	CustomerNo      string                    `json:"customer_no" binding:"required_with=PaymentMethodNo"`
+	PaymentMethodNo string                    `json:"payment_method_no"`
+}
+
+// OrderItemRequest 商品明细，单价和税额均为单件金额
+type OrderItemRequest struct {
+	Name      string  `json:"name" binding:"required"`
+	SKU       string  `json:"sku"`
+	Quantity  int64   `json:"quantity" binding:"required,gt=0"`
+	UnitPrice float64 `json:"unit_price" binding:"gte=0"`
+	Tax       float64 `json:"tax" binding:"gte=0"`
+}
+
+// BuyerRequest 买家信息
+type BuyerRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email" binding:"omitempty,email"`
+	Phone string `json:"phone"`
+}
+
+// ShippingRequest 收货信息，运费计入订单金额
+type ShippingRequest struct {
+	Name       string  `json:"name" binding:"required"`
+	Phone      string  `json:"phone"`
+	Country    string  `json:"country" binding:"required,len=2"`
+	State      string  `json:"state"`
+	City       string  `json:"city"`
+	Line1      string  `json:"line1" binding:"required"`
+	Line2      string  `json:"line2"`
+	PostalCode string  `json:"postal_code"`
+	Fee        float64 `json:"fee" binding:"gte=0"`
 }
 
 // CreatePayment 创建支付
 func (h *PaymentHandler) CreatePayment(c *gin.Context) {
+	h.createPayment(c, h.paymentService.CreatePayment)
+}
+
+// Authorize 创建预授权支付
+func (h *PaymentHandler) Authorize(c *gin.Context) {
+	h.createPayment(c, h.paymentService.Authorize)
+}
+
+// createPayment 解析创建支付请求并调用对应的服务方法
+func (h *PaymentHandler) createPayment(c *gin.Context, create func(context.Context, *paymentService.CreatePaymentRequest) (*paymentService.CreatePaymentResponse, error)) {
 	var req CreatePaymentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{
@@ -63,18 +180,25 @@ func (h *PaymentHandler) CreatePayment(c *gin.Context) {
 	}
 
 	// 创建支付
-	resp, err := h.paymentService.CreatePayment(c.Request.Context(), &paymentService.CreatePaymentRequest{
-		UserID:      userID.(uint64),
-		Provider:    req.Provider,
-		OutTradeNo:  req.OutTradeNo,
-		Subject:     req.Subject,
-		Body:        req.Body,
-		Amount:      req.Amount,
-		Currency:    req.Currency,
-		NotifyURL:   req.NotifyURL,
-		ReturnURL:   req.ReturnURL,
-		ClientIP:    c.ClientIP(),
-		ExtraParams: req.ExtraParams,
+	resp, err := create(c.Request.Context(), &paymentService.CreatePaymentRequest{
+		UserID:          userID.(uint64),
+		Provider:        req.Provider,
+		OutTradeNo:      req.OutTradeNo,
+		Subject:         req.Subject,
+		Body:            req.Body,
+		Amount:          req.Amount,
+		Currency:        req.Currency,
+		Scene:           req.Scene,
+		NotifyURL:       req.NotifyURL,
+		ReturnURL:       req.ReturnURL,
+		ClientIP:        c.ClientIP(),
+		ExtraParams:     req.ExtraParams,
+		Items:           toOrderItems(req.Items),
+		Buyer:           toBuyer(req.Buyer),
+		Shipping:        toShipping(req.Shipping),
+		ProfitSharing:   toProfitSharingPlan(req.ProfitSharing),
+		CustomerNo:      req.CustomerNo,
+		PaymentMethodNo: req.PaymentMethodNo,
 	})
 
 	if err != nil {
@@ -100,6 +224,54 @@ func (h *PaymentHandler) CreatePayment(c *gin.Context) {
 	})
 }
 
+// toOrderItems 转换为服务层使用的商品明细
+func toOrderItems(items []OrderItemRequest) entity.OrderItems {
+	if len(items) == 0 {
+		return nil
+	}
+	result := make(entity.OrderItems, 0, len(items))
+	for _, item := range items {
+		result = append(result, entity.OrderItem{
+			Name:      item.Name,
+			SKU:       item.SKU,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			Tax:       item.Tax,
+		})
+	}
+	return result
+}
+
+// toBuyer 转换为服务层使用的买家信息
+func toBuyer(buyer *BuyerRequest) *entity.Buyer {
+	if buyer == nil {
+		return nil
+	}
+	return &entity.Buyer{
+		Name:  buyer.Name,
+		Email: buyer.Email,
+		Phone: buyer.Phone,
+	}
+}
+
+// toShipping 转换为服务层使用的收货信息
+func toShipping(shipping *ShippingRequest) *entity.Shipping {
+	if shipping == nil {
+		return nil
+	}
+	return &entity.Shipping{
+		Name:       shipping.Name,
+		Phone:      shipping.Phone,
+		Country:    strings.ToUpper(shipping.Country),
+		State:      shipping.State,
+		City:       shipping.City,
+		Line1:      shipping.Line1,
+		Line2:      shipping.Line2,
+		PostalCode: shipping.PostalCode,
+		Fee:        shipping.Fee,
+	}
+}
+
 // QueryPayment 查询支付
 func (h *PaymentHandler) QueryPayment(c *gin.Context) {
 	orderNo := c.Param("order_no")
@@ -121,7 +293,21 @@ func (h *PaymentHandler) QueryPayment(c *gin.Context) {
 		return
 	}
 
-	order, err := h.paymentService.QueryPayment(c.Request.Context(), userID.(uint64), orderNo)
+	wait, err := parseWait(c.Query("wait"), h.maxQueryWait)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"code":    apperrors.ErrInvalidParam,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	var order interface{}
+	if wait > 0 {
+		order, err = h.paymentService.WaitPayment(c.Request.Context(), userID.(uint64), orderNo, wait)
+	} else {
+		order, err = h.paymentService.QueryPayment(c.Request.Context(), userID.(uint64), orderNo)
+	}
 	if err != nil {
 		if appErr, ok := err.(*apperrors.AppError); ok {
 			c.JSON(400, gin.H{
@@ -145,32 +331,210 @@ func (h *PaymentHandler) QueryPayment(c *gin.Context) {
 	})
 }
 
-// HandleNotify 处理支付通知
-func (h *PaymentHandler) HandleNotify(c *gin.Context) {
-	provider := c.Param("provider")
-	configIDStr := c.Param("config_id")
+// parseWait 解析长轮询等待时间，支持 30s、1m 等时长格式或秒数，超过上限时按上限等待
+func parseWait(value string, max time.Duration) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
 
-	if provider == "" {
-		c.String(400, "provider is required")
+	wait, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, convErr := strconv.Atoi(value)
+		if convErr != nil {
+			return 0, fmt.Errorf("invalid wait %q", value)
+		}
+		wait = time.Duration(seconds) * time.Second
+	}
+	if wait < 0 {
+		return 0, fmt.Errorf("wait must not be negative")
+	}
+	if wait > max {
+		wait = max
+	}
+	return wait, nil
+}
+
+// CaptureRequest 预授权扣款请求
+type CaptureRequest struct {
+	OrderNo string  `json:"order_no" binding:"required"`
+	Amount  float64 `json:"amount" binding:"gte=0"`
+}
+
+// Capture 预授权扣款，amount 为空或0时全额扣款
+func (h *PaymentHandler) Capture(c *gin.Context) {
+	var req CaptureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"code":    apperrors.ErrInvalidParam,
+			"message": err.Error(),
+		})
 		return
 	}
 
-	if configIDStr == "" {
-		c.String(400, "config_id is required")
+	userID, _ := c.Get("user_id")
+
+	order, err := h.paymentService.Capture(c.Request.Context(), userID.(uint64), req.OrderNo, req.Amount)
+	h.respond(c, order, err)
+}
+
+// VoidRequest 撤销预授权请求
+type VoidRequest struct {
+	OrderNo string `json:"order_no" binding:"required"`
+}
+
+// Void 撤销预授权
+func (h *PaymentHandler) Void(c *gin.Context) {
+	var req VoidRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"code":    apperrors.ErrInvalidParam,
+			"message": err.Error(),
+		})
 		return
 	}
 
-	// 解析 config_id
-	var configID uint64
-	if _, err := fmt.Sscanf(configIDStr, "%d", &configID); err != nil {
-		c.String(400, "invalid config_id")
+	userID, _ := c.Get("user_id")
+
+	order, err := h.paymentService.Void(c.Request.Context(), userID.(uint64), req.OrderNo)
+	h.respond(c, order, err)
+}
+
+// ClosePaymentRequest 关闭支付请求
+type ClosePaymentRequest struct {
+	OrderNo string `json:"order_no" binding:"required"`
+}
+
+// ClosePayment 关闭未支付订单
+func (h *PaymentHandler) ClosePayment(c *gin.Context) {
+	var req ClosePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"code":    apperrors.ErrInvalidParam,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	order, err := h.paymentService.ClosePayment(c.Request.Context(), userID.(uint64), req.OrderNo)
+	h.respond(c, order, err)
+}
+
+// RefundRequest 退款请求
+type RefundRequest struct {
+	OrderNo string  `json:"order_no" binding:"required"`
+	Amount  float64 `json:"amount" binding:"gte=0"`
+	Reason  string  `json:"reason"`
+}
+
+// Refund 发起退款，amount 为空或0时退还剩余可退金额
+func (h *PaymentHandler) Refund(c *gin.Context) {
+	var req RefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"code":    apperrors.ErrInvalidParam,
+			"message": err.Error(),
+		})
 		return
 	}
 
-	// 获取支付配置
-	config, err := h.paymentService.GetConfigByID(c.Request.Context(), configID)
+	userID, _ := c.Get("user_id")
+
+	refund, err := h.paymentService.Refund(c.Request.Context(), userID.(uint64), req.OrderNo, req.Amount, req.Reason)
+	h.respond(c, refund, err)
+}
+
+// QueryRefund 查询退款
+func (h *PaymentHandler) QueryRefund(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	refund, err := h.paymentService.QueryRefund(c.Request.Context(), userID.(uint64), c.Param("refund_no"))
+	h.respond(c, refund, err)
+}
+
+// DownloadBill 获取对账单下载地址
+func (h *PaymentHandler) DownloadBill(c *gin.Context) {
+	provider := c.Query("provider")
+	billDate := c.Query("bill_date")
+	if provider == "" || billDate == "" {
+		c.JSON(400, gin.H{
+			"code":    apperrors.ErrInvalidParam,
+			"message": "provider and bill_date are required",
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	resp, err := h.paymentService.DownloadBill(c.Request.Context(), userID.(uint64), provider, billDate, c.Query("bill_type"))
 	if err != nil {
-		c.String(400, "config not found")
+		if appErr, ok := err.(*apperrors.AppError); ok {
+			c.JSON(400, gin.H{
+				"code":    appErr.Code,
+				"message": appErr.Message,
+			})
+			return
+		}
+
+		c.JSON(500, gin.H{
+			"code":    apperrors.ErrInternalServer,
+			"message": "internal server error",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"code":    apperrors.ErrSuccess,
+		"message": "success",
+		"data": gin.H{
+			"download_url": resp.DownloadURL,
+		},
+	})
+}
+
+// ListProviders 获取已注册的支付提供商及其支持的能力
+func (h *PaymentHandler) ListProviders(c *gin.Context) {
+	c.JSON(200, gin.H{
+		"code":    apperrors.ErrSuccess,
+		"message": "success",
+		"data":    payment.ListProviders(),
+	})
+}
+
+// respond 返回数据（订单、退款等）或错误信息
+func (h *PaymentHandler) respond(c *gin.Context, data interface{}, err error) {
+	if err != nil {
+		if appErr, ok := err.(*apperrors.AppError); ok {
+			c.JSON(400, gin.H{
+				"code":    appErr.Code,
+				"message": appErr.Message,
+			})
+			return
+		}
+
+		c.JSON(500, gin.H{
+			"code":    apperrors.ErrInternalServer,
+			"message": "internal server error",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"code":    apperrors.ErrSuccess,
+		"message": "success",
+		"data":    data,
+	})
+}
+
+// HandleNotify 处理支付通知
+// 通知地址不带配置令牌时，从通知内容中的提供商账号识别支付配置
+func (h *PaymentHandler) HandleNotify(c *gin.Context) {
+	provider := c.Param("provider")
+	token := c.Param("token")
+
+	if provider == "" {
+		c.String(400, "provider is required")
 		return
 	}
 
@@ -182,9 +546,22 @@ func (h *PaymentHandler) HandleNotify(c *gin.Context) {
 		RawData:    bodyBytes,
 		FormData:   c.Request.Form,
 		RequestURL: c.Request.URL.String(),
-		Config:     config, // 从数据库获取的配置
 	}
 
+	// 解析通知地址中的配置令牌或通知内容，获取支付配置
+	var config map[string]interface{}
+	var err error
+	if token == "" {
+		config, err = h.paymentService.ResolveProviderNotifyConfig(c.Request.Context(), provider, notifyReq)
+	} else {
+		config, err = h.paymentService.ResolveNotifyConfig(c.Request.Context(), provider, token)
+	}
+	if err != nil {
+		c.String(400, "config not found")
+		return
+	}
+	notifyReq.Config = config
+
 	// 处理通知
 	returnData, err := h.paymentService.HandleNotify(c.Request.Context(), provider, notifyReq)
 	if err != nil {
@@ -194,3 +571,66 @@ func (h *PaymentHandler) HandleNotify(c *gin.Context) {
 
 	c.Data(200, "text/plain", returnData)
 }
+
+// HandleReturn 处理买家支付后的同步跳转
+// 向提供商确认支付结果后，携带本服务签名的参数跳转到商户的 return_url
+func (h *PaymentHandler) HandleReturn(c *gin.Context) {
+	configID, err := strconv.ParseUint(c.Param("config_id"), 10, 64)
+	if err != nil {
+		c.String(400, "invalid config_id")
+		return
+	}
+
+	// 部分提供商（如银联）以表单 POST 方式跳转
+	if err := c.Request.ParseForm(); err != nil {
+		c.String(400, "invalid request")
+		return
+	}
+
+	order, redirectURL, err := h.paymentService.HandleReturn(c.Request.Context(), c.Param("provider"), configID, c.Request.Form)
+	if err != nil {
+		c.String(400, "order not found")
+		return
+	}
+
+	if redirectURL != "" {
+		c.Redirect(302, redirectURL)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"code":    apperrors.ErrSuccess,
+		"message": "success",
+		"data": gin.H{
+			"order_no":     order.OrderNo,
+			"out_trade_no": order.OutTradeNo,
+			"status":       order.Status,
+		},
+	})
+}
+
+// GetNotifyURL 获取支付配置的通知地址，用于在提供商后台配置 Webhook
+func (h *PaymentHandler) GetNotifyURL(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	notifyURL, err := h.paymentService.GetNotifyURL(c.Request.Context(), userID.(uint64), c.Param("provider"))
+	if err != nil {
+		h.respond(c, nil, err)
+		return
+	}
+
+	h.respond(c, gin.H{"notify_url": notifyURL}, nil)
+}
+
+// GetSignKey 获取签名密钥，用于校验跳转到商户页面的参数签名
+func (h *PaymentHandler) GetSignKey(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	key, err := h.paymentService.GetSignKey(c.Request.Context(), userID.(uint64))
+	if err != nil {
+		h.respond(c, nil, err)
+		return
+	}
+
+	h.respond(c, gin.H{"sign_key": key}, nil)
+}
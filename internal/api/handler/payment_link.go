@@ -0,0 +1,275 @@
+package handler
+
+import (
+	"html/template"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	paymentService "github.com/zqdfound/go-uni-pay/internal/service/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+)
+
+// paymentLinkPage 支付链接页面
+var paymentLinkPage = template.Must(template.New("payment_link").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Link.Subject}}</title>
+</head>
+<body>
+<h2>{{.Link.Subject}}</h2>
+{{if .Link.Description}}<p>{{.Link.Description}}</p>{{end}}
+{{if .Error}}<p>{{.Error}}</p>{{end}}
+{{if .Result}}
+<p>Order {{.Result.OrderNo}} created.</p>
+{{if .Result.QRCode}}<p>Scan the code to pay:</p>
+<p><img src="/api/v1/payment/qrcode/{{.Result.OrderNo}}.png" alt="QR code"></p>{{end}}
+{{else if .Unavailable}}
+<p>{{.Unavailable}}</p>
+{{else if .Providers}}
+<form method="post">
+{{if .Link.CustomAmount}}<p>Amount ({{.Link.Currency}}) <input type="number" name="amount" step="0.01" min="0.01" required></p>
+{{else}}<p>Amount: {{printf "%.2f" .Link.Amount}} {{.Link.Currency}}</p>
+{{end}}
+{{range .Providers}}<p><button type="submit" name="provider" value="{{.}}">{{.}}</button></p>
+{{end}}
+</form>
+{{else}}
+<p>No payment method is available.</p>
+{{end}}
+</body>
+</html>
+`))
+
+// paymentLinkPageData 支付链接页面模板数据
+type paymentLinkPageData struct {
+	*paymentService.PaymentLinkPage
+	Result *paymentService.CreatePaymentResponse
+	Error  string
+}
+
+// CreatePaymentLinkRequest 创建支付链接请求
+type CreatePaymentLinkRequest struct {
+	Subject      string     `json:"subject" binding:"required,max=256"`
+	Description  string     `json:"description"`
+	Amount       float64    `json:"amount" binding:"gte=0"`
+	CustomAmount bool       `json:"custom_amount"`
+	Currency     string     `json:"currency"`
+	Providers    []string   `json:"providers"`
+	ExpireAt     *time.Time `json:"expire_at"`
+	MaxUses      int        `json:"max_uses" binding:"gte=0"`
+	NotifyURL    string     `json:"notify_url"`
+	ReturnURL    string     `json:"return_url"`
+}
+
+// CreatePaymentLink 创建支付链接
+func (h *PaymentHandler) CreatePaymentLink(c *gin.Context) {
+	var req CreatePaymentLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"code":    apperrors.ErrInvalidParam,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if req.Currency == "" {
+		req.Currency = "CNY"
+	}
+
+	link, err := h.paymentService.CreatePaymentLink(c.Request.Context(), &paymentService.CreatePaymentLinkRequest{
+		UserID:       userID.(uint64),
+		Subject:      req.Subject,
+		Description:  req.Description,
+		Amount:       req.Amount,
+		CustomAmount: req.CustomAmount,
+		Currency:     req.Currency,
+		Providers:    req.Providers,
+		ExpireAt:     req.ExpireAt,
+		MaxUses:      req.MaxUses,
+		NotifyURL:    req.NotifyURL,
+		ReturnURL:    req.ReturnURL,
+	})
+	h.respond(c, link, err)
+}
+
+// QueryPaymentLink 查询支付链接
+func (h *PaymentHandler) QueryPaymentLink(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	link, err := h.paymentService.QueryPaymentLink(c.Request.Context(), userID.(uint64), c.Param("link_no"))
+	h.respond(c, link, err)
+}
+
+// ListPaymentLinks 分页查询支付链接
+func (h *PaymentHandler) ListPaymentLinks(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	page, pageSize := pagination(c)
+
+	links, total, err := h.paymentService.ListPaymentLinks(c.Request.Context(), userID.(uint64), page, pageSize)
+	if err != nil {
+		h.respond(c, nil, err)
+		return
+	}
+
+	h.respond(c, gin.H{
+		"list":      links,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	}, nil)
+}
+
+// UpdatePaymentLinkRequest 更新支付链接请求，未传入的字段不更新
+type UpdatePaymentLinkRequest struct {
+	LinkNo       string     `json:"link_no" binding:"required"`
+	Subject      *string    `json:"subject" binding:"omitempty,min=1,max=256"`
+	Description  *string    `json:"description"`
+	Amount       *float64   `json:"amount" binding:"omitempty,gte=0"`
+	CustomAmount *bool      `json:"custom_amount"`
+	Providers    []string   `json:"providers"`
+	ExpireAt     *time.Time `json:"expire_at"`
+	MaxUses      *int       `json:"max_uses" binding:"omitempty,gte=0"`
+	Status       *string    `json:"status" binding:"omitempty,oneof=active inactive"`
+	NotifyURL    *string    `json:"notify_url"`
+	ReturnURL    *string    `json:"return_url"`
+}
+
+// UpdatePaymentLink 更新支付链接，可通过 status 停用或重新启用
+func (h *PaymentHandler) UpdatePaymentLink(c *gin.Context) {
+	var req UpdatePaymentLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"code":    apperrors.ErrInvalidParam,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	link, err := h.paymentService.UpdatePaymentLink(c.Request.Context(), &paymentService.UpdatePaymentLinkRequest{
+		UserID:       userID.(uint64),
+		LinkNo:       req.LinkNo,
+		Subject:      req.Subject,
+		Description:  req.Description,
+		Amount:       req.Amount,
+		CustomAmount: req.CustomAmount,
+		Providers:    req.Providers,
+		ExpireAt:     req.ExpireAt,
+		MaxUses:      req.MaxUses,
+		Status:       req.Status,
+		NotifyURL:    req.NotifyURL,
+		ReturnURL:    req.ReturnURL,
+	})
+	h.respond(c, link, err)
+}
+
+// DeletePaymentLinkRequest 删除支付链接请求
+type DeletePaymentLinkRequest struct {
+	LinkNo string `json:"link_no" binding:"required"`
+}
+
+// DeletePaymentLink 删除支付链接
+func (h *PaymentHandler) DeletePaymentLink(c *gin.Context) {
+	var req DeletePaymentLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"code":    apperrors.ErrInvalidParam,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	err := h.paymentService.DeletePaymentLink(c.Request.Context(), userID.(uint64), req.LinkNo)
+	h.respond(c, nil, err)
+}
+
+// ListPaymentLinkOrders 分页查询通过支付链接创建的订单
+func (h *PaymentHandler) ListPaymentLinkOrders(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	page, pageSize := pagination(c)
+
+	orders, total, err := h.paymentService.ListPaymentLinkOrders(c.Request.Context(), userID.(uint64), c.Param("link_no"), page, pageSize)
+	if err != nil {
+		h.respond(c, nil, err)
+		return
+	}
+
+	h.respond(c, gin.H{
+		"list":      orders,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	}, nil)
+}
+
+// PaymentLinkPage 展示支付链接页面
+func (h *PaymentHandler) PaymentLinkPage(c *gin.Context) {
+	page, err := h.paymentService.GetPaymentLinkPage(c.Request.Context(), c.Param("link_no"))
+	if err != nil {
+		c.String(404, "payment link not found")
+		return
+	}
+
+	h.renderPaymentLink(c, &paymentLinkPageData{PaymentLinkPage: page})
+}
+
+// PayPaymentLink 买家通过支付链接选择提供商，创建支付后跳转到提供商的支付页面
+func (h *PaymentHandler) PayPaymentLink(c *gin.Context) {
+	ctx := c.Request.Context()
+	linkNo := c.Param("link_no")
+	amount, _ := strconv.ParseFloat(c.PostForm("amount"), 64)
+
+	resp, err := h.paymentService.PayPaymentLink(ctx, linkNo, c.PostForm("provider"), amount, c.ClientIP(), isMobile(c.Request.UserAgent()))
+	if err == nil && resp.PaymentURL != "" && resp.QRCode == "" {
+		c.Redirect(302, resp.PaymentURL)
+		return
+	}
+
+	page, pageErr := h.paymentService.GetPaymentLinkPage(ctx, linkNo)
+	if pageErr != nil {
+		c.String(404, "payment link not found")
+		return
+	}
+
+	data := &paymentLinkPageData{PaymentLinkPage: page}
+	if err != nil {
+		data.Error = "failed to create payment, please retry"
+		if appErr, ok := err.(*apperrors.AppError); ok {
+			data.Error = appErr.Message
+		}
+	} else {
+		data.Result = resp
+	}
+	h.renderPaymentLink(c, data)
+}
+
+// renderPaymentLink 渲染支付链接页面
+func (h *PaymentHandler) renderPaymentLink(c *gin.Context, data *paymentLinkPageData) {
+	c.Status(200)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := paymentLinkPage.Execute(c.Writer, data); err != nil {
+		c.String(500, "error")
+	}
+}
+
+// pagination 解析分页参数，默认第1页每页10条，每页最多100条
+func pagination(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+	return page, pageSize
+}
@@ -5,18 +5,28 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"image/png"
 	"io"
+	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zqdfound/go-uni-pay/internal/api/middleware"
+	"github.com/zqdfound/go-uni-pay/internal/domain/entity"
+	"github.com/zqdfound/go-uni-pay/internal/domain/repository"
 	"github.com/zqdfound/go-uni-pay/internal/payment"
 	paymentService "github.com/zqdfound/go-uni-pay/internal/service/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+	"github.com/zqdfound/go-uni-pay/pkg/logger"
+	"go.uber.org/zap"
 )
 
 // MockPaymentService 模拟支付服务
@@ -45,14 +55,351 @@ func (m *MockPaymentService) HandleNotify(ctx context.Context, provider string,
 	return args.Get(0).([]byte), args.Error(1)
 }
 
-func (m *MockPaymentService) GetConfigByID(ctx context.Context, configID uint64) (map[string]interface{}, error) {
-	args := m.Called(ctx, configID)
+func (m *MockPaymentService) ResolveNotifyConfig(ctx context.Context, provider, token string) (map[string]interface{}, error) {
+	args := m.Called(ctx, provider, token)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(map[string]interface{}), args.Error(1)
 }
 
+func (m *MockPaymentService) ResolveProviderNotifyConfig(ctx context.Context, provider string, req *payment.NotifyRequest) (map[string]interface{}, error) {
+	args := m.Called(ctx, provider, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]interface{}), args.Error(1)
+}
+
+func (m *MockPaymentService) Authorize(ctx context.Context, req *paymentService.CreatePaymentRequest) (*paymentService.CreatePaymentResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*paymentService.CreatePaymentResponse), args.Error(1)
+}
+
+func (m *MockPaymentService) Capture(ctx context.Context, userID uint64, orderNo string, amount float64) (*entity.PaymentOrder, error) {
+	args := m.Called(ctx, userID, orderNo, amount)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.PaymentOrder), args.Error(1)
+}
+
+func (m *MockPaymentService) Void(ctx context.Context, userID uint64, orderNo string) (*entity.PaymentOrder, error) {
+	args := m.Called(ctx, userID, orderNo)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.PaymentOrder), args.Error(1)
+}
+
+func (m *MockPaymentService) ClosePayment(ctx context.Context, userID uint64, orderNo string) (*entity.PaymentOrder, error) {
+	args := m.Called(ctx, userID, orderNo)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.PaymentOrder), args.Error(1)
+}
+
+func (m *MockPaymentService) DownloadBill(ctx context.Context, userID uint64, provider, billDate, billType string) (*payment.DownloadBillResponse, error) {
+	args := m.Called(ctx, userID, provider, billDate, billType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*payment.DownloadBillResponse), args.Error(1)
+}
+
+func (m *MockPaymentService) SimulationOrder(ctx context.Context, orderNo string) (*entity.PaymentOrder, error) {
+	args := m.Called(ctx, orderNo)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.PaymentOrder), args.Error(1)
+}
+
+func (m *MockPaymentService) SimulatePayment(ctx context.Context, orderNo, action string) (*entity.PaymentOrder, error) {
+	args := m.Called(ctx, orderNo, action)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.PaymentOrder), args.Error(1)
+}
+
+func (m *MockPaymentService) Refund(ctx context.Context, userID uint64, orderNo string, amount float64, reason string) (*entity.Refund, error) {
+	args := m.Called(ctx, userID, orderNo, amount, reason)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Refund), args.Error(1)
+}
+
+func (m *MockPaymentService) QueryRefund(ctx context.Context, userID uint64, refundNo string) (*entity.Refund, error) {
+	args := m.Called(ctx, userID, refundNo)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Refund), args.Error(1)
+}
+
+func (m *MockPaymentService) CreatePayout(ctx context.Context, req *paymentService.CreatePayoutRequest) (*entity.Payout, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Payout), args.Error(1)
+}
+
+func (m *MockPaymentService) QueryPayout(ctx context.Context, userID uint64, payoutNo string) (*entity.Payout, error) {
+	args := m.Called(ctx, userID, payoutNo)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Payout), args.Error(1)
+}
+
+func (m *MockPaymentService) ShareProfit(ctx context.Context, req *paymentService.ShareProfitRequest) (*entity.ProfitSharing, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.ProfitSharing), args.Error(1)
+}
+
+func (m *MockPaymentService) QueryProfitSharing(ctx context.Context, userID uint64, sharingNo string) (*entity.ProfitSharing, error) {
+	args := m.Called(ctx, userID, sharingNo)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.ProfitSharing), args.Error(1)
+}
+
+func (m *MockPaymentService) ReturnProfitSharing(ctx context.Context, req *paymentService.ReturnProfitSharingRequest) (*entity.ProfitSharingReturn, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.ProfitSharingReturn), args.Error(1)
+}
+
+func (m *MockPaymentService) QueryProfitSharingReturn(ctx context.Context, userID uint64, returnNo string) (*entity.ProfitSharingReturn, error) {
+	args := m.Called(ctx, userID, returnNo)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.ProfitSharingReturn), args.Error(1)
+}
+
+func (m *MockPaymentService) CreatePlan(ctx context.Context, req *paymentService.CreatePlanRequest) (*entity.Plan, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Plan), args.Error(1)
+}
+
+func (m *MockPaymentService) QueryPlan(ctx context.Context, userID uint64, planNo string) (*entity.Plan, error) {
+	args := m.Called(ctx, userID, planNo)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Plan), args.Error(1)
+}
+
+func (m *MockPaymentService) CreateSubscription(ctx context.Context, req *paymentService.CreateSubscriptionRequest) (*entity.Subscription, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Subscription), args.Error(1)
+}
+
+func (m *MockPaymentService) QuerySubscription(ctx context.Context, userID uint64, subscriptionNo string) (*entity.Subscription, error) {
+	args := m.Called(ctx, userID, subscriptionNo)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Subscription), args.Error(1)
+}
+
+func (m *MockPaymentService) CancelSubscription(ctx context.Context, userID uint64, subscriptionNo, reason string) (*entity.Subscription, error) {
+	args := m.Called(ctx, userID, subscriptionNo, reason)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Subscription), args.Error(1)
+}
+
+func (m *MockPaymentService) CreateCustomer(ctx context.Context, req *paymentService.CreateCustomerRequest) (*entity.Customer, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Customer), args.Error(1)
+}
+
+func (m *MockPaymentService) QueryCustomer(ctx context.Context, userID uint64, customerNo string) (*entity.Customer, error) {
+	args := m.Called(ctx, userID, customerNo)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Customer), args.Error(1)
+}
+
+func (m *MockPaymentService) CreatePaymentMethod(ctx context.Context, req *paymentService.CreatePaymentMethodRequest) (*entity.PaymentMethod, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.PaymentMethod), args.Error(1)
+}
+
+func (m *MockPaymentService) ListPaymentMethods(ctx context.Context, userID uint64, customerNo string) ([]*entity.PaymentMethod, error) {
+	args := m.Called(ctx, userID, customerNo)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.PaymentMethod), args.Error(1)
+}
+
+func (m *MockPaymentService) DeletePaymentMethod(ctx context.Context, userID uint64, methodNo string) (*entity.PaymentMethod, error) {
+	args := m.Called(ctx, userID, methodNo)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.PaymentMethod), args.Error(1)
+}
+
+func (m *MockPaymentService) CreateCheckout(ctx context.Context, req *paymentService.CreateCheckoutRequest) (*entity.Checkout, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Checkout), args.Error(1)
+}
+
+func (m *MockPaymentService) QueryCheckout(ctx context.Context, userID uint64, checkoutNo string) (*entity.Checkout, error) {
+	args := m.Called(ctx, userID, checkoutNo)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Checkout), args.Error(1)
+}
+
+func (m *MockPaymentService) GetCheckoutPage(ctx context.Context, checkoutNo string) (*paymentService.CheckoutPage, error) {
+	args := m.Called(ctx, checkoutNo)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*paymentService.CheckoutPage), args.Error(1)
+}
+
+func (m *MockPaymentService) PayCheckout(ctx context.Context, checkoutNo, provider, clientIP string, mobile bool) (*entity.Checkout, error) {
+	args := m.Called(ctx, checkoutNo, provider, clientIP, mobile)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Checkout), args.Error(1)
+}
+
+func (m *MockPaymentService) CreatePaymentLink(ctx context.Context, req *paymentService.CreatePaymentLinkRequest) (*entity.PaymentLink, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.PaymentLink), args.Error(1)
+}
+
+func (m *MockPaymentService) QueryPaymentLink(ctx context.Context, userID uint64, linkNo string) (*entity.PaymentLink, error) {
+	args := m.Called(ctx, userID, linkNo)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.PaymentLink), args.Error(1)
+}
+
+func (m *MockPaymentService) ListPaymentLinks(ctx context.Context, userID uint64, page, pageSize int) ([]*entity.PaymentLink, int64, error) {
+	args := m.Called(ctx, userID, page, pageSize)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*entity.PaymentLink), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockPaymentService) UpdatePaymentLink(ctx context.Context, req *paymentService.UpdatePaymentLinkRequest) (*entity.PaymentLink, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.PaymentLink), args.Error(1)
+}
+
+func (m *MockPaymentService) DeletePaymentLink(ctx context.Context, userID uint64, linkNo string) error {
+	args := m.Called(ctx, userID, linkNo)
+	return args.Error(0)
+}
+
+func (m *MockPaymentService) ListPaymentLinkOrders(ctx context.Context, userID uint64, linkNo string, page, pageSize int) ([]*entity.PaymentOrder, int64, error) {
+	args := m.Called(ctx, userID, linkNo, page, pageSize)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*entity.PaymentOrder), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockPaymentService) GetPaymentLinkPage(ctx context.Context, linkNo string) (*paymentService.PaymentLinkPage, error) {
+	args := m.Called(ctx, linkNo)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*paymentService.PaymentLinkPage), args.Error(1)
+}
+
+func (m *MockPaymentService) PayPaymentLink(ctx context.Context, linkNo, provider string, amount float64, clientIP string, mobile bool) (*paymentService.CreatePaymentResponse, error) {
+	args := m.Called(ctx, linkNo, provider, amount, clientIP, mobile)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*paymentService.CreatePaymentResponse), args.Error(1)
+}
+
+func (m *MockPaymentService) GetQRCode(ctx context.Context, orderNo string) (string, error) {
+	args := m.Called(ctx, orderNo)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPaymentService) WatchOrderStatus(ctx context.Context, userID uint64, orderNo string) (<-chan *paymentService.OrderStatusEvent, error) {
+	args := m.Called(ctx, userID, orderNo)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(<-chan *paymentService.OrderStatusEvent), args.Error(1)
+}
+
+func (m *MockPaymentService) WaitPayment(ctx context.Context, userID uint64, orderNo string, wait time.Duration) (interface{}, error) {
+	args := m.Called(ctx, userID, orderNo, wait)
+	return args.Get(0), args.Error(1)
+}
+
+func (m *MockPaymentService) HandleReturn(ctx context.Context, provider string, configID uint64, params map[string][]string) (*entity.PaymentOrder, string, error) {
+	args := m.Called(ctx, provider, configID, params)
+	if args.Get(0) == nil {
+		return nil, "", args.Error(2)
+	}
+	return args.Get(0).(*entity.PaymentOrder), args.String(1), args.Error(2)
+}
+
+func (m *MockPaymentService) GetSignKey(ctx context.Context, userID uint64) (string, error) {
+	args := m.Called(ctx, userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPaymentService) GetNotifyURL(ctx context.Context, userID uint64, provider string) (string, error) {
+	args := m.Called(ctx, userID, provider)
+	return args.String(0), args.Error(1)
+}
+
 // TestHandleNotify_Alipay 测试支付宝支付通知
 func TestHandleNotify_Alipay(t *testing.T) {
 	gin.SetMode(gin.TestMode)
@@ -64,7 +411,7 @@ func TestHandleNotify_Alipay(t *testing.T) {
 
 	mockService := new(MockPaymentService)
 	// Mock 配置查询
-	mockService.On("GetConfigByID", mock.Anything, uint64(1)).Return(map[string]interface{}{
+	mockService.On("ResolveNotifyConfig", mock.Anything, "alipay", "1").Return(map[string]interface{}{
 		"app_id":      "test_app_id",
 		"private_key": "test_private_key",
 		"public_key":  "test_public_key",
@@ -80,7 +427,7 @@ func TestHandleNotify_Alipay(t *testing.T) {
 	c.Request.Form = formData
 	c.Params = gin.Params{
 		{Key: "provider", Value: "alipay"},
-		{Key: "config_id", Value: "1"},
+		{Key: "token", Value: "1"},
 	}
 
 	handler.HandleNotify(c)
@@ -99,7 +446,7 @@ func TestHandleNotify_Wechat(t *testing.T) {
 
 	mockService := new(MockPaymentService)
 	// Mock 配置查询
-	mockService.On("GetConfigByID", mock.Anything, uint64(2)).Return(map[string]interface{}{
+	mockService.On("ResolveNotifyConfig", mock.Anything, "wechat", "2").Return(map[string]interface{}{
 		"mch_id":     "test_mch_id",
 		"api_v3_key": "test_api_v3_key",
 	}, nil)
@@ -113,7 +460,7 @@ func TestHandleNotify_Wechat(t *testing.T) {
 	c.Request.Header.Set("Content-Type", "application/xml")
 	c.Params = gin.Params{
 		{Key: "provider", Value: "wechat"},
-		{Key: "config_id", Value: "2"},
+		{Key: "token", Value: "2"},
 	}
 
 	handler.HandleNotify(c)
@@ -132,7 +479,7 @@ func TestHandleNotify_Stripe(t *testing.T) {
 
 	mockService := new(MockPaymentService)
 	// Mock 配置查询
-	mockService.On("GetConfigByID", mock.Anything, uint64(3)).Return(map[string]interface{}{
+	mockService.On("ResolveNotifyConfig", mock.Anything, "stripe", "3").Return(map[string]interface{}{
 		"api_key":        "test_api_key",
 		"webhook_secret": "test_webhook_secret",
 	}, nil)
@@ -147,7 +494,7 @@ func TestHandleNotify_Stripe(t *testing.T) {
 	c.Request.Header.Set("Stripe-Signature", "t=1234567890,v1=test_signature")
 	c.Params = gin.Params{
 		{Key: "provider", Value: "stripe"},
-		{Key: "config_id", Value: "3"},
+		{Key: "token", Value: "3"},
 	}
 
 	handler.HandleNotify(c)
@@ -166,7 +513,7 @@ func TestHandleNotify_PayPal(t *testing.T) {
 
 	mockService := new(MockPaymentService)
 	// Mock 配置查询
-	mockService.On("GetConfigByID", mock.Anything, uint64(4)).Return(map[string]interface{}{
+	mockService.On("ResolveNotifyConfig", mock.Anything, "paypal", "4").Return(map[string]interface{}{
 		"client_id":     "test_client_id",
 		"client_secret": "test_client_secret",
 		"webhook_id":    "test_webhook_id",
@@ -186,7 +533,7 @@ func TestHandleNotify_PayPal(t *testing.T) {
 	c.Request.Header.Set("Paypal-Auth-Algo", "SHA256withRSA")
 	c.Params = gin.Params{
 		{Key: "provider", Value: "paypal"},
-		{Key: "config_id", Value: "4"},
+		{Key: "token", Value: "4"},
 	}
 
 	handler.HandleNotify(c)
@@ -208,7 +555,7 @@ func TestHandleNotify_InvalidProvider(t *testing.T) {
 	c.Request = httptest.NewRequest("POST", "/notify//1", nil)
 	c.Params = gin.Params{
 		{Key: "provider", Value: ""},
-		{Key: "config_id", Value: "1"},
+		{Key: "token", Value: "1"},
 	}
 
 	handler.HandleNotify(c)
@@ -217,11 +564,14 @@ func TestHandleNotify_InvalidProvider(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "provider is required")
 }
 
-// TestHandleNotify_InvalidConfigID 测试无效的配置ID
-func TestHandleNotify_InvalidConfigID(t *testing.T) {
+// TestHandleNotify_InvalidToken 测试无效的配置令牌
+func TestHandleNotify_InvalidToken(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(MockPaymentService)
+	mockService.On("ResolveNotifyConfig", mock.Anything, "stripe", "abc").
+		Return(nil, apperrors.New(apperrors.ErrInvalidParam, "invalid notify token"))
+
 	handler := NewPaymentHandler(mockService)
 
 	w := httptest.NewRecorder()
@@ -229,13 +579,14 @@ func TestHandleNotify_InvalidConfigID(t *testing.T) {
 	c.Request = httptest.NewRequest("POST", "/notify/stripe/abc", nil)
 	c.Params = gin.Params{
 		{Key: "provider", Value: "stripe"},
-		{Key: "config_id", Value: "abc"},
+		{Key: "token", Value: "abc"},
 	}
 
 	handler.HandleNotify(c)
 
 	assert.Equal(t, 400, w.Code)
-	assert.Contains(t, w.Body.String(), "invalid config_id")
+	assert.Contains(t, w.Body.String(), "config not found")
+	mockService.AssertExpectations(t)
 }
 
 // TestHandleNotify_ConfigNotFound 测试配置不存在
@@ -243,7 +594,7 @@ func TestHandleNotify_ConfigNotFound(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(MockPaymentService)
-	mockService.On("GetConfigByID", mock.Anything, uint64(999)).Return(nil, assert.AnError)
+	mockService.On("ResolveNotifyConfig", mock.Anything, "stripe", "999").Return(nil, assert.AnError)
 
 	handler := NewPaymentHandler(mockService)
 
@@ -252,7 +603,7 @@ func TestHandleNotify_ConfigNotFound(t *testing.T) {
 	c.Request = httptest.NewRequest("POST", "/notify/stripe/999", nil)
 	c.Params = gin.Params{
 		{Key: "provider", Value: "stripe"},
-		{Key: "config_id", Value: "999"},
+		{Key: "token", Value: "999"},
 	}
 
 	handler.HandleNotify(c)
@@ -262,6 +613,38 @@ func TestHandleNotify_ConfigNotFound(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+// TestHandleNotify_WithoutToken 测试不带配置令牌的通知按通知内容识别配置
+func TestHandleNotify_WithoutToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testData := []byte(`{"notificationItems": [{"NotificationRequestItem": {"merchantAccountCode": "TestMerchantECOM"}}]}`)
+	config := map[string]interface{}{"merchant_account": "TestMerchantECOM"}
+
+	mockService := new(MockPaymentService)
+	mockService.On("ResolveProviderNotifyConfig", mock.Anything, "adyen", mock.MatchedBy(func(req *payment.NotifyRequest) bool {
+		return bytes.Equal(req.RawData, testData)
+	})).Return(config, nil)
+	mockService.On("HandleNotify", mock.Anything, "adyen", mock.MatchedBy(func(req *payment.NotifyRequest) bool {
+		return req.Config["merchant_account"] == "TestMerchantECOM"
+	})).Return([]byte("[accepted]"), nil)
+
+	handler := NewPaymentHandler(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/notify/adyen", bytes.NewBuffer(testData))
+	c.Params = gin.Params{
+		{Key: "provider", Value: "adyen"},
+	}
+
+	handler.HandleNotify(c)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "[accepted]", w.Body.String())
+	mockService.AssertNotCalled(t, "ResolveNotifyConfig", mock.Anything, mock.Anything, mock.Anything)
+	mockService.AssertExpectations(t)
+}
+
 // TestHandleNotify_ServiceError 测试服务错误
 func TestHandleNotify_ServiceError(t *testing.T) {
 	gin.SetMode(gin.TestMode)
@@ -269,7 +652,7 @@ func TestHandleNotify_ServiceError(t *testing.T) {
 	testData := []byte(`{"test": "data"}`)
 
 	mockService := new(MockPaymentService)
-	mockService.On("GetConfigByID", mock.Anything, uint64(3)).Return(map[string]interface{}{
+	mockService.On("ResolveNotifyConfig", mock.Anything, "stripe", "3").Return(map[string]interface{}{
 		"api_key": "test_api_key",
 	}, nil)
 	mockService.On("HandleNotify", mock.Anything, "stripe", mock.Anything).Return(nil, assert.AnError)
@@ -281,7 +664,7 @@ func TestHandleNotify_ServiceError(t *testing.T) {
 	c.Request = httptest.NewRequest("POST", "/notify/stripe/3", bytes.NewBuffer(testData))
 	c.Params = gin.Params{
 		{Key: "provider", Value: "stripe"},
-		{Key: "config_id", Value: "3"},
+		{Key: "token", Value: "3"},
 	}
 
 	handler.HandleNotify(c)
@@ -291,6 +674,157 @@ func TestHandleNotify_ServiceError(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+// TestCapture_Partial 测试预授权部分扣款
+func TestCapture_Partial(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockPaymentService)
+	mockService.On("Capture", mock.Anything, uint64(1), "UNI123", 80.5).Return(&entity.PaymentOrder{
+		OrderNo:       "UNI123",
+		PreAuth:       true,
+		Amount:        100,
+		CaptureAmount: 80.5,
+		Status:        entity.OrderStatusCaptured,
+	}, nil)
+
+	handler := NewPaymentHandler(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/payment/capture", strings.NewReader(`{"order_no":"UNI123","amount":80.5}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("user_id", uint64(1))
+
+	handler.Capture(c)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"captured"`)
+	mockService.AssertExpectations(t)
+}
+
+// TestCreatePayment_Items 测试商品明细、买家和收货信息传递给服务层
+func TestCreatePayment_Items(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockPaymentService)
+	mockService.On("CreatePayment", mock.Anything, mock.MatchedBy(func(req *paymentService.CreatePaymentRequest) bool {
+		return len(req.Items) == 2 &&
+			req.Items[0] == entity.OrderItem{Name: "T-Shirt", SKU: "TS-01", Quantity: 2, UnitPrice: 10, Tax: 1} &&
+			req.Buyer.Email == "buyer@example.com" &&
+			req.Shipping.Country == "US" && req.Shipping.Fee == 5
+	})).Return(&paymentService.CreatePaymentResponse{OrderNo: "UNI123", Status: entity.OrderStatusPending}, nil)
+
+	handler := NewPaymentHandler(mockService)
+
+	body := `{
+		"provider": "paypal", "out_trade_no": "ORDER1", "subject": "Order", "amount": 32, "currency": "USD",
+		"items": [
+			{"name": "T-Shirt", "sku": "TS-01", "quantity": 2, "unit_price": 10, "tax": 1},
+			{"name": "Sticker", "quantity": 1, "unit_price": 5}
+		],
+		"buyer": {"email": "buyer@example.com"},
+		"shipping": {"name": "Jane Doe", "country": "us", "city": "San Jose", "line1": "1 Main St", "fee": 5}
+	}`
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/payment/create", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("user_id", uint64(1))
+
+	handler.CreatePayment(c)
+
+	assert.Equal(t, 200, w.Code)
+	mockService.AssertExpectations(t)
+
+	// 商品数量必须大于0
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/payment/create", strings.NewReader(
+		`{"provider": "paypal", "out_trade_no": "ORDER2", "subject": "Order", "amount": 10, "items": [{"name": "T-Shirt", "quantity": 0, "unit_price": 10}]}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("user_id", uint64(1))
+
+	handler.CreatePayment(c)
+
+	assert.Equal(t, 400, w.Code)
+}
+
+// TestRefund_Processing 测试退款受理后返回处理中的退款记录
+func TestRefund_Processing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockPaymentService)
+	mockService.On("Refund", mock.Anything, uint64(1), "UNI123", 30.0, "out of stock").Return(&entity.Refund{
+		RefundNo: "RF123",
+		OrderNo:  "UNI123",
+		Amount:   30,
+		Status:   entity.RefundStatusProcessing,
+	}, nil)
+
+	handler := NewPaymentHandler(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/payment/refund", strings.NewReader(`{"order_no":"UNI123","amount":30,"reason":"out of stock"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("user_id", uint64(1))
+
+	handler.Refund(c)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), `"refund_no":"RF123"`)
+	assert.Contains(t, w.Body.String(), `"status":"processing"`)
+	mockService.AssertExpectations(t)
+}
+
+// TestClosePayment_NotSupported 测试提供商不支持关闭订单
+func TestClosePayment_NotSupported(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockPaymentService)
+	mockService.On("ClosePayment", mock.Anything, uint64(1), "UNI123").
+		Return(nil, apperrors.New(apperrors.ErrNotSupported, "provider wechat does not support closing payments"))
+
+	handler := NewPaymentHandler(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/payment/close", strings.NewReader(`{"order_no":"UNI123"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("user_id", uint64(1))
+
+	handler.ClosePayment(c)
+
+	assert.Equal(t, 400, w.Code)
+	assert.Contains(t, w.Body.String(), fmt.Sprintf(`"code":%d`, apperrors.ErrNotSupported))
+	mockService.AssertExpectations(t)
+}
+
+// TestSimulatePayment_Redirect 测试收银台模拟页面操作后跳转到商户页面
+func TestSimulatePayment_Redirect(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockPaymentService)
+	mockService.On("SimulatePayment", mock.Anything, "UNI123", "pay").
+		Return(&entity.PaymentOrder{OrderNo: "UNI123", Status: entity.OrderStatusSuccess, ReturnURL: "https://merchant.example.com/return"}, nil)
+
+	handler := NewPaymentHandler(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/public/mock/checkout/UNI123", strings.NewReader("action=pay"))
+	c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	c.Params = gin.Params{{Key: "order_no", Value: "UNI123"}}
+
+	handler.SimulatePayment(c)
+	c.Writer.WriteHeaderNow()
+
+	assert.Equal(t, 302, w.Code)
+	assert.Equal(t, "https://merchant.example.com/return", w.Header().Get("Location"))
+	mockService.AssertExpectations(t)
+}
+
 // parseFormData 解析表单数据
 func parseFormData(data string) url.Values {
 	values := url.Values{}
@@ -312,7 +846,7 @@ func BenchmarkHandleNotify_Alipay(b *testing.B) {
 
 	testData, _ := os.ReadFile("testdata/alipay_notify.txt")
 	mockService := new(MockPaymentService)
-	mockService.On("GetConfigByID", mock.Anything, uint64(1)).Return(map[string]interface{}{
+	mockService.On("ResolveNotifyConfig", mock.Anything, "alipay", "1").Return(map[string]interface{}{
 		"app_id": "test_app_id",
 	}, nil)
 	mockService.On("HandleNotify", mock.Anything, "alipay", mock.Anything).Return([]byte("success"), nil)
@@ -326,7 +860,7 @@ func BenchmarkHandleNotify_Alipay(b *testing.B) {
 		c.Request = httptest.NewRequest("POST", "/notify/alipay/1", bytes.NewBuffer(testData))
 		c.Params = gin.Params{
 			{Key: "provider", Value: "alipay"},
-			{Key: "config_id", Value: "1"},
+			{Key: "token", Value: "1"},
 		}
 		handler.HandleNotify(c)
 	}
@@ -337,7 +871,7 @@ func ExamplePaymentHandler_HandleNotify() {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(MockPaymentService)
-	mockService.On("GetConfigByID", mock.Anything, uint64(3)).Return(map[string]interface{}{
+	mockService.On("ResolveNotifyConfig", mock.Anything, "stripe", "3").Return(map[string]interface{}{
 		"api_key": "test_api_key",
 	}, nil)
 	mockService.On("HandleNotify", mock.Anything, "stripe", mock.Anything).Return([]byte(`{"received": true}`), nil)
@@ -362,7 +896,7 @@ func ExamplePaymentHandler_HandleNotify() {
 	c.Request.Header.Set("Content-Type", "application/json")
 	c.Params = gin.Params{
 		{Key: "provider", Value: "stripe"},
-		{Key: "config_id", Value: "3"},
+		{Key: "token", Value: "3"},
 	}
 
 	handler.HandleNotify(c)
@@ -371,3 +905,226 @@ func ExamplePaymentHandler_HandleNotify() {
 	fmt.Print(string(response))
 	// Output: {"received": true}
 }
+
+// TestPayCheckout_Mobile 测试手机访问收银台选择提供商后跳转到支付页面
+func TestPayCheckout_Mobile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockPaymentService)
+	mockService.On("PayCheckout", mock.Anything, "CO123", "alipay", mock.Anything, true).Return(&entity.Checkout{
+		CheckoutNo: "CO123",
+		Provider:   "alipay",
+		Status:     entity.CheckoutStatusPaying,
+		PaymentURL: "https://openapi.alipay.com/gateway.do?method=alipay.trade.wap.pay",
+	}, nil)
+
+	handler := NewPaymentHandler(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/checkout/CO123", strings.NewReader("provider=alipay"))
+	c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	c.Request.Header.Set("User-Agent", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) Mobile/15E148")
+	c.Params = gin.Params{{Key: "checkout_no", Value: "CO123"}}
+
+	handler.PayCheckout(c)
+	c.Writer.WriteHeaderNow()
+
+	assert.Equal(t, 302, w.Code)
+	assert.Equal(t, "https://openapi.alipay.com/gateway.do?method=alipay.trade.wap.pay", w.Header().Get("Location"))
+	mockService.AssertExpectations(t)
+}
+
+// TestPayPaymentLink_Unavailable 测试支付链接不可支付时展示原因
+func TestPayPaymentLink_Unavailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	link := &entity.PaymentLink{LinkNo: "LNK123", Subject: "Consulting", Status: entity.PaymentLinkStatusCompleted}
+	mockService := new(MockPaymentService)
+	mockService.On("PayPaymentLink", mock.Anything, "LNK123", "stripe", 50.0, mock.Anything, false).
+		Return(nil, apperrors.New(apperrors.ErrOrderStatus, "payment link is completed"))
+	mockService.On("GetPaymentLinkPage", mock.Anything, "LNK123").
+		Return(&paymentService.PaymentLinkPage{Link: link, Unavailable: "payment link is completed"}, nil)
+
+	handler := NewPaymentHandler(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/pay/LNK123", strings.NewReader("provider=stripe&amount=50"))
+	c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	c.Params = gin.Params{{Key: "link_no", Value: "LNK123"}}
+
+	handler.PayPaymentLink(c)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), "payment link is completed")
+	mockService.AssertExpectations(t)
+}
+
+// TestQRCodeImage 测试生成订单二维码图片
+func TestQRCodeImage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockPaymentService)
+	mockService.On("GetQRCode", mock.Anything, "UNI123").Return("weixin://wxpay/bizpayurl?pr=abc123", nil)
+
+	handler := NewPaymentHandler(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/payment/qrcode/UNI123.png?size=200&margin=2&level=H", nil)
+	c.Params = gin.Params{{Key: "file", Value: "UNI123.png"}}
+
+	handler.QRCodeImage(c)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	img, err := png.Decode(w.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, img.Bounds().Dx())
+	mockService.AssertExpectations(t)
+}
+
+// TestOrderEvents 测试推送订单状态变更
+func TestOrderEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger.Log = zap.NewNop()
+
+	events := make(chan *paymentService.OrderStatusEvent, 2)
+	events <- &paymentService.OrderStatusEvent{OrderNo: "UNI123", Status: entity.OrderStatusPending}
+	events <- &paymentService.OrderStatusEvent{OrderNo: "UNI123", Status: entity.OrderStatusSuccess}
+	close(events)
+
+	mockService := new(MockPaymentService)
+	mockService.On("WatchOrderStatus", mock.Anything, uint64(1), "UNI123").
+		Return((<-chan *paymentService.OrderStatusEvent)(events), nil)
+
+	handler := NewPaymentHandler(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/payment/events/UNI123", nil)
+	c.Params = gin.Params{{Key: "order_no", Value: "UNI123"}}
+	c.Set("user_id", uint64(1))
+
+	handler.OrderEvents(c)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	assert.Equal(t, 2, strings.Count(body, "event:status"))
+	assert.Contains(t, body, `"status":"pending"`)
+	assert.Contains(t, body, `"status":"success"`)
+	mockService.AssertExpectations(t)
+}
+
+// apiLogRecorder 记录日志中间件保存的请求日志
+type apiLogRecorder struct {
+	repository.APILogRepository
+	logs chan *entity.APILog
+}
+
+func (r *apiLogRecorder) Create(ctx context.Context, log *entity.APILog) error {
+	r.logs <- log
+	return nil
+}
+
+// TestOrderEvents_WriteTimeout 测试经过日志中间件时，推送连接不受服务器写超时限制，响应体不记录到请求日志
+func TestOrderEvents_WriteTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger.Log = zap.NewNop()
+
+	events := make(chan *paymentService.OrderStatusEvent)
+	go func() {
+		events <- &paymentService.OrderStatusEvent{OrderNo: "UNI123", Status: entity.OrderStatusPending}
+		// 超过服务器写超时后订单才支付成功
+		time.Sleep(500 * time.Millisecond)
+		events <- &paymentService.OrderStatusEvent{OrderNo: "UNI123", Status: entity.OrderStatusSuccess}
+		close(events)
+	}()
+
+	mockService := new(MockPaymentService)
+	mockService.On("WatchOrderStatus", mock.Anything, uint64(1), "UNI123").
+		Return((<-chan *paymentService.OrderStatusEvent)(events), nil)
+	handler := NewPaymentHandler(mockService)
+
+	logs := &apiLogRecorder{logs: make(chan *entity.APILog, 1)}
+	r := gin.New()
+	r.Use(middleware.LoggerMiddleware(logs))
+	r.GET("/payment/events/:order_no", func(c *gin.Context) {
+		c.Set("user_id", uint64(1))
+		handler.OrderEvents(c)
+	})
+
+	srv := httptest.NewUnstartedServer(r)
+	srv.Config.WriteTimeout = 200 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/payment/events/UNI123")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	assert.Contains(t, string(body), `"status":"pending"`)
+	assert.Contains(t, string(body), `"status":"success"`)
+
+	select {
+	case log := <-logs.logs:
+		assert.Empty(t, log.ResponseBody)
+	case <-time.After(2 * time.Second):
+		t.Fatal("api log not saved")
+	}
+	mockService.AssertExpectations(t)
+}
+
+// TestQueryPayment_Wait 测试长轮询查询支付，等待时间不超过服务器写超时
+func TestQueryPayment_Wait(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	order := &entity.PaymentOrder{OrderNo: "UNI123", Status: entity.OrderStatusSuccess}
+	mockService := new(MockPaymentService)
+	mockService.On("WaitPayment", mock.Anything, uint64(1), "UNI123", 25*time.Second).Return(order, nil)
+
+	handler := NewPaymentHandler(mockService)
+	handler.SetWriteTimeout(30 * time.Second)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/payment/query/UNI123?wait=60s", nil)
+	c.Params = gin.Params{{Key: "order_no", Value: "UNI123"}}
+	c.Set("user_id", uint64(1))
+
+	handler.QueryPayment(c)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"success"`)
+	mockService.AssertExpectations(t)
+}
+
+// TestHandleReturn_Redirect 测试同步跳转确认支付结果后携带签名参数跳转到商户页面
+func TestHandleReturn_Redirect(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	redirectURL := "https://merchant.example.com/result?order_no=UNI123&status=success&sig=abc"
+	mockService := new(MockPaymentService)
+	mockService.On("HandleReturn", mock.Anything, "alipay", uint64(12), mock.MatchedBy(func(params map[string][]string) bool {
+		return params["order_no"][0] == "UNI123" && params["out_trade_no"][0] == "ORDER_001"
+	})).Return(&entity.PaymentOrder{OrderNo: "UNI123", Status: entity.OrderStatusSuccess}, redirectURL, nil)
+
+	handler := NewPaymentHandler(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/public/return/alipay/12?order_no=UNI123&out_trade_no=ORDER_001&sign=xyz", nil)
+	c.Params = gin.Params{{Key: "provider", Value: "alipay"}, {Key: "config_id", Value: "12"}}
+
+	handler.HandleReturn(c)
+	c.Writer.WriteHeaderNow()
+
+	assert.Equal(t, 302, w.Code)
+	assert.Equal(t, redirectURL, w.Header().Get("Location"))
+	mockService.AssertExpectations(t)
+}
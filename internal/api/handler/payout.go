@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	paymentService "github.com/zqdfound/go-uni-pay/internal/service/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+)
+
+// CreatePayoutRequest 创建付款请求
+type CreatePayoutRequest struct {
+	Provider     string  `json:"provider" binding:"required"`
+	OutPayoutNo  string  `json:"out_payout_no" binding:"required,max=64"`
+	Amount       float64 `json:"amount" binding:"required,gt=0"`
+	Currency     string  `json:"currency"`
+	PayeeType    string  `json:"payee_type"`
+	PayeeAccount string  `json:"payee_account" binding:"required"`
+	PayeeName    string  `json:"payee_name"`
+	Remark       string  `json:"remark"`
+	NotifyURL    string  `json:"notify_url"`
+}
+
+// CreatePayout 发起付款，同一 out_payout_no 重复请求返回已有付款
+func (h *PaymentHandler) CreatePayout(c *gin.Context) {
+	var req CreatePayoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"code":    apperrors.ErrInvalidParam,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	payout, err := h.paymentService.CreatePayout(c.Request.Context(), &paymentService.CreatePayoutRequest{
+		UserID:       userID.(uint64),
+		Provider:     req.Provider,
+		OutPayoutNo:  req.OutPayoutNo,
+		Amount:       req.Amount,
+		Currency:     req.Currency,
+		PayeeType:    req.PayeeType,
+		PayeeAccount: req.PayeeAccount,
+		PayeeName:    req.PayeeName,
+		Remark:       req.Remark,
+		NotifyURL:    req.NotifyURL,
+	})
+	h.respond(c, payout, err)
+}
+
+// QueryPayout 查询付款
+func (h *PaymentHandler) QueryPayout(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	payout, err := h.paymentService.QueryPayout(c.Request.Context(), userID.(uint64), c.Param("payout_no"))
+	h.respond(c, payout, err)
+}
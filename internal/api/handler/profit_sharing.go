@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/zqdfound/go-uni-pay/internal/domain/entity"
+	paymentService "github.com/zqdfound/go-uni-pay/internal/service/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+)
+
+// ProfitSharingReceiverRequest 分账接收方，amount 为空时按 ratio（0-1）计算
+type ProfitSharingReceiverRequest struct {
+	Type        string  `json:"type"`
+	Account     string  `json:"account" binding:"required"`
+	Name        string  `json:"name"`
+	Amount      float64 `json:"amount" binding:"gte=0"`
+	Ratio       float64 `json:"ratio" binding:"gte=0,lte=1"`
+	Description string  `json:"description"`
+}
+
+// ProfitSharingPlanRequest 创建支付时的分账计划
+type ProfitSharingPlanRequest struct {
+	Settle    string                         `json:"settle"`
+	Receivers []ProfitSharingReceiverRequest `json:"receivers" binding:"required,min=1,dive"`
+}
+
+// ShareProfitRequest 分账请求
+type ShareProfitRequest struct {
+	OrderNo      string                         `json:"order_no" binding:"required"`
+	OutSharingNo string                         `json:"out_sharing_no" binding:"required,max=64"`
+	Receivers    []ProfitSharingReceiverRequest `json:"receivers" binding:"dive"`
+	Finish       bool                           `json:"finish"`
+}
+
+// ReturnProfitSharingRequest 分账回退请求
+type ReturnProfitSharingRequest struct {
+	SharingNo   string  `json:"sharing_no" binding:"required"`
+	OutReturnNo string  `json:"out_return_no" binding:"required,max=64"`
+	Account     string  `json:"account" binding:"required"`
+	Amount      float64 `json:"amount" binding:"gte=0"`
+	Description string  `json:"description"`
+}
+
+// ShareProfit 发起分账，同一 out_sharing_no 重复请求返回已有分账
+func (h *PaymentHandler) ShareProfit(c *gin.Context) {
+	var req ShareProfitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"code":    apperrors.ErrInvalidParam,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	sharing, err := h.paymentService.ShareProfit(c.Request.Context(), &paymentService.ShareProfitRequest{
+		UserID:       userID.(uint64),
+		OrderNo:      req.OrderNo,
+		OutSharingNo: req.OutSharingNo,
+		Receivers:    toProfitSharingReceivers(req.Receivers),
+		Finish:       req.Finish,
+	})
+	h.respond(c, sharing, err)
+}
+
+// QueryProfitSharing 查询分账
+func (h *PaymentHandler) QueryProfitSharing(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	sharing, err := h.paymentService.QueryProfitSharing(c.Request.Context(), userID.(uint64), c.Param("sharing_no"))
+	h.respond(c, sharing, err)
+}
+
+// ReturnProfitSharing 发起分账回退，同一 out_return_no 重复请求返回已有回退
+func (h *PaymentHandler) ReturnProfitSharing(c *gin.Context) {
+	var req ReturnProfitSharingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"code":    apperrors.ErrInvalidParam,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	ret, err := h.paymentService.ReturnProfitSharing(c.Request.Context(), &paymentService.ReturnProfitSharingRequest{
+		UserID:      userID.(uint64),
+		SharingNo:   req.SharingNo,
+		OutReturnNo: req.OutReturnNo,
+		Account:     req.Account,
+		Amount:      req.Amount,
+		Description: req.Description,
+	})
+	h.respond(c, ret, err)
+}
+
+// QueryProfitSharingReturn 查询分账回退
+func (h *PaymentHandler) QueryProfitSharingReturn(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	ret, err := h.paymentService.QueryProfitSharingReturn(c.Request.Context(), userID.(uint64), c.Param("return_no"))
+	h.respond(c, ret, err)
+}
+
+// toProfitSharingPlan 转换为服务层使用的分账计划
+func toProfitSharingPlan(plan *ProfitSharingPlanRequest) *entity.ProfitSharingPlan {
+	if plan == nil {
+		return nil
+	}
+	return &entity.ProfitSharingPlan{
+		Settle:    plan.Settle,
+		Receivers: toProfitSharingReceivers(plan.Receivers),
+	}
+}
+
+// toProfitSharingReceivers 转换为服务层使用的分账接收方
+func toProfitSharingReceivers(receivers []ProfitSharingReceiverRequest) []entity.ProfitSharingReceiver {
+	result := make([]entity.ProfitSharingReceiver, 0, len(receivers))
+	for _, r := range receivers {
+		result = append(result, entity.ProfitSharingReceiver{
+			Type:        r.Type,
+			Account:     r.Account,
+			Name:        r.Name,
+			Amount:      r.Amount,
+			Ratio:       r.Ratio,
+			Description: r.Description,
+		})
+	}
+	return result
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+	"github.com/zqdfound/go-uni-pay/pkg/qrcode"
+)
+
+// QRCodeImage 生成订单的二维码图片，路径为 /payment/qrcode/:order_no.png 或 .svg
+// 可通过 size（像素）、margin（留白模块数）、level（纠错级别 L/M/Q/H）调整
+func (h *PaymentHandler) QRCodeImage(c *gin.Context) {
+	file := c.Param("file")
+	ext := path.Ext(file)
+	if ext != ".png" && ext != ".svg" {
+		c.JSON(400, gin.H{
+			"code":    apperrors.ErrInvalidParam,
+			"message": "image format must be png or svg",
+		})
+		return
+	}
+
+	opts := qrcode.Options{
+		Size:   qrcode.DefaultSize,
+		Margin: qrcode.DefaultMargin,
+		Level:  c.DefaultQuery("level", qrcode.DefaultLevel),
+	}
+	var err error
+	if size := c.Query("size"); size != "" {
+		if opts.Size, err = strconv.Atoi(size); err != nil {
+			c.JSON(400, gin.H{
+				"code":    apperrors.ErrInvalidParam,
+				"message": "size must be an integer",
+			})
+			return
+		}
+	}
+	if margin := c.Query("margin"); margin != "" {
+		if opts.Margin, err = strconv.Atoi(margin); err != nil {
+			c.JSON(400, gin.H{
+				"code":    apperrors.ErrInvalidParam,
+				"message": "margin must be an integer",
+			})
+			return
+		}
+	}
+
+	content, err := h.paymentService.GetQRCode(c.Request.Context(), strings.TrimSuffix(file, ext))
+	if err != nil {
+		h.respond(c, nil, err)
+		return
+	}
+
+	var (
+		image       []byte
+		contentType string
+	)
+	if ext == ".svg" {
+		image, err = qrcode.SVG(content, opts)
+		contentType = "image/svg+xml"
+	} else {
+		image, err = qrcode.PNG(content, opts)
+		contentType = "image/png"
+	}
+	if err != nil {
+		h.respond(c, nil, err)
+		return
+	}
+
+	// 订单支付后二维码失效，不允许缓存
+	c.Header("Cache-Control", "no-store")
+	c.Data(200, contentType, image)
+}
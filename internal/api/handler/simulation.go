@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"html/template"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+)
+
+// simulationPage 收银台模拟页面
+var simulationPage = template.Must(template.New("simulation").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Mock Checkout</title>
+</head>
+<body>
+<h2>Mock Checkout</h2>
+<p>Sandbox only, no real funds are moved.</p>
+<table>
+<tr><td>Order No</td><td>{{.OrderNo}}</td></tr>
+<tr><td>Subject</td><td>{{.Subject}}</td></tr>
+<tr><td>Amount</td><td>{{printf "%.2f" .Amount}} {{.Currency}}</td></tr>
+<tr><td>Status</td><td>{{.Status}}</td></tr>
+</table>
+<form method="post">
+<button type="submit" name="action" value="pay">Pay</button>
+<button type="submit" name="action" value="fail">Fail</button>
+<button type="submit" name="action" value="cancel">Cancel</button>
+</form>
+</body>
+</html>
+`))
+
+// SimulationPage 展示沙箱提供商的收银台模拟页面
+func (h *PaymentHandler) SimulationPage(c *gin.Context) {
+	order, err := h.paymentService.SimulationOrder(c.Request.Context(), c.Param("order_no"))
+	if err != nil {
+		c.String(404, "order not found")
+		return
+	}
+
+	c.Status(200)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := simulationPage.Execute(c.Writer, order); err != nil {
+		c.String(500, "error")
+	}
+}
+
+// SimulatePayment 提交收银台模拟页面的操作
+// 操作完成后跳转到商户的 return_url
+func (h *PaymentHandler) SimulatePayment(c *gin.Context) {
+	action := c.PostForm("action")
+	switch action {
+	case payment.SimulatePay, payment.SimulateFail, payment.SimulateCancel:
+	default:
+		c.JSON(400, gin.H{
+			"code":    apperrors.ErrInvalidParam,
+			"message": "action must be one of pay, fail, cancel",
+		})
+		return
+	}
+
+	order, err := h.paymentService.SimulatePayment(c.Request.Context(), c.Param("order_no"), action)
+	if err == nil && order.ReturnURL != "" {
+		c.Redirect(302, order.ReturnURL)
+		return
+	}
+
+	h.respond(c, order, err)
+}
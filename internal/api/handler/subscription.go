@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	paymentService "github.com/zqdfound/go-uni-pay/internal/service/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+)
+
+// CreatePlanRequest 创建订阅计划请求
+type CreatePlanRequest struct {
+	OutPlanNo     string  `json:"out_plan_no" binding:"required,max=64"`
+	Name          string  `json:"name" binding:"required,max=128"`
+	Description   string  `json:"description"`
+	Amount        float64 `json:"amount" binding:"required,gt=0"`
+	Currency      string  `json:"currency" binding:"required"`
+	Interval      string  `json:"interval" binding:"required,oneof=day week month year"`
+	IntervalCount int     `json:"interval_count" binding:"gte=0"`
+	TrialDays     int     `json:"trial_days" binding:"gte=0"`
+}
+
+// CreatePlan 创建订阅计划，同一 out_plan_no 重复请求返回已有计划
+func (h *PaymentHandler) CreatePlan(c *gin.Context) {
+	var req CreatePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"code":    apperrors.ErrInvalidParam,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	plan, err := h.paymentService.CreatePlan(c.Request.Context(), &paymentService.CreatePlanRequest{
+		UserID:        userID.(uint64),
+		OutPlanNo:     req.OutPlanNo,
+		Name:          req.Name,
+		Description:   req.Description,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		Interval:      req.Interval,
+		IntervalCount: req.IntervalCount,
+		TrialDays:     req.TrialDays,
+	})
+	h.respond(c, plan, err)
+}
+
+// QueryPlan 查询订阅计划
+func (h *PaymentHandler) QueryPlan(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	plan, err := h.paymentService.QueryPlan(c.Request.Context(), userID.(uint64), c.Param("plan_no"))
+	h.respond(c, plan, err)
+}
+
+// CreateSubscriptionRequest 创建订阅请求
+type CreateSubscriptionRequest struct {
+	Provider          string `json:"provider" binding:"required"`
+	PlanNo            string `json:"plan_no" binding:"required"`
+	OutSubscriptionNo string `json:"out_subscription_no" binding:"required,max=64"`
+	NotifyURL         string `json:"notify_url"`
+	ReturnURL         string `json:"return_url"`
+}
+
+// CreateSubscription 创建订阅，返回买家签约或授权页面，同一 out_subscription_no 重复请求返回已有订阅
+func (h *PaymentHandler) CreateSubscription(c *gin.Context) {
+	var req CreateSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"code":    apperrors.ErrInvalidParam,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	sub, err := h.paymentService.CreateSubscription(c.Request.Context(), &paymentService.CreateSubscriptionRequest{
+		UserID:            userID.(uint64),
+		Provider:          req.Provider,
+		PlanNo:            req.PlanNo,
+		OutSubscriptionNo: req.OutSubscriptionNo,
+		NotifyURL:         req.NotifyURL,
+		ReturnURL:         req.ReturnURL,
+	})
+	h.respond(c, sub, err)
+}
+
+// QuerySubscription 查询订阅
+func (h *PaymentHandler) QuerySubscription(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	sub, err := h.paymentService.QuerySubscription(c.Request.Context(), userID.(uint64), c.Param("subscription_no"))
+	h.respond(c, sub, err)
+}
+
+// CancelSubscriptionRequest 取消订阅请求
+type CancelSubscriptionRequest struct {
+	SubscriptionNo string `json:"subscription_no" binding:"required"`
+	Reason         string `json:"reason"`
+}
+
+// CancelSubscription 取消订阅
+func (h *PaymentHandler) CancelSubscription(c *gin.Context) {
+	var req CancelSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"code":    apperrors.ErrInvalidParam,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	sub, err := h.paymentService.CancelSubscription(c.Request.Context(), userID.(uint64), req.SubscriptionNo, req.Reason)
+	h.respond(c, sub, err)
+}
@@ -3,12 +3,17 @@ package middleware
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 	"github.com/zqdfound/go-uni-pay/internal/domain/entity"
 	"github.com/zqdfound/go-uni-pay/internal/domain/repository"
 	"github.com/zqdfound/go-uni-pay/internal/infrastructure/cache"
@@ -130,17 +135,25 @@ type bodyLogWriter struct {
 	body *bytes.Buffer
 }
 
+// Write 写入响应并记录响应体，Server-Sent Events 长连接的响应体不记录
 func (w bodyLogWriter) Write(b []byte) (int, error) {
-	w.body.Write(b)
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream") {
+		w.body.Write(b)
+	}
 	return w.ResponseWriter.Write(b)
 }
 
+// Unwrap 返回底层的 ResponseWriter，供 http.ResponseController 设置写超时等
+func (w bodyLogWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 // CORSMiddleware CORS中间件
 func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-API-Key")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-API-Key, Idempotency-Key")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
@@ -227,6 +240,156 @@ func RateLimitMiddleware(limit int, window time.Duration) gin.HandlerFunc {
 	}
 }
 
+// idempotencyLockTTL 请求处理期间幂等记录的有效期，处理期间定期续期，进程异常退出时在此之后可以重试
+const idempotencyLockTTL = time.Minute
+
+// idempotencyRenewInterval 请求处理期间续期处理中记录的间隔
+var idempotencyRenewInterval = idempotencyLockTTL / 3
+
+// renewIdempotencyScript 幂等记录仍为处理中记录时续期，请求已完成或记录已被删除时不处理
+var renewIdempotencyScript = redis.NewScript(`
+	if redis.call("get", KEYS[1]) == ARGV[1] then
+		return redis.call("pexpire", KEYS[1], ARGV[2])
+	else
+		return 0
+	end
+`)
+
+// idempotencyRecord 幂等记录，保存请求指纹和完整响应
+type idempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	Completed   bool   `json:"completed"`
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// IdempotencyMiddleware 幂等中间件
+// 写请求携带 Idempotency-Key 时，在Redis中保存请求指纹和完整响应，重试时原样返回保存的响应
+// 同一个键的请求内容不同，或上一次请求仍在处理时返回409；服务端错误不保存，可以使用同一个键重试
+func IdempotencyMiddleware(ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" || c.Request.Method == "GET" || c.Request.Method == "HEAD" {
+			c.Next()
+			return
+		}
+
+		if len(key) > 255 {
+			c.JSON(400, gin.H{
+				"code":    apperrors.ErrInvalidParam,
+				"message": "idempotency key is too long",
+			})
+			c.Abort()
+			return
+		}
+
+		// 请求指纹包含方法、路径和请求体，同一个键不能用于不同的接口
+		var bodyBytes []byte
+		if c.Request.Body != nil {
+			bodyBytes, _ = io.ReadAll(c.Request.Body)
+			c.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+		}
+		sum := sha256.Sum256(append([]byte(c.Request.Method+" "+c.Request.URL.Path+"\n"), bodyBytes...))
+		fingerprint := hex.EncodeToString(sum[:])
+
+		userID, _ := c.Get("user_id")
+		cacheKey := fmt.Sprintf("idempotency:%v:%s", userID, key)
+
+		ctx := c.Request.Context()
+		pending, _ := json.Marshal(&idempotencyRecord{Fingerprint: fingerprint})
+		acquired, err := cache.Client.SetNX(ctx, cacheKey, pending, idempotencyLockTTL).Result()
+		if err != nil {
+			// 无法保证幂等时拒绝请求，避免重试导致重复扣款或退款
+			logger.Error("idempotency redis error", zap.Error(err))
+			c.JSON(500, gin.H{
+				"code":    apperrors.ErrInternalServer,
+				"message": "idempotency store unavailable",
+			})
+			c.Abort()
+			return
+		}
+
+		if !acquired {
+			var record idempotencyRecord
+			data, err := cache.Client.Get(ctx, cacheKey).Bytes()
+			if err == nil {
+				err = json.Unmarshal(data, &record)
+			}
+
+			switch {
+			case err != nil || (!record.Completed && record.Fingerprint == fingerprint):
+				c.JSON(409, gin.H{
+					"code":    apperrors.ErrConflict,
+					"message": "a request with the same idempotency key is in progress",
+				})
+			case record.Fingerprint != fingerprint:
+				c.JSON(409, gin.H{
+					"code":    apperrors.ErrConflict,
+					"message": "idempotency key was used with a different request",
+				})
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(record.Status, record.ContentType, record.Body)
+			}
+			c.Abort()
+			return
+		}
+
+		w := &bodyLogWriter{body: bytes.NewBufferString(""), ResponseWriter: c.Writer}
+		c.Writer = w
+
+		// 处理时间可能超过处理中记录的有效期（如长时间等待提供商响应），处理期间续期，避免重试请求被重复执行
+		done := make(chan struct{})
+		defer close(done)
+		go renewIdempotencyLock(cacheKey, pending, idempotencyRenewInterval, done)
+
+		c.Next()
+
+		// 使用独立的 context，避免客户端断开后无法保存响应
+		storeCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if w.Status() >= 500 {
+			if err := cache.Client.Del(storeCtx, cacheKey).Err(); err != nil {
+				logger.Error("failed to release idempotency key", zap.String("key", cacheKey), zap.Error(err))
+			}
+			return
+		}
+
+		data, _ := json.Marshal(&idempotencyRecord{
+			Fingerprint: fingerprint,
+			Completed:   true,
+			Status:      w.Status(),
+			ContentType: w.Header().Get("Content-Type"),
+			Body:        w.body.Bytes(),
+		})
+		if err := cache.Client.Set(storeCtx, cacheKey, data, ttl).Err(); err != nil {
+			logger.Error("failed to save idempotent response", zap.String("key", cacheKey), zap.Error(err))
+		}
+	}
+}
+
+// renewIdempotencyLock 按 interval 续期处理中的幂等记录，直到 done 关闭
+func renewIdempotencyLock(cacheKey string, pending []byte, interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			err := renewIdempotencyScript.Run(ctx, cache.Client, []string{cacheKey}, pending, idempotencyLockTTL.Milliseconds()).Err()
+			cancel()
+			if err != nil {
+				logger.Warn("failed to renew idempotency key", zap.String("key", cacheKey), zap.Error(err))
+			}
+		}
+	}
+}
+
 // max 返回两个整数中的较大值
 func max(a, b int) int {
 	if a > b {
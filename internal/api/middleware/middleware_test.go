@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zqdfound/go-uni-pay/internal/infrastructure/cache"
+	"github.com/zqdfound/go-uni-pay/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// setupIdempotency 使用 miniredis 创建带幂等中间件的路由，handler 处理 POST /orders
+func setupIdempotency(t *testing.T, handler gin.HandlerFunc) (*gin.Engine, *miniredis.Miniredis) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	logger.Log = zap.NewNop()
+
+	mr := miniredis.RunT(t)
+	cache.Client = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { cache.Client.Close() })
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", uint64(1))
+		c.Next()
+	})
+	r.Use(IdempotencyMiddleware(time.Hour))
+	r.POST("/orders", handler)
+	return r, mr
+}
+
+// idempotentRequest 携带幂等键发送 POST /orders
+func idempotentRequest(r *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", key)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// TestIdempotency_Replay 测试首次请求正常执行，重试时返回保存的响应且不再执行
+func TestIdempotency_Replay(t *testing.T) {
+	var calls int32
+	r, _ := setupIdempotency(t, func(c *gin.Context) {
+		n := atomic.AddInt32(&calls, 1)
+		c.JSON(201, gin.H{"code": 0, "call": n})
+	})
+
+	first := idempotentRequest(r, "key-1", `{"amount":10}`)
+	assert.Equal(t, 201, first.Code)
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+
+	replay := idempotentRequest(r, "key-1", `{"amount":10}`)
+	assert.Equal(t, 201, replay.Code)
+	assert.Equal(t, "true", replay.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, first.Body.String(), replay.Body.String())
+	assert.Equal(t, first.Header().Get("Content-Type"), replay.Header().Get("Content-Type"))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// 其他幂等键正常执行
+	assert.Equal(t, 201, idempotentRequest(r, "key-2", `{"amount":10}`).Code)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+// TestIdempotency_DifferentBody 测试同一个幂等键用于不同的请求内容时返回409
+func TestIdempotency_DifferentBody(t *testing.T) {
+	var calls int32
+	r, _ := setupIdempotency(t, func(c *gin.Context) {
+		atomic.AddInt32(&calls, 1)
+		c.JSON(200, gin.H{"code": 0})
+	})
+
+	assert.Equal(t, 200, idempotentRequest(r, "key-1", `{"amount":10}`).Code)
+
+	w := idempotentRequest(r, "key-1", `{"amount":20}`)
+	assert.Equal(t, 409, w.Code)
+	assert.Contains(t, w.Body.String(), "different request")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+// TestIdempotency_InProgress 测试上一次请求仍在处理时返回409，处理时间超过记录有效期时续期
+func TestIdempotency_InProgress(t *testing.T) {
+	interval := idempotencyRenewInterval
+	idempotencyRenewInterval = 10 * time.Millisecond
+	t.Cleanup(func() { idempotencyRenewInterval = interval })
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var calls int32
+	r, mr := setupIdempotency(t, func(c *gin.Context) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+			<-release
+		}
+		c.JSON(200, gin.H{"code": 0})
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- idempotentRequest(r, "key-1", `{"amount":10}`) }()
+	<-started
+
+	w := idempotentRequest(r, "key-1", `{"amount":10}`)
+	assert.Equal(t, 409, w.Code)
+	assert.Contains(t, w.Body.String(), "in progress")
+
+	// 处理中记录在处理期间续期，超过初始有效期后仍不能重复执行
+	for i := 0; i < 3; i++ {
+		mr.FastForward(idempotencyLockTTL / 2)
+		time.Sleep(50 * time.Millisecond)
+	}
+	w = idempotentRequest(r, "key-1", `{"amount":10}`)
+	assert.Equal(t, 409, w.Code)
+
+	close(release)
+	require.Equal(t, 200, (<-done).Code)
+	assert.Equal(t, "true", idempotentRequest(r, "key-1", `{"amount":10}`).Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+// TestIdempotency_ServerErrorReleasesKey 测试服务端错误不保存响应，可以使用同一个幂等键重试
+func TestIdempotency_ServerErrorReleasesKey(t *testing.T) {
+	var calls int32
+	r, mr := setupIdempotency(t, func(c *gin.Context) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			c.JSON(502, gin.H{"code": 1000})
+			return
+		}
+		c.JSON(200, gin.H{"code": 0})
+	})
+
+	assert.Equal(t, 502, idempotentRequest(r, "key-1", `{"amount":10}`).Code)
+	assert.False(t, mr.Exists("idempotency:1:key-1"))
+
+	w := idempotentRequest(r, "key-1", `{"amount":10}`)
+	assert.Equal(t, 200, w.Code)
+	assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
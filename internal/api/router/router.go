@@ -34,6 +34,10 @@ func SetupRouter(
 		})
 	})
 
+	// 支付链接页面（由买家访问），每次支付创建一个订单
+	r.GET("/pay/:link_no", paymentHandler.PaymentLinkPage)
+	r.POST("/pay/:link_no", paymentHandler.PayPaymentLink)
+
 	// API版本1
 	v1 := r.Group("/api/v1")
 	{
@@ -41,23 +45,124 @@ func SetupRouter(
 		public := v1.Group("/public")
 		{
 			// 支付通知回调（由第三方支付平台调用）
-			// URL格式: /api/v1/public/notify/:provider/:config_id
-			// 例如: /api/v1/public/notify/paypal/123
-			public.POST("/notify/:provider/:config_id", paymentHandler.HandleNotify)
+			// URL格式: /api/v1/public/notify/:provider/:token，token 为「配置ID.签名」
+			// 例如: /api/v1/public/notify/paypal/123.5f0c8e2a9b7d4c1e3a6f8b0d2c4e6a8b
+			public.POST("/notify/:provider/:token", paymentHandler.HandleNotify)
+			// 每个账号只能配置一个Webhook地址的提供商，可使用不带令牌的地址，按通知中的提供商账号识别配置
+			public.POST("/notify/:provider", paymentHandler.HandleNotify)
+
+			// 买家支付后的同步跳转，确认支付结果后携带签名参数跳转到商户页面
+			// URL格式: /api/v1/public/return/:provider/:config_id?order_no=xxx
+			public.GET("/return/:provider/:config_id", paymentHandler.HandleReturn)
+			public.POST("/return/:provider/:config_id", paymentHandler.HandleReturn)
+
+			// 沙箱提供商的收银台模拟页面，仅用于开发和测试
+			public.GET("/mock/checkout/:order_no", paymentHandler.SimulationPage)
+			public.POST("/mock/checkout/:order_no", paymentHandler.SimulatePayment)
+
+			// 托管收银台，买家选择提供商后创建支付
+			public.GET("/checkout/:checkout_no", paymentHandler.CheckoutPage)
+			public.POST("/checkout/:checkout_no", paymentHandler.PayCheckout)
 		}
 
+		// 订单二维码图片，无需认证以便商户页面直接使用 <img> 引用
+		// 例如: /api/v1/payment/qrcode/UNI123.png?size=300&margin=2&level=H
+		v1.GET("/payment/qrcode/:file", paymentHandler.QRCodeImage)
+
 		// 需要认证的接口
 		authenticated := v1.Group("")
 		authenticated.Use(middleware.AuthMiddleware(authService))
 		// 添加限流：每分钟最多100次请求
 		authenticated.Use(middleware.RateLimitMiddleware(100, time.Minute))
+		// 写请求携带 Idempotency-Key 时保存响应24小时，重试时返回相同的响应
+		authenticated.Use(middleware.IdempotencyMiddleware(24 * time.Hour))
 		{
 			// 支付相关接口
 			payment := authenticated.Group("/payment")
 			{
 				payment.POST("/create", paymentHandler.CreatePayment)
 				payment.GET("/query/:order_no", paymentHandler.QueryPayment)
+				// 订单状态推送（SSE），浏览器 EventSource 可通过 api_key 查询参数认证
+				payment.GET("/events/:order_no", paymentHandler.OrderEvents)
+				// 同步跳转参数的签名密钥
+				payment.GET("/sign-key", paymentHandler.GetSignKey)
+				// 支付配置的通知地址，用于在提供商后台配置 Webhook
+				payment.GET("/notify-url/:provider", paymentHandler.GetNotifyURL)
+
+				// 预授权：冻结资金后扣款或撤销
+				payment.POST("/authorize", paymentHandler.Authorize)
+				payment.POST("/capture", paymentHandler.Capture)
+				payment.POST("/void", paymentHandler.Void)
+
+				payment.POST("/close", paymentHandler.ClosePayment)
+
+				// 退款
+				payment.POST("/refund", paymentHandler.Refund)
+				payment.GET("/refund/:refund_no", paymentHandler.QueryRefund)
+				payment.GET("/bill", paymentHandler.DownloadBill)
 			}
+
+			// 付款（转账）接口
+			payout := authenticated.Group("/payout")
+			{
+				payout.POST("/create", paymentHandler.CreatePayout)
+				payout.GET("/query/:payout_no", paymentHandler.QueryPayout)
+			}
+
+			// 分账接口
+			profitSharing := authenticated.Group("/profit-sharing")
+			{
+				profitSharing.POST("/create", paymentHandler.ShareProfit)
+				profitSharing.GET("/query/:sharing_no", paymentHandler.QueryProfitSharing)
+				profitSharing.POST("/return", paymentHandler.ReturnProfitSharing)
+				profitSharing.GET("/return/:return_no", paymentHandler.QueryProfitSharingReturn)
+			}
+
+			// 订阅计划接口
+			plan := authenticated.Group("/plan")
+			{
+				plan.POST("/create", paymentHandler.CreatePlan)
+				plan.GET("/query/:plan_no", paymentHandler.QueryPlan)
+			}
+
+			// 订阅接口
+			subscription := authenticated.Group("/subscription")
+			{
+				subscription.POST("/create", paymentHandler.CreateSubscription)
+				subscription.GET("/query/:subscription_no", paymentHandler.QuerySubscription)
+				subscription.POST("/cancel", paymentHandler.CancelSubscription)
+			}
+
+			// 客户及已保存支付方式接口
+			customer := authenticated.Group("/customer")
+			{
+				customer.POST("/create", paymentHandler.CreateCustomer)
+				customer.GET("/query/:customer_no", paymentHandler.QueryCustomer)
+				customer.POST("/payment-method/create", paymentHandler.CreatePaymentMethod)
+				customer.GET("/payment-method/list/:customer_no", paymentHandler.ListPaymentMethods)
+				customer.POST("/payment-method/delete", paymentHandler.DeletePaymentMethod)
+			}
+
+			// 托管收银台接口
+			checkout := authenticated.Group("/checkout")
+			{
+				checkout.POST("/create", paymentHandler.CreateCheckout)
+				checkout.GET("/query/:checkout_no", paymentHandler.QueryCheckout)
+			}
+
+			// 支付链接接口
+			paymentLink := authenticated.Group("/payment-link")
+			{
+				paymentLink.POST("/create", paymentHandler.CreatePaymentLink)
+				paymentLink.GET("/query/:link_no", paymentHandler.QueryPaymentLink)
+				paymentLink.GET("/list", paymentHandler.ListPaymentLinks)
+				paymentLink.POST("/update", paymentHandler.UpdatePaymentLink)
+				paymentLink.POST("/delete", paymentHandler.DeletePaymentLink)
+				paymentLink.GET("/orders/:link_no", paymentHandler.ListPaymentLinkOrders)
+			}
+
+			// 支付提供商及其支持的能力
+			authenticated.GET("/providers", paymentHandler.ListProviders)
 		}
 
 		// 管理后台接口
@@ -90,6 +195,10 @@ func SetupRouter(
 				adminAuth.GET("/orders", managementHandler.ListOrders)
 				adminAuth.GET("/orders/:id", managementHandler.GetOrder)
 
+				// 争议管理
+				adminAuth.GET("/disputes", managementHandler.ListDisputes)
+				adminAuth.GET("/disputes/:id", managementHandler.GetDispute)
+
 				// 支付日志
 				adminAuth.GET("/payment-logs", managementHandler.ListPaymentLogs)
 
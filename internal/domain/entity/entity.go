@@ -13,6 +13,7 @@ type User struct {
 	Email     string    `gorm:"type:varchar(100);uniqueIndex;not null" json:"email"`
 	APIKey    string    `gorm:"type:varchar(64);uniqueIndex;not null" json:"api_key"`
 	APISecret string    `gorm:"type:varchar(128);not null" json:"-"`
+	SignKey   string    `gorm:"type:varchar(64)" json:"-"` // 本服务向商户签名跳转参数的密钥
 	Status    int8      `gorm:"type:tinyint;not null;default:1" json:"status"`
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
@@ -27,9 +28,10 @@ func (User) TableName() string {
 type PaymentConfig struct {
 	ID         uint64     `gorm:"primaryKey;autoIncrement" json:"id"`
 	UserID     uint64     `gorm:"not null;index" json:"user_id"`
-	Provider   string     `gorm:"type:varchar(20);not null;index" json:"provider"`
+	Provider   string     `gorm:"type:varchar(20);not null;index;index:idx_provider_account,priority:1" json:"provider"`
 	ConfigName string     `gorm:"type:varchar(50);not null" json:"config_name"`
 	ConfigData ConfigData `gorm:"type:json;not null" json:"config_data"`
+	AccountID  string     `gorm:"type:varchar(128);index:idx_provider_account,priority:2" json:"account_id"` // 提供商账号，用于识别不带配置令牌的通知
 	Status     int8       `gorm:"type:tinyint;not null;default:1" json:"status"`
 	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt  time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
@@ -66,25 +68,38 @@ func (c *ConfigData) Scan(value interface{}) error {
 
 // PaymentOrder 支付订单实体
 type PaymentOrder struct {
-	ID          uint64     `gorm:"primaryKey;autoIncrement" json:"id"`
-	OrderNo     string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"order_no"`
-	UserID      uint64     `gorm:"not null;uniqueIndex:idx_user_out_trade;index" json:"user_id"`
-	Provider    string     `gorm:"type:varchar(20);not null;index" json:"provider"`
-	ConfigID    uint64     `gorm:"not null" json:"config_id"`
-	OutTradeNo  string     `gorm:"type:varchar(64);not null;uniqueIndex:idx_user_out_trade;index" json:"out_trade_no"`
-	TradeNo     string     `gorm:"type:varchar(64);index" json:"trade_no"`
-	Subject     string     `gorm:"type:varchar(256);not null" json:"subject"`
-	Body        string     `gorm:"type:text" json:"body"`
-	Amount      float64    `gorm:"type:decimal(10,2);not null" json:"amount"`
-	Currency    string     `gorm:"type:varchar(10);not null;default:'CNY'" json:"currency"`
-	Status      string     `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
-	NotifyURL   string     `gorm:"type:varchar(512)" json:"notify_url"`
-	ReturnURL   string     `gorm:"type:varchar(512)" json:"return_url"`
-	ClientIP    string     `gorm:"type:varchar(45)" json:"client_ip"`
-	ExtraData   ConfigData `gorm:"type:json" json:"extra_data"`
-	PaymentTime *time.Time `gorm:"index" json:"payment_time"`
-	CreatedAt   time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
-	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	ID              uint64             `gorm:"primaryKey;autoIncrement" json:"id"`
+	OrderNo         string             `gorm:"type:varchar(64);uniqueIndex;not null" json:"order_no"`
+	UserID          uint64             `gorm:"not null;uniqueIndex:idx_user_out_trade;index" json:"user_id"`
+	Provider        string             `gorm:"type:varchar(20);not null;index" json:"provider"`
+	ConfigID        uint64             `gorm:"not null" json:"config_id"`
+	OutTradeNo      string             `gorm:"type:varchar(64);not null;uniqueIndex:idx_user_out_trade;index" json:"out_trade_no"`
+	TradeNo         string             `gorm:"type:varchar(64);index" json:"trade_no"`
+	CaptureID       string             `gorm:"type:varchar(64);index" json:"capture_id"`
+	PreAuth         bool               `gorm:"not null;default:false" json:"pre_auth"`
+	AuthorizationID string             `gorm:"type:varchar(64)" json:"authorization_id"`
+	Subject         string             `gorm:"type:varchar(256);not null" json:"subject"`
+	Body            string             `gorm:"type:text" json:"body"`
+	Amount          float64            `gorm:"type:decimal(10,2);not null" json:"amount"`
+	CaptureAmount   float64            `gorm:"type:decimal(10,2);not null;default:0" json:"capture_amount"`
+	Currency        string             `gorm:"type:varchar(10);not null;default:'CNY'" json:"currency"`
+	Scene           string             `gorm:"type:varchar(20)" json:"scene"`
+	Status          string             `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	NotifyURL       string             `gorm:"type:varchar(512)" json:"notify_url"`
+	ReturnURL       string             `gorm:"type:varchar(512)" json:"return_url"`
+	ClientIP        string             `gorm:"type:varchar(45)" json:"client_ip"`
+	QRCode          string             `gorm:"type:text" json:"qr_code,omitempty"`
+	PaymentData     *PaymentData       `gorm:"type:json" json:"payment_data,omitempty"`
+	ExtraData       ConfigData         `gorm:"type:json" json:"extra_data"`
+	Items           OrderItems         `gorm:"type:json" json:"items,omitempty"`
+	Buyer           *Buyer             `gorm:"type:json" json:"buyer,omitempty"`
+	Shipping        *Shipping          `gorm:"type:json" json:"shipping,omitempty"`
+	ProfitSharing   *ProfitSharingPlan `gorm:"type:json" json:"profit_sharing,omitempty"`
+	PaymentMethodNo string             `gorm:"type:varchar(64);index" json:"payment_method_no,omitempty"`
+	PaymentLinkNo   string             `gorm:"type:varchar(64);index" json:"payment_link_no,omitempty"`
+	PaymentTime     *time.Time         `gorm:"index" json:"payment_time"`
+	CreatedAt       time.Time          `gorm:"autoCreateTime;index" json:"created_at"`
+	UpdatedAt       time.Time          `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
 // TableName 表名
@@ -99,6 +114,579 @@ const (
 	OrderStatusSuccess    = "success"
 	OrderStatusFailed     = "failed"
 	OrderStatusClosed     = "closed"
+	OrderStatusAuthorized = "authorized"
+	OrderStatusCaptured   = "captured"
+	OrderStatusVoided     = "voided"
+)
+
+// OrderItem 订单商品明细，单价和税额均为单件金额
+type OrderItem struct {
+	Name      string  `json:"name"`
+	SKU       string  `json:"sku,omitempty"`
+	Quantity  int64   `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+	Tax       float64 `json:"tax,omitempty"`
+}
+
+// OrderItems 订单商品明细列表（JSON类型）
+type OrderItems []OrderItem
+
+// Value 实现driver.Valuer接口
+func (i OrderItems) Value() (driver.Value, error) {
+	if i == nil {
+		return nil, nil
+	}
+	return json.Marshal(i)
+}
+
+// Scan 实现sql.Scanner接口
+func (i *OrderItems) Scan(value interface{}) error {
+	if value == nil {
+		*i = nil
+		return nil
+	}
+	return scanJSON(value, i)
+}
+
+// Buyer 买家信息（JSON类型）
+type Buyer struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+	Phone string `json:"phone,omitempty"`
+}
+
+// Value 实现driver.Valuer接口
+func (b Buyer) Value() (driver.Value, error) {
+	return json.Marshal(b)
+}
+
+// Scan 实现sql.Scanner接口
+func (b *Buyer) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	return scanJSON(value, b)
+}
+
+// Shipping 收货信息（JSON类型），国家为 ISO 3166-1 两位代码，运费计入订单金额
+type Shipping struct {
+	Name       string  `json:"name"`
+	Phone      string  `json:"phone,omitempty"`
+	Country    string  `json:"country"`
+	State      string  `json:"state,omitempty"`
+	City       string  `json:"city,omitempty"`
+	Line1      string  `json:"line1"`
+	Line2      string  `json:"line2,omitempty"`
+	PostalCode string  `json:"postal_code,omitempty"`
+	Fee        float64 `json:"fee,omitempty"`
+}
+
+// Value 实现driver.Valuer接口
+func (s Shipping) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan 实现sql.Scanner接口
+func (s *Shipping) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	return scanJSON(value, s)
+}
+
+// PaymentData 提供商返回的支付信息（JSON类型），重复创建订单时返回给商户
+// ExpireTime 为空表示提供商未说明有效期
+type PaymentData struct {
+	PaymentURL string                 `json:"payment_url,omitempty"`
+	PaymentID  string                 `json:"payment_id,omitempty"`
+	FormData   string                 `json:"form_data,omitempty"`
+	ExtraData  map[string]interface{} `json:"extra_data,omitempty"`
+	ExpireTime *time.Time             `json:"expire_time,omitempty"`
+}
+
+// Value 实现driver.Valuer接口
+func (d PaymentData) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
+
+// Scan 实现sql.Scanner接口
+func (d *PaymentData) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	return scanJSON(value, d)
+}
+
+// ProfitSharingPlan 订单分账计划（JSON类型）
+type ProfitSharingPlan struct {
+	Settle    string                 `json:"settle"`
+	Receivers ProfitSharingReceivers `json:"receivers"`
+}
+
+// Value 实现driver.Valuer接口
+func (p ProfitSharingPlan) Value() (driver.Value, error) {
+	return json.Marshal(p)
+}
+
+// Scan 实现sql.Scanner接口
+func (p *ProfitSharingPlan) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	return scanJSON(value, p)
+}
+
+// ProfitSharingReceiver 分账接收方，金额为空时按比例（0-1）计算；分账后记录各接收方的结果
+type ProfitSharingReceiver struct {
+	Type        string  `json:"type,omitempty"`
+	Account     string  `json:"account"`
+	Name        string  `json:"name,omitempty"`
+	Amount      float64 `json:"amount"`
+	Ratio       float64 `json:"ratio,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Status      string  `json:"status,omitempty"`
+	DetailID    string  `json:"detail_id,omitempty"`
+	FailReason  string  `json:"fail_reason,omitempty"`
+}
+
+// ProfitSharingReceivers 分账接收方列表（JSON类型）
+type ProfitSharingReceivers []ProfitSharingReceiver
+
+// Value 实现driver.Valuer接口
+func (r ProfitSharingReceivers) Value() (driver.Value, error) {
+	if r == nil {
+		return nil, nil
+	}
+	return json.Marshal(r)
+}
+
+// Scan 实现sql.Scanner接口
+func (r *ProfitSharingReceivers) Scan(value interface{}) error {
+	if value == nil {
+		*r = nil
+		return nil
+	}
+	return scanJSON(value, r)
+}
+
+// scanJSON 将数据库中的JSON数据解析到目标
+func scanJSON(value interface{}, dest interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return json.Unmarshal([]byte(value.(string)), dest)
+	}
+	return json.Unmarshal(bytes, dest)
+}
+
+// ProfitSharing 分账实体，一个订单可以多次分账
+type ProfitSharing struct {
+	ID           uint64                 `gorm:"primaryKey;autoIncrement" json:"id"`
+	SharingNo    string                 `gorm:"type:varchar(64);uniqueIndex;not null" json:"sharing_no"`
+	UserID       uint64                 `gorm:"not null;uniqueIndex:idx_user_out_sharing;index" json:"user_id"`
+	OutSharingNo string                 `gorm:"type:varchar(64);not null;uniqueIndex:idx_user_out_sharing" json:"out_sharing_no"`
+	OrderID      uint64                 `gorm:"not null;index" json:"order_id"`
+	OrderNo      string                 `gorm:"type:varchar(64);not null;index" json:"order_no"`
+	Provider     string                 `gorm:"type:varchar(20);not null" json:"provider"`
+	ConfigID     uint64                 `gorm:"not null" json:"config_id"`
+	SharingID    string                 `gorm:"type:varchar(64)" json:"sharing_id"`
+	Amount       float64                `gorm:"type:decimal(10,2);not null" json:"amount"`
+	Currency     string                 `gorm:"type:varchar(10);not null" json:"currency"`
+	Receivers    ProfitSharingReceivers `gorm:"type:json;not null" json:"receivers"`
+	Finish       bool                   `gorm:"not null;default:false" json:"finish"`
+	Status       string                 `gorm:"type:varchar(20);not null;default:'processing';index" json:"status"`
+	FailReason   string                 `gorm:"type:varchar(256)" json:"fail_reason"`
+	FinishTime   *time.Time             `json:"finish_time"`
+	CreatedAt    time.Time              `gorm:"autoCreateTime;index" json:"created_at"`
+	UpdatedAt    time.Time              `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 表名
+func (ProfitSharing) TableName() string {
+	return "profit_sharings"
+}
+
+// ProfitSharingReturn 分账回退实体
+type ProfitSharingReturn struct {
+	ID          uint64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	ReturnNo    string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"return_no"`
+	UserID      uint64     `gorm:"not null;uniqueIndex:idx_user_out_return;index" json:"user_id"`
+	OutReturnNo string     `gorm:"type:varchar(64);not null;uniqueIndex:idx_user_out_return" json:"out_return_no"`
+	SharingNo   string     `gorm:"type:varchar(64);not null;index" json:"sharing_no"`
+	OrderNo     string     `gorm:"type:varchar(64);not null" json:"order_no"`
+	Provider    string     `gorm:"type:varchar(20);not null" json:"provider"`
+	ConfigID    uint64     `gorm:"not null" json:"config_id"`
+	ReturnID    string     `gorm:"type:varchar(64)" json:"return_id"`
+	Account     string     `gorm:"type:varchar(128);not null" json:"account"`
+	Amount      float64    `gorm:"type:decimal(10,2);not null" json:"amount"`
+	Currency    string     `gorm:"type:varchar(10);not null" json:"currency"`
+	Description string     `gorm:"type:varchar(256)" json:"description"`
+	Status      string     `gorm:"type:varchar(20);not null;default:'processing';index" json:"status"`
+	FailReason  string     `gorm:"type:varchar(256)" json:"fail_reason"`
+	FinishTime  *time.Time `json:"finish_time"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 表名
+func (ProfitSharingReturn) TableName() string {
+	return "profit_sharing_returns"
+}
+
+// ProfitSharingStatus 分账及分账回退状态常量
+const (
+	ProfitSharingStatusProcessing = "processing"
+	ProfitSharingStatusSuccess    = "success"
+	ProfitSharingStatusFailed     = "failed"
+)
+
+// ProfitSharingSettle 分账时机常量
+const (
+	ProfitSharingSettleAuto   = "auto"
+	ProfitSharingSettleManual = "manual"
+)
+
+// Refund 退款实体
+type Refund struct {
+	ID         uint64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	RefundNo   string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"refund_no"`
+	OrderID    uint64     `gorm:"not null;index" json:"order_id"`
+	OrderNo    string     `gorm:"type:varchar(64);not null;index" json:"order_no"`
+	UserID     uint64     `gorm:"not null;index" json:"user_id"`
+	Provider   string     `gorm:"type:varchar(20);not null" json:"provider"`
+	ConfigID   uint64     `gorm:"not null" json:"config_id"`
+	RefundID   string     `gorm:"type:varchar(64);index" json:"refund_id"`
+	Amount     float64    `gorm:"type:decimal(10,2);not null" json:"amount"`
+	Currency   string     `gorm:"type:varchar(10);not null" json:"currency"`
+	Reason     string     `gorm:"type:varchar(256)" json:"reason"`
+	Status     string     `gorm:"type:varchar(20);not null;default:'processing';index" json:"status"`
+	RefundTime *time.Time `json:"refund_time"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 表名
+func (Refund) TableName() string {
+	return "refunds"
+}
+
+// RefundStatus 退款状态常量
+const (
+	RefundStatusProcessing = "processing"
+	RefundStatusSuccess    = "success"
+	RefundStatusFailed     = "failed"
+)
+
+// Payout 付款（转账）实体
+type Payout struct {
+	ID           uint64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	PayoutNo     string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"payout_no"`
+	UserID       uint64     `gorm:"not null;uniqueIndex:idx_user_out_payout;index" json:"user_id"`
+	OutPayoutNo  string     `gorm:"type:varchar(64);not null;uniqueIndex:idx_user_out_payout" json:"out_payout_no"`
+	Provider     string     `gorm:"type:varchar(20);not null;index" json:"provider"`
+	ConfigID     uint64     `gorm:"not null" json:"config_id"`
+	PayoutID     string     `gorm:"type:varchar(64);index" json:"payout_id"`
+	Amount       float64    `gorm:"type:decimal(10,2);not null" json:"amount"`
+	Currency     string     `gorm:"type:varchar(10);not null" json:"currency"`
+	PayeeType    string     `gorm:"type:varchar(20)" json:"payee_type"`
+	PayeeAccount string     `gorm:"type:varchar(128);not null" json:"payee_account"`
+	PayeeName    string     `gorm:"type:varchar(64)" json:"payee_name"`
+	Remark       string     `gorm:"type:varchar(256)" json:"remark"`
+	NotifyURL    string     `gorm:"type:varchar(512)" json:"notify_url"`
+	Status       string     `gorm:"type:varchar(20);not null;default:'processing';index" json:"status"`
+	FailReason   string     `gorm:"type:varchar(256)" json:"fail_reason"`
+	FinishTime   *time.Time `json:"finish_time"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 表名
+func (Payout) TableName() string {
+	return "payouts"
+}
+
+// PayoutStatus 付款状态常量
+const (
+	PayoutStatusProcessing = "processing"
+	PayoutStatusSuccess    = "success"
+	PayoutStatusFailed     = "failed"
+)
+
+// Plan 订阅计划实体
+type Plan struct {
+	ID            uint64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	PlanNo        string      `gorm:"type:varchar(64);uniqueIndex;not null" json:"plan_no"`
+	UserID        uint64      `gorm:"not null;uniqueIndex:idx_user_out_plan;index" json:"user_id"`
+	OutPlanNo     string      `gorm:"type:varchar(64);not null;uniqueIndex:idx_user_out_plan" json:"out_plan_no"`
+	Name          string      `gorm:"type:varchar(128);not null" json:"name"`
+	Description   string      `gorm:"type:varchar(256)" json:"description"`
+	Amount        float64     `gorm:"type:decimal(10,2);not null" json:"amount"`
+	Currency      string      `gorm:"type:varchar(10);not null" json:"currency"`
+	Interval      string      `gorm:"column:billing_interval;type:varchar(10);not null" json:"interval"`
+	IntervalCount int         `gorm:"not null;default:1" json:"interval_count"`
+	TrialDays     int         `gorm:"not null;default:0" json:"trial_days"`
+	Status        string      `gorm:"type:varchar(20);not null;default:'active'" json:"status"`
+	ProviderPlans ProviderIDs `gorm:"type:json" json:"-"`
+	CreatedAt     time.Time   `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time   `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 表名
+func (Plan) TableName() string {
+	return "plans"
+}
+
+// PlanStatus 订阅计划状态常量
+const (
+	PlanStatusActive   = "active"
+	PlanStatusInactive = "inactive"
+)
+
+// ProviderIDs 提供商侧ID（JSON类型），如计划ID、客户ID，键为 "provider:config_id"
+type ProviderIDs map[string]string
+
+// Value 实现driver.Valuer接口
+func (p ProviderIDs) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+	return json.Marshal(p)
+}
+
+// Scan 实现sql.Scanner接口
+func (p *ProviderIDs) Scan(value interface{}) error {
+	if value == nil {
+		*p = nil
+		return nil
+	}
+	return scanJSON(value, p)
+}
+
+// Subscription 订阅实体，计划的金额和周期在订阅时复制，计划变更不影响已有订阅
+type Subscription struct {
+	ID                 uint64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	SubscriptionNo     string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"subscription_no"`
+	UserID             uint64     `gorm:"not null;uniqueIndex:idx_user_out_subscription;index" json:"user_id"`
+	OutSubscriptionNo  string     `gorm:"type:varchar(64);not null;uniqueIndex:idx_user_out_subscription" json:"out_subscription_no"`
+	PlanID             uint64     `gorm:"not null;index" json:"plan_id"`
+	PlanNo             string     `gorm:"type:varchar(64);not null" json:"plan_no"`
+	Provider           string     `gorm:"type:varchar(20);not null" json:"provider"`
+	ConfigID           uint64     `gorm:"not null" json:"config_id"`
+	SubscriptionID     string     `gorm:"type:varchar(128);index" json:"subscription_id"`
+	Amount             float64    `gorm:"type:decimal(10,2);not null" json:"amount"`
+	Currency           string     `gorm:"type:varchar(10);not null" json:"currency"`
+	Interval           string     `gorm:"column:billing_interval;type:varchar(10);not null" json:"interval"`
+	IntervalCount      int        `gorm:"not null;default:1" json:"interval_count"`
+	TrialDays          int        `gorm:"not null;default:0" json:"trial_days"`
+	Status             string     `gorm:"type:varchar(20);not null;default:'pending';index:idx_status_next_billing" json:"status"`
+	PaymentURL         string     `gorm:"type:text" json:"payment_url"`
+	TrialEnd           *time.Time `json:"trial_end"`
+	CurrentPeriodStart *time.Time `json:"current_period_start"`
+	CurrentPeriodEnd   *time.Time `json:"current_period_end"`
+	NextBillingTime    *time.Time `gorm:"index:idx_status_next_billing" json:"next_billing_time"`
+	BillingDay         int        `gorm:"not null;default:0" json:"billing_day"`
+	Cycles             int        `gorm:"not null;default:0" json:"cycles"`
+	RetryCount         int        `gorm:"not null;default:0" json:"retry_count"`
+	LastOrderNo        string     `gorm:"type:varchar(64)" json:"last_order_no"`
+	NotifyURL          string     `gorm:"type:varchar(512)" json:"notify_url"`
+	ReturnURL          string     `gorm:"type:varchar(512)" json:"return_url"`
+	FailReason         string     `gorm:"type:varchar(256)" json:"fail_reason"`
+	CanceledAt         *time.Time `json:"canceled_at"`
+	CreatedAt          time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt          time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 表名
+func (Subscription) TableName() string {
+	return "subscriptions"
+}
+
+// SubscriptionStatus 订阅状态常量
+const (
+	SubscriptionStatusPending  = "pending"
+	SubscriptionStatusTrialing = "trialing"
+	SubscriptionStatusActive   = "active"
+	SubscriptionStatusPastDue  = "past_due"
+	SubscriptionStatusCanceled = "canceled"
+)
+
+// Customer 客户实体，即商户的买家，保存各提供商的客户ID用于绑定支付方式
+type Customer struct {
+	ID                uint64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	CustomerNo        string      `gorm:"type:varchar(64);uniqueIndex;not null" json:"customer_no"`
+	UserID            uint64      `gorm:"not null;uniqueIndex:idx_user_out_customer;index" json:"user_id"`
+	OutCustomerNo     string      `gorm:"type:varchar(64);not null;uniqueIndex:idx_user_out_customer" json:"out_customer_no"`
+	Name              string      `gorm:"type:varchar(128)" json:"name"`
+	Email             string      `gorm:"type:varchar(128)" json:"email"`
+	Phone             string      `gorm:"type:varchar(32)" json:"phone"`
+	ProviderCustomers ProviderIDs `gorm:"type:json" json:"-"`
+	CreatedAt         time.Time   `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time   `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 表名
+func (Customer) TableName() string {
+	return "customers"
+}
+
+// PaymentMethod 客户保存的支付方式（银行卡、PayPal 授权、支付宝代扣协议）
+type PaymentMethod struct {
+	ID         uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
+	MethodNo   string    `gorm:"type:varchar(64);uniqueIndex;not null" json:"payment_method_no"`
+	UserID     uint64    `gorm:"not null;index" json:"user_id"`
+	CustomerID uint64    `gorm:"not null;index" json:"customer_id"`
+	CustomerNo string    `gorm:"type:varchar(64);not null" json:"customer_no"`
+	Provider   string    `gorm:"type:varchar(20);not null" json:"provider"`
+	ConfigID   uint64    `gorm:"not null" json:"config_id"`
+	SetupID    string    `gorm:"type:varchar(128)" json:"setup_id"`
+	MethodID   string    `gorm:"type:varchar(128);index" json:"method_id"`
+	Type       string    `gorm:"type:varchar(20)" json:"type"`
+	Brand      string    `gorm:"type:varchar(20)" json:"brand"`
+	Last4      string    `gorm:"type:varchar(4)" json:"last4"`
+	ExpMonth   int       `gorm:"not null;default:0" json:"exp_month"`
+	ExpYear    int       `gorm:"not null;default:0" json:"exp_year"`
+	Account    string    `gorm:"type:varchar(128)" json:"account"`
+	Status     string    `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	SetupURL   string    `gorm:"type:text" json:"setup_url"`
+	NotifyURL  string    `gorm:"type:varchar(512)" json:"notify_url"`
+	ReturnURL  string    `gorm:"type:varchar(512)" json:"return_url"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 表名
+func (PaymentMethod) TableName() string {
+	return "payment_methods"
+}
+
+// PaymentMethodStatus 支付方式状态常量
+const (
+	PaymentMethodStatusPending  = "pending"
+	PaymentMethodStatusActive   = "active"
+	PaymentMethodStatusCanceled = "canceled"
+)
+
+// StringList 字符串列表（JSON类型）
+type StringList []string
+
+// Value 实现driver.Valuer接口
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return nil, nil
+	}
+	return json.Marshal(l)
+}
+
+// Scan 实现sql.Scanner接口
+func (l *StringList) Scan(value interface{}) error {
+	if value == nil {
+		*l = nil
+		return nil
+	}
+	return scanJSON(value, l)
+}
+
+// Checkout 托管收银台，买家在收银台选择提供商后才创建支付订单
+// 订单使用收银台的 out_trade_no，选择后不可更换提供商
+type Checkout struct {
+	ID         uint64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	CheckoutNo string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"checkout_no"`
+	UserID     uint64     `gorm:"not null;uniqueIndex:idx_user_out_checkout;index" json:"user_id"`
+	OutTradeNo string     `gorm:"type:varchar(64);not null;uniqueIndex:idx_user_out_checkout" json:"out_trade_no"`
+	Subject    string     `gorm:"type:varchar(256);not null" json:"subject"`
+	Body       string     `gorm:"type:text" json:"body"`
+	Amount     float64    `gorm:"type:decimal(10,2);not null" json:"amount"`
+	Currency   string     `gorm:"type:varchar(10);not null;default:'CNY'" json:"currency"`
+	Providers  StringList `gorm:"type:json" json:"providers"`
+	Provider   string     `gorm:"type:varchar(20)" json:"provider"`
+	OrderNo    string     `gorm:"type:varchar(64);index" json:"order_no"`
+	Status     string     `gorm:"type:varchar(20);not null;default:'open'" json:"status"`
+	URL        string     `gorm:"column:checkout_url;type:varchar(512)" json:"checkout_url"`
+	PaymentURL string     `gorm:"type:text" json:"-"`
+	QRCode     string     `gorm:"type:text" json:"-"`
+	NotifyURL  string     `gorm:"type:varchar(512)" json:"notify_url"`
+	ReturnURL  string     `gorm:"type:varchar(512)" json:"return_url"`
+	ExpireAt   *time.Time `json:"expire_at"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 表名
+func (Checkout) TableName() string {
+	return "checkouts"
+}
+
+// CheckoutStatus 收银台状态常量，买家选择提供商后的支付结果以订单状态为准
+const (
+	CheckoutStatusOpen    = "open"
+	CheckoutStatusPaying  = "paying"
+	CheckoutStatusExpired = "expired"
+)
+
+// PaymentLink 支付链接，买家每次通过链接支付都会创建一个订单，支付成功的订单汇总到链接
+// CustomAmount 为 true 时由买家填写金额，Amount 不生效
+type PaymentLink struct {
+	ID           uint64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	LinkNo       string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"link_no"`
+	UserID       uint64     `gorm:"not null;index" json:"user_id"`
+	Subject      string     `gorm:"type:varchar(256);not null" json:"subject"`
+	Description  string     `gorm:"type:text" json:"description"`
+	Amount       float64    `gorm:"type:decimal(10,2);not null;default:0" json:"amount"`
+	CustomAmount bool       `gorm:"not null;default:false" json:"custom_amount"`
+	Currency     string     `gorm:"type:varchar(10);not null;default:'CNY'" json:"currency"`
+	Providers    StringList `gorm:"type:json" json:"providers"`
+	ExpireAt     *time.Time `json:"expire_at"`
+	MaxUses      int        `gorm:"not null;default:0" json:"max_uses"`
+	PaidCount    int        `gorm:"not null;default:0" json:"paid_count"`
+	PaidAmount   float64    `gorm:"type:decimal(12,2);not null;default:0" json:"paid_amount"`
+	Status       string     `gorm:"type:varchar(20);not null;default:'active'" json:"status"`
+	URL          string     `gorm:"column:link_url;type:varchar(512)" json:"link_url"`
+	NotifyURL    string     `gorm:"type:varchar(512)" json:"notify_url"`
+	ReturnURL    string     `gorm:"type:varchar(512)" json:"return_url"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 表名
+func (PaymentLink) TableName() string {
+	return "payment_links"
+}
+
+// PaymentLinkStatus 支付链接状态常量，达到最大支付次数后为 completed
+const (
+	PaymentLinkStatusActive    = "active"
+	PaymentLinkStatusInactive  = "inactive"
+	PaymentLinkStatusCompleted = "completed"
+)
+
+// Dispute 争议（拒付）实体
+type Dispute struct {
+	ID            uint64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	OrderID       uint64     `gorm:"not null;index" json:"order_id"`
+	OrderNo       string     `gorm:"type:varchar(64);not null;index" json:"order_no"`
+	UserID        uint64     `gorm:"not null;index" json:"user_id"`
+	Provider      string     `gorm:"type:varchar(20);not null;uniqueIndex:idx_provider_dispute" json:"provider"`
+	DisputeID     string     `gorm:"type:varchar(64);not null;uniqueIndex:idx_provider_dispute" json:"dispute_id"`
+	Reason        string     `gorm:"type:varchar(64)" json:"reason"`
+	Amount        float64    `gorm:"type:decimal(10,2);not null" json:"amount"`
+	Currency      string     `gorm:"type:varchar(10);not null" json:"currency"`
+	Status        string     `gorm:"type:varchar(20);not null;index" json:"status"`
+	EvidenceDueBy *time.Time `json:"evidence_due_by"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 表名
+func (Dispute) TableName() string {
+	return "disputes"
+}
+
+// DisputeStatus 争议状态常量
+const (
+	DisputeStatusNeedsResponse = "needs_response"
+	DisputeStatusUnderReview   = "under_review"
+	DisputeStatusWon           = "won"
+	DisputeStatusLost          = "lost"
+	DisputeStatusClosed        = "closed"
 )
 
 // PaymentLog 支付日志实体
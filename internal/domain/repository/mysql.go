@@ -121,6 +121,22 @@ func (r *MySQLPaymentConfigRepository) GetActiveByUserAndProvider(ctx context.Co
 	return &config, nil
 }
 
+func (r *MySQLPaymentConfigRepository) GetActiveByProvider(ctx context.Context, provider string) ([]*entity.PaymentConfig, error) {
+	var configs []*entity.PaymentConfig
+	if err := r.db.WithContext(ctx).Where("provider = ? AND status = 1", provider).Find(&configs).Error; err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to get configs", err)
+	}
+	return configs, nil
+}
+
+func (r *MySQLPaymentConfigRepository) GetActiveByProviderAccount(ctx context.Context, provider, accountID string) ([]*entity.PaymentConfig, error) {
+	var configs []*entity.PaymentConfig
+	if err := r.db.WithContext(ctx).Where("provider = ? AND account_id = ? AND status = 1", provider, accountID).Find(&configs).Error; err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to get configs", err)
+	}
+	return configs, nil
+}
+
 func (r *MySQLPaymentConfigRepository) Update(ctx context.Context, config *entity.PaymentConfig) error {
 	if err := r.db.WithContext(ctx).Save(config).Error; err != nil {
 		return apperrors.Wrap(apperrors.ErrDatabaseUpdate, "failed to update config", err)
@@ -245,6 +261,692 @@ func (r *MySQLPaymentOrderRepository) List(ctx context.Context, userID uint64, p
 	return orders, total, nil
 }
 
+func (r *MySQLPaymentOrderRepository) ListByPaymentLink(ctx context.Context, linkNo string, page, pageSize int) ([]*entity.PaymentOrder, int64, error) {
+	var orders []*entity.PaymentOrder
+	var total int64
+
+	db := r.db.WithContext(ctx).Model(&entity.PaymentOrder{}).Where("payment_link_no = ?", linkNo)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to count orders", err)
+	}
+
+	offset := (page - 1) * pageSize
+	if err := db.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&orders).Error; err != nil {
+		return nil, 0, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to list orders", err)
+	}
+
+	return orders, total, nil
+}
+
+// MySQLRefundRepository MySQL退款仓储实现
+type MySQLRefundRepository struct {
+	db *gorm.DB
+}
+
+// NewMySQLRefundRepository 创建MySQL退款仓储
+func NewMySQLRefundRepository(db *gorm.DB) *MySQLRefundRepository {
+	return &MySQLRefundRepository{db: db}
+}
+
+func (r *MySQLRefundRepository) Create(ctx context.Context, refund *entity.Refund) error {
+	if err := r.db.WithContext(ctx).Create(refund).Error; err != nil {
+		return apperrors.Wrap(apperrors.ErrDatabaseInsert, "failed to create refund", err)
+	}
+	return nil
+}
+
+func (r *MySQLRefundRepository) GetByRefundNo(ctx context.Context, refundNo string) (*entity.Refund, error) {
+	var refund entity.Refund
+	if err := r.db.WithContext(ctx).Where("refund_no = ?", refundNo).First(&refund).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.New(apperrors.ErrNotFound, "refund not found")
+		}
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to get refund", err)
+	}
+	return &refund, nil
+}
+
+func (r *MySQLRefundRepository) GetByProviderRefundID(ctx context.Context, provider, refundID string) (*entity.Refund, error) {
+	var refund entity.Refund
+	if err := r.db.WithContext(ctx).Where("provider = ? AND refund_id = ?", provider, refundID).First(&refund).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.New(apperrors.ErrNotFound, "refund not found")
+		}
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to get refund", err)
+	}
+	return &refund, nil
+}
+
+func (r *MySQLRefundRepository) Update(ctx context.Context, refund *entity.Refund) error {
+	if err := r.db.WithContext(ctx).Save(refund).Error; err != nil {
+		return apperrors.Wrap(apperrors.ErrDatabaseUpdate, "failed to update refund", err)
+	}
+	return nil
+}
+
+func (r *MySQLRefundRepository) ListByOrder(ctx context.Context, orderID uint64) ([]*entity.Refund, error) {
+	var refunds []*entity.Refund
+	if err := r.db.WithContext(ctx).Where("order_id = ?", orderID).Order("created_at ASC").Find(&refunds).Error; err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to list refunds", err)
+	}
+	return refunds, nil
+}
+
+func (r *MySQLRefundRepository) ListProcessing(ctx context.Context, createdBefore time.Time, limit int) ([]*entity.Refund, error) {
+	var refunds []*entity.Refund
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND created_at <= ?", entity.RefundStatusProcessing, createdBefore).
+		Order("updated_at ASC").
+		Limit(limit).
+		Find(&refunds).Error; err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to list processing refunds", err)
+	}
+	return refunds, nil
+}
+
+// MySQLPayoutRepository MySQL付款仓储实现
+type MySQLPayoutRepository struct {
+	db *gorm.DB
+}
+
+// NewMySQLPayoutRepository 创建MySQL付款仓储
+func NewMySQLPayoutRepository(db *gorm.DB) *MySQLPayoutRepository {
+	return &MySQLPayoutRepository{db: db}
+}
+
+func (r *MySQLPayoutRepository) Create(ctx context.Context, payout *entity.Payout) error {
+	if err := r.db.WithContext(ctx).Create(payout).Error; err != nil {
+		return apperrors.Wrap(apperrors.ErrDatabaseInsert, "failed to create payout", err)
+	}
+	return nil
+}
+
+func (r *MySQLPayoutRepository) GetByPayoutNo(ctx context.Context, payoutNo string) (*entity.Payout, error) {
+	var payout entity.Payout
+	if err := r.db.WithContext(ctx).Where("payout_no = ?", payoutNo).First(&payout).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.New(apperrors.ErrNotFound, "payout not found")
+		}
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to get payout", err)
+	}
+	return &payout, nil
+}
+
+func (r *MySQLPayoutRepository) GetByUserAndOutPayoutNo(ctx context.Context, userID uint64, outPayoutNo string) (*entity.Payout, error) {
+	var payout entity.Payout
+	if err := r.db.WithContext(ctx).Where("user_id = ? AND out_payout_no = ?", userID, outPayoutNo).First(&payout).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.New(apperrors.ErrNotFound, "payout not found")
+		}
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to get payout", err)
+	}
+	return &payout, nil
+}
+
+func (r *MySQLPayoutRepository) GetByProviderPayoutID(ctx context.Context, provider, payoutID string) (*entity.Payout, error) {
+	var payout entity.Payout
+	if err := r.db.WithContext(ctx).Where("provider = ? AND payout_id = ?", provider, payoutID).First(&payout).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.New(apperrors.ErrNotFound, "payout not found")
+		}
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to get payout", err)
+	}
+	return &payout, nil
+}
+
+func (r *MySQLPayoutRepository) Update(ctx context.Context, payout *entity.Payout) error {
+	if err := r.db.WithContext(ctx).Save(payout).Error; err != nil {
+		return apperrors.Wrap(apperrors.ErrDatabaseUpdate, "failed to update payout", err)
+	}
+	return nil
+}
+
+func (r *MySQLPayoutRepository) ListProcessing(ctx context.Context, createdBefore time.Time, limit int) ([]*entity.Payout, error) {
+	var payouts []*entity.Payout
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND created_at <= ?", entity.PayoutStatusProcessing, createdBefore).
+		Order("updated_at ASC").
+		Limit(limit).
+		Find(&payouts).Error; err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to list processing payouts", err)
+	}
+	return payouts, nil
+}
+
+// MySQLProfitSharingRepository MySQL分账仓储实现
+type MySQLProfitSharingRepository struct {
+	db *gorm.DB
+}
+
+// NewMySQLProfitSharingRepository 创建MySQL分账仓储
+func NewMySQLProfitSharingRepository(db *gorm.DB) *MySQLProfitSharingRepository {
+	return &MySQLProfitSharingRepository{db: db}
+}
+
+func (r *MySQLProfitSharingRepository) Create(ctx context.Context, sharing *entity.ProfitSharing) error {
+	if err := r.db.WithContext(ctx).Create(sharing).Error; err != nil {
+		return apperrors.Wrap(apperrors.ErrDatabaseInsert, "failed to create profit sharing", err)
+	}
+	return nil
+}
+
+func (r *MySQLProfitSharingRepository) GetBySharingNo(ctx context.Context, sharingNo string) (*entity.ProfitSharing, error) {
+	var sharing entity.ProfitSharing
+	if err := r.db.WithContext(ctx).Where("sharing_no = ?", sharingNo).First(&sharing).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.New(apperrors.ErrNotFound, "profit sharing not found")
+		}
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to get profit sharing", err)
+	}
+	return &sharing, nil
+}
+
+func (r *MySQLProfitSharingRepository) GetByUserAndOutSharingNo(ctx context.Context, userID uint64, outSharingNo string) (*entity.ProfitSharing, error) {
+	var sharing entity.ProfitSharing
+	if err := r.db.WithContext(ctx).Where("user_id = ? AND out_sharing_no = ?", userID, outSharingNo).First(&sharing).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.New(apperrors.ErrNotFound, "profit sharing not found")
+		}
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to get profit sharing", err)
+	}
+	return &sharing, nil
+}
+
+func (r *MySQLProfitSharingRepository) Update(ctx context.Context, sharing *entity.ProfitSharing) error {
+	if err := r.db.WithContext(ctx).Save(sharing).Error; err != nil {
+		return apperrors.Wrap(apperrors.ErrDatabaseUpdate, "failed to update profit sharing", err)
+	}
+	return nil
+}
+
+func (r *MySQLProfitSharingRepository) ListByOrder(ctx context.Context, orderID uint64) ([]*entity.ProfitSharing, error) {
+	var sharings []*entity.ProfitSharing
+	if err := r.db.WithContext(ctx).Where("order_id = ?", orderID).Order("id ASC").Find(&sharings).Error; err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to list profit sharings", err)
+	}
+	return sharings, nil
+}
+
+func (r *MySQLProfitSharingRepository) ListProcessing(ctx context.Context, createdBefore time.Time, limit int) ([]*entity.ProfitSharing, error) {
+	var sharings []*entity.ProfitSharing
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND created_at <= ?", entity.ProfitSharingStatusProcessing, createdBefore).
+		Order("updated_at ASC").
+		Limit(limit).
+		Find(&sharings).Error; err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to list processing profit sharings", err)
+	}
+	return sharings, nil
+}
+
+// MySQLProfitSharingReturnRepository MySQL分账回退仓储实现
+type MySQLProfitSharingReturnRepository struct {
+	db *gorm.DB
+}
+
+// NewMySQLProfitSharingReturnRepository 创建MySQL分账回退仓储
+func NewMySQLProfitSharingReturnRepository(db *gorm.DB) *MySQLProfitSharingReturnRepository {
+	return &MySQLProfitSharingReturnRepository{db: db}
+}
+
+func (r *MySQLProfitSharingReturnRepository) Create(ctx context.Context, ret *entity.ProfitSharingReturn) error {
+	if err := r.db.WithContext(ctx).Create(ret).Error; err != nil {
+		return apperrors.Wrap(apperrors.ErrDatabaseInsert, "failed to create profit sharing return", err)
+	}
+	return nil
+}
+
+func (r *MySQLProfitSharingReturnRepository) GetByReturnNo(ctx context.Context, returnNo string) (*entity.ProfitSharingReturn, error) {
+	var ret entity.ProfitSharingReturn
+	if err := r.db.WithContext(ctx).Where("return_no = ?", returnNo).First(&ret).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.New(apperrors.ErrNotFound, "profit sharing return not found")
+		}
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to get profit sharing return", err)
+	}
+	return &ret, nil
+}
+
+func (r *MySQLProfitSharingReturnRepository) GetByUserAndOutReturnNo(ctx context.Context, userID uint64, outReturnNo string) (*entity.ProfitSharingReturn, error) {
+	var ret entity.ProfitSharingReturn
+	if err := r.db.WithContext(ctx).Where("user_id = ? AND out_return_no = ?", userID, outReturnNo).First(&ret).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.New(apperrors.ErrNotFound, "profit sharing return not found")
+		}
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to get profit sharing return", err)
+	}
+	return &ret, nil
+}
+
+func (r *MySQLProfitSharingReturnRepository) Update(ctx context.Context, ret *entity.ProfitSharingReturn) error {
+	if err := r.db.WithContext(ctx).Save(ret).Error; err != nil {
+		return apperrors.Wrap(apperrors.ErrDatabaseUpdate, "failed to update profit sharing return", err)
+	}
+	return nil
+}
+
+func (r *MySQLProfitSharingReturnRepository) ListBySharing(ctx context.Context, sharingNo string) ([]*entity.ProfitSharingReturn, error) {
+	var returns []*entity.ProfitSharingReturn
+	if err := r.db.WithContext(ctx).Where("sharing_no = ?", sharingNo).Order("id ASC").Find(&returns).Error; err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to list profit sharing returns", err)
+	}
+	return returns, nil
+}
+
+// MySQLPlanRepository MySQL订阅计划仓储实现
+type MySQLPlanRepository struct {
+	db *gorm.DB
+}
+
+// NewMySQLPlanRepository 创建MySQL订阅计划仓储
+func NewMySQLPlanRepository(db *gorm.DB) *MySQLPlanRepository {
+	return &MySQLPlanRepository{db: db}
+}
+
+func (r *MySQLPlanRepository) Create(ctx context.Context, plan *entity.Plan) error {
+	if err := r.db.WithContext(ctx).Create(plan).Error; err != nil {
+		return apperrors.Wrap(apperrors.ErrDatabaseInsert, "failed to create plan", err)
+	}
+	return nil
+}
+
+func (r *MySQLPlanRepository) GetByPlanNo(ctx context.Context, planNo string) (*entity.Plan, error) {
+	var plan entity.Plan
+	if err := r.db.WithContext(ctx).Where("plan_no = ?", planNo).First(&plan).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.New(apperrors.ErrNotFound, "plan not found")
+		}
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to get plan", err)
+	}
+	return &plan, nil
+}
+
+func (r *MySQLPlanRepository) GetByUserAndOutPlanNo(ctx context.Context, userID uint64, outPlanNo string) (*entity.Plan, error) {
+	var plan entity.Plan
+	if err := r.db.WithContext(ctx).Where("user_id = ? AND out_plan_no = ?", userID, outPlanNo).First(&plan).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.New(apperrors.ErrNotFound, "plan not found")
+		}
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to get plan", err)
+	}
+	return &plan, nil
+}
+
+func (r *MySQLPlanRepository) Update(ctx context.Context, plan *entity.Plan) error {
+	if err := r.db.WithContext(ctx).Save(plan).Error; err != nil {
+		return apperrors.Wrap(apperrors.ErrDatabaseUpdate, "failed to update plan", err)
+	}
+	return nil
+}
+
+// MySQLSubscriptionRepository MySQL订阅仓储实现
+type MySQLSubscriptionRepository struct {
+	db *gorm.DB
+}
+
+// NewMySQLSubscriptionRepository 创建MySQL订阅仓储
+func NewMySQLSubscriptionRepository(db *gorm.DB) *MySQLSubscriptionRepository {
+	return &MySQLSubscriptionRepository{db: db}
+}
+
+func (r *MySQLSubscriptionRepository) Create(ctx context.Context, subscription *entity.Subscription) error {
+	if err := r.db.WithContext(ctx).Create(subscription).Error; err != nil {
+		return apperrors.Wrap(apperrors.ErrDatabaseInsert, "failed to create subscription", err)
+	}
+	return nil
+}
+
+func (r *MySQLSubscriptionRepository) GetBySubscriptionNo(ctx context.Context, subscriptionNo string) (*entity.Subscription, error) {
+	var subscription entity.Subscription
+	if err := r.db.WithContext(ctx).Where("subscription_no = ?", subscriptionNo).First(&subscription).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.New(apperrors.ErrNotFound, "subscription not found")
+		}
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to get subscription", err)
+	}
+	return &subscription, nil
+}
+
+func (r *MySQLSubscriptionRepository) GetByUserAndOutSubscriptionNo(ctx context.Context, userID uint64, outSubscriptionNo string) (*entity.Subscription, error) {
+	var subscription entity.Subscription
+	if err := r.db.WithContext(ctx).Where("user_id = ? AND out_subscription_no = ?", userID, outSubscriptionNo).First(&subscription).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.New(apperrors.ErrNotFound, "subscription not found")
+		}
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to get subscription", err)
+	}
+	return &subscription, nil
+}
+
+func (r *MySQLSubscriptionRepository) GetByProviderSubscriptionID(ctx context.Context, provider, subscriptionID string) (*entity.Subscription, error) {
+	var subscription entity.Subscription
+	if err := r.db.WithContext(ctx).Where("provider = ? AND subscription_id = ?", provider, subscriptionID).First(&subscription).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.New(apperrors.ErrNotFound, "subscription not found")
+		}
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to get subscription", err)
+	}
+	return &subscription, nil
+}
+
+func (r *MySQLSubscriptionRepository) Update(ctx context.Context, subscription *entity.Subscription) error {
+	if err := r.db.WithContext(ctx).Save(subscription).Error; err != nil {
+		return apperrors.Wrap(apperrors.ErrDatabaseUpdate, "failed to update subscription", err)
+	}
+	return nil
+}
+
+func (r *MySQLSubscriptionRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*entity.Subscription, error) {
+	var subscriptions []*entity.Subscription
+	if err := r.db.WithContext(ctx).
+		Where("status IN ? AND next_billing_time <= ?", []string{
+			entity.SubscriptionStatusPending,
+			entity.SubscriptionStatusTrialing,
+			entity.SubscriptionStatusActive,
+			entity.SubscriptionStatusPastDue,
+		}, now).
+		Order("next_billing_time ASC").
+		Limit(limit).
+		Find(&subscriptions).Error; err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to list due subscriptions", err)
+	}
+	return subscriptions, nil
+}
+
+// MySQLCustomerRepository MySQL客户仓储实现
+type MySQLCustomerRepository struct {
+	db *gorm.DB
+}
+
+// NewMySQLCustomerRepository 创建MySQL客户仓储
+func NewMySQLCustomerRepository(db *gorm.DB) *MySQLCustomerRepository {
+	return &MySQLCustomerRepository{db: db}
+}
+
+func (r *MySQLCustomerRepository) Create(ctx context.Context, customer *entity.Customer) error {
+	if err := r.db.WithContext(ctx).Create(customer).Error; err != nil {
+		return apperrors.Wrap(apperrors.ErrDatabaseInsert, "failed to create customer", err)
+	}
+	return nil
+}
+
+func (r *MySQLCustomerRepository) GetByCustomerNo(ctx context.Context, customerNo string) (*entity.Customer, error) {
+	var customer entity.Customer
+	if err := r.db.WithContext(ctx).Where("customer_no = ?", customerNo).First(&customer).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.New(apperrors.ErrNotFound, "customer not found")
+		}
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to get customer", err)
+	}
+	return &customer, nil
+}
+
+func (r *MySQLCustomerRepository) GetByUserAndOutCustomerNo(ctx context.Context, userID uint64, outCustomerNo string) (*entity.Customer, error) {
+	var customer entity.Customer
+	if err := r.db.WithContext(ctx).Where("user_id = ? AND out_customer_no = ?", userID, outCustomerNo).First(&customer).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.New(apperrors.ErrNotFound, "customer not found")
+		}
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to get customer", err)
+	}
+	return &customer, nil
+}
+
+func (r *MySQLCustomerRepository) Update(ctx context.Context, customer *entity.Customer) error {
+	if err := r.db.WithContext(ctx).Save(customer).Error; err != nil {
+		return apperrors.Wrap(apperrors.ErrDatabaseUpdate, "failed to update customer", err)
+	}
+	return nil
+}
+
+// MySQLPaymentMethodRepository MySQL支付方式仓储实现
+type MySQLPaymentMethodRepository struct {
+	db *gorm.DB
+}
+
+// NewMySQLPaymentMethodRepository 创建MySQL支付方式仓储
+func NewMySQLPaymentMethodRepository(db *gorm.DB) *MySQLPaymentMethodRepository {
+	return &MySQLPaymentMethodRepository{db: db}
+}
+
+func (r *MySQLPaymentMethodRepository) Create(ctx context.Context, method *entity.PaymentMethod) error {
+	if err := r.db.WithContext(ctx).Create(method).Error; err != nil {
+		return apperrors.Wrap(apperrors.ErrDatabaseInsert, "failed to create payment method", err)
+	}
+	return nil
+}
+
+func (r *MySQLPaymentMethodRepository) GetByMethodNo(ctx context.Context, methodNo string) (*entity.PaymentMethod, error) {
+	var method entity.PaymentMethod
+	if err := r.db.WithContext(ctx).Where("method_no = ?", methodNo).First(&method).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.New(apperrors.ErrNotFound, "payment method not found")
+		}
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to get payment method", err)
+	}
+	return &method, nil
+}
+
+func (r *MySQLPaymentMethodRepository) GetByProviderMethodID(ctx context.Context, provider, methodID string) (*entity.PaymentMethod, error) {
+	var method entity.PaymentMethod
+	if err := r.db.WithContext(ctx).Where("provider = ? AND method_id = ?", provider, methodID).First(&method).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.New(apperrors.ErrNotFound, "payment method not found")
+		}
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to get payment method", err)
+	}
+	return &method, nil
+}
+
+func (r *MySQLPaymentMethodRepository) Update(ctx context.Context, method *entity.PaymentMethod) error {
+	if err := r.db.WithContext(ctx).Save(method).Error; err != nil {
+		return apperrors.Wrap(apperrors.ErrDatabaseUpdate, "failed to update payment method", err)
+	}
+	return nil
+}
+
+func (r *MySQLPaymentMethodRepository) ListByCustomer(ctx context.Context, customerID uint64) ([]*entity.PaymentMethod, error) {
+	var methods []*entity.PaymentMethod
+	if err := r.db.WithContext(ctx).Where("customer_id = ?", customerID).Order("id DESC").Find(&methods).Error; err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to list payment methods", err)
+	}
+	return methods, nil
+}
+
+// MySQLCheckoutRepository MySQL收银台仓储实现
+type MySQLCheckoutRepository struct {
+	db *gorm.DB
+}
+
+// NewMySQLCheckoutRepository 创建MySQL收银台仓储
+func NewMySQLCheckoutRepository(db *gorm.DB) *MySQLCheckoutRepository {
+	return &MySQLCheckoutRepository{db: db}
+}
+
+func (r *MySQLCheckoutRepository) Create(ctx context.Context, checkout *entity.Checkout) error {
+	if err := r.db.WithContext(ctx).Create(checkout).Error; err != nil {
+		return apperrors.Wrap(apperrors.ErrDatabaseInsert, "failed to create checkout", err)
+	}
+	return nil
+}
+
+func (r *MySQLCheckoutRepository) GetByCheckoutNo(ctx context.Context, checkoutNo string) (*entity.Checkout, error) {
+	var checkout entity.Checkout
+	if err := r.db.WithContext(ctx).Where("checkout_no = ?", checkoutNo).First(&checkout).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.New(apperrors.ErrNotFound, "checkout not found")
+		}
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to get checkout", err)
+	}
+	return &checkout, nil
+}
+
+func (r *MySQLCheckoutRepository) GetByUserAndOutTradeNo(ctx context.Context, userID uint64, outTradeNo string) (*entity.Checkout, error) {
+	var checkout entity.Checkout
+	if err := r.db.WithContext(ctx).Where("user_id = ? AND out_trade_no = ?", userID, outTradeNo).First(&checkout).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.New(apperrors.ErrNotFound, "checkout not found")
+		}
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to get checkout", err)
+	}
+	return &checkout, nil
+}
+
+func (r *MySQLCheckoutRepository) Update(ctx context.Context, checkout *entity.Checkout) error {
+	if err := r.db.WithContext(ctx).Save(checkout).Error; err != nil {
+		return apperrors.Wrap(apperrors.ErrDatabaseUpdate, "failed to update checkout", err)
+	}
+	return nil
+}
+
+// MySQLPaymentLinkRepository MySQL支付链接仓储实现
+type MySQLPaymentLinkRepository struct {
+	db *gorm.DB
+}
+
+// NewMySQLPaymentLinkRepository 创建MySQL支付链接仓储
+func NewMySQLPaymentLinkRepository(db *gorm.DB) *MySQLPaymentLinkRepository {
+	return &MySQLPaymentLinkRepository{db: db}
+}
+
+func (r *MySQLPaymentLinkRepository) Create(ctx context.Context, link *entity.PaymentLink) error {
+	if err := r.db.WithContext(ctx).Create(link).Error; err != nil {
+		return apperrors.Wrap(apperrors.ErrDatabaseInsert, "failed to create payment link", err)
+	}
+	return nil
+}
+
+func (r *MySQLPaymentLinkRepository) GetByLinkNo(ctx context.Context, linkNo string) (*entity.PaymentLink, error) {
+	var link entity.PaymentLink
+	if err := r.db.WithContext(ctx).Where("link_no = ?", linkNo).First(&link).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.New(apperrors.ErrNotFound, "payment link not found")
+		}
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to get payment link", err)
+	}
+	return &link, nil
+}
+
+func (r *MySQLPaymentLinkRepository) Update(ctx context.Context, link *entity.PaymentLink) error {
+	if err := r.db.WithContext(ctx).Save(link).Error; err != nil {
+		return apperrors.Wrap(apperrors.ErrDatabaseUpdate, "failed to update payment link", err)
+	}
+	return nil
+}
+
+func (r *MySQLPaymentLinkRepository) Delete(ctx context.Context, id uint64) error {
+	if err := r.db.WithContext(ctx).Delete(&entity.PaymentLink{}, id).Error; err != nil {
+		return apperrors.Wrap(apperrors.ErrDatabaseDelete, "failed to delete payment link", err)
+	}
+	return nil
+}
+
+func (r *MySQLPaymentLinkRepository) List(ctx context.Context, userID uint64, page, pageSize int) ([]*entity.PaymentLink, int64, error) {
+	var links []*entity.PaymentLink
+	var total int64
+
+	db := r.db.WithContext(ctx).Model(&entity.PaymentLink{}).Where("user_id = ?", userID)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to count payment links", err)
+	}
+
+	offset := (page - 1) * pageSize
+	if err := db.Order("id DESC").Offset(offset).Limit(pageSize).Find(&links).Error; err != nil {
+		return nil, 0, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to list payment links", err)
+	}
+
+	return links, total, nil
+}
+
+// RecordPayment 原子累加支付成功次数和金额，达到最大支付次数时将链接置为已完成
+func (r *MySQLPaymentLinkRepository) RecordPayment(ctx context.Context, id uint64, amount float64) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.PaymentLink{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"paid_count":  gorm.Expr("paid_count + 1"),
+			"paid_amount": gorm.Expr("paid_amount + ?", amount),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&entity.PaymentLink{}).
+			Where("id = ? AND status = ? AND max_uses > 0 AND paid_count >= max_uses", id, entity.PaymentLinkStatusActive).
+			Update("status", entity.PaymentLinkStatusCompleted).Error
+	})
+	if err != nil {
+		return apperrors.Wrap(apperrors.ErrDatabaseUpdate, "failed to record payment link payment", err)
+	}
+	return nil
+}
+
+// MySQLDisputeRepository MySQL争议仓储实现
+type MySQLDisputeRepository struct {
+	db *gorm.DB
+}
+
+// NewMySQLDisputeRepository 创建MySQL争议仓储
+func NewMySQLDisputeRepository(db *gorm.DB) *MySQLDisputeRepository {
+	return &MySQLDisputeRepository{db: db}
+}
+
+func (r *MySQLDisputeRepository) Create(ctx context.Context, dispute *entity.Dispute) error {
+	if err := r.db.WithContext(ctx).Create(dispute).Error; err != nil {
+		return apperrors.Wrap(apperrors.ErrDatabaseInsert, "failed to create dispute", err)
+	}
+	return nil
+}
+
+func (r *MySQLDisputeRepository) GetByID(ctx context.Context, id uint64) (*entity.Dispute, error) {
+	var dispute entity.Dispute
+	if err := r.db.WithContext(ctx).First(&dispute, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.New(apperrors.ErrNotFound, "dispute not found")
+		}
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to get dispute", err)
+	}
+	return &dispute, nil
+}
+
+func (r *MySQLDisputeRepository) GetByProviderDisputeID(ctx context.Context, provider, disputeID string) (*entity.Dispute, error) {
+	var dispute entity.Dispute
+	if err := r.db.WithContext(ctx).Where("provider = ? AND dispute_id = ?", provider, disputeID).First(&dispute).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.New(apperrors.ErrNotFound, "dispute not found")
+		}
+		return nil, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to get dispute", err)
+	}
+	return &dispute, nil
+}
+
+func (r *MySQLDisputeRepository) Update(ctx context.Context, dispute *entity.Dispute) error {
+	if err := r.db.WithContext(ctx).Save(dispute).Error; err != nil {
+		return apperrors.Wrap(apperrors.ErrDatabaseUpdate, "failed to update dispute", err)
+	}
+	return nil
+}
+
+func (r *MySQLDisputeRepository) List(ctx context.Context, userID uint64, status string, page, pageSize int) ([]*entity.Dispute, int64, error) {
+	var disputes []*entity.Dispute
+	var total int64
+
+	db := r.db.WithContext(ctx).Model(&entity.Dispute{})
+	if userID > 0 {
+		db = db.Where("user_id = ?", userID)
+	}
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to count disputes", err)
+	}
+
+	offset := (page - 1) * pageSize
+	if err := db.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&disputes).Error; err != nil {
+		return nil, 0, apperrors.Wrap(apperrors.ErrDatabaseQuery, "failed to list disputes", err)
+	}
+
+	return disputes, total, nil
+}
+
 // MySQLPaymentLogRepository MySQL支付日志仓储实现
 type MySQLPaymentLogRepository struct {
 	db *gorm.DB
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/zqdfound/go-uni-pay/internal/domain/entity"
 )
@@ -21,6 +22,8 @@ type PaymentConfigRepository interface {
 	GetByID(ctx context.Context, id uint64) (*entity.PaymentConfig, error)
 	GetByUserAndProvider(ctx context.Context, userID uint64, provider string) ([]*entity.PaymentConfig, error)
 	GetActiveByUserAndProvider(ctx context.Context, userID uint64, provider string) (*entity.PaymentConfig, error)
+	GetActiveByProvider(ctx context.Context, provider string) ([]*entity.PaymentConfig, error)
+	GetActiveByProviderAccount(ctx context.Context, provider, accountID string) ([]*entity.PaymentConfig, error)
 	Update(ctx context.Context, config *entity.PaymentConfig) error
 	Delete(ctx context.Context, id uint64) error
 	List(ctx context.Context, page, pageSize int, userID uint64) ([]*entity.PaymentConfig, int64, error)
@@ -35,6 +38,108 @@ type PaymentOrderRepository interface {
 	GetByUserAndOutTradeNo(ctx context.Context, userID uint64, outTradeNo string) (*entity.PaymentOrder, error)
 	Update(ctx context.Context, order *entity.PaymentOrder) error
 	List(ctx context.Context, userID uint64, page, pageSize int) ([]*entity.PaymentOrder, int64, error)
+	ListByPaymentLink(ctx context.Context, linkNo string, page, pageSize int) ([]*entity.PaymentOrder, int64, error)
+}
+
+// RefundRepository 退款仓储接口
+type RefundRepository interface {
+	Create(ctx context.Context, refund *entity.Refund) error
+	GetByRefundNo(ctx context.Context, refundNo string) (*entity.Refund, error)
+	GetByProviderRefundID(ctx context.Context, provider, refundID string) (*entity.Refund, error)
+	Update(ctx context.Context, refund *entity.Refund) error
+	ListByOrder(ctx context.Context, orderID uint64) ([]*entity.Refund, error)
+	ListProcessing(ctx context.Context, createdBefore time.Time, limit int) ([]*entity.Refund, error)
+}
+
+// PayoutRepository 付款仓储接口
+type PayoutRepository interface {
+	Create(ctx context.Context, payout *entity.Payout) error
+	GetByPayoutNo(ctx context.Context, payoutNo string) (*entity.Payout, error)
+	GetByUserAndOutPayoutNo(ctx context.Context, userID uint64, outPayoutNo string) (*entity.Payout, error)
+	GetByProviderPayoutID(ctx context.Context, provider, payoutID string) (*entity.Payout, error)
+	Update(ctx context.Context, payout *entity.Payout) error
+	ListProcessing(ctx context.Context, createdBefore time.Time, limit int) ([]*entity.Payout, error)
+}
+
+// ProfitSharingRepository 分账仓储接口
+type ProfitSharingRepository interface {
+	Create(ctx context.Context, sharing *entity.ProfitSharing) error
+	GetBySharingNo(ctx context.Context, sharingNo string) (*entity.ProfitSharing, error)
+	GetByUserAndOutSharingNo(ctx context.Context, userID uint64, outSharingNo string) (*entity.ProfitSharing, error)
+	Update(ctx context.Context, sharing *entity.ProfitSharing) error
+	ListByOrder(ctx context.Context, orderID uint64) ([]*entity.ProfitSharing, error)
+	ListProcessing(ctx context.Context, createdBefore time.Time, limit int) ([]*entity.ProfitSharing, error)
+}
+
+// ProfitSharingReturnRepository 分账回退仓储接口
+type ProfitSharingReturnRepository interface {
+	Create(ctx context.Context, ret *entity.ProfitSharingReturn) error
+	GetByReturnNo(ctx context.Context, returnNo string) (*entity.ProfitSharingReturn, error)
+	GetByUserAndOutReturnNo(ctx context.Context, userID uint64, outReturnNo string) (*entity.ProfitSharingReturn, error)
+	Update(ctx context.Context, ret *entity.ProfitSharingReturn) error
+	ListBySharing(ctx context.Context, sharingNo string) ([]*entity.ProfitSharingReturn, error)
+}
+
+// PlanRepository 订阅计划仓储接口
+type PlanRepository interface {
+	Create(ctx context.Context, plan *entity.Plan) error
+	GetByPlanNo(ctx context.Context, planNo string) (*entity.Plan, error)
+	GetByUserAndOutPlanNo(ctx context.Context, userID uint64, outPlanNo string) (*entity.Plan, error)
+	Update(ctx context.Context, plan *entity.Plan) error
+}
+
+// SubscriptionRepository 订阅仓储接口
+type SubscriptionRepository interface {
+	Create(ctx context.Context, subscription *entity.Subscription) error
+	GetBySubscriptionNo(ctx context.Context, subscriptionNo string) (*entity.Subscription, error)
+	GetByUserAndOutSubscriptionNo(ctx context.Context, userID uint64, outSubscriptionNo string) (*entity.Subscription, error)
+	GetByProviderSubscriptionID(ctx context.Context, provider, subscriptionID string) (*entity.Subscription, error)
+	Update(ctx context.Context, subscription *entity.Subscription) error
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*entity.Subscription, error)
+}
+
+// CustomerRepository 客户仓储接口
+type CustomerRepository interface {
+	Create(ctx context.Context, customer *entity.Customer) error
+	GetByCustomerNo(ctx context.Context, customerNo string) (*entity.Customer, error)
+	GetByUserAndOutCustomerNo(ctx context.Context, userID uint64, outCustomerNo string) (*entity.Customer, error)
+	Update(ctx context.Context, customer *entity.Customer) error
+}
+
+// PaymentMethodRepository 支付方式仓储接口
+type PaymentMethodRepository interface {
+	Create(ctx context.Context, method *entity.PaymentMethod) error
+	GetByMethodNo(ctx context.Context, methodNo string) (*entity.PaymentMethod, error)
+	GetByProviderMethodID(ctx context.Context, provider, methodID string) (*entity.PaymentMethod, error)
+	Update(ctx context.Context, method *entity.PaymentMethod) error
+	ListByCustomer(ctx context.Context, customerID uint64) ([]*entity.PaymentMethod, error)
+}
+
+// CheckoutRepository 收银台仓储接口
+type CheckoutRepository interface {
+	Create(ctx context.Context, checkout *entity.Checkout) error
+	GetByCheckoutNo(ctx context.Context, checkoutNo string) (*entity.Checkout, error)
+	GetByUserAndOutTradeNo(ctx context.Context, userID uint64, outTradeNo string) (*entity.Checkout, error)
+	Update(ctx context.Context, checkout *entity.Checkout) error
+}
+
+// PaymentLinkRepository 支付链接仓储接口
+type PaymentLinkRepository interface {
+	Create(ctx context.Context, link *entity.PaymentLink) error
+	GetByLinkNo(ctx context.Context, linkNo string) (*entity.PaymentLink, error)
+	Update(ctx context.Context, link *entity.PaymentLink) error
+	Delete(ctx context.Context, id uint64) error
+	List(ctx context.Context, userID uint64, page, pageSize int) ([]*entity.PaymentLink, int64, error)
+	RecordPayment(ctx context.Context, id uint64, amount float64) error
+}
+
+// DisputeRepository 争议仓储接口
+type DisputeRepository interface {
+	Create(ctx context.Context, dispute *entity.Dispute) error
+	GetByID(ctx context.Context, id uint64) (*entity.Dispute, error)
+	GetByProviderDisputeID(ctx context.Context, provider, disputeID string) (*entity.Dispute, error)
+	Update(ctx context.Context, dispute *entity.Dispute) error
+	List(ctx context.Context, userID uint64, status string, page, pageSize int) ([]*entity.Dispute, int64, error)
 }
 
 // PaymentLogRepository 支付日志仓储接口
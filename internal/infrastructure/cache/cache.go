@@ -100,3 +100,13 @@ func HGetAll(ctx context.Context, key string) (map[string]string, error) {
 func HDel(ctx context.Context, key string, fields ...string) error {
 	return Client.HDel(ctx, key, fields...).Err()
 }
+
+// Publish 发布消息到频道
+func Publish(ctx context.Context, channel string, message interface{}) error {
+	return Client.Publish(ctx, channel, message).Err()
+}
+
+// Subscribe 订阅频道，使用完毕后需调用 Close
+func Subscribe(ctx context.Context, channels ...string) *redis.PubSub {
+	return Client.Subscribe(ctx, channels...)
+}
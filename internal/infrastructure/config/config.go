@@ -10,18 +10,25 @@ var Cfg *Config
 
 // Config 全局配置结构
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Redis    RedisConfig    `mapstructure:"redis"`
-	Logger   LoggerConfig   `mapstructure:"logger"`
-	JWT      JWTConfig      `mapstructure:"jwt"`
-	Notify   NotifyConfig   `mapstructure:"notify"`
+	Server        ServerConfig        `mapstructure:"server"`
+	Database      DatabaseConfig      `mapstructure:"database"`
+	Redis         RedisConfig         `mapstructure:"redis"`
+	Logger        LoggerConfig        `mapstructure:"logger"`
+	JWT           JWTConfig           `mapstructure:"jwt"`
+	Notify        NotifyConfig        `mapstructure:"notify"`
+	Refund        RefundConfig        `mapstructure:"refund"`
+	Payout        PayoutConfig        `mapstructure:"payout"`
+	ProfitSharing ProfitSharingConfig `mapstructure:"profit_sharing"`
+	Subscription  SubscriptionConfig  `mapstructure:"subscription"`
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
 	Port         int `mapstructure:"port"`
 	Mode         string `mapstructure:"mode"`
+	BaseURL      string `mapstructure:"base_url"` // 对外访问地址，用于生成回调和跳转URL
+	NotifySecret string `mapstructure:"notify_secret"` // 通知地址签名密钥（必填），通知地址使用签名令牌代替配置ID
+	LegacyNotify bool   `mapstructure:"legacy_notify"` // 是否仍接受只有配置ID的旧通知地址，用于迁移期间
 	ReadTimeout  int `mapstructure:"read_timeout"`
 	WriteTimeout int `mapstructure:"write_timeout"`
 }
@@ -71,6 +78,36 @@ type NotifyConfig struct {
 	WorkerCount   int `mapstructure:"worker_count"`
 }
 
+// RefundConfig 退款配置
+type RefundConfig struct {
+	SyncInterval int `mapstructure:"sync_interval"` // 处理中退款的同步间隔（秒）
+}
+
+// PayoutConfig 付款配置
+type PayoutConfig struct {
+	SyncInterval int `mapstructure:"sync_interval"` // 处理中付款的同步间隔（秒）
+}
+
+// ProfitSharingConfig 分账配置
+type ProfitSharingConfig struct {
+	SyncInterval int `mapstructure:"sync_interval"` // 处理中分账的同步间隔（秒）
+}
+
+// SubscriptionConfig 订阅配置
+type SubscriptionConfig struct {
+	SyncInterval     int   `mapstructure:"sync_interval"`     // 到期订阅的处理间隔（秒）
+	DunningIntervals []int `mapstructure:"dunning_intervals"` // 续费失败后的重试间隔（天），重试用尽后取消订阅
+}
+
+// DunningDurations 续费失败后的重试间隔
+func (c SubscriptionConfig) DunningDurations() []time.Duration {
+	durations := make([]time.Duration, 0, len(c.DunningIntervals))
+	for _, days := range c.DunningIntervals {
+		durations = append(durations, time.Duration(days)*24*time.Hour)
+	}
+	return durations
+}
+
 // Load 加载配置文件
 func Load(configPath string) error {
 	viper.SetConfigFile(configPath)
@@ -57,6 +57,17 @@ func autoMigrate() error {
 		&entity.User{},
 		&entity.PaymentConfig{},
 		&entity.PaymentOrder{},
+		&entity.Refund{},
+		&entity.Payout{},
+		&entity.ProfitSharing{},
+		&entity.ProfitSharingReturn{},
+		&entity.Plan{},
+		&entity.Subscription{},
+		&entity.Customer{},
+		&entity.PaymentMethod{},
+		&entity.Checkout{},
+		&entity.PaymentLink{},
+		&entity.Dispute{},
 		&entity.PaymentLog{},
 		&entity.APILog{},
 		&entity.NotifyQueue{},
@@ -0,0 +1,472 @@
+package adyen
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+)
+
+// Checkout API 版本和测试环境地址
+const (
+	apiVersion  = "v71"
+	testBaseURL = "https://checkout-test.adyen.com/" + apiVersion
+)
+
+// Provider Adyen支付提供商
+type Provider struct {
+	httpClient *http.Client
+}
+
+// client Adyen商户账户客户端
+type client struct {
+	baseURL         string
+	apiKey          string
+	merchantAccount string
+}
+
+// amount Adyen金额，value 为最小货币单位
+type amount struct {
+	Currency string `json:"currency"`
+	Value    int64  `json:"value"`
+}
+
+// notification 标准 Webhook 通知
+type notification struct {
+	Live              string `json:"live"`
+	NotificationItems []struct {
+		NotificationRequestItem notificationItem `json:"NotificationRequestItem"`
+	} `json:"notificationItems"`
+}
+
+// notificationItem 通知项
+type notificationItem struct {
+	AdditionalData      map[string]string `json:"additionalData"`
+	Amount              amount            `json:"amount"`
+	EventCode           string            `json:"eventCode"`
+	EventDate           string            `json:"eventDate"`
+	MerchantAccountCode string            `json:"merchantAccountCode"`
+	MerchantReference   string            `json:"merchantReference"`
+	OriginalReference   string            `json:"originalReference"`
+	PspReference        string            `json:"pspReference"`
+	Reason              string            `json:"reason"`
+	Success             string            `json:"success"`
+}
+
+// NewProvider 创建Adyen提供商
+func NewProvider() *Provider {
+	return &Provider{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// GetName 获取提供商名称
+func (p *Provider) GetName() string {
+	return payment.ProviderAdyen
+}
+
+// CreatePayment 创建 Checkout Session
+// 返回托管支付页面地址，同时返回 session_id 和 session_data 供前端 Drop-in 使用
+func (p *Provider) CreatePayment(ctx context.Context, req *payment.CreatePaymentRequest) (*payment.CreatePaymentResponse, error) {
+	c, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]interface{}{
+		"merchantAccount": c.merchantAccount,
+		"reference":       req.OutTradeNo,
+		"amount":          toMinorUnits(req.Amount, req.Currency),
+		"returnUrl":       req.ReturnURL,
+		"mode":            "hosted",
+	}
+	if countryCode := getStringParam(req.ExtraParams, "country_code"); countryCode != "" {
+		body["countryCode"] = countryCode
+	} else if countryCode, ok := req.Config["country_code"].(string); ok && countryCode != "" {
+		body["countryCode"] = countryCode
+	}
+	if locale := getStringParam(req.ExtraParams, "shopper_locale"); locale != "" {
+		body["shopperLocale"] = locale
+	}
+	if len(req.Items) > 0 {
+		body["lineItems"] = lineItems(req)
+	}
+	if req.Buyer != nil {
+		if req.Buyer.Email != "" {
+			body["shopperEmail"] = req.Buyer.Email
+		}
+		if req.Buyer.Phone != "" {
+			body["telephoneNumber"] = req.Buyer.Phone
+		}
+	}
+	if req.Shipping != nil {
+		body["deliveryAddress"] = map[string]interface{}{
+			"street":            req.Shipping.Line1,
+			"houseNumberOrName": req.Shipping.Line2,
+			"city":              req.Shipping.City,
+			"stateOrProvince":   req.Shipping.State,
+			"postalCode":        req.Shipping.PostalCode,
+			"country":           req.Shipping.Country,
+		}
+	}
+
+	var session struct {
+		ID          string `json:"id"`
+		SessionData string `json:"sessionData"`
+		URL         string `json:"url"`
+		ExpiresAt   string `json:"expiresAt"`
+	}
+	if err := p.post(ctx, c, "/sessions", body, &session); err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentCreate, "failed to create adyen session", err)
+	}
+
+	resp := &payment.CreatePaymentResponse{
+		PaymentURL: session.URL,
+		PaymentID:  session.ID,
+		TradeNo:    session.ID,
+		ExtraData: map[string]interface{}{
+			"session_id":   session.ID,
+			"session_data": session.SessionData,
+			"expires_at":   session.ExpiresAt,
+		},
+	}
+	if expireTime, err := time.Parse(time.RFC3339, session.ExpiresAt); err == nil {
+		resp.ExpireTime = &expireTime
+	}
+
+	return resp, nil
+}
+
+// AccountID Adyen 通知总是校验 HMAC 签名，以商户账号识别支付配置
+func (p *Provider) AccountID(config map[string]interface{}) string {
+	merchantAccount, _ := config["merchant_account"].(string)
+	return merchantAccount
+}
+
+// NotifyAccountID 读取通知项中的商户账号
+func (p *Provider) NotifyAccountID(req *payment.NotifyRequest) string {
+	var n notification
+	if err := json.Unmarshal(req.RawData, &n); err != nil || len(n.NotificationItems) == 0 {
+		return ""
+	}
+	return n.NotificationItems[0].NotificationRequestItem.MerchantAccountCode
+}
+
+// HandleNotify 处理标准 Webhook 通知，逐项校验 HMAC 签名
+func (p *Provider) HandleNotify(ctx context.Context, req *payment.NotifyRequest) (*payment.NotifyResponse, error) {
+	hmacKey, ok := req.Config["hmac_key"].(string)
+	if !ok || hmacKey == "" {
+		return nil, apperrors.New(apperrors.ErrConfigNotFound, "hmac_key not found in config")
+	}
+
+	var n notification
+	if err := json.Unmarshal(req.RawData, &n); err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to parse adyen notification", err)
+	}
+	if len(n.NotificationItems) == 0 {
+		return nil, apperrors.New(apperrors.ErrPaymentNotify, "adyen notification has no items")
+	}
+
+	for _, wrapper := range n.NotificationItems {
+		valid, err := verifyHMAC(&wrapper.NotificationRequestItem, hmacKey)
+		if err != nil {
+			return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, "invalid adyen hmac_key", err)
+		}
+		if !valid {
+			return nil, apperrors.New(apperrors.ErrPaymentNotify, "invalid adyen notification signature")
+		}
+	}
+
+	// 标准 Webhook 每次只包含一个通知项
+	item := n.NotificationItems[0].NotificationRequestItem
+	response := &payment.NotifyResponse{
+		OutTradeNo:  item.MerchantReference,
+		Status:      convertStatus(item.EventCode, item.Success == "true"),
+		Amount:      fromMinorUnits(item.Amount),
+		PaymentTime: item.EventDate,
+		ReturnData:  []byte("[accepted]"),
+	}
+
+	// 退款结果，pspReference 为退款单号，merchantReference 为发起退款时的 reference
+	switch item.EventCode {
+	case "REFUND", "REFUND_FAILED", "REFUNDED_REVERSED":
+		refundStatus := payment.RefundStatusFailed
+		if item.EventCode == "REFUND" && item.Success == "true" {
+			refundStatus = payment.RefundStatusSuccess
+		}
+
+		response.Event = payment.EventRefund
+		response.OutTradeNo = ""
+		response.Refund = &payment.RefundInfo{
+			RefundNo:   item.MerchantReference,
+			RefundID:   item.PspReference,
+			Amount:     response.Amount,
+			Status:     refundStatus,
+			RefundTime: item.EventDate,
+		}
+	}
+
+	// 退款、撤销等修改类事件的 pspReference 为修改单号，原支付单号在 originalReference 中
+	if item.OriginalReference != "" {
+		response.TradeNo = item.OriginalReference
+	} else {
+		response.TradeNo = item.PspReference
+	}
+
+	return response, nil
+}
+
+// RefundPayment 退款，结果以 REFUND 通知为准
+func (p *Provider) RefundPayment(ctx context.Context, req *payment.RefundRequest) (*payment.RefundResponse, error) {
+	c, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]interface{}{
+		"merchantAccount": c.merchantAccount,
+		"amount":          toMinorUnits(req.RefundAmount, req.Currency),
+		"reference":       req.RefundNo,
+	}
+
+	var result struct {
+		PspReference string `json:"pspReference"`
+	}
+
+	path := fmt.Sprintf("/payments/%s/refunds", url.PathEscape(req.TradeNo))
+	if err := p.post(ctx, c, path, body, &result); err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentRefund, "failed to refund adyen payment", err)
+	}
+
+	// 退款结果以 REFUND 通知为准
+	return &payment.RefundResponse{
+		RefundNo: req.RefundNo,
+		RefundID: result.PspReference,
+		TradeNo:  req.TradeNo,
+		Status:   payment.RefundStatusProcessing,
+	}, nil
+}
+
+// ClosePayment 撤销未完成的支付，尚未获得 pspReference 时按商户订单号撤销
+func (p *Provider) ClosePayment(ctx context.Context, req *payment.ClosePaymentRequest) error {
+	c, err := p.getClient(req.Config)
+	if err != nil {
+		return err
+	}
+
+	body := map[string]interface{}{
+		"merchantAccount": c.merchantAccount,
+		"reference":       req.OutTradeNo,
+	}
+
+	path := "/cancels"
+	if isPspReference(req.TradeNo) {
+		path = fmt.Sprintf("/payments/%s/cancels", url.PathEscape(req.TradeNo))
+	} else {
+		body["paymentReference"] = req.OutTradeNo
+	}
+
+	if err := p.post(ctx, c, path, body, nil); err != nil {
+		return apperrors.Wrap(apperrors.ErrPaymentCancel, "failed to cancel adyen payment", err)
+	}
+
+	return nil
+}
+
+// post 发送 Checkout API 请求
+func (p *Provider) post(ctx context.Context, c *client, path string, body interface{}, result interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("X-API-Key", c.apiKey)
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			ErrorCode string `json:"errorCode"`
+			Message   string `json:"message"`
+		}
+		json.Unmarshal(respBody, &apiErr)
+		return fmt.Errorf("adyen api error: status=%d code=%s message=%s", resp.StatusCode, apiErr.ErrorCode, apiErr.Message)
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(respBody, result)
+}
+
+// getClient 获取Adyen商户账户客户端
+func (p *Provider) getClient(config map[string]interface{}) (*client, error) {
+	apiKey, ok := config["api_key"].(string)
+	if !ok {
+		return nil, apperrors.New(apperrors.ErrConfigNotFound, "api_key not found in config")
+	}
+
+	merchantAccount, ok := config["merchant_account"].(string)
+	if !ok {
+		return nil, apperrors.New(apperrors.ErrConfigNotFound, "merchant_account not found in config")
+	}
+
+	baseURL := testBaseURL
+	if isProduction, _ := config["is_production"].(bool); isProduction {
+		// 生产环境使用商户专属的地址前缀
+		prefix, ok := config["live_url_prefix"].(string)
+		if !ok || prefix == "" {
+			return nil, apperrors.New(apperrors.ErrConfigNotFound, "live_url_prefix not found in config")
+		}
+		baseURL = fmt.Sprintf("https://%s-checkout-live.adyenpayments.com/checkout/%s", prefix, apiVersion)
+	}
+
+	return &client{
+		baseURL:         baseURL,
+		apiKey:          apiKey,
+		merchantAccount: merchantAccount,
+	}, nil
+}
+
+// verifyHMAC 校验通知项的 HMAC 签名
+// 签名串为 pspReference:originalReference:merchantAccountCode:merchantReference:value:currency:eventCode:success
+func verifyHMAC(item *notificationItem, hmacKey string) (bool, error) {
+	key, err := hex.DecodeString(hmacKey)
+	if err != nil {
+		return false, err
+	}
+
+	payload := strings.Join([]string{
+		item.PspReference,
+		item.OriginalReference,
+		item.MerchantAccountCode,
+		item.MerchantReference,
+		strconv.FormatInt(item.Amount.Value, 10),
+		item.Amount.Currency,
+		item.EventCode,
+		item.Success,
+	}, ":")
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(expected), []byte(item.AdditionalData["hmacSignature"])), nil
+}
+
+// convertStatus 转换通知事件为支付状态，空状态表示该事件不影响订单状态
+func convertStatus(eventCode string, success bool) string {
+	switch eventCode {
+	case "AUTHORISATION":
+		if success {
+			return payment.StatusSuccess
+		}
+		return payment.StatusFailed
+	case "CANCELLATION", "OFFER_CLOSED":
+		if success {
+			return payment.StatusClosed
+		}
+	}
+	return ""
+}
+
+// isPspReference 是否为 Adyen 支付单号（16位字母数字），Session ID 以 CS 开头
+func isPspReference(tradeNo string) bool {
+	return tradeNo != "" && !strings.HasPrefix(tradeNo, "CS")
+}
+
+// toMinorUnits 将金额转换为最小货币单位
+func toMinorUnits(value float64, currency string) amount {
+	currency = strings.ToUpper(currency)
+	return amount{
+		Currency: currency,
+		Value:    int64(math.Round(value * math.Pow10(currencyExponent(currency)))),
+	}
+}
+
+// lineItems 转换商品明细，金额为单件的最小货币单位，运费作为单独的一项
+func lineItems(req *payment.CreatePaymentRequest) []map[string]interface{} {
+	items := make([]map[string]interface{}, 0, len(req.Items)+1)
+	for _, item := range req.Items {
+		excludingTax := toMinorUnits(item.UnitPrice, req.Currency).Value
+		tax := toMinorUnits(item.Tax, req.Currency).Value
+		items = append(items, map[string]interface{}{
+			"id":                 item.SKU,
+			"description":        item.Name,
+			"quantity":           item.Quantity,
+			"amountExcludingTax": excludingTax,
+			"taxAmount":          tax,
+			"amountIncludingTax": excludingTax + tax,
+		})
+	}
+
+	if req.Shipping != nil && req.Shipping.Fee > 0 {
+		fee := toMinorUnits(req.Shipping.Fee, req.Currency).Value
+		items = append(items, map[string]interface{}{
+			"description":        "Shipping",
+			"quantity":           1,
+			"amountExcludingTax": fee,
+			"taxAmount":          0,
+			"amountIncludingTax": fee,
+		})
+	}
+	return items
+}
+
+// fromMinorUnits 将最小货币单位转换为金额
+func fromMinorUnits(a amount) float64 {
+	return float64(a.Value) / math.Pow10(currencyExponent(a.Currency))
+}
+
+// currencyExponent 货币小数位数
+func currencyExponent(currency string) int {
+	switch currency {
+	case "CVE", "IDR", "ISK", "JPY", "KRW", "PYG", "VND", "XAF", "XOF", "XPF":
+		return 0
+	case "BHD", "IQD", "JOD", "KWD", "LYD", "OMR", "TND":
+		return 3
+	default:
+		return 2
+	}
+}
+
+// getStringParam 获取字符串参数
+func getStringParam(params map[string]interface{}, key string) string {
+	if v, ok := params[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+// init 注册支付提供商
+func init() {
+	payment.Register(NewProvider())
+}
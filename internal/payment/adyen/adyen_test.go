@@ -0,0 +1,74 @@
+package adyen
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+)
+
+// testHMACKey testdata/notify.json 的签名密钥
+const testHMACKey = "44782DEF547AAA06C910C43932B1EB0C71FC68D9D0C057550C48EC2ACF6BA056"
+
+// TestHandleNotify 测试 Adyen 通知的 HMAC 校验和应答
+func TestHandleNotify(t *testing.T) {
+	rawData, err := os.ReadFile("testdata/notify.json")
+	require.NoError(t, err)
+
+	resp, err := NewProvider().HandleNotify(context.Background(), &payment.NotifyRequest{
+		RawData: rawData,
+		Config:  map[string]interface{}{"hmac_key": testHMACKey},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "TEST20240101000001", resp.OutTradeNo)
+	assert.Equal(t, "QFQTPCQ8HXSKGK82", resp.TradeNo)
+	assert.Equal(t, payment.StatusSuccess, resp.Status)
+	assert.Equal(t, 10.00, resp.Amount)
+	assert.Equal(t, "[accepted]", string(resp.ReturnData))
+}
+
+// TestHandleNotify_Tampered 测试篡改金额后 HMAC 校验失败
+func TestHandleNotify_Tampered(t *testing.T) {
+	rawData, err := os.ReadFile("testdata/notify.json")
+	require.NoError(t, err)
+
+	tampered := strings.Replace(string(rawData), `"value": 1000`, `"value": 1`, 1)
+
+	_, err = NewProvider().HandleNotify(context.Background(), &payment.NotifyRequest{
+		RawData: []byte(tampered),
+		Config:  map[string]interface{}{"hmac_key": testHMACKey},
+	})
+	assert.Error(t, err)
+}
+
+// TestNotifyAccountID 测试验签前从通知中读取商户账号
+func TestNotifyAccountID(t *testing.T) {
+	rawData, err := os.ReadFile("testdata/notify.json")
+	require.NoError(t, err)
+
+	p := NewProvider()
+	assert.Equal(t, "TestMerchantECOM", p.NotifyAccountID(&payment.NotifyRequest{RawData: rawData}))
+	assert.Equal(t, "TestMerchantECOM", p.AccountID(map[string]interface{}{"merchant_account": "TestMerchantECOM"}))
+	assert.Empty(t, p.NotifyAccountID(&payment.NotifyRequest{RawData: []byte("invalid")}))
+}
+
+// TestLineItems 测试商品明细和运费转换为最小货币单位
+func TestLineItems(t *testing.T) {
+	items := lineItems(&payment.CreatePaymentRequest{
+		Currency: "EUR",
+		Items:    []payment.Item{{Name: "T-Shirt", SKU: "TS-01", Quantity: 2, UnitPrice: 10, Tax: 2.1}},
+		Shipping: &payment.Shipping{Fee: 4.95},
+	})
+	require.Len(t, items, 2)
+
+	assert.Equal(t, "TS-01", items[0]["id"])
+	assert.Equal(t, int64(1000), items[0]["amountExcludingTax"])
+	assert.Equal(t, int64(210), items[0]["taxAmount"])
+	assert.Equal(t, int64(1210), items[0]["amountIncludingTax"])
+	assert.Equal(t, int64(495), items[1]["amountIncludingTax"])
+}
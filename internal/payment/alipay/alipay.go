@@ -2,13 +2,20 @@ package alipay
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"math"
+	"net/url"
+	"time"
 
 	"github.com/smartwalle/alipay/v3"
 	"github.com/zqdfound/go-uni-pay/internal/payment"
 	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
 )
 
+// qrCodeValidity 当面付预下单二维码的有效期
+const qrCodeValidity = 2 * time.Hour
+
 // Provider 支付宝支付提供商
 type Provider struct{}
 
@@ -23,33 +30,215 @@ func (p *Provider) GetName() string {
 }
 
 // CreatePayment 创建支付
+// req.Scene 为 h5 时使用手机网站支付，为 native 时使用当面付扫码（预下单返回二维码），否则使用电脑网站支付
 func (p *Provider) CreatePayment(ctx context.Context, req *payment.CreatePaymentRequest) (*payment.CreatePaymentResponse, error) {
 	client, err := p.getClient(req.Config)
 	if err != nil {
 		return nil, err
 	}
 
-	// 创建支付请求
-	var pay = alipay.TradePagePay{}
-	pay.NotifyURL = req.NotifyURL
-	pay.ReturnURL = req.ReturnURL
-	pay.Subject = req.Subject
-	pay.OutTradeNo = req.OutTradeNo
-	pay.TotalAmount = fmt.Sprintf("%.2f", req.Amount)
-	pay.ProductCode = "FAST_INSTANT_TRADE_PAY"
+	trade := alipay.Trade{
+		NotifyURL:   req.NotifyURL,
+		ReturnURL:   req.ReturnURL,
+		Subject:     req.Subject,
+		OutTradeNo:  req.OutTradeNo,
+		TotalAmount: fmt.Sprintf("%.2f", req.Amount),
+		GoodsDetail: goodsDetail(req.Items),
+	}
+
+	if req.Scene == payment.SceneNative {
+		trade.ProductCode = "FACE_TO_FACE_PAYMENT"
+		rsp, err := client.TradePreCreate(alipay.TradePreCreate{Trade: trade})
+		if err != nil {
+			return nil, apperrors.Wrap(apperrors.ErrPaymentCreate, "failed to create alipay precreate payment", err)
+		}
+		if rsp.IsFailure() {
+			return nil, apperrors.New(apperrors.ErrPaymentCreate, rsp.Msg)
+		}
+		expireTime := time.Now().Add(qrCodeValidity)
+		return &payment.CreatePaymentResponse{
+			PaymentID:  req.OutTradeNo,
+			QRCode:     rsp.QRCode,
+			ExpireTime: &expireTime,
+		}, nil
+	}
 
 	// 生成支付URL
-	url, err := client.TradePagePay(pay)
+	var payURL *url.URL
+	if req.Scene == payment.SceneH5 {
+		trade.ProductCode = "QUICK_WAP_WAY"
+		payURL, err = client.TradeWapPay(alipay.TradeWapPay{Trade: trade, QuitURL: req.ReturnURL})
+	} else {
+		trade.ProductCode = "FAST_INSTANT_TRADE_PAY"
+		payURL, err = client.TradePagePay(alipay.TradePagePay{Trade: trade})
+	}
 	if err != nil {
 		return nil, apperrors.Wrap(apperrors.ErrPaymentCreate, "failed to create alipay payment", err)
 	}
 
 	return &payment.CreatePaymentResponse{
-		PaymentURL: url.String(),
+		PaymentURL: payURL.String(),
 		PaymentID:  req.OutTradeNo,
 	}, nil
 }
 
+// goodsDetail 转换商品明细，支付宝的商品单价包含税额，商品编码为空时使用商品名称
+func goodsDetail(items []payment.Item) []*alipay.GoodsDetail {
+	if len(items) == 0 {
+		return nil
+	}
+
+	details := make([]*alipay.GoodsDetail, 0, len(items))
+	for _, item := range items {
+		goodsID := item.SKU
+		if goodsID == "" {
+			goodsID = item.Name
+		}
+		details = append(details, &alipay.GoodsDetail{
+			GoodsId:   goodsID,
+			GoodsName: item.Name,
+			Quantity:  int(item.Quantity),
+			Price:     math.Round((item.UnitPrice+item.Tax)*100) / 100,
+		})
+	}
+	return details
+}
+
+// Authorize 创建资金授权（预授权）订单，返回授权二维码，买家扫码后冻结资金
+func (p *Provider) Authorize(ctx context.Context, req *payment.CreatePaymentRequest) (*payment.CreatePaymentResponse, error) {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	var voucher = alipay.FundAuthOrderVoucherCreate{}
+	voucher.NotifyURL = req.NotifyURL
+	voucher.OutOrderNo = req.OutTradeNo
+	voucher.OutRequestNo = req.OutTradeNo
+	voucher.ProductCode = "PRE_AUTH"
+	voucher.OrderTitle = req.Subject
+	voucher.Amount = fmt.Sprintf("%.2f", req.Amount)
+	if req.Currency != "" && req.Currency != "CNY" {
+		voucher.TransCurrency = req.Currency
+	}
+
+	rsp, err := client.FundAuthOrderVoucherCreate(voucher)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentCreate, "failed to create alipay fund authorization", err)
+	}
+
+	if rsp.IsFailure() {
+		return nil, apperrors.New(apperrors.ErrPaymentCreate, rsp.Msg)
+	}
+
+	return &payment.CreatePaymentResponse{
+		PaymentURL: rsp.CodeURL,
+		PaymentID:  req.OutTradeNo,
+		QRCode:     rsp.CodeValue,
+	}, nil
+}
+
+// Capture 预授权转支付，金额为0时按冻结金额全额扣款，剩余冻结资金自动解冻
+func (p *Provider) Capture(ctx context.Context, req *payment.CaptureRequest) (*payment.CaptureResponse, error) {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	// 查询授权信息，转支付需要买家用户ID
+	detail, err := p.queryFundAuth(client, req.AuthorizationID, req.OutTradeNo)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentCapture, "failed to query alipay fund authorization", err)
+	}
+
+	amount := req.Amount
+	if amount <= 0 {
+		amount = parseAmount(detail.RestAmount)
+	}
+
+	var pay = alipay.TradePay{}
+	pay.Subject = req.Subject
+	pay.OutTradeNo = req.OutTradeNo
+	pay.TotalAmount = fmt.Sprintf("%.2f", amount)
+	pay.ProductCode = "PRE_AUTH"
+	pay.AuthNo = detail.AuthNo
+	pay.BuyerId = detail.PayerUserId
+	pay.AuthConfirmMode = "COMPLETE"
+
+	rsp, err := client.TradePay(pay)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentCapture, "failed to capture alipay fund authorization", err)
+	}
+
+	// 10003 表示支付处理中，结果以异步通知为准
+	status := payment.StatusSuccess
+	if rsp.Code == "10003" {
+		status = payment.StatusPending
+	} else if rsp.IsFailure() {
+		return nil, apperrors.New(apperrors.ErrPaymentCapture, rsp.Msg)
+	}
+
+	return &payment.CaptureResponse{
+		TradeNo:   rsp.TradeNo,
+		CaptureID: rsp.TradeNo,
+		Status:    status,
+		Amount:    parseAmount(rsp.TotalAmount),
+	}, nil
+}
+
+// Void 解冻全部剩余冻结资金
+func (p *Provider) Void(ctx context.Context, req *payment.VoidRequest) error {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return err
+	}
+
+	detail, err := p.queryFundAuth(client, req.AuthorizationID, req.OutTradeNo)
+	if err != nil {
+		return apperrors.Wrap(apperrors.ErrPaymentVoid, "failed to query alipay fund authorization", err)
+	}
+
+	var unfreeze = alipay.FundAuthOrderUnfreeze{}
+	unfreeze.AuthNo = detail.AuthNo
+	unfreeze.OutRequestNo = req.OutTradeNo + "V"
+	unfreeze.Amount = detail.RestAmount
+	unfreeze.Remark = "void authorization"
+
+	rsp, err := client.FundAuthOrderUnfreeze(unfreeze)
+	if err != nil {
+		return apperrors.Wrap(apperrors.ErrPaymentVoid, "failed to unfreeze alipay fund authorization", err)
+	}
+
+	if rsp.IsFailure() {
+		return apperrors.New(apperrors.ErrPaymentVoid, rsp.Msg)
+	}
+
+	return nil
+}
+
+// queryFundAuth 查询资金授权冻结操作
+func (p *Provider) queryFundAuth(client *alipay.Client, authNo, outOrderNo string) (*alipay.FundAuthOperationDetailQueryRsp, error) {
+	var query = alipay.FundAuthOperationDetailQuery{}
+	if authNo != "" {
+		query.AuthNo = authNo
+	} else {
+		query.OutOrderNo = outOrderNo
+	}
+	// 冻结操作的请求流水号与授权订单号一致
+	query.OutRequestNo = outOrderNo
+
+	rsp, err := client.FundAuthOperationDetailQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if rsp.IsFailure() {
+		return nil, fmt.Errorf("%s", rsp.Msg)
+	}
+
+	return rsp, nil
+}
+
 // QueryPayment 查询支付
 func (p *Provider) QueryPayment(ctx context.Context, req *payment.QueryPaymentRequest) (*payment.QueryPaymentResponse, error) {
 	client, err := p.getClient(req.Config)
@@ -88,6 +277,47 @@ func (p *Provider) QueryPayment(ctx context.Context, req *payment.QueryPaymentRe
 	}, nil
 }
 
+// VerifyReturn 校验同步跳转参数的签名
+// 同步跳转参数不包含交易状态，签名校验通过后查询交易状态
+func (p *Provider) VerifyReturn(ctx context.Context, req *payment.VerifyReturnRequest) (*payment.QueryPaymentResponse, error) {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	values := url.Values(req.Params)
+	if err := client.VerifySign(values); err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to verify alipay return signature", err)
+	}
+	if values.Get("out_trade_no") != req.OutTradeNo {
+		return nil, apperrors.New(apperrors.ErrPaymentNotify, "alipay return does not match the order")
+	}
+
+	return p.QueryPayment(ctx, &payment.QueryPaymentRequest{
+		OutTradeNo: req.OutTradeNo,
+		TradeNo:    values.Get("trade_no"),
+		Config:     req.Config,
+	})
+}
+
+// AccountID 支付宝通知总是校验签名，以应用ID识别支付配置
+func (p *Provider) AccountID(config map[string]interface{}) string {
+	appID, _ := config["app_id"].(string)
+	return appID
+}
+
+// NotifyAccountID 读取通知中的应用ID
+func (p *Provider) NotifyAccountID(req *payment.NotifyRequest) string {
+	if appID := getFirstValue(req.FormData, "app_id"); appID != "" {
+		return appID
+	}
+	values, err := url.ParseQuery(string(req.RawData))
+	if err != nil {
+		return ""
+	}
+	return values.Get("app_id")
+}
+
 // HandleNotify 处理支付通知
 func (p *Provider) HandleNotify(ctx context.Context, req *payment.NotifyRequest) (*payment.NotifyResponse, error) {
 	client, err := p.getClient(req.Config)
@@ -95,12 +325,44 @@ func (p *Provider) HandleNotify(ctx context.Context, req *payment.NotifyRequest)
 		return nil, err
 	}
 
+	// 资金授权冻结通知
+	if getFirstValue(req.FormData, "notify_type") == "fund_auth_freeze" {
+		return p.handleFreezeNotify(client, req)
+	}
+
+	// 周期扣款、商户代扣签约和解约通知
+	switch getFirstValue(req.FormData, "notify_type") {
+	case "dut_user_sign", "dut_user_unsign":
+		return p.handleAgreementNotify(client, req)
+	}
+
+	// 转账单据状态变更通知
+	if getFirstValue(req.FormData, "msg_method") == "alipay.fund.trans.order.changed" {
+		return p.handlePayoutNotify(client, req)
+	}
+
 	// 解析通知
 	notification, err := client.DecodeNotification(req.FormData)
 	if err != nil {
 		return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to decode alipay notification", err)
 	}
 
+	// 退款后支付宝发送的交易状态同步通知，out_biz_no 为退款请求号
+	// 全额退款后交易状态为 TRADE_CLOSED，不能作为订单关闭处理
+	if notification.OutBizNo != "" && notification.RefundFee != "" {
+		return &payment.NotifyResponse{
+			Event:      payment.EventRefund,
+			TradeNo:    notification.TradeNo,
+			OutTradeNo: notification.OutTradeNo,
+			Refund: &payment.RefundInfo{
+				RefundNo:   notification.OutBizNo,
+				Status:     payment.RefundStatusSuccess,
+				RefundTime: notification.GmtRefund,
+			},
+			ReturnData: []byte("success"),
+		}, nil
+	}
+
 	// 转换支付状态
 	status := p.convertStatus(string(notification.TradeStatus))
 
@@ -117,6 +379,270 @@ func (p *Provider) HandleNotify(ctx context.Context, req *payment.NotifyRequest)
 	return response, nil
 }
 
+// handleFreezeNotify 处理资金授权冻结通知
+func (p *Provider) handleFreezeNotify(client *alipay.Client, req *payment.NotifyRequest) (*payment.NotifyResponse, error) {
+	if err := client.VerifySign(url.Values(req.FormData)); err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to verify alipay fund auth notification", err)
+	}
+
+	response := &payment.NotifyResponse{
+		TradeNo:         getFirstValue(req.FormData, "auth_no"),
+		OutTradeNo:      getFirstValue(req.FormData, "out_order_no"),
+		AuthorizationID: getFirstValue(req.FormData, "auth_no"),
+		Amount:          parseAmount(getFirstValue(req.FormData, "amount")),
+		PaymentTime:     getFirstValue(req.FormData, "gmt_trans"),
+		BuyerInfo:       getFirstValue(req.FormData, "payer_logon_id"),
+		ReturnData:      []byte("success"),
+	}
+
+	switch getFirstValue(req.FormData, "status") {
+	case "SUCCESS":
+		response.Status = payment.StatusAuthorized
+	case "CLOSED":
+		response.Status = payment.StatusClosed
+	}
+
+	return response, nil
+}
+
+// payoutNotify 转账单据状态变更通知的业务参数
+type payoutNotify struct {
+	OutBizNo   string `json:"out_biz_no"`
+	OrderID    string `json:"order_id"`
+	Status     string `json:"status"`
+	PayDate    string `json:"pay_date"`
+	FailReason string `json:"fail_reason"`
+}
+
+// handlePayoutNotify 处理转账单据状态变更通知
+func (p *Provider) handlePayoutNotify(client *alipay.Client, req *payment.NotifyRequest) (*payment.NotifyResponse, error) {
+	if err := client.VerifySign(url.Values(req.FormData)); err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to verify alipay fund trans notification", err)
+	}
+
+	var biz payoutNotify
+	if err := json.Unmarshal([]byte(getFirstValue(req.FormData, "biz_content")), &biz); err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to parse alipay fund trans notification", err)
+	}
+
+	return &payment.NotifyResponse{
+		Event: payment.EventPayout,
+		Payout: &payment.PayoutInfo{
+			PayoutNo:   biz.OutBizNo,
+			PayoutID:   biz.OrderID,
+			Status:     p.convertPayoutStatus(biz.Status),
+			FailReason: biz.FailReason,
+			FinishTime: biz.PayDate,
+		},
+		ReturnData: []byte("success"),
+	}, nil
+}
+
+// CreatePayout 单笔转账到支付宝账户（alipay.fund.trans.uni.transfer）
+func (p *Provider) CreatePayout(ctx context.Context, req *payment.PayoutRequest) (*payment.PayoutResponse, error) {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	identityType, err := p.payeeIdentityType(req.PayeeType)
+	if err != nil {
+		return nil, err
+	}
+
+	var transfer = alipay.FundTransUniTransfer{}
+	transfer.OutBizNo = req.PayoutNo
+	transfer.TransAmount = fmt.Sprintf("%.2f", req.Amount)
+	transfer.ProductCode = "TRANS_ACCOUNT_NO_PWD"
+	transfer.BizScene = "DIRECT_TRANSFER"
+	transfer.OrderTitle = req.Remark
+	transfer.Remark = req.Remark
+	transfer.PayeeInfo = &alipay.PayeeInfo{
+		Identity:     req.PayeeAccount,
+		IdentityType: identityType,
+		Name:         req.PayeeName,
+	}
+
+	rsp, err := client.FundTransUniTransfer(transfer)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPayout, "failed to create alipay transfer", err)
+	}
+
+	if rsp.IsFailure() {
+		return nil, apperrors.New(apperrors.ErrPayout, fmt.Sprintf("%s: %s", rsp.Msg, rsp.SubMsg))
+	}
+
+	return &payment.PayoutResponse{
+		PayoutNo:   rsp.OutBizNo,
+		PayoutID:   rsp.OrderId,
+		Status:     p.convertPayoutStatus(rsp.Status),
+		FinishTime: rsp.TransDate,
+	}, nil
+}
+
+// QueryPayout 查询转账单据
+func (p *Provider) QueryPayout(ctx context.Context, req *payment.QueryPayoutRequest) (*payment.PayoutResponse, error) {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp, err := client.FundTransCommonQuery(alipay.FundTransCommonQuery{
+		ProductCode: "TRANS_ACCOUNT_NO_PWD",
+		BizScene:    "DIRECT_TRANSFER",
+		OutBizNo:    req.PayoutNo,
+		OrderId:     req.PayoutID,
+	})
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentQuery, "failed to query alipay transfer", err)
+	}
+
+	if rsp.IsFailure() {
+		return nil, apperrors.New(apperrors.ErrPaymentQuery, rsp.Msg)
+	}
+
+	return &payment.PayoutResponse{
+		PayoutNo:   rsp.OutBizNo,
+		PayoutID:   rsp.OrderId,
+		Status:     p.convertPayoutStatus(rsp.Status),
+		FailReason: rsp.FailReason,
+		FinishTime: rsp.PayDate,
+	}, nil
+}
+
+// payeeIdentityType 转换收款方账户类型，默认为支付宝会员ID
+func (p *Provider) payeeIdentityType(payeeType string) (string, error) {
+	switch payeeType {
+	case "", payment.PayeeTypeUserID:
+		return "ALIPAY_USER_ID", nil
+	case payment.PayeeTypeLoginID:
+		return "ALIPAY_LOGON_ID", nil
+	case payment.PayeeTypeOpenID:
+		return "ALIPAY_OPEN_ID", nil
+	default:
+		return "", apperrors.New(apperrors.ErrInvalidParam, fmt.Sprintf("unsupported alipay payee type: %s", payeeType))
+	}
+}
+
+// convertPayoutStatus 转换转账单据状态，退票（REFUND）表示资金已退回，按失败处理
+func (p *Provider) convertPayoutStatus(status string) string {
+	switch status {
+	case "SUCCESS":
+		return payment.PayoutStatusSuccess
+	case "FAIL", "REFUND":
+		return payment.PayoutStatusFailed
+	default:
+		return payment.PayoutStatusProcessing
+	}
+}
+
+// settleQueryRsp 交易分账查询（alipay.trade.order.settle.query）响应
+type settleQueryRsp struct {
+	alipay.Error
+	OutRequestNo      string `json:"out_request_no"`
+	SettleNo          string `json:"settle_no"`
+	RoyaltyDetailList []struct {
+		TransIn   string `json:"trans_in"`
+		Amount    string `json:"amount"`
+		State     string `json:"state"`
+		DetailID  string `json:"detail_id"`
+		ErrorCode string `json:"error_code"`
+		ErrorDesc string `json:"error_desc"`
+	} `json:"royalty_detail_list"`
+}
+
+// ShareProfit 交易结算分账（alipay.trade.order.settle），分账结果通过分账查询获取
+// 接收方需事先通过分账关系绑定接口与商户绑定，只支持支付宝会员ID
+func (p *Provider) ShareProfit(ctx context.Context, req *payment.ProfitSharingRequest) (*payment.ProfitSharingResponse, error) {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	var settle = alipay.TradeOrderSettle{}
+	settle.OutRequestNo = req.SharingNo
+	settle.TradeNo = req.TradeNo
+	for _, r := range req.Receivers {
+		if r.Type != "" && r.Type != payment.PayeeTypeUserID {
+			return nil, apperrors.New(apperrors.ErrInvalidParam, fmt.Sprintf("unsupported alipay receiver type: %s", r.Type))
+		}
+		settle.RoyaltyParameters = append(settle.RoyaltyParameters, &alipay.RoyaltyParameter{
+			TransIn: r.Account,
+			Amount:  r.Amount,
+			Desc:    r.Description,
+		})
+	}
+
+	rsp, err := client.TradeOrderSettle(settle)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrProfitSharing, "failed to settle alipay trade", err)
+	}
+
+	if rsp.IsFailure() {
+		return nil, apperrors.New(apperrors.ErrProfitSharing, fmt.Sprintf("%s: %s", rsp.Msg, rsp.SubMsg))
+	}
+
+	return &payment.ProfitSharingResponse{
+		SharingNo: req.SharingNo,
+		Status:    payment.ProfitSharingStatusProcessing,
+	}, nil
+}
+
+// QueryProfitSharing 交易分账查询，SDK 未提供该接口，使用通用请求调用
+func (p *Provider) QueryProfitSharing(ctx context.Context, req *payment.QueryProfitSharingRequest) (*payment.ProfitSharingResponse, error) {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := alipay.NewPayload("alipay.trade.order.settle.query")
+	payload.AddBizField("out_request_no", req.SharingNo)
+	payload.AddBizField("trade_no", req.TradeNo)
+
+	var rsp *settleQueryRsp
+	if err := client.Request(payload, &rsp); err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentQuery, "failed to query alipay settle", err)
+	}
+
+	if rsp.IsFailure() {
+		return nil, apperrors.New(apperrors.ErrPaymentQuery, rsp.Msg)
+	}
+
+	response := &payment.ProfitSharingResponse{
+		SharingNo: req.SharingNo,
+		SharingID: rsp.SettleNo,
+		Status:    payment.ProfitSharingStatusSuccess,
+	}
+	for _, d := range rsp.RoyaltyDetailList {
+		result := payment.ProfitSharingResult{
+			Account:    d.TransIn,
+			Amount:     parseAmount(d.Amount),
+			DetailID:   d.DetailID,
+			Status:     payment.ProfitSharingStatusProcessing,
+			FailReason: d.ErrorDesc,
+		}
+		switch d.State {
+		case "SUCCESS":
+			result.Status = payment.ProfitSharingStatusSuccess
+		case "FAIL":
+			result.Status = payment.ProfitSharingStatusFailed
+		}
+
+		// 有接收方处理中时整体处理中，全部完成后有失败则整体失败
+		if result.Status == payment.ProfitSharingStatusProcessing {
+			response.Status = payment.ProfitSharingStatusProcessing
+		} else if result.Status == payment.ProfitSharingStatusFailed && response.Status != payment.ProfitSharingStatusProcessing {
+			response.Status = payment.ProfitSharingStatusFailed
+		}
+		response.Receivers = append(response.Receivers, result)
+	}
+	if len(rsp.RoyaltyDetailList) == 0 {
+		response.Status = payment.ProfitSharingStatusProcessing
+	}
+
+	return response, nil
+}
+
 // RefundPayment 退款
 func (p *Provider) RefundPayment(ctx context.Context, req *payment.RefundRequest) (*payment.RefundResponse, error) {
 	client, err := p.getClient(req.Config)
@@ -145,10 +671,50 @@ func (p *Provider) RefundPayment(ctx context.Context, req *payment.RefundRequest
 		return nil, apperrors.New(apperrors.ErrPaymentRefund, rsp.Msg)
 	}
 
+	// 未发生资金变化时退款可能仍在处理中，需通过退款查询确认
+	status := payment.RefundStatusSuccess
+	if rsp.FundChange != "Y" {
+		status = payment.RefundStatusProcessing
+	}
+
 	return &payment.RefundResponse{
 		RefundNo: req.RefundNo,
 		TradeNo:  rsp.TradeNo,
-		Status:   "success",
+		Status:   status,
+	}, nil
+}
+
+// QueryRefund 查询退款，未返回 REFUND_SUCCESS 时表示退款未完成
+func (p *Provider) QueryRefund(ctx context.Context, req *payment.QueryRefundRequest) (*payment.QueryRefundResponse, error) {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp, err := client.TradeFastPayRefundQuery(alipay.TradeFastPayRefundQuery{
+		OutTradeNo:   req.OutTradeNo,
+		TradeNo:      req.TradeNo,
+		OutRequestNo: req.RefundNo,
+		QueryOptions: []string{"gmt_refund_pay"},
+	})
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentQuery, "failed to query alipay refund", err)
+	}
+
+	if rsp.IsFailure() {
+		return nil, apperrors.New(apperrors.ErrPaymentQuery, rsp.Msg)
+	}
+
+	status := payment.RefundStatusProcessing
+	if rsp.RefundStatus == "REFUND_SUCCESS" {
+		status = payment.RefundStatusSuccess
+	}
+
+	return &payment.QueryRefundResponse{
+		RefundNo:   rsp.OutRequestNo,
+		Amount:     parseAmount(rsp.RefundAmount),
+		Status:     status,
+		RefundTime: rsp.GMTRefundPay,
 	}, nil
 }
 
@@ -180,6 +746,354 @@ func (p *Provider) ClosePayment(ctx context.Context, req *payment.ClosePaymentRe
 	return nil
 }
 
+// DownloadBill 查询对账单下载地址，默认下载交易账单
+func (p *Provider) DownloadBill(ctx context.Context, req *payment.DownloadBillRequest) (*payment.DownloadBillResponse, error) {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	billType := req.BillType
+	if billType == "" {
+		billType = "trade"
+	}
+
+	rsp, err := client.BillDownloadURLQuery(alipay.BillDownloadURLQuery{
+		BillType: billType,
+		BillDate: req.BillDate,
+	})
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentBill, "failed to query alipay bill download url", err)
+	}
+
+	if rsp.IsFailure() {
+		return nil, apperrors.New(apperrors.ErrPaymentBill, rsp.Msg)
+	}
+
+	return &payment.DownloadBillResponse{
+		DownloadURL: rsp.BillDownloadURL,
+	}, nil
+}
+
+// 周期扣款、商户代扣签约使用的产品码
+const (
+	agreementProductCode           = "GENERAL_WITHHOLDING"
+	agreementPersonalProductCode   = "CYCLE_PAY_AUTH_P"
+	withholdingPersonalProductCode = "GENERAL_WITHHOLDING_P"
+	defaultAgreementSignScene      = "INDUSTRY|DIGITAL_MEDIA"
+)
+
+// CreateSubscription 创建周期扣款签约（alipay.user.agreement.page.sign），返回签约页面
+// 支付宝只在签约时登记扣款周期，每期扣款由本服务按账单日调用 ChargeSubscription 发起
+func (p *Provider) CreateSubscription(ctx context.Context, req *payment.SubscriptionRequest) (*payment.SubscriptionResponse, error) {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	// 支付宝周期只支持按天（DAY）和按月（MONTH）
+	periodType, period := "DAY", req.IntervalCount
+	switch req.Interval {
+	case payment.IntervalWeek:
+		period = req.IntervalCount * 7
+	case payment.IntervalMonth:
+		periodType = "MONTH"
+	case payment.IntervalYear:
+		periodType, period = "MONTH", req.IntervalCount*12
+	}
+
+	sign := alipay.AgreementPageSign{
+		ReturnURL:           req.ReturnURL,
+		NotifyURL:           req.NotifyURL,
+		ProductCode:         agreementProductCode,
+		PersonalProductCode: agreementPersonalProductCode,
+		SignScene:           p.signScene(req.Config),
+		ExternalAgreementNo: req.SubscriptionNo,
+		AccessParams:        &alipay.AccessParams{Channel: "ALIPAYAPP"},
+		PeriodRuleParams: &alipay.PeriodRuleParams{
+			PeriodType:   periodType,
+			Period:       fmt.Sprintf("%d", period),
+			ExecuteTime:  req.FirstChargeTime.Format("2006-01-02"),
+			SingleAmount: fmt.Sprintf("%.2f", req.Amount),
+		},
+	}
+
+	signURL, err := client.AgreementPageSign(sign)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrSubscription, "failed to create alipay agreement sign", err)
+	}
+
+	return &payment.SubscriptionResponse{
+		PaymentURL: signURL.String(),
+		Status:     payment.SubscriptionStatusPending,
+	}, nil
+}
+
+// QuerySubscription 查询签约协议（alipay.user.agreement.query）
+func (p *Provider) QuerySubscription(ctx context.Context, req *payment.QuerySubscriptionRequest) (*payment.SubscriptionResponse, error) {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp, err := client.AgreementQuery(alipay.AgreementQuery{
+		PersonalProductCode: agreementPersonalProductCode,
+		SignScene:           p.signScene(req.Config),
+		ExternalAgreementNo: req.SubscriptionNo,
+		AgreementNo:         req.SubscriptionID,
+	})
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrSubscription, "failed to query alipay agreement", err)
+	}
+
+	// 买家尚未签约时查询不到协议
+	if rsp.IsFailure() {
+		if req.SubscriptionID == "" {
+			return &payment.SubscriptionResponse{Status: payment.SubscriptionStatusPending}, nil
+		}
+		return nil, apperrors.New(apperrors.ErrSubscription, fmt.Sprintf("%s: %s", rsp.Msg, rsp.SubMsg))
+	}
+
+	return &payment.SubscriptionResponse{
+		SubscriptionID: rsp.AgreementNo,
+		Status:         p.convertAgreementStatus(rsp.Status),
+	}, nil
+}
+
+// CancelSubscription 解约（alipay.user.agreement.unsign）
+func (p *Provider) CancelSubscription(ctx context.Context, req *payment.CancelSubscriptionRequest) error {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return err
+	}
+
+	rsp, err := client.AgreementUnsign(alipay.AgreementUnsign{
+		PersonalProductCode: agreementPersonalProductCode,
+		SignScene:           p.signScene(req.Config),
+		ExternalAgreementNo: req.SubscriptionNo,
+		AgreementNo:         req.SubscriptionID,
+	})
+	if err != nil {
+		return apperrors.Wrap(apperrors.ErrSubscription, "failed to unsign alipay agreement", err)
+	}
+
+	if rsp.IsFailure() {
+		return apperrors.New(apperrors.ErrSubscription, fmt.Sprintf("%s: %s", rsp.Msg, rsp.SubMsg))
+	}
+	return nil
+}
+
+// ChargeSubscription 按签约协议扣款（alipay.trade.pay），10003 表示扣款处理中，结果通过交易通知或查询获取
+func (p *Provider) ChargeSubscription(ctx context.Context, req *payment.ChargeSubscriptionRequest) (*payment.ChargeSubscriptionResponse, error) {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp, err := p.agreementPay(client, req.NotifyURL, req.Subject, req.OutTradeNo, req.Amount, req.SubscriptionID)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrSubscription, "failed to charge alipay agreement", err)
+	}
+
+	return &payment.ChargeSubscriptionResponse{
+		TradeNo:    rsp.TradeNo,
+		Status:     rsp.Status,
+		FailReason: rsp.FailReason,
+	}, nil
+}
+
+// agreementPay 按代扣协议发起扣款，10003 表示扣款处理中
+func (p *Provider) agreementPay(client *alipay.Client, notifyURL, subject, outTradeNo string, amount float64, agreementNo string) (*payment.ChargePaymentMethodResponse, error) {
+	var pay = alipay.TradePay{}
+	pay.NotifyURL = notifyURL
+	pay.Subject = subject
+	pay.OutTradeNo = outTradeNo
+	pay.TotalAmount = fmt.Sprintf("%.2f", amount)
+	pay.ProductCode = agreementProductCode
+	pay.AgreementParams = &alipay.AgreementParams{AgreementNo: agreementNo}
+
+	rsp, err := client.TradePay(pay)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &payment.ChargePaymentMethodResponse{TradeNo: rsp.TradeNo}
+	switch rsp.Code {
+	case alipay.CodeSuccess:
+		response.Status = payment.StatusSuccess
+	case "10003":
+		response.Status = payment.StatusPending
+	default:
+		response.Status = payment.StatusFailed
+		response.FailReason = fmt.Sprintf("%s: %s", rsp.Msg, rsp.SubMsg)
+	}
+
+	return response, nil
+}
+
+// SetupPaymentMethod 创建商户代扣签约（alipay.user.agreement.page.sign），返回签约页面
+// 支付方式单号作为商户签约号，签约成功后协议号即第三方支付方式ID
+func (p *Provider) SetupPaymentMethod(ctx context.Context, req *payment.SetupPaymentMethodRequest) (*payment.SetupPaymentMethodResponse, error) {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	signURL, err := client.AgreementPageSign(alipay.AgreementPageSign{
+		ReturnURL:           req.ReturnURL,
+		NotifyURL:           req.NotifyURL,
+		ProductCode:         agreementProductCode,
+		PersonalProductCode: withholdingPersonalProductCode,
+		SignScene:           p.signScene(req.Config),
+		ExternalAgreementNo: req.MethodNo,
+		AccessParams:        &alipay.AccessParams{Channel: "ALIPAYAPP"},
+	})
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentMethod, "failed to create alipay agreement sign", err)
+	}
+
+	return &payment.SetupPaymentMethodResponse{
+		SetupURL: signURL.String(),
+		SetupID:  req.MethodNo,
+		Status:   payment.PaymentMethodStatusPending,
+	}, nil
+}
+
+// QueryPaymentMethod 查询代扣协议（alipay.user.agreement.query）
+func (p *Provider) QueryPaymentMethod(ctx context.Context, req *payment.QueryPaymentMethodRequest) (*payment.PaymentMethodInfo, error) {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp, err := client.AgreementQuery(alipay.AgreementQuery{
+		PersonalProductCode: withholdingPersonalProductCode,
+		SignScene:           p.signScene(req.Config),
+		ExternalAgreementNo: req.MethodNo,
+		AgreementNo:         req.MethodID,
+	})
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentMethod, "failed to query alipay agreement", err)
+	}
+
+	// 买家尚未签约时查询不到协议
+	if rsp.IsFailure() {
+		if req.MethodID == "" {
+			return &payment.PaymentMethodInfo{MethodNo: req.MethodNo, SetupID: req.SetupID, Status: payment.PaymentMethodStatusPending}, nil
+		}
+		return nil, apperrors.New(apperrors.ErrPaymentMethod, fmt.Sprintf("%s: %s", rsp.Msg, rsp.SubMsg))
+	}
+
+	return &payment.PaymentMethodInfo{
+		MethodNo: req.MethodNo,
+		SetupID:  req.SetupID,
+		MethodID: rsp.AgreementNo,
+		Status:   p.convertAgreementMethodStatus(rsp.Status),
+		Type:     payment.PaymentMethodTypeAlipay,
+		Account:  rsp.AlipayLogonId,
+	}, nil
+}
+
+// DeletePaymentMethod 解约代扣协议（alipay.user.agreement.unsign）
+func (p *Provider) DeletePaymentMethod(ctx context.Context, req *payment.DeletePaymentMethodRequest) error {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return err
+	}
+
+	rsp, err := client.AgreementUnsign(alipay.AgreementUnsign{
+		PersonalProductCode: withholdingPersonalProductCode,
+		SignScene:           p.signScene(req.Config),
+		ExternalAgreementNo: req.MethodNo,
+		AgreementNo:         req.MethodID,
+	})
+	if err != nil {
+		return apperrors.Wrap(apperrors.ErrPaymentMethod, "failed to unsign alipay agreement", err)
+	}
+
+	if rsp.IsFailure() {
+		return apperrors.New(apperrors.ErrPaymentMethod, fmt.Sprintf("%s: %s", rsp.Msg, rsp.SubMsg))
+	}
+	return nil
+}
+
+// ChargePaymentMethod 按代扣协议扣款（alipay.trade.pay），结果通过交易通知或查询获取
+func (p *Provider) ChargePaymentMethod(ctx context.Context, req *payment.ChargePaymentMethodRequest) (*payment.ChargePaymentMethodResponse, error) {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp, err := p.agreementPay(client, req.NotifyURL, req.Subject, req.OutTradeNo, req.Amount, req.MethodID)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentMethod, "failed to charge alipay agreement", err)
+	}
+	return rsp, nil
+}
+
+// handleAgreementNotify 处理签约、解约通知，按个人产品码区分周期扣款和商户代扣
+func (p *Provider) handleAgreementNotify(client *alipay.Client, req *payment.NotifyRequest) (*payment.NotifyResponse, error) {
+	if err := client.VerifySign(url.Values(req.FormData)); err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to verify alipay agreement notification", err)
+	}
+
+	if getFirstValue(req.FormData, "personal_product_code") == withholdingPersonalProductCode {
+		return &payment.NotifyResponse{
+			Event: payment.EventPaymentMethod,
+			PaymentMethod: &payment.PaymentMethodInfo{
+				MethodNo: getFirstValue(req.FormData, "external_agreement_no"),
+				MethodID: getFirstValue(req.FormData, "agreement_no"),
+				Status:   p.convertAgreementMethodStatus(getFirstValue(req.FormData, "status")),
+				Type:     payment.PaymentMethodTypeAlipay,
+				Account:  getFirstValue(req.FormData, "alipay_logon_id"),
+			},
+			ReturnData: []byte("success"),
+		}, nil
+	}
+
+	return &payment.NotifyResponse{
+		Event: payment.EventSubscription,
+		Subscription: &payment.SubscriptionInfo{
+			SubscriptionNo: getFirstValue(req.FormData, "external_agreement_no"),
+			SubscriptionID: getFirstValue(req.FormData, "agreement_no"),
+			Status:         p.convertAgreementStatus(getFirstValue(req.FormData, "status")),
+		},
+		ReturnData: []byte("success"),
+	}, nil
+}
+
+// signScene 签约场景，与支付宝签约周期扣款产品时确定，可在支付配置中通过 sign_scene 指定
+func (p *Provider) signScene(config map[string]interface{}) string {
+	if scene, ok := config["sign_scene"].(string); ok && scene != "" {
+		return scene
+	}
+	return defaultAgreementSignScene
+}
+
+// convertAgreementStatus 转换签约协议状态，暂存（TEMP）表示买家尚未完成签约
+func (p *Provider) convertAgreementStatus(status string) string {
+	switch status {
+	case "NORMAL":
+		return payment.SubscriptionStatusActive
+	case "STOP", "UNSIGN":
+		return payment.SubscriptionStatusCanceled
+	default:
+		return payment.SubscriptionStatusPending
+	}
+}
+
+// convertAgreementMethodStatus 转换代扣协议状态为支付方式状态
+func (p *Provider) convertAgreementMethodStatus(status string) string {
+	switch status {
+	case "NORMAL":
+		return payment.PaymentMethodStatusActive
+	case "STOP", "UNSIGN":
+		return payment.PaymentMethodStatusCanceled
+	default:
+		return payment.PaymentMethodStatusPending
+	}
+}
+
 // getClient 获取支付宝客户端
 func (p *Provider) getClient(config map[string]interface{}) (*alipay.Client, error) {
 	appID, ok := config["app_id"].(string)
@@ -263,6 +1177,14 @@ func parseAmount(amount string) float64 {
 	return result
 }
 
+// getFirstValue 从表单数据中获取第一个值
+func getFirstValue(formData map[string][]string, key string) string {
+	if values, ok := formData[key]; ok && len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
 // init 注册支付提供商
 func init() {
 	payment.Register(NewProvider())
@@ -0,0 +1,354 @@
+package mock
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zqdfound/go-uni-pay/internal/infrastructure/cache"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+)
+
+// 脚本化的支付结果
+const (
+	outcomeSuccess = "success" // 支付成功
+	outcomeFail    = "fail"    // 支付失败
+	outcomePending = "pending" // 一直处理中，不发送通知
+)
+
+// stateTTL 模拟交易状态的保存时间
+const stateTTL = 24 * time.Hour
+
+// Provider 沙箱支付提供商，用于本地开发和端到端测试
+// 不对接任何第三方，支付结果由收银台模拟页面的操作和脚本规则决定
+// 不在 init 中注册，由服务在非 release 模式下注册
+type Provider struct {
+	httpClient *http.Client
+}
+
+// NewProvider 创建沙箱提供商
+func NewProvider() *Provider {
+	return &Provider{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// GetName 获取提供商名称
+func (p *Provider) GetName() string {
+	return payment.ProviderMock
+}
+
+// CreatePayment 创建支付，返回收银台模拟页面地址
+// 支付结果由 extra_params.mock_outcome 决定，未指定时按金额的分位：.01 失败，.02 处理中，其他成功
+func (p *Provider) CreatePayment(ctx context.Context, req *payment.CreatePaymentRequest) (*payment.CreatePaymentResponse, error) {
+	if _, err := getSecret(req.Config); err != nil {
+		return nil, err
+	}
+
+	// 收银台模拟页面由本服务提供
+	if req.BaseURL == "" {
+		return nil, apperrors.New(apperrors.ErrNotSupported, "base url is required for mock checkout")
+	}
+
+	outcome := scriptedOutcome(req.Amount, req.ExtraParams)
+	tradeNo := newTradeNo(outcome, req.OutTradeNo)
+
+	return &payment.CreatePaymentResponse{
+		PaymentURL: fmt.Sprintf("%s/api/v1/public/mock/checkout/%s", strings.TrimRight(req.BaseURL, "/"), url.PathEscape(req.OrderNo)),
+		PaymentID:  tradeNo,
+		TradeNo:    tradeNo,
+		ExtraData: map[string]interface{}{
+			"mock_outcome": outcome,
+		},
+	}, nil
+}
+
+// Simulate 模拟买家操作，记录交易状态并向本服务发送签名通知
+func (p *Provider) Simulate(ctx context.Context, req *payment.SimulateRequest) error {
+	secret, err := getSecret(req.Config)
+	if err != nil {
+		return err
+	}
+
+	var status string
+	switch req.Action {
+	case payment.SimulatePay:
+		switch parseOutcome(req.TradeNo) {
+		case outcomeFail:
+			status = payment.StatusFailed
+		case outcomePending:
+			// 处理中的交易不发送通知
+			return nil
+		default:
+			status = payment.StatusSuccess
+		}
+	case payment.SimulateFail:
+		status = payment.StatusFailed
+	case payment.SimulateCancel:
+		status = payment.StatusClosed
+	default:
+		return apperrors.New(apperrors.ErrInvalidParam, fmt.Sprintf("unknown mock action %s", req.Action))
+	}
+
+	if err := setState(ctx, req.TradeNo, status); err != nil {
+		return apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to save mock trade state", err)
+	}
+
+	values := url.Values{}
+	values.Set("out_trade_no", req.OutTradeNo)
+	values.Set("trade_no", req.TradeNo)
+	values.Set("amount", fmt.Sprintf("%.2f", req.Amount))
+	values.Set("currency", req.Currency)
+	values.Set("status", status)
+	values.Set("notify_time", time.Now().Format(time.RFC3339))
+	values.Set("sign", sign(values, secret))
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.NotifyURL, strings.NewReader(values.Encode()))
+	if err != nil {
+		return apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to build mock notification", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to send mock notification", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "success" {
+		return apperrors.New(apperrors.ErrPaymentNotify, fmt.Sprintf("mock notification rejected: status=%d body=%s", resp.StatusCode, body))
+	}
+
+	return nil
+}
+
+// HandleNotify 处理支付通知，校验 HMAC-SHA256 签名
+func (p *Provider) HandleNotify(ctx context.Context, req *payment.NotifyRequest) (*payment.NotifyResponse, error) {
+	secret, err := getSecret(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := url.ParseQuery(string(req.RawData))
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to parse mock notification", err)
+	}
+
+	if !hmac.Equal([]byte(values.Get("sign")), []byte(sign(values, secret))) {
+		return nil, apperrors.New(apperrors.ErrPaymentNotify, "invalid mock notification signature")
+	}
+
+	return &payment.NotifyResponse{
+		TradeNo:     values.Get("trade_no"),
+		OutTradeNo:  values.Get("out_trade_no"),
+		Status:      values.Get("status"),
+		Amount:      parseAmount(values.Get("amount")),
+		PaymentTime: values.Get("notify_time"),
+		ReturnData:  []byte("success"),
+	}, nil
+}
+
+// QueryPayment 查询支付，返回模拟页面操作后的状态，未操作时为处理中
+func (p *Provider) QueryPayment(ctx context.Context, req *payment.QueryPaymentRequest) (*payment.QueryPaymentResponse, error) {
+	status, err := getState(ctx, req.TradeNo)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentQuery, "failed to query mock trade state", err)
+	}
+
+	return &payment.QueryPaymentResponse{
+		TradeNo:    req.TradeNo,
+		OutTradeNo: req.OutTradeNo,
+		Status:     status,
+	}, nil
+}
+
+// RefundPayment 退款，仅已支付的交易可退款
+// 按退款金额的分位：.03 退款被拒绝，.04 退款处理中，其他退款成功
+func (p *Provider) RefundPayment(ctx context.Context, req *payment.RefundRequest) (*payment.RefundResponse, error) {
+	status, err := getState(ctx, req.TradeNo)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentRefund, "failed to query mock trade state", err)
+	}
+
+	if status != payment.StatusSuccess {
+		return nil, apperrors.New(apperrors.ErrPaymentRefund, "mock trade is not paid")
+	}
+
+	if req.TotalAmount > 0 && req.RefundAmount > req.TotalAmount {
+		return nil, apperrors.New(apperrors.ErrPaymentRefund, "refund amount exceeds total amount")
+	}
+
+	refundStatus := payment.RefundStatusSuccess
+	switch toCents(req.RefundAmount) % 100 {
+	case 3:
+		return nil, apperrors.New(apperrors.ErrPaymentRefund, "mock refund rejected")
+	case 4:
+		refundStatus = payment.RefundStatusProcessing
+	}
+
+	if err := setState(ctx, refundKey(req.RefundNo), refundStatus); err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentRefund, "failed to save mock refund state", err)
+	}
+
+	return &payment.RefundResponse{
+		RefundNo: req.RefundNo,
+		TradeNo:  req.TradeNo,
+		Status:   refundStatus,
+	}, nil
+}
+
+// QueryRefund 查询退款，处理中的退款在首次查询时完成，用于模拟退款状态同步
+func (p *Provider) QueryRefund(ctx context.Context, req *payment.QueryRefundRequest) (*payment.QueryRefundResponse, error) {
+	exists, err := cache.Exists(ctx, stateKey(refundKey(req.RefundNo)))
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentQuery, "failed to query mock refund state", err)
+	}
+	if exists == 0 {
+		return nil, apperrors.New(apperrors.ErrPaymentQuery, "mock refund not found")
+	}
+
+	if err := setState(ctx, refundKey(req.RefundNo), payment.RefundStatusSuccess); err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentQuery, "failed to save mock refund state", err)
+	}
+
+	return &payment.QueryRefundResponse{
+		RefundNo: req.RefundNo,
+		Status:   payment.RefundStatusSuccess,
+	}, nil
+}
+
+// ClosePayment 关闭支付，已支付的交易不能关闭
+func (p *Provider) ClosePayment(ctx context.Context, req *payment.ClosePaymentRequest) error {
+	status, err := getState(ctx, req.TradeNo)
+	if err != nil {
+		return apperrors.Wrap(apperrors.ErrPaymentCancel, "failed to query mock trade state", err)
+	}
+
+	if status == payment.StatusSuccess {
+		return apperrors.New(apperrors.ErrPaymentCancel, "mock trade is already paid")
+	}
+
+	if err := setState(ctx, req.TradeNo, payment.StatusClosed); err != nil {
+		return apperrors.Wrap(apperrors.ErrPaymentCancel, "failed to save mock trade state", err)
+	}
+
+	return nil
+}
+
+// scriptedOutcome 获取脚本化的支付结果
+func scriptedOutcome(amount float64, params map[string]interface{}) string {
+	if outcome, ok := params["mock_outcome"].(string); ok {
+		switch outcome {
+		case outcomeSuccess, outcomeFail, outcomePending:
+			return outcome
+		}
+	}
+
+	switch toCents(amount) % 100 {
+	case 1:
+		return outcomeFail
+	case 2:
+		return outcomePending
+	default:
+		return outcomeSuccess
+	}
+}
+
+// newTradeNo 生成交易号，交易号中包含脚本化的支付结果
+func newTradeNo(outcome, outTradeNo string) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s:%d", outTradeNo, time.Now().UnixNano())))
+	return fmt.Sprintf("MOCK_%s_%s", strings.ToUpper(outcome), hex.EncodeToString(hash[:])[:16])
+}
+
+// parseOutcome 从交易号中解析脚本化的支付结果
+func parseOutcome(tradeNo string) string {
+	parts := strings.Split(tradeNo, "_")
+	if len(parts) == 3 && parts[0] == "MOCK" {
+		return strings.ToLower(parts[1])
+	}
+	return outcomeSuccess
+}
+
+// getState 获取模拟交易状态，未操作时为处理中
+func getState(ctx context.Context, tradeNo string) (string, error) {
+	if tradeNo == "" {
+		return payment.StatusPending, nil
+	}
+
+	exists, err := cache.Exists(ctx, stateKey(tradeNo))
+	if err != nil {
+		return "", err
+	}
+	if exists == 0 {
+		return payment.StatusPending, nil
+	}
+
+	return cache.Get(ctx, stateKey(tradeNo))
+}
+
+// setState 保存模拟交易状态
+func setState(ctx context.Context, tradeNo, status string) error {
+	return cache.Set(ctx, stateKey(tradeNo), status, stateTTL)
+}
+
+// stateKey 模拟交易状态的缓存key
+func stateKey(tradeNo string) string {
+	return fmt.Sprintf("payment:mock:%s", tradeNo)
+}
+
+// refundKey 模拟退款状态的key
+func refundKey(refundNo string) string {
+	return "refund:" + refundNo
+}
+
+// sign 计算签名：除 sign 外的参数按key排序拼接为 k=v&k=v，使用 HMAC-SHA256 计算十六进制摘要
+func sign(values url.Values, secret string) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if key != "sign" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+values.Get(key))
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.Join(pairs, "&")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// getSecret 获取签名密钥
+func getSecret(config map[string]interface{}) (string, error) {
+	secret, ok := config["secret"].(string)
+	if !ok || secret == "" {
+		return "", apperrors.New(apperrors.ErrConfigNotFound, "secret not found in config")
+	}
+	return secret, nil
+}
+
+// toCents 将金额转换为分
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// parseAmount 解析金额
+func parseAmount(amount string) float64 {
+	var result float64
+	fmt.Sscanf(amount, "%f", &result)
+	return result
+}
@@ -0,0 +1,187 @@
+package mock
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zqdfound/go-uni-pay/internal/infrastructure/cache"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+)
+
+var testConfig = map[string]interface{}{"secret": "test-secret"}
+
+// setupCache 使用 miniredis 保存模拟交易状态
+func setupCache(t *testing.T) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	cache.Client = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { cache.Client.Close() })
+}
+
+// createPayment 创建指定金额的模拟支付
+func createPayment(t *testing.T, amount float64, extra map[string]interface{}) *payment.CreatePaymentResponse {
+	t.Helper()
+
+	resp, err := NewProvider().CreatePayment(context.Background(), &payment.CreatePaymentRequest{
+		OrderNo:     "PAY1",
+		OutTradeNo:  "ORDER1",
+		Amount:      amount,
+		BaseURL:     "https://pay.example.com/",
+		Config:      testConfig,
+		ExtraParams: extra,
+	})
+	require.NoError(t, err)
+	return resp
+}
+
+// TestCreatePayment_Outcome 测试收银台地址使用本服务地址，支付结果按金额分位或 mock_outcome 决定
+func TestCreatePayment_Outcome(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount float64
+		extra  map[string]interface{}
+		want   string
+	}{
+		{".01 fails", 10.01, nil, outcomeFail},
+		{".02 pending", 10.02, nil, outcomePending},
+		{"other succeeds", 10.00, nil, outcomeSuccess},
+		{".03 succeeds", 0.03, nil, outcomeSuccess},
+		{"outcome param", 10.00, map[string]interface{}{"mock_outcome": "fail"}, outcomeFail},
+		{"unknown outcome param", 10.01, map[string]interface{}{"mock_outcome": "refund"}, outcomeFail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := createPayment(t, tt.amount, tt.extra)
+			assert.Equal(t, "https://pay.example.com/api/v1/public/mock/checkout/PAY1", resp.PaymentURL)
+			assert.Equal(t, tt.want, resp.ExtraData["mock_outcome"])
+			assert.Equal(t, tt.want, parseOutcome(resp.TradeNo))
+		})
+	}
+
+	_, err := NewProvider().CreatePayment(context.Background(), &payment.CreatePaymentRequest{OrderNo: "PAY1", Amount: 10, Config: testConfig})
+	require.Error(t, err)
+	assert.Equal(t, apperrors.ErrNotSupported, err.(*apperrors.AppError).Code)
+}
+
+// TestSimulatePay 测试模拟支付按脚本结果发送签名通知，处理中的交易不发送通知
+func TestSimulatePay(t *testing.T) {
+	setupCache(t)
+
+	var notifies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		notifies = append(notifies, string(body))
+		w.Write([]byte("success"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		amount float64
+		want   string
+	}{
+		{"success", 10.00, payment.StatusSuccess},
+		{"fail", 10.01, payment.StatusFailed},
+		{"pending", 10.02, payment.StatusPending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifies = nil
+			ctx := context.Background()
+			created := createPayment(t, tt.amount, nil)
+
+			p := NewProvider()
+			err := p.Simulate(ctx, &payment.SimulateRequest{
+				OutTradeNo: "ORDER1",
+				TradeNo:    created.TradeNo,
+				Amount:     tt.amount,
+				Currency:   "CNY",
+				Action:     payment.SimulatePay,
+				NotifyURL:  server.URL,
+				Config:     testConfig,
+			})
+			require.NoError(t, err)
+
+			query, err := p.QueryPayment(ctx, &payment.QueryPaymentRequest{TradeNo: created.TradeNo, OutTradeNo: "ORDER1"})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, query.Status)
+
+			if tt.want == payment.StatusPending {
+				assert.Empty(t, notifies)
+				return
+			}
+			require.Len(t, notifies, 1)
+			notify, err := p.HandleNotify(ctx, &payment.NotifyRequest{RawData: []byte(notifies[0]), Config: testConfig})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, notify.Status)
+			assert.Equal(t, "ORDER1", notify.OutTradeNo)
+			assert.Equal(t, tt.amount, notify.Amount)
+
+			// 签名密钥不一致的通知被拒绝
+			_, err = p.HandleNotify(ctx, &payment.NotifyRequest{RawData: []byte(notifies[0]), Config: map[string]interface{}{"secret": "other"}})
+			assert.Error(t, err)
+		})
+	}
+}
+
+// TestRefundPayment_Outcome 测试已支付的交易按退款金额分位决定退款结果：.03 拒绝，.04 处理中并在查询时完成
+func TestRefundPayment_Outcome(t *testing.T) {
+	setupCache(t)
+	ctx := context.Background()
+	p := NewProvider()
+
+	// 未支付的交易不能退款
+	created := createPayment(t, 10, nil)
+	_, err := p.RefundPayment(ctx, &payment.RefundRequest{TradeNo: created.TradeNo, RefundNo: "R0", RefundAmount: 1, TotalAmount: 10})
+	require.Error(t, err)
+	require.NoError(t, setState(ctx, created.TradeNo, payment.StatusSuccess))
+
+	tests := []struct {
+		name     string
+		refundNo string
+		amount   float64
+		want     string
+	}{
+		{"success", "R1", 1.00, payment.RefundStatusSuccess},
+		{"rejected", "R2", 1.03, ""},
+		{"processing", "R3", 1.04, payment.RefundStatusProcessing},
+		{"exceeds total", "R4", 10.05, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := p.RefundPayment(ctx, &payment.RefundRequest{
+				TradeNo:      created.TradeNo,
+				RefundNo:     tt.refundNo,
+				RefundAmount: tt.amount,
+				TotalAmount:  10,
+			})
+			if tt.want == "" {
+				require.Error(t, err)
+				assert.Equal(t, apperrors.ErrPaymentRefund, err.(*apperrors.AppError).Code)
+				_, err = p.QueryRefund(ctx, &payment.QueryRefundRequest{RefundNo: tt.refundNo})
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, resp.Status)
+
+			query, err := p.QueryRefund(ctx, &payment.QueryRefundRequest{RefundNo: tt.refundNo})
+			require.NoError(t, err)
+			assert.Equal(t, payment.RefundStatusSuccess, query.Status)
+		})
+	}
+
+	// 已支付的交易不能关闭
+	err = p.ClosePayment(ctx, &payment.ClosePaymentRequest{TradeNo: created.TradeNo})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already paid")
+}
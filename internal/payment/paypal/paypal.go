@@ -4,12 +4,20 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/plutov/paypal/v4"
 	"github.com/zqdfound/go-uni-pay/internal/payment"
 	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
 )
 
+// approveLinkValidity 买家批准链接的有效期为3小时，过期后需重新创建订单
+const approveLinkValidity = 3 * time.Hour
+
 // Provider PayPal支付提供商
 type Provider struct{}
 
@@ -25,22 +33,54 @@ func (p *Provider) GetName() string {
 
 // CreatePayment 创建支付
 func (p *Provider) CreatePayment(ctx context.Context, req *payment.CreatePaymentRequest) (*payment.CreatePaymentResponse, error) {
+	return p.createOrder(ctx, req, paypal.OrderIntentCapture)
+}
+
+// Authorize 创建 AUTHORIZE 订单，买家批准后授权资金，由商户后续扣款或撤销
+func (p *Provider) Authorize(ctx context.Context, req *payment.CreatePaymentRequest) (*payment.CreatePaymentResponse, error) {
+	return p.createOrder(ctx, req, paypal.OrderIntentAuthorize)
+}
+
+// createOrder 创建PayPal订单
+func (p *Provider) createOrder(ctx context.Context, req *payment.CreatePaymentRequest, intent string) (*payment.CreatePaymentResponse, error) {
 	client, err := p.getClient(req.Config)
 	if err != nil {
 		return nil, err
 	}
 
-	// 创建订单
-	order, err := client.CreateOrder(ctx, paypal.OrderIntentCapture, []paypal.PurchaseUnitRequest{
-		{
-			ReferenceID: req.OutTradeNo,
-			Amount: &paypal.PurchaseUnitAmount{
-				Currency: req.Currency,
-				Value:    fmt.Sprintf("%.2f", req.Amount),
-			},
-			Description: req.Subject,
+	purchaseUnit := paypal.PurchaseUnitRequest{
+		ReferenceID: req.OutTradeNo,
+		Amount: &paypal.PurchaseUnitAmount{
+			Currency: req.Currency,
+			Value:    fmt.Sprintf("%.2f", req.Amount),
 		},
-	}, nil, &paypal.ApplicationContext{
+		Description: req.Subject,
+		CustomID:    req.OutTradeNo,
+	}
+	if len(req.Items) > 0 {
+		purchaseUnit.Items, purchaseUnit.Amount.Breakdown = purchaseItems(req)
+	}
+	if req.Shipping != nil {
+		purchaseUnit.Shipping = &paypal.ShippingDetail{
+			Name: &paypal.Name{FullName: req.Shipping.Name},
+			Address: &paypal.ShippingDetailAddressPortable{
+				AddressLine1: req.Shipping.Line1,
+				AddressLine2: req.Shipping.Line2,
+				AdminArea1:   req.Shipping.State,
+				AdminArea2:   req.Shipping.City,
+				PostalCode:   req.Shipping.PostalCode,
+				CountryCode:  req.Shipping.Country,
+			},
+		}
+	}
+
+	var payer *paypal.CreateOrderPayer
+	if req.Buyer != nil && req.Buyer.Email != "" {
+		payer = &paypal.CreateOrderPayer{EmailAddress: req.Buyer.Email}
+	}
+
+	// 创建订单
+	order, err := client.CreateOrder(ctx, intent, []paypal.PurchaseUnitRequest{purchaseUnit}, payer, &paypal.ApplicationContext{
 		ReturnURL: req.ReturnURL,
 		CancelURL: req.ReturnURL,
 	})
@@ -58,13 +98,51 @@ func (p *Provider) CreatePayment(ctx context.Context, req *payment.CreatePayment
 		}
 	}
 
+	expireTime := time.Now().Add(approveLinkValidity)
 	return &payment.CreatePaymentResponse{
 		PaymentURL: approveURL,
 		PaymentID:  order.ID,
 		TradeNo:    order.ID,
+		ExpireTime: &expireTime,
 	}, nil
 }
 
+// purchaseItems 转换商品明细，订单金额明细中的商品总额、税额和运费之和需等于订单金额
+func purchaseItems(req *payment.CreatePaymentRequest) ([]paypal.Item, *paypal.PurchaseUnitAmountBreakdown) {
+	money := func(cents int64) *paypal.Money {
+		return &paypal.Money{Currency: req.Currency, Value: fmt.Sprintf("%.2f", float64(cents)/100)}
+	}
+
+	items := make([]paypal.Item, 0, len(req.Items))
+	var itemTotal, taxTotal int64
+	for _, item := range req.Items {
+		unitAmount := int64(math.Round(item.UnitPrice * 100))
+		tax := int64(math.Round(item.Tax * 100))
+		itemTotal += unitAmount * item.Quantity
+		taxTotal += tax * item.Quantity
+
+		paypalItem := paypal.Item{
+			Name:       item.Name,
+			UnitAmount: money(unitAmount),
+			Quantity:   strconv.FormatInt(item.Quantity, 10),
+			SKU:        item.SKU,
+		}
+		if tax > 0 {
+			paypalItem.Tax = money(tax)
+		}
+		items = append(items, paypalItem)
+	}
+
+	breakdown := &paypal.PurchaseUnitAmountBreakdown{ItemTotal: money(itemTotal)}
+	if taxTotal > 0 {
+		breakdown.TaxTotal = money(taxTotal)
+	}
+	if req.Shipping != nil && req.Shipping.Fee > 0 {
+		breakdown.Shipping = money(int64(math.Round(req.Shipping.Fee * 100)))
+	}
+	return items, breakdown
+}
+
 // QueryPayment 查询支付
 func (p *Provider) QueryPayment(ctx context.Context, req *payment.QueryPaymentRequest) (*payment.QueryPaymentResponse, error) {
 	client, err := p.getClient(req.Config)
@@ -80,18 +158,206 @@ func (p *Provider) QueryPayment(ctx context.Context, req *payment.QueryPaymentRe
 	status := p.convertStatus(order.Status)
 
 	var amount float64
+	var outTradeNo string
 	if len(order.PurchaseUnits) > 0 {
-		fmt.Sscanf(order.PurchaseUnits[0].Amount.Value, "%f", &amount)
+		unit := order.PurchaseUnits[0]
+		outTradeNo = unit.ReferenceID
+		if unit.Amount != nil {
+			fmt.Sscanf(unit.Amount.Value, "%f", &amount)
+		}
+
+		// 订单完成后以扣款状态为准，AUTHORIZE 订单未扣款时为已授权
+		if order.Status == "COMPLETED" && unit.Payments != nil && len(unit.Payments.Captures) > 0 {
+			status = p.convertCaptureStatus(unit.Payments.Captures[0].Status)
+		} else if order.Status == "COMPLETED" && order.Intent == paypal.OrderIntentAuthorize {
+			status = payment.StatusAuthorized
+		}
 	}
 
 	return &payment.QueryPaymentResponse{
 		TradeNo:    order.ID,
-		OutTradeNo: order.PurchaseUnits[0].ReferenceID,
+		OutTradeNo: outTradeNo,
 		Status:     status,
 		Amount:     amount,
 	}, nil
 }
 
+// CapturePayment 买家批准订单后发起扣款，AUTHORIZE 订单仅完成授权
+func (p *Provider) CapturePayment(ctx context.Context, req *payment.CapturePaymentRequest) (*payment.CapturePaymentResponse, error) {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.AuthorizeOnly {
+		return p.authorizeOrder(ctx, client, req.TradeNo)
+	}
+
+	order, err := client.CaptureOrder(ctx, req.TradeNo, paypal.CaptureOrderRequest{})
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentCapture, "failed to capture paypal order", err)
+	}
+
+	response := &payment.CapturePaymentResponse{
+		TradeNo: order.ID,
+		Status:  p.convertStatus(order.Status),
+	}
+
+	// 获取扣款ID，退款时需要使用
+	if len(order.PurchaseUnits) > 0 && order.PurchaseUnits[0].Payments != nil && len(order.PurchaseUnits[0].Payments.Captures) > 0 {
+		capture := order.PurchaseUnits[0].Payments.Captures[0]
+		response.CaptureID = capture.ID
+		response.Status = p.convertCaptureStatus(capture.Status)
+		if capture.Amount != nil {
+			fmt.Sscanf(capture.Amount.Value, "%f", &response.Amount)
+		}
+	}
+
+	if response.CaptureID == "" {
+		return nil, apperrors.New(apperrors.ErrPaymentCapture, "paypal capture id not found in response")
+	}
+
+	return response, nil
+}
+
+// authorizeOrderResponse 订单授权响应
+// SDK 的 AuthorizeOrderResponse 不包含 payments.authorizations，这里自行解析
+type authorizeOrderResponse struct {
+	ID            string `json:"id"`
+	Status        string `json:"status"`
+	PurchaseUnits []struct {
+		Payments struct {
+			Authorizations []paypal.Authorization `json:"authorizations"`
+		} `json:"payments"`
+	} `json:"purchase_units"`
+}
+
+// authorizeOrder 对买家已批准的 AUTHORIZE 订单完成授权
+func (p *Provider) authorizeOrder(ctx context.Context, client *paypal.Client, orderID string) (*payment.CapturePaymentResponse, error) {
+	httpReq, err := client.NewRequest(ctx, "POST", fmt.Sprintf("%s/v2/checkout/orders/%s/authorize", client.APIBase, orderID), paypal.AuthorizeOrderRequest{})
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentCapture, "failed to build paypal authorize request", err)
+	}
+
+	order := &authorizeOrderResponse{}
+	if err := client.SendWithAuth(httpReq, order); err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentCapture, "failed to authorize paypal order", err)
+	}
+
+	response := &payment.CapturePaymentResponse{
+		TradeNo: order.ID,
+	}
+
+	// 获取授权ID，扣款和撤销时需要使用
+	if len(order.PurchaseUnits) > 0 && len(order.PurchaseUnits[0].Payments.Authorizations) > 0 {
+		authorization := order.PurchaseUnits[0].Payments.Authorizations[0]
+		response.AuthorizationID = authorization.ID
+		response.Status = p.convertAuthorizationStatus(authorization.Status)
+		if authorization.Amount != nil {
+			fmt.Sscanf(authorization.Amount.Value, "%f", &response.Amount)
+		}
+	}
+
+	if response.AuthorizationID == "" {
+		return nil, apperrors.New(apperrors.ErrPaymentCapture, "paypal authorization id not found in response")
+	}
+
+	return response, nil
+}
+
+// Capture 对已授权资金发起扣款，金额为0时全额扣款
+func (p *Provider) Capture(ctx context.Context, req *payment.CaptureRequest) (*payment.CaptureResponse, error) {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	captureReq := &paypal.PaymentCaptureRequest{
+		InvoiceID:    req.OutTradeNo,
+		FinalCapture: true,
+	}
+	if req.Amount > 0 {
+		currency := req.Currency
+		if currency == "" {
+			currency = "USD"
+		}
+		captureReq.Amount = &paypal.Money{
+			Currency: currency,
+			Value:    fmt.Sprintf("%.2f", req.Amount),
+		}
+	}
+
+	capture, err := client.CaptureAuthorization(ctx, req.AuthorizationID, captureReq)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentCapture, "failed to capture paypal authorization", err)
+	}
+
+	response := &payment.CaptureResponse{
+		TradeNo:   req.TradeNo,
+		CaptureID: capture.ID,
+		Status:    p.convertCaptureStatus(capture.Status),
+		Amount:    req.Amount,
+	}
+	if capture.Amount != nil {
+		fmt.Sscanf(capture.Amount.Value, "%f", &response.Amount)
+	}
+
+	return response, nil
+}
+
+// Void 撤销授权
+func (p *Provider) Void(ctx context.Context, req *payment.VoidRequest) error {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return err
+	}
+
+	if _, err := client.VoidAuthorization(ctx, req.AuthorizationID); err != nil {
+		return apperrors.Wrap(apperrors.ErrPaymentVoid, "failed to void paypal authorization", err)
+	}
+
+	return nil
+}
+
+// AccountID 返回配置中的 PayPal 商户ID，未配置 webhook_id 时不能校验通知签名，返回空
+func (p *Provider) AccountID(config map[string]interface{}) string {
+	if webhookID, _ := config["webhook_id"].(string); webhookID == "" {
+		return ""
+	}
+	merchantID, _ := config["merchant_id"].(string)
+	return merchantID
+}
+
+// NotifyAccountID 读取事件资源中的收款商户ID
+// 扣款事件在 resource.payee 中，订单事件在 resource.purchase_units[].payee 中
+func (p *Provider) NotifyAccountID(req *payment.NotifyRequest) string {
+	var event struct {
+		Resource struct {
+			Payee struct {
+				MerchantID string `json:"merchant_id"`
+			} `json:"payee"`
+			PurchaseUnits []struct {
+				Payee struct {
+					MerchantID string `json:"merchant_id"`
+				} `json:"payee"`
+			} `json:"purchase_units"`
+		} `json:"resource"`
+	}
+	if err := json.Unmarshal(req.RawData, &event); err != nil {
+		return ""
+	}
+
+	if event.Resource.Payee.MerchantID != "" {
+		return event.Resource.Payee.MerchantID
+	}
+	for _, unit := range event.Resource.PurchaseUnits {
+		if unit.Payee.MerchantID != "" {
+			return unit.Payee.MerchantID
+		}
+	}
+	return ""
+}
+
 // HandleNotify 处理支付通知
 func (p *Provider) HandleNotify(ctx context.Context, req *payment.NotifyRequest) (*payment.NotifyResponse, error) {
 	client, err := p.getClient(req.Config)
@@ -129,25 +395,10 @@ func (p *Provider) HandleNotify(ctx context.Context, req *payment.NotifyRequest)
 	// 处理不同的事件类型
 	switch eventType {
 	case "PAYMENT.CAPTURE.COMPLETED":
-		// 支付成功
+		// 扣款完成，支付成功
 		response.Status = payment.StatusSuccess
-
-		// 获取订单ID
-		var orderID string
-		if supplementaryData, ok := resource["supplementary_data"].(map[string]interface{}); ok {
-			if relatedIDs, ok := supplementaryData["related_ids"].(map[string]interface{}); ok {
-				orderID = getStringValue(relatedIDs, "order_id")
-				response.TradeNo = orderID
-			}
-		}
-
-		// 查询订单以获取商户订单号
-		if orderID != "" {
-			order, err := client.GetOrder(ctx, orderID)
-			if err == nil && len(order.PurchaseUnits) > 0 {
-				response.OutTradeNo = order.PurchaseUnits[0].ReferenceID
-			}
-		}
+		response.CaptureID = getStringValue(resource, "id")
+		response.TradeNo, response.OutTradeNo = p.resolveCaptureOrder(ctx, client, resource)
 
 		// 获取金额
 		if amount, ok := resource["amount"].(map[string]interface{}); ok {
@@ -166,14 +417,38 @@ func (p *Provider) HandleNotify(ctx context.Context, req *payment.NotifyRequest)
 			}
 		}
 
+	case "PAYMENT.CAPTURE.PENDING":
+		// 扣款处理中（如需人工审核），等待 PAYMENT.CAPTURE.COMPLETED
+		response.CaptureID = getStringValue(resource, "id")
+		response.TradeNo, response.OutTradeNo = p.resolveCaptureOrder(ctx, client, resource)
+
 	case "PAYMENT.CAPTURE.DENIED", "PAYMENT.CAPTURE.FAILED":
 		// 支付失败
 		response.Status = payment.StatusFailed
-		response.TradeNo = getStringValue(resource, "id")
+		response.CaptureID = getStringValue(resource, "id")
+		response.TradeNo, response.OutTradeNo = p.resolveCaptureOrder(ctx, client, resource)
+
+	case "PAYMENT.AUTHORIZATION.CREATED":
+		// 资金已授权，等待商户扣款或撤销
+		response.Status = payment.StatusAuthorized
+		response.AuthorizationID = getStringValue(resource, "id")
+		response.TradeNo, response.OutTradeNo = p.resolveCaptureOrder(ctx, client, resource)
+
+		if amount, ok := resource["amount"].(map[string]interface{}); ok {
+			if value, ok := amount["value"].(string); ok {
+				fmt.Sscanf(value, "%f", &response.Amount)
+			}
+		}
+
+	case "PAYMENT.AUTHORIZATION.VOIDED":
+		// 授权已撤销（商户撤销或授权过期）
+		response.Status = payment.StatusVoided
+		response.AuthorizationID = getStringValue(resource, "id")
+		response.TradeNo, response.OutTradeNo = p.resolveCaptureOrder(ctx, client, resource)
 
 	case "CHECKOUT.ORDER.APPROVED":
-		// 订单已批准(但未捕获支付)
-		response.Status = payment.StatusPending
+		// 订单已批准但尚未扣款（或授权），由支付服务发起扣款，不更新状态
+		response.NeedCapture = true
 		response.TradeNo = getStringValue(resource, "id")
 
 		// 获取商户订单号
@@ -191,8 +466,7 @@ func (p *Provider) HandleNotify(ctx context.Context, req *payment.NotifyRequest)
 		}
 
 	case "CHECKOUT.ORDER.COMPLETED":
-		// 订单完成
-		response.Status = payment.StatusSuccess
+		// 订单完成，支付结果以 PAYMENT.CAPTURE.COMPLETED 为准，不更新状态
 		response.TradeNo = getStringValue(resource, "id")
 
 		// 获取商户订单号和金额
@@ -208,6 +482,144 @@ func (p *Provider) HandleNotify(ctx context.Context, req *payment.NotifyRequest)
 			}
 		}
 
+	case "PAYMENT.CAPTURE.REFUNDED":
+		// 退款完成，resource 为退款对象，invoice_id 为发起退款时的退款单号
+		response.Event = payment.EventRefund
+		response.Refund = &payment.RefundInfo{
+			RefundNo:   getStringValue(resource, "invoice_id"),
+			RefundID:   getStringValue(resource, "id"),
+			Status:     p.convertRefundStatus(getStringValue(resource, "status")),
+			RefundTime: getStringValue(resource, "update_time"),
+		}
+
+		if amount, ok := resource["amount"].(map[string]interface{}); ok {
+			fmt.Sscanf(getStringValue(amount, "value"), "%f", &response.Refund.Amount)
+		}
+
+	case "PAYMENT.PAYOUTS-ITEM.SUCCEEDED", "PAYMENT.PAYOUTS-ITEM.FAILED", "PAYMENT.PAYOUTS-ITEM.BLOCKED",
+		"PAYMENT.PAYOUTS-ITEM.DENIED", "PAYMENT.PAYOUTS-ITEM.RETURNED", "PAYMENT.PAYOUTS-ITEM.REFUNDED",
+		"PAYMENT.PAYOUTS-ITEM.CANCELED", "PAYMENT.PAYOUTS-ITEM.UNCLAIMED", "PAYMENT.PAYOUTS-ITEM.HELD":
+		// 付款明细状态变更，resource 为付款明细，sender_item_id 为付款单号
+		response.Event = payment.EventPayout
+		response.Payout = &payment.PayoutInfo{
+			PayoutID: getStringValue(resource, "payout_batch_id"),
+			Status:   p.convertPayoutStatus(getStringValue(resource, "transaction_status")),
+		}
+		if item, ok := resource["payout_item"].(map[string]interface{}); ok {
+			response.Payout.PayoutNo = getStringValue(item, "sender_item_id")
+		}
+		if errs, ok := resource["errors"].(map[string]interface{}); ok {
+			response.Payout.FailReason = getStringValue(errs, "message")
+		}
+		if response.Payout.Status == payment.PayoutStatusSuccess {
+			response.Payout.FinishTime = getStringValue(resource, "time_processed")
+		}
+
+	case "PAYMENT.PAYOUTSBATCH.DENIED":
+		// 付款批次被拒绝，批次内明细均不会出款
+		response.Event = payment.EventPayout
+		response.Payout = &payment.PayoutInfo{
+			Status:     payment.PayoutStatusFailed,
+			FailReason: "payout batch denied",
+		}
+		if header, ok := resource["batch_header"].(map[string]interface{}); ok {
+			response.Payout.PayoutID = getStringValue(header, "payout_batch_id")
+			if sender, ok := header["sender_batch_header"].(map[string]interface{}); ok {
+				response.Payout.PayoutNo = getStringValue(sender, "sender_batch_id")
+			}
+		}
+
+	case "CUSTOMER.DISPUTE.CREATED", "CUSTOMER.DISPUTE.UPDATED", "CUSTOMER.DISPUTE.RESOLVED":
+		// 争议（拒付），不影响订单状态
+		response.Event = payment.EventDispute
+		response.Dispute = &payment.DisputeInfo{
+			DisputeID: getStringValue(resource, "dispute_id"),
+			Reason:    getStringValue(resource, "reason"),
+			Status:    p.convertDisputeStatus(resource),
+		}
+
+		if amount, ok := resource["dispute_amount"].(map[string]interface{}); ok {
+			fmt.Sscanf(getStringValue(amount, "value"), "%f", &response.Dispute.Amount)
+			response.Dispute.Currency = getStringValue(amount, "currency_code")
+			response.Amount = response.Dispute.Amount
+		}
+
+		if dueDate, err := time.Parse(time.RFC3339, getStringValue(resource, "seller_response_due_date")); err == nil {
+			response.Dispute.EvidenceDueBy = &dueDate
+		}
+
+		// 争议交易的 custom 即创建订单时的 custom_id，未设置时通过扣款查询
+		var captureID string
+		if transactions, ok := resource["disputed_transactions"].([]interface{}); ok && len(transactions) > 0 {
+			if transaction, ok := transactions[0].(map[string]interface{}); ok {
+				captureID = getStringValue(transaction, "seller_transaction_id")
+				response.OutTradeNo = getStringValue(transaction, "custom")
+			}
+		}
+		if response.OutTradeNo == "" && captureID != "" {
+			capture, err := client.CapturedDetail(ctx, captureID)
+			if err != nil {
+				return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to resolve paypal dispute order", err)
+			}
+			response.OutTradeNo = capture.CustomID
+		}
+
+	case "BILLING.SUBSCRIPTION.ACTIVATED", "BILLING.SUBSCRIPTION.UPDATED", "BILLING.SUBSCRIPTION.RE-ACTIVATED",
+		"BILLING.SUBSCRIPTION.SUSPENDED", "BILLING.SUBSCRIPTION.CANCELLED", "BILLING.SUBSCRIPTION.EXPIRED":
+		// 订阅状态变更，resource 为订阅，custom_id 为订阅单号
+		response.Event = payment.EventSubscription
+		response.Subscription = &payment.SubscriptionInfo{
+			SubscriptionNo: getStringValue(resource, "custom_id"),
+			SubscriptionID: getStringValue(resource, "id"),
+			Status:         p.convertSubscriptionStatus(getStringValue(resource, "status")),
+		}
+		if billingInfo, ok := resource["billing_info"].(map[string]interface{}); ok {
+			if nextBillingTime, err := time.Parse(time.RFC3339, getStringValue(billingInfo, "next_billing_time")); err == nil {
+				response.Subscription.CurrentPeriodEnd = &nextBillingTime
+			}
+		}
+
+	case "BILLING.SUBSCRIPTION.PAYMENT.FAILED":
+		// 订阅扣款失败，PayPal 按计划的失败次数上限继续扣款，超过后暂停订阅
+		response.Event = payment.EventSubscription
+		response.Subscription = &payment.SubscriptionInfo{
+			SubscriptionNo: getStringValue(resource, "custom_id"),
+			SubscriptionID: getStringValue(resource, "id"),
+			Status:         payment.SubscriptionStatusPastDue,
+			FailReason:     "subscription payment failed",
+		}
+
+	case "PAYMENT.SALE.COMPLETED":
+		// 订阅扣款成功，billing_agreement_id 为订阅ID，查询订阅获取下次扣款时间
+		subscriptionID := getStringValue(resource, "billing_agreement_id")
+		if subscriptionID == "" {
+			break
+		}
+
+		sub, err := client.GetSubscriptionDetails(ctx, subscriptionID)
+		if err != nil {
+			return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to get paypal subscription", err)
+		}
+
+		response.Event = payment.EventSubscription
+		response.Subscription = &payment.SubscriptionInfo{
+			SubscriptionNo: sub.CustomID,
+			SubscriptionID: sub.ID,
+			Status:         p.convertSubscriptionStatus(string(sub.SubscriptionStatus)),
+		}
+		if !sub.BillingInfo.NextBillingTime.IsZero() {
+			nextBillingTime := sub.BillingInfo.NextBillingTime
+			response.Subscription.CurrentPeriodEnd = &nextBillingTime
+		}
+
+	case "VAULT.PAYMENT-TOKEN.DELETED":
+		// payment token 已删除（含买家在 PayPal 账户中取消授权）
+		response.Event = payment.EventPaymentMethod
+		response.PaymentMethod = &payment.PaymentMethodInfo{
+			MethodID: getStringValue(resource, "id"),
+			Status:   payment.PaymentMethodStatusCanceled,
+		}
+
 	default:
 		// 其他事件类型,只记录但不更新状态
 		response.Status = ""
@@ -216,6 +628,49 @@ func (p *Provider) HandleNotify(ctx context.Context, req *payment.NotifyRequest)
 	return response, nil
 }
 
+// convertDisputeStatus 转换争议状态，已解决的争议按处理结果区分胜诉、败诉
+func (p *Provider) convertDisputeStatus(resource map[string]interface{}) string {
+	switch getStringValue(resource, "status") {
+	case "OPEN", "WAITING_FOR_SELLER_RESPONSE":
+		return payment.DisputeStatusNeedsResponse
+	case "RESOLVED":
+		outcome, _ := resource["dispute_outcome"].(map[string]interface{})
+		switch getStringValue(outcome, "outcome_code") {
+		case "RESOLVED_SELLER_FAVOUR":
+			return payment.DisputeStatusWon
+		case "RESOLVED_BUYER_FAVOUR", "RESOLVED_WITH_PAYOUT":
+			return payment.DisputeStatusLost
+		default:
+			return payment.DisputeStatusClosed
+		}
+	default:
+		return payment.DisputeStatusUnderReview
+	}
+}
+
+// resolveCaptureOrder 从扣款或授权资源中获取PayPal订单ID和商户订单号
+func (p *Provider) resolveCaptureOrder(ctx context.Context, client *paypal.Client, resource map[string]interface{}) (string, string) {
+	var orderID string
+	if supplementaryData, ok := resource["supplementary_data"].(map[string]interface{}); ok {
+		if relatedIDs, ok := supplementaryData["related_ids"].(map[string]interface{}); ok {
+			orderID = getStringValue(relatedIDs, "order_id")
+		}
+	}
+
+	// 创建订单时 custom_id 即商户订单号
+	outTradeNo := getStringValue(resource, "custom_id")
+
+	// 兼容未设置 custom_id 的历史订单，查询订单获取商户订单号
+	if outTradeNo == "" && orderID != "" {
+		order, err := client.GetOrder(ctx, orderID)
+		if err == nil && len(order.PurchaseUnits) > 0 {
+			outTradeNo = order.PurchaseUnits[0].ReferenceID
+		}
+	}
+
+	return orderID, outTradeNo
+}
+
 // verifyWebhookSignature 验证webhook签名
 func (p *Provider) verifyWebhookSignature(req *payment.NotifyRequest, webhookID string, client *paypal.Client) error {
 	// 从请求头中获取签名相关信息
@@ -269,13 +724,24 @@ func (p *Provider) RefundPayment(ctx context.Context, req *payment.RefundRequest
 		return nil, err
 	}
 
-	// 首先需要获取capture ID
-	// 这里简化处理
-	_, err = client.RefundCapture(ctx, req.TradeNo, paypal.RefundCaptureRequest{
+	// PayPal 退款针对扣款（capture）发起
+	captureID := req.CaptureID
+	if captureID == "" {
+		return nil, apperrors.New(apperrors.ErrPaymentRefund, "paypal capture id is required for refund")
+	}
+
+	currency := req.Currency
+	if currency == "" {
+		currency = "USD"
+	}
+
+	refund, err := client.RefundCapture(ctx, captureID, paypal.RefundCaptureRequest{
 		Amount: &paypal.Money{
-			Currency: "USD",
+			Currency: currency,
 			Value:    fmt.Sprintf("%.2f", req.RefundAmount),
 		},
+		InvoiceID:   req.RefundNo,
+		NoteToPayer: req.Reason,
 	})
 
 	if err != nil {
@@ -284,15 +750,512 @@ func (p *Provider) RefundPayment(ctx context.Context, req *payment.RefundRequest
 
 	return &payment.RefundResponse{
 		RefundNo: req.RefundNo,
-		Status:   "success",
+		RefundID: refund.ID,
+		TradeNo:  req.TradeNo,
+		Status:   p.convertRefundStatus(refund.Status),
+	}, nil
+}
+
+// refundDetail v2 退款详情
+type refundDetail struct {
+	ID         string        `json:"id"`
+	Status     string        `json:"status"`
+	InvoiceID  string        `json:"invoice_id"`
+	Amount     *paypal.Money `json:"amount"`
+	UpdateTime string        `json:"update_time"`
+}
+
+// QueryRefund 查询退款，SDK 的 GetRefund 为 v1 接口，这里直接请求 v2 退款详情
+func (p *Provider) QueryRefund(ctx context.Context, req *payment.QueryRefundRequest) (*payment.QueryRefundResponse, error) {
+	if req.RefundID == "" {
+		return nil, apperrors.New(apperrors.ErrInvalidParam, "paypal refund id is required for refund query")
+	}
+
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := client.NewRequest(ctx, "GET", fmt.Sprintf("%s/v2/payments/refunds/%s", client.APIBase, req.RefundID), nil)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentQuery, "failed to build paypal refund query request", err)
+	}
+
+	refund := &refundDetail{}
+	if err := client.SendWithAuth(httpReq, refund); err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentQuery, "failed to query paypal refund", err)
+	}
+
+	response := &payment.QueryRefundResponse{
+		RefundNo:   refund.InvoiceID,
+		RefundID:   refund.ID,
+		Status:     p.convertRefundStatus(refund.Status),
+		RefundTime: refund.UpdateTime,
+	}
+	if refund.Amount != nil {
+		fmt.Sscanf(refund.Amount.Value, "%f", &response.Amount)
+	}
+
+	return response, nil
+}
+
+// CreatePayout 通过 Payouts 发起付款，每个付款单对应一个只有一笔明细的批次
+// 批次号和明细号均使用付款单号，PayPal 拒绝重复的批次号
+func (p *Provider) CreatePayout(ctx context.Context, req *payment.PayoutRequest) (*payment.PayoutResponse, error) {
+	recipientType, err := p.recipientType(req.PayeeType)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	currency := req.Currency
+	if currency == "" {
+		currency = "USD"
+	}
+
+	resp, err := client.CreatePayout(ctx, paypal.Payout{
+		SenderBatchHeader: &paypal.SenderBatchHeader{
+			SenderBatchID: req.PayoutNo,
+			EmailSubject:  req.Remark,
+		},
+		Items: []paypal.PayoutItem{
+			{
+				RecipientType: recipientType,
+				Receiver:      req.PayeeAccount,
+				Amount: &paypal.AmountPayout{
+					Currency: currency,
+					Value:    fmt.Sprintf("%.2f", req.Amount),
+				},
+				Note:         req.Remark,
+				SenderItemID: req.PayoutNo,
+			},
+		},
+	})
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPayout, "failed to create paypal payout", err)
+	}
+
+	response := &payment.PayoutResponse{
+		PayoutNo: req.PayoutNo,
+		Status:   payment.PayoutStatusProcessing,
+	}
+	if resp.BatchHeader != nil {
+		response.PayoutID = resp.BatchHeader.PayoutBatchID
+	}
+
+	return response, nil
+}
+
+// QueryPayout 查询付款批次，以批次内唯一明细的状态为准
+func (p *Provider) QueryPayout(ctx context.Context, req *payment.QueryPayoutRequest) (*payment.PayoutResponse, error) {
+	if req.PayoutID == "" {
+		return nil, apperrors.New(apperrors.ErrInvalidParam, "paypal payout batch id is required for payout query")
+	}
+
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.GetPayout(ctx, req.PayoutID)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentQuery, "failed to query paypal payout", err)
+	}
+
+	response := &payment.PayoutResponse{
+		PayoutNo: req.PayoutNo,
+		PayoutID: req.PayoutID,
+		Status:   payment.PayoutStatusProcessing,
+	}
+
+	if resp.BatchHeader != nil && resp.BatchHeader.BatchStatus == "DENIED" {
+		response.Status = payment.PayoutStatusFailed
+		response.FailReason = "payout batch denied"
+		return response, nil
+	}
+
+	if len(resp.Items) > 0 {
+		item := resp.Items[0]
+		response.Status = p.convertPayoutStatus(item.TransactionStatus)
+		response.FailReason = item.Error.Message
+		if response.Status == payment.PayoutStatusSuccess && item.TimeProcessed != nil {
+			response.FinishTime = item.TimeProcessed.Format(time.RFC3339)
+		}
+	}
+
+	return response, nil
+}
+
+// recipientType 转换收款方账户类型，默认为邮箱
+func (p *Provider) recipientType(payeeType string) (string, error) {
+	switch payeeType {
+	case "", payment.PayeeTypeEmail:
+		return "EMAIL", nil
+	case payment.PayeeTypePhone:
+		return "PHONE", nil
+	case payment.PayeeTypeUserID:
+		return "PAYPAL_ID", nil
+	default:
+		return "", apperrors.New(apperrors.ErrInvalidParam, fmt.Sprintf("unsupported paypal payee type: %s", payeeType))
+	}
+}
+
+// CreateSubscription 创建订阅，买家在批准页面同意后由 PayPal 按周期扣款
+// 首次为订阅计划创建商品和计划，计划ID由本服务保存后复用；订阅单号记录在 custom_id 中，用于关联订阅通知
+func (p *Provider) CreateSubscription(ctx context.Context, req *payment.SubscriptionRequest) (*payment.SubscriptionResponse, error) {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	planID := req.ProviderPlanID
+	if planID == "" {
+		if planID, err = p.createPlan(ctx, client, req); err != nil {
+			return nil, err
+		}
+	}
+
+	sub, err := client.CreateSubscription(ctx, paypal.SubscriptionBase{
+		PlanID:   planID,
+		CustomID: req.SubscriptionNo,
+		ApplicationContext: &paypal.ApplicationContext{
+			ReturnURL: req.ReturnURL,
+			CancelURL: req.ReturnURL,
+		},
+	})
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrSubscription, "failed to create paypal subscription", err)
+	}
+
+	// 获取批准链接
+	var approveURL string
+	for _, link := range sub.Links {
+		if link.Rel == "approve" {
+			approveURL = link.Href
+			break
+		}
+	}
+
+	return &payment.SubscriptionResponse{
+		SubscriptionID: sub.ID,
+		ProviderPlanID: planID,
+		PaymentURL:     approveURL,
+		Status:         payment.SubscriptionStatusPending,
 	}, nil
 }
 
-// ClosePayment 关闭支付
-func (p *Provider) ClosePayment(ctx context.Context, req *payment.ClosePaymentRequest) error {
+// createPlan 创建商品及订阅计划，试用期作为免费的试用周期
+func (p *Provider) createPlan(ctx context.Context, client *paypal.Client, req *payment.SubscriptionRequest) (string, error) {
+	product, err := client.CreateProduct(ctx, paypal.Product{
+		Name:        req.PlanName,
+		Description: req.PlanDescription,
+		Type:        paypal.ProductTypeService,
+	})
+	if err != nil {
+		return "", apperrors.Wrap(apperrors.ErrSubscription, "failed to create paypal product", err)
+	}
+
+	var cycles []paypal.BillingCycle
+	if req.TrialDays > 0 {
+		cycles = append(cycles, paypal.BillingCycle{
+			PricingScheme: paypal.PricingScheme{
+				FixedPrice: paypal.Money{Currency: req.Currency, Value: "0"},
+			},
+			Frequency: paypal.Frequency{
+				IntervalUnit:  paypal.IntervalUnitDay,
+				IntervalCount: req.TrialDays,
+			},
+			TenureType:  paypal.TenureTypeTrial,
+			Sequence:    1,
+			TotalCycles: 1,
+		})
+	}
+	cycles = append(cycles, paypal.BillingCycle{
+		PricingScheme: paypal.PricingScheme{
+			FixedPrice: paypal.Money{Currency: req.Currency, Value: fmt.Sprintf("%.2f", req.Amount)},
+		},
+		Frequency: paypal.Frequency{
+			IntervalUnit:  paypal.IntervalUnit(strings.ToUpper(req.Interval)),
+			IntervalCount: req.IntervalCount,
+		},
+		TenureType:  paypal.TenureTypeRegular,
+		Sequence:    len(cycles) + 1,
+		TotalCycles: 0,
+	})
+
+	plan, err := client.CreateSubscriptionPlan(ctx, paypal.SubscriptionPlan{
+		ProductId:     product.ID,
+		Name:          req.PlanName,
+		Description:   req.PlanDescription,
+		Status:        paypal.SubscriptionPlanStatusActive,
+		BillingCycles: cycles,
+		PaymentPreferences: &paypal.PaymentPreferences{
+			AutoBillOutstanding:     true,
+			SetupFeeFailureAction:   paypal.SetupFeeFailureActionCancel,
+			PaymentFailureThreshold: 3,
+		},
+	})
+	if err != nil {
+		return "", apperrors.Wrap(apperrors.ErrSubscription, "failed to create paypal subscription plan", err)
+	}
+
+	return plan.ID, nil
+}
+
+// QuerySubscription 查询订阅
+func (p *Provider) QuerySubscription(ctx context.Context, req *payment.QuerySubscriptionRequest) (*payment.SubscriptionResponse, error) {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := client.GetSubscriptionDetails(ctx, req.SubscriptionID)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrSubscription, "failed to query paypal subscription", err)
+	}
+
+	response := &payment.SubscriptionResponse{
+		SubscriptionID: sub.ID,
+		ProviderPlanID: sub.PlanID,
+		Status:         p.convertSubscriptionStatus(string(sub.SubscriptionStatus)),
+	}
+	if !sub.BillingInfo.NextBillingTime.IsZero() {
+		nextBillingTime := sub.BillingInfo.NextBillingTime
+		response.CurrentPeriodEnd = &nextBillingTime
+	}
+
+	return response, nil
+}
+
+// CancelSubscription 取消订阅
+func (p *Provider) CancelSubscription(ctx context.Context, req *payment.CancelSubscriptionRequest) error {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return err
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = "canceled by merchant"
+	}
+
+	if err := client.CancelSubscription(ctx, req.SubscriptionID, reason); err != nil {
+		return apperrors.Wrap(apperrors.ErrSubscription, "failed to cancel paypal subscription", err)
+	}
 	return nil
 }
 
+// vaultToken PayPal 保存支付方式的 setup token 或 payment token
+type vaultToken struct {
+	ID       string `json:"id"`
+	Status   string `json:"status"`
+	Customer struct {
+		ID string `json:"id"`
+	} `json:"customer"`
+	PaymentSource struct {
+		PayPal struct {
+			EmailAddress string `json:"email_address"`
+		} `json:"paypal"`
+	} `json:"payment_source"`
+	Links []paypal.Link `json:"links"`
+}
+
+// vaultOrderResponse 使用已保存支付方式创建订单的响应
+type vaultOrderResponse struct {
+	ID            string `json:"id"`
+	Status        string `json:"status"`
+	PurchaseUnits []struct {
+		Payments struct {
+			Captures []paypal.CaptureAmount `json:"captures"`
+		} `json:"payments"`
+	} `json:"purchase_units"`
+}
+
+// SetupPaymentMethod 创建 setup token，买家在批准页面同意商户后续免密扣款
+// 客户ID为空时由 PayPal 创建客户，批准后需将 setup token 兑换为 payment token
+func (p *Provider) SetupPaymentMethod(ctx context.Context, req *payment.SetupPaymentMethodRequest) (*payment.SetupPaymentMethodResponse, error) {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]interface{}{
+		"payment_source": map[string]interface{}{
+			"paypal": map[string]interface{}{
+				"usage_type": "MERCHANT",
+				"experience_context": map[string]interface{}{
+					"return_url": req.ReturnURL,
+					"cancel_url": req.ReturnURL,
+				},
+			},
+		},
+	}
+	if req.CustomerID != "" {
+		body["customer"] = map[string]interface{}{"id": req.CustomerID}
+	}
+
+	httpReq, err := client.NewRequest(ctx, "POST", fmt.Sprintf("%s/v3/vault/setup-tokens", client.APIBase), body)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentMethod, "failed to build paypal setup token request", err)
+	}
+
+	token := &vaultToken{}
+	if err := client.SendWithAuth(httpReq, token); err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentMethod, "failed to create paypal setup token", err)
+	}
+
+	// 获取批准链接
+	var approveURL string
+	for _, link := range token.Links {
+		if link.Rel == "approve" {
+			approveURL = link.Href
+			break
+		}
+	}
+
+	return &payment.SetupPaymentMethodResponse{
+		SetupURL:   approveURL,
+		CustomerID: token.Customer.ID,
+		SetupID:    token.ID,
+		Status:     payment.PaymentMethodStatusPending,
+	}, nil
+}
+
+// QueryPaymentMethod 查询支付方式，买家已批准的 setup token 兑换为 payment token 后返回
+func (p *Provider) QueryPaymentMethod(ctx context.Context, req *payment.QueryPaymentMethodRequest) (*payment.PaymentMethodInfo, error) {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.MethodID != "" {
+		token := &vaultToken{}
+		if err := p.sendVault(ctx, client, "GET", fmt.Sprintf("/v3/vault/payment-tokens/%s", req.MethodID), nil, token); err != nil {
+			return nil, apperrors.Wrap(apperrors.ErrPaymentMethod, "failed to query paypal payment token", err)
+		}
+		return p.toPaymentMethodInfo(req, token), nil
+	}
+
+	setupToken := &vaultToken{}
+	if err := p.sendVault(ctx, client, "GET", fmt.Sprintf("/v3/vault/setup-tokens/%s", req.SetupID), nil, setupToken); err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentMethod, "failed to query paypal setup token", err)
+	}
+	if setupToken.Status != "APPROVED" {
+		return &payment.PaymentMethodInfo{
+			MethodNo:   req.MethodNo,
+			CustomerID: setupToken.Customer.ID,
+			SetupID:    setupToken.ID,
+			Status:     payment.PaymentMethodStatusPending,
+		}, nil
+	}
+
+	body := map[string]interface{}{
+		"payment_source": map[string]interface{}{
+			"token": map[string]interface{}{
+				"id":   setupToken.ID,
+				"type": "SETUP_TOKEN",
+			},
+		},
+	}
+	token := &vaultToken{}
+	if err := p.sendVault(ctx, client, "POST", "/v3/vault/payment-tokens", body, token); err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentMethod, "failed to create paypal payment token", err)
+	}
+	return p.toPaymentMethodInfo(req, token), nil
+}
+
+// DeletePaymentMethod 删除 payment token
+func (p *Provider) DeletePaymentMethod(ctx context.Context, req *payment.DeletePaymentMethodRequest) error {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return err
+	}
+
+	if err := p.sendVault(ctx, client, "DELETE", fmt.Sprintf("/v3/vault/payment-tokens/%s", req.MethodID), nil, nil); err != nil {
+		return apperrors.Wrap(apperrors.ErrPaymentMethod, "failed to delete paypal payment token", err)
+	}
+	return nil
+}
+
+// ChargePaymentMethod 使用 payment token 创建并直接扣款的订单，买家无需批准
+// 商户订单号记录在 custom_id 中，与普通订单一致；PayPal 拒绝扣款（422）按扣款失败返回
+func (p *Provider) ChargePaymentMethod(ctx context.Context, req *payment.ChargePaymentMethodRequest) (*payment.ChargePaymentMethodResponse, error) {
+	client, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]interface{}{
+		"intent": paypal.OrderIntentCapture,
+		"purchase_units": []map[string]interface{}{
+			{
+				"reference_id": req.OrderNo,
+				"custom_id":    req.OutTradeNo,
+				"description":  req.Subject,
+				"amount": map[string]interface{}{
+					"currency_code": req.Currency,
+					"value":         fmt.Sprintf("%.2f", req.Amount),
+				},
+			},
+		},
+		"payment_source": map[string]interface{}{
+			"paypal": map[string]interface{}{
+				"vault_id": req.MethodID,
+			},
+		},
+	}
+
+	order := &vaultOrderResponse{}
+	if err := p.sendVault(ctx, client, "POST", "/v2/checkout/orders", body, order); err != nil {
+		if errResp, ok := err.(*paypal.ErrorResponse); ok && errResp.Response != nil && errResp.Response.StatusCode == http.StatusUnprocessableEntity {
+			return &payment.ChargePaymentMethodResponse{
+				Status:     payment.StatusFailed,
+				FailReason: errResp.Message,
+			}, nil
+		}
+		return nil, apperrors.Wrap(apperrors.ErrPaymentMethod, "failed to charge paypal payment token", err)
+	}
+
+	response := &payment.ChargePaymentMethodResponse{
+		TradeNo: order.ID,
+		Status:  p.convertStatus(order.Status),
+	}
+	if len(order.PurchaseUnits) > 0 && len(order.PurchaseUnits[0].Payments.Captures) > 0 {
+		capture := order.PurchaseUnits[0].Payments.Captures[0]
+		response.Status = p.convertCaptureStatus(capture.Status)
+		if response.Status == payment.StatusFailed {
+			response.FailReason = fmt.Sprintf("paypal capture %s", strings.ToLower(capture.Status))
+		}
+	}
+	return response, nil
+}
+
+// sendVault 发送 PayPal REST 请求，path 为 API 路径
+func (p *Provider) sendVault(ctx context.Context, client *paypal.Client, method, path string, body, v interface{}) error {
+	httpReq, err := client.NewRequest(ctx, method, client.APIBase+path, body)
+	if err != nil {
+		return err
+	}
+	return client.SendWithAuth(httpReq, v)
+}
+
+// toPaymentMethodInfo 转换 payment token
+func (p *Provider) toPaymentMethodInfo(req *payment.QueryPaymentMethodRequest, token *vaultToken) *payment.PaymentMethodInfo {
+	return &payment.PaymentMethodInfo{
+		MethodNo:   req.MethodNo,
+		CustomerID: token.Customer.ID,
+		SetupID:    req.SetupID,
+		MethodID:   token.ID,
+		Status:     payment.PaymentMethodStatusActive,
+		Type:       payment.PaymentMethodTypePayPal,
+		Account:    token.PaymentSource.PayPal.EmailAddress,
+	}
+}
+
 // getClient 获取PayPal客户端
 func (p *Provider) getClient(config map[string]interface{}) (*paypal.Client, error) {
 	clientID, ok := config["client_id"].(string)
@@ -345,6 +1308,77 @@ func (p *Provider) convertStatus(paypalStatus string) string {
 	}
 }
 
+// convertSubscriptionStatus 转换订阅状态，扣款失败次数超过上限后订阅被暂停
+func (p *Provider) convertSubscriptionStatus(subscriptionStatus string) string {
+	switch subscriptionStatus {
+	case "ACTIVE":
+		return payment.SubscriptionStatusActive
+	case "SUSPENDED":
+		return payment.SubscriptionStatusPastDue
+	case "CANCELLED", "EXPIRED":
+		return payment.SubscriptionStatusCanceled
+	default:
+		return payment.SubscriptionStatusPending
+	}
+}
+
+// convertCaptureStatus 转换扣款状态
+func (p *Provider) convertCaptureStatus(captureStatus string) string {
+	switch captureStatus {
+	case "COMPLETED":
+		return payment.StatusSuccess
+	case "PENDING":
+		return payment.StatusPending
+	case "DECLINED", "FAILED":
+		return payment.StatusFailed
+	case "REFUNDED", "PARTIALLY_REFUNDED":
+		return payment.StatusSuccess
+	default:
+		return payment.StatusPending
+	}
+}
+
+// convertRefundStatus 转换退款状态
+func (p *Provider) convertRefundStatus(refundStatus string) string {
+	switch refundStatus {
+	case "COMPLETED":
+		return payment.RefundStatusSuccess
+	case "CANCELLED", "FAILED":
+		return payment.RefundStatusFailed
+	default:
+		return payment.RefundStatusProcessing
+	}
+}
+
+// convertPayoutStatus 转换付款明细状态
+// UNCLAIMED 表示收款方尚未领取，超时未领取会退回，继续等待通知
+func (p *Provider) convertPayoutStatus(transactionStatus string) string {
+	switch transactionStatus {
+	case "SUCCESS":
+		return payment.PayoutStatusSuccess
+	case "FAILED", "BLOCKED", "DENIED", "RETURNED", "REFUNDED", "REVERSED":
+		return payment.PayoutStatusFailed
+	default:
+		return payment.PayoutStatusProcessing
+	}
+}
+
+// convertAuthorizationStatus 转换授权状态
+func (p *Provider) convertAuthorizationStatus(authorizationStatus string) string {
+	switch authorizationStatus {
+	case "CREATED", "PARTIALLY_CAPTURED":
+		return payment.StatusAuthorized
+	case "CAPTURED":
+		return payment.StatusCaptured
+	case "VOIDED", "EXPIRED":
+		return payment.StatusVoided
+	case "DENIED":
+		return payment.StatusFailed
+	default:
+		return payment.StatusPending
+	}
+}
+
 // getStringValue 从map中获取字符串值
 func getStringValue(m map[string]interface{}, key string) string {
 	if v, ok := m[key].(string); ok {
@@ -0,0 +1,149 @@
+package paypal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+)
+
+// roundTripFunc 将请求交给 handler 处理，替代访问 PayPal API
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// stubPayPalAPI 替换默认的 HTTP Transport，PayPal API 请求由 handler 处理
+func stubPayPalAPI(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+
+	transport := http.DefaultTransport
+	http.DefaultTransport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		w := httptest.NewRecorder()
+		if req.URL.Path == "/v1/oauth2/token" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token":"A21","token_type":"Bearer","expires_in":32400}`))
+		} else {
+			handler(w, req)
+		}
+		return w.Result(), nil
+	})
+	t.Cleanup(func() { http.DefaultTransport = transport })
+}
+
+// disputeEvent 争议 webhook 请求
+func disputeEvent(t *testing.T, eventType string, resource map[string]interface{}) *payment.NotifyRequest {
+	t.Helper()
+
+	body, err := json.Marshal(map[string]interface{}{
+		"id":         "WH-1",
+		"event_type": eventType,
+		"resource":   resource,
+	})
+	require.NoError(t, err)
+	return &payment.NotifyRequest{
+		RawData: body,
+		Config:  map[string]interface{}{"client_id": "client", "secret": "secret"},
+	}
+}
+
+// disputeResource 争议资源，custom 为空时通知中没有商户订单号
+func disputeResource(status, outcome, custom string) map[string]interface{} {
+	resource := map[string]interface{}{
+		"dispute_id":               "PP-D-1",
+		"reason":                   "MERCHANDISE_OR_SERVICE_NOT_RECEIVED",
+		"status":                   status,
+		"dispute_amount":           map[string]interface{}{"currency_code": "USD", "value": "19.99"},
+		"seller_response_due_date": "2026-11-01T00:00:00Z",
+		"disputed_transactions": []interface{}{
+			map[string]interface{}{"seller_transaction_id": "CAP1", "custom": custom},
+		},
+	}
+	if outcome != "" {
+		resource["dispute_outcome"] = map[string]interface{}{"outcome_code": outcome}
+	}
+	return resource
+}
+
+// TestHandleNotify_Dispute 测试争议事件解析争议信息，已解决的争议按处理结果转换状态
+func TestHandleNotify_Dispute(t *testing.T) {
+	captures := 0
+	stubPayPalAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		captures++
+		http.NotFound(w, r)
+	})
+
+	tests := []struct {
+		eventType string
+		status    string
+		outcome   string
+		want      string
+	}{
+		{"CUSTOMER.DISPUTE.CREATED", "OPEN", "", payment.DisputeStatusNeedsResponse},
+		{"CUSTOMER.DISPUTE.UPDATED", "WAITING_FOR_SELLER_RESPONSE", "", payment.DisputeStatusNeedsResponse},
+		{"CUSTOMER.DISPUTE.UPDATED", "UNDER_REVIEW", "", payment.DisputeStatusUnderReview},
+		{"CUSTOMER.DISPUTE.RESOLVED", "RESOLVED", "RESOLVED_SELLER_FAVOUR", payment.DisputeStatusWon},
+		{"CUSTOMER.DISPUTE.RESOLVED", "RESOLVED", "RESOLVED_BUYER_FAVOUR", payment.DisputeStatusLost},
+		{"CUSTOMER.DISPUTE.RESOLVED", "RESOLVED", "RESOLVED_WITH_PAYOUT", payment.DisputeStatusLost},
+		{"CUSTOMER.DISPUTE.RESOLVED", "RESOLVED", "CANCELED_BY_BUYER", payment.DisputeStatusClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.eventType+"/"+tt.status+"/"+tt.outcome, func(t *testing.T) {
+			resp, err := NewProvider().HandleNotify(context.Background(), disputeEvent(t, tt.eventType, disputeResource(tt.status, tt.outcome, "ORDER1")))
+			require.NoError(t, err)
+
+			assert.Equal(t, payment.EventDispute, resp.Event)
+			assert.Equal(t, "ORDER1", resp.OutTradeNo)
+			assert.Equal(t, 19.99, resp.Amount)
+			assert.Empty(t, resp.Status)
+			require.NotNil(t, resp.Dispute)
+			assert.Equal(t, "PP-D-1", resp.Dispute.DisputeID)
+			assert.Equal(t, "MERCHANDISE_OR_SERVICE_NOT_RECEIVED", resp.Dispute.Reason)
+			assert.Equal(t, 19.99, resp.Dispute.Amount)
+			assert.Equal(t, "USD", resp.Dispute.Currency)
+			assert.Equal(t, tt.want, resp.Dispute.Status)
+			require.NotNil(t, resp.Dispute.EvidenceDueBy)
+			assert.Equal(t, "2026-11-01T00:00:00Z", resp.Dispute.EvidenceDueBy.Format("2006-01-02T15:04:05Z07:00"))
+		})
+	}
+
+	// 通知中带有商户订单号时不查询扣款
+	assert.Zero(t, captures)
+}
+
+// TestHandleNotify_DisputeCapturedDetail 测试争议交易没有 custom 时查询扣款的 custom_id 作为商户订单号
+func TestHandleNotify_DisputeCapturedDetail(t *testing.T) {
+	var paths []string
+	found := true
+	stubPayPalAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if !found {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"name":"RESOURCE_NOT_FOUND","message":"The specified resource does not exist."}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"CAP1","status":"COMPLETED","custom_id":"ORDER2"}`))
+	})
+
+	req := disputeEvent(t, "CUSTOMER.DISPUTE.CREATED", disputeResource("OPEN", "", ""))
+	resp, err := NewProvider().HandleNotify(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "ORDER2", resp.OutTradeNo)
+	assert.Equal(t, "PP-D-1", resp.Dispute.DisputeID)
+	assert.Equal(t, []string{"/v2/payments/captures/CAP1"}, paths)
+
+	// 扣款查询失败时返回错误，由 PayPal 重试通知
+	found = false
+	_, err = NewProvider().HandleNotify(context.Background(), req)
+	require.Error(t, err)
+	assert.Equal(t, apperrors.ErrPaymentNotify, err.(*apperrors.AppError).Code)
+}
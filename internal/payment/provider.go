@@ -2,9 +2,11 @@ package payment
 
 import (
 	"context"
+	"time"
 )
 
 // Provider 支付提供商接口
+// 只包含所有提供商都必须支持的能力，其余能力通过可选接口（Querier、Refunder、Closer 等）声明
 type Provider interface {
 	// GetName 获取提供商名称
 	GetName() string
@@ -12,31 +14,197 @@ type Provider interface {
 	// CreatePayment 创建支付
 	CreatePayment(ctx context.Context, req *CreatePaymentRequest) (*CreatePaymentResponse, error)
 
-	// QueryPayment 查询支付
-	QueryPayment(ctx context.Context, req *QueryPaymentRequest) (*QueryPaymentResponse, error)
-
 	// HandleNotify 处理支付通知
 	HandleNotify(ctx context.Context, req *NotifyRequest) (*NotifyResponse, error)
+}
 
+// Querier 支持主动查询支付状态的提供商
+type Querier interface {
+	// QueryPayment 查询支付
+	QueryPayment(ctx context.Context, req *QueryPaymentRequest) (*QueryPaymentResponse, error)
+}
+
+// Refunder 支持退款的提供商
+type Refunder interface {
 	// RefundPayment 退款
 	RefundPayment(ctx context.Context, req *RefundRequest) (*RefundResponse, error)
+}
+
+// RefundQuerier 支持主动查询退款状态的提供商，用于同步处理中的退款
+type RefundQuerier interface {
+	// QueryRefund 查询退款
+	QueryRefund(ctx context.Context, req *QueryRefundRequest) (*QueryRefundResponse, error)
+}
 
+// Closer 支持关闭未支付订单的提供商
+type Closer interface {
 	// ClosePayment 关闭支付
 	ClosePayment(ctx context.Context, req *ClosePaymentRequest) error
 }
 
+// BillDownloader 支持下载对账单的提供商
+type BillDownloader interface {
+	// DownloadBill 获取对账单下载地址
+	DownloadBill(ctx context.Context, req *DownloadBillRequest) (*DownloadBillResponse, error)
+}
+
+// Capturer 买家授权后需要商户发起扣款的提供商（如PayPal CAPTURE订单）
+type Capturer interface {
+	// CapturePayment 发起扣款
+	CapturePayment(ctx context.Context, req *CapturePaymentRequest) (*CapturePaymentResponse, error)
+}
+
+// ReturnVerifier 支持校验买家支付后同步跳转结果的提供商
+// 同步跳转参数可被买家篡改，需校验签名或向提供商查询后才能确认支付结果
+type ReturnVerifier interface {
+	// VerifyReturn 校验同步跳转参数，返回提供商确认的支付状态
+	VerifyReturn(ctx context.Context, req *VerifyReturnRequest) (*QueryPaymentResponse, error)
+}
+
+// NotifyIdentifier 可在验签前从通知内容识别商户账号的提供商
+// 用于不带配置令牌的通知地址（部分提供商每个账号只能配置一个Webhook地址）
+type NotifyIdentifier interface {
+	// AccountID 返回支付配置对应的提供商账号，配置不能校验通知签名时返回空，不参与识别
+	AccountID(config map[string]interface{}) string
+
+	// NotifyAccountID 从未验签的通知内容中读取提供商账号，不能识别时返回空
+	NotifyAccountID(req *NotifyRequest) string
+}
+
+// NotifyMatcher 通知内容加密、验签前不能读取账号的提供商（如微信支付 V3），逐个候选配置判断通知是否属于该配置
+type NotifyMatcher interface {
+	// MatchNotify 使用配置中的密钥识别通知，通知属于该配置时返回 true
+	MatchNotify(req *NotifyRequest, config map[string]interface{}) bool
+}
+
+// Authorizer 支持预授权（先冻结资金，后扣款或撤销）的提供商
+type Authorizer interface {
+	// Authorize 创建预授权
+	Authorize(ctx context.Context, req *CreatePaymentRequest) (*CreatePaymentResponse, error)
+
+	// Capture 预授权扣款，支持全额或部分扣款
+	Capture(ctx context.Context, req *CaptureRequest) (*CaptureResponse, error)
+
+	// Void 撤销预授权，释放冻结资金
+	Void(ctx context.Context, req *VoidRequest) error
+}
+
+// Payouter 支持向第三方账户付款（转账）的提供商，用于平台向卖家等收款方出款
+type Payouter interface {
+	// CreatePayout 发起付款，付款单号在提供商侧用作幂等键
+	CreatePayout(ctx context.Context, req *PayoutRequest) (*PayoutResponse, error)
+
+	// QueryPayout 查询付款
+	QueryPayout(ctx context.Context, req *QueryPayoutRequest) (*PayoutResponse, error)
+}
+
+// ProfitSharer 支持分账的提供商，将已支付订单的资金分给多个接收方（如平台佣金、卖家）
+type ProfitSharer interface {
+	// ShareProfit 发起分账，分账单号在提供商侧用作幂等键
+	ShareProfit(ctx context.Context, req *ProfitSharingRequest) (*ProfitSharingResponse, error)
+
+	// QueryProfitSharing 查询分账结果
+	QueryProfitSharing(ctx context.Context, req *QueryProfitSharingRequest) (*ProfitSharingResponse, error)
+}
+
+// ProfitSharingReturner 支持分账回退的提供商，将已分给接收方的资金退回商户
+type ProfitSharingReturner interface {
+	// ReturnProfitSharing 发起分账回退
+	ReturnProfitSharing(ctx context.Context, req *ProfitSharingReturnRequest) (*ProfitSharingReturnResponse, error)
+
+	// QueryProfitSharingReturn 查询分账回退结果
+	QueryProfitSharingReturn(ctx context.Context, req *QueryProfitSharingReturnRequest) (*ProfitSharingReturnResponse, error)
+}
+
+// Subscriber 支持订阅的提供商，买家在提供商页面签约或授权后按周期扣款
+// 未实现 SubscriptionCharger 的提供商自行按周期扣款并通知结果（如 Stripe Billing、PayPal Subscriptions）
+type Subscriber interface {
+	// CreateSubscription 创建订阅，返回买家签约或授权页面
+	CreateSubscription(ctx context.Context, req *SubscriptionRequest) (*SubscriptionResponse, error)
+
+	// QuerySubscription 查询订阅状态
+	QuerySubscription(ctx context.Context, req *QuerySubscriptionRequest) (*SubscriptionResponse, error)
+
+	// CancelSubscription 取消订阅（解约），之后不再扣款
+	CancelSubscription(ctx context.Context, req *CancelSubscriptionRequest) error
+}
+
+// SubscriptionCharger 签约后由本服务按账单日发起扣款的订阅提供商（如支付宝周期扣款）
+type SubscriptionCharger interface {
+	// ChargeSubscription 按签约协议扣款
+	ChargeSubscription(ctx context.Context, req *ChargeSubscriptionRequest) (*ChargeSubscriptionResponse, error)
+}
+
+// PaymentMethodSaver 支持保存买家支付方式并免密扣款的提供商
+// 买家在提供商页面完成绑定（Stripe 绑卡、PayPal 授权、支付宝代扣签约）后，商户可直接发起扣款
+type PaymentMethodSaver interface {
+	// SetupPaymentMethod 发起绑定，返回买家绑定页面
+	SetupPaymentMethod(ctx context.Context, req *SetupPaymentMethodRequest) (*SetupPaymentMethodResponse, error)
+
+	// QueryPaymentMethod 查询绑定结果及支付方式详情
+	QueryPaymentMethod(ctx context.Context, req *QueryPaymentMethodRequest) (*PaymentMethodInfo, error)
+
+	// DeletePaymentMethod 删除支付方式（解绑），之后不能再扣款
+	DeletePaymentMethod(ctx context.Context, req *DeletePaymentMethodRequest) error
+
+	// ChargePaymentMethod 使用已保存的支付方式扣款，买家无需在场
+	ChargePaymentMethod(ctx context.Context, req *ChargePaymentMethodRequest) (*ChargePaymentMethodResponse, error)
+}
+
+// Simulator 沙箱提供商，可模拟买家在收银台的操作并向本服务发送签名通知
+type Simulator interface {
+	// Simulate 模拟买家操作
+	Simulate(ctx context.Context, req *SimulateRequest) error
+}
+
 // CreatePaymentRequest 创建支付请求
 type CreatePaymentRequest struct {
-	OutTradeNo  string                 // 商户订单号
-	Subject     string                 // 订单标题
-	Body        string                 // 订单描述
-	Amount      float64                // 订单金额
-	Currency    string                 // 货币类型
-	NotifyURL   string                 // 异步通知URL
-	ReturnURL   string                 // 同步跳转URL
-	ClientIP    string                 // 客户端IP
-	Config      map[string]interface{} // 支付配置
-	ExtraParams map[string]interface{} // 额外参数
+	OrderNo       string                 // 系统订单号
+	OutTradeNo    string                 // 商户订单号
+	Subject       string                 // 订单标题
+	Body          string                 // 订单描述
+	Amount        float64                // 订单金额
+	Currency      string                 // 货币类型
+	Scene         string                 // 支付场景：native/jsapi/mini_program/h5/app/embedded，为空时由提供商决定默认场景
+	NotifyURL     string                 // 异步通知URL
+	ReturnURL     string                 // 同步跳转URL
+	ClientIP      string                 // 客户端IP
+	BaseURL       string                 // 本服务的对外访问地址，用于生成本服务托管的页面地址
+	Items         []Item                 // 商品明细，不为空时各项金额与运费之和等于订单金额
+	Buyer         *Buyer                 // 买家信息
+	Shipping      *Shipping              // 收货信息
+	ProfitSharing *ProfitSharingPlan     // 分账计划，不为空时需在创建支付时开启分账
+	Config        map[string]interface{} // 支付配置
+	ExtraParams   map[string]interface{} // 额外参数
+}
+
+// Item 商品明细，单价和税额均为单件金额
+type Item struct {
+	Name      string  // 商品名称
+	SKU       string  // 商品编码
+	Quantity  int64   // 数量
+	UnitPrice float64 // 单价（不含税）
+	Tax       float64 // 单件税额
+}
+
+// Buyer 买家信息
+type Buyer struct {
+	Name  string // 姓名
+	Email string // 邮箱
+	Phone string // 手机号
+}
+
+// Shipping 收货信息
+type Shipping struct {
+	Name       string  // 收货人
+	Phone      string  // 收货人手机号
+	Country    string  // 国家代码（ISO 3166-1 alpha-2）
+	State      string  // 省、州
+	City       string  // 城市
+	Line1      string  // 详细地址
+	Line2      string  // 详细地址（第二行）
+	PostalCode string  // 邮编
+	Fee        float64 // 运费，计入订单金额
 }
 
 // CreatePaymentResponse 创建支付响应
@@ -47,6 +215,7 @@ type CreatePaymentResponse struct {
 	QRCode     string                 // 二维码内容（如果是扫码支付）
 	FormData   string                 // 表单数据（如果是表单支付）
 	ExtraData  map[string]interface{} // 额外数据
+	ExpireTime *time.Time             // 支付链接、二维码等的过期时间，为空表示提供商未说明
 }
 
 // QueryPaymentRequest 查询支付请求
@@ -56,6 +225,14 @@ type QueryPaymentRequest struct {
 	Config     map[string]interface{} // 支付配置
 }
 
+// VerifyReturnRequest 校验同步跳转请求
+type VerifyReturnRequest struct {
+	OutTradeNo string                 // 商户订单号
+	TradeNo    string                 // 第三方交易号
+	Params     map[string][]string    // 提供商附加的跳转参数（查询参数或表单）
+	Config     map[string]interface{} // 支付配置
+}
+
 // QueryPaymentResponse 查询支付响应
 type QueryPaymentResponse struct {
 	TradeNo     string  // 第三方交易号
@@ -76,31 +253,204 @@ type NotifyRequest struct {
 
 // NotifyResponse 通知响应
 type NotifyResponse struct {
-	TradeNo     string  // 第三方交易号
-	OutTradeNo  string  // 商户订单号
-	Status      string  // 支付状态
-	Amount      float64 // 订单金额
-	PaymentTime string  // 支付时间
-	BuyerInfo   string  // 买家信息
-	ReturnData  []byte  // 返回给第三方的数据
+	Event           string             // 事件类型：payment/refund/payout/dispute/subscription/payment_method，为空时按 payment 处理
+	TradeNo         string             // 第三方交易号
+	OutTradeNo      string             // 商户订单号
+	Status          string             // 支付状态
+	Amount          float64            // 订单金额
+	PaymentTime     string             // 支付时间
+	BuyerInfo       string             // 买家信息
+	CaptureID       string             // 扣款ID（先授权后扣款的提供商）
+	AuthorizationID string             // 预授权ID
+	NeedCapture     bool               // 买家已授权，需要发起扣款
+	Refund          *RefundInfo        // 退款信息（退款事件）
+	Payout          *PayoutInfo        // 付款信息（付款事件）
+	Dispute         *DisputeInfo       // 争议信息（争议事件）
+	Subscription    *SubscriptionInfo  // 订阅信息（订阅事件）
+	PaymentMethod   *PaymentMethodInfo // 支付方式信息（支付方式事件）
+	ReturnData      []byte             // 返回给第三方的数据
+}
+
+// RefundInfo 退款结果信息
+type RefundInfo struct {
+	RefundNo   string  // 退款单号（本系统生成，提交给第三方的商户退款单号）
+	RefundID   string  // 第三方退款单号
+	Amount     float64 // 退款金额
+	Status     string  // 退款状态：processing/success/failed
+	RefundTime string  // 退款完成时间
+}
+
+// PayoutInfo 付款结果信息
+type PayoutInfo struct {
+	PayoutNo   string // 付款单号（本系统生成，提交给第三方的商户付款单号）
+	PayoutID   string // 第三方付款单号
+	Status     string // 付款状态：processing/success/failed
+	FailReason string // 失败原因
+	FinishTime string // 付款完成时间
+}
+
+// DisputeInfo 争议（拒付）信息
+type DisputeInfo struct {
+	DisputeID     string     // 第三方争议ID
+	Reason        string     // 争议原因
+	Amount        float64    // 争议金额
+	Currency      string     // 货币类型
+	Status        string     // 争议状态：needs_response/under_review/won/lost/closed
+	EvidenceDueBy *time.Time // 提交证据截止时间
 }
 
 // RefundRequest 退款请求
 type RefundRequest struct {
 	OutTradeNo   string                 // 商户订单号
 	TradeNo      string                 // 第三方交易号
+	CaptureID    string                 // 扣款ID（先授权后扣款的提供商）
 	RefundNo     string                 // 退款单号
 	RefundAmount float64                // 退款金额
 	TotalAmount  float64                // 订单总金额
+	Currency     string                 // 货币类型
 	Reason       string                 // 退款原因
+	NotifyURL    string                 // 退款结果通知地址
 	Config       map[string]interface{} // 支付配置
 }
 
 // RefundResponse 退款响应
 type RefundResponse struct {
 	RefundNo string // 退款单号
+	RefundID string // 第三方退款单号
 	TradeNo  string // 第三方交易号
-	Status   string // 退款状态
+	Status   string // 退款状态：processing/success/failed
+}
+
+// QueryRefundRequest 查询退款请求
+type QueryRefundRequest struct {
+	OutTradeNo string                 // 商户订单号
+	TradeNo    string                 // 第三方交易号
+	RefundNo   string                 // 退款单号
+	RefundID   string                 // 第三方退款单号
+	Config     map[string]interface{} // 支付配置
+}
+
+// QueryRefundResponse 查询退款响应
+type QueryRefundResponse struct {
+	RefundNo   string  // 退款单号
+	RefundID   string  // 第三方退款单号
+	Amount     float64 // 退款金额
+	Status     string  // 退款状态：processing/success/failed
+	RefundTime string  // 退款完成时间
+}
+
+// PayoutRequest 付款请求
+type PayoutRequest struct {
+	PayoutNo     string                 // 付款单号
+	Amount       float64                // 付款金额
+	Currency     string                 // 货币类型
+	PayeeType    string                 // 收款方账户类型，为空时由提供商决定默认类型
+	PayeeAccount string                 // 收款方账户
+	PayeeName    string                 // 收款方真实姓名，部分提供商用于校验账户
+	Remark       string                 // 付款备注，收款方可见
+	NotifyURL    string                 // 付款结果通知地址
+	Config       map[string]interface{} // 支付配置
+}
+
+// QueryPayoutRequest 查询付款请求
+type QueryPayoutRequest struct {
+	PayoutNo string                 // 付款单号
+	PayoutID string                 // 第三方付款单号
+	Config   map[string]interface{} // 支付配置
+}
+
+// PayoutResponse 付款响应
+type PayoutResponse struct {
+	PayoutNo   string // 付款单号
+	PayoutID   string // 第三方付款单号
+	Status     string // 付款状态：processing/success/failed
+	FailReason string // 失败原因
+	FinishTime string // 付款完成时间
+}
+
+// ProfitSharingPlan 订单的分账计划
+type ProfitSharingPlan struct {
+	Settle    string                  // 分账时机：auto 支付成功后自动分账，manual 由商户发起分账
+	Receivers []ProfitSharingReceiver // 分账接收方
+}
+
+// ProfitSharingReceiver 分账接收方
+type ProfitSharingReceiver struct {
+	Type        string  // 接收方账户类型，取值同 PayeeType，为空时由提供商决定默认类型
+	Account     string  // 接收方账户
+	Name        string  // 接收方名称，部分提供商用于校验账户
+	Amount      float64 // 分账金额
+	Description string  // 分账描述
+}
+
+// ProfitSharingRequest 分账请求
+type ProfitSharingRequest struct {
+	SharingNo  string                  // 分账单号
+	OutTradeNo string                  // 商户订单号
+	TradeNo    string                  // 第三方交易号
+	CaptureID  string                  // 扣款ID（先授权后扣款的提供商）
+	Currency   string                  // 货币类型
+	Receivers  []ProfitSharingReceiver // 分账接收方
+	Finish     bool                    // 完结分账，剩余冻结资金解冻给商户
+	Config     map[string]interface{}  // 支付配置
+}
+
+// QueryProfitSharingRequest 查询分账请求
+type QueryProfitSharingRequest struct {
+	SharingNo  string                 // 分账单号
+	SharingID  string                 // 第三方分账单号
+	OutTradeNo string                 // 商户订单号
+	TradeNo    string                 // 第三方交易号
+	Config     map[string]interface{} // 支付配置
+}
+
+// ProfitSharingResponse 分账响应
+type ProfitSharingResponse struct {
+	SharingNo string                // 分账单号
+	SharingID string                // 第三方分账单号
+	Status    string                // 分账状态：processing/success/failed
+	Receivers []ProfitSharingResult // 各接收方的分账结果
+}
+
+// ProfitSharingResult 接收方分账结果
+type ProfitSharingResult struct {
+	Account    string  // 接收方账户
+	Amount     float64 // 分账金额
+	Status     string  // 分账状态：processing/success/failed
+	DetailID   string  // 第三方分账明细ID（Stripe 为转账ID），用于分账回退
+	FailReason string  // 失败原因
+}
+
+// ProfitSharingReturnRequest 分账回退请求
+type ProfitSharingReturnRequest struct {
+	ReturnNo    string                 // 回退单号
+	SharingNo   string                 // 分账单号
+	SharingID   string                 // 第三方分账单号
+	DetailID    string                 // 第三方分账明细ID
+	AccountType string                 // 接收方账户类型
+	Account     string                 // 接收方账户
+	Amount      float64                // 回退金额
+	Currency    string                 // 货币类型
+	Description string                 // 回退描述
+	Config      map[string]interface{} // 支付配置
+}
+
+// QueryProfitSharingReturnRequest 查询分账回退请求
+type QueryProfitSharingReturnRequest struct {
+	ReturnNo  string                 // 回退单号
+	ReturnID  string                 // 第三方回退单号
+	SharingNo string                 // 分账单号
+	DetailID  string                 // 第三方分账明细ID
+	Config    map[string]interface{} // 支付配置
+}
+
+// ProfitSharingReturnResponse 分账回退响应
+type ProfitSharingReturnResponse struct {
+	ReturnNo   string // 回退单号
+	ReturnID   string // 第三方回退单号
+	Status     string // 回退状态：processing/success/failed
+	FailReason string // 失败原因
+	FinishTime string // 回退完成时间
 }
 
 // ClosePaymentRequest 关闭支付请求
@@ -110,18 +460,359 @@ type ClosePaymentRequest struct {
 	Config     map[string]interface{} // 支付配置
 }
 
+// DownloadBillRequest 下载对账单请求
+type DownloadBillRequest struct {
+	BillDate string                 // 账单日期，格式 yyyy-MM-dd
+	BillType string                 // 账单类型，为空时由提供商决定默认类型
+	Config   map[string]interface{} // 支付配置
+}
+
+// DownloadBillResponse 下载对账单响应
+type DownloadBillResponse struct {
+	DownloadURL string // 对账单下载地址
+}
+
+// SimulateRequest 模拟买家操作请求
+type SimulateRequest struct {
+	OutTradeNo string                 // 商户订单号
+	TradeNo    string                 // 第三方交易号
+	Amount     float64                // 订单金额
+	Currency   string                 // 货币类型
+	Action     string                 // 买家操作：pay/fail/cancel
+	NotifyURL  string                 // 本服务的通知地址
+	Config     map[string]interface{} // 支付配置
+}
+
+// CapturePaymentRequest 扣款请求
+type CapturePaymentRequest struct {
+	OutTradeNo    string                 // 商户订单号
+	TradeNo       string                 // 第三方交易号
+	AuthorizeOnly bool                   // 仅完成授权不扣款（预授权订单）
+	Config        map[string]interface{} // 支付配置
+}
+
+// CapturePaymentResponse 扣款响应
+type CapturePaymentResponse struct {
+	TradeNo         string  // 第三方交易号
+	CaptureID       string  // 扣款ID
+	AuthorizationID string  // 预授权ID（AuthorizeOnly 时返回）
+	Status          string  // 扣款状态
+	Amount          float64 // 扣款金额
+}
+
+// CaptureRequest 预授权扣款请求
+type CaptureRequest struct {
+	OutTradeNo      string                 // 商户订单号
+	TradeNo         string                 // 第三方交易号
+	AuthorizationID string                 // 预授权ID
+	Subject         string                 // 订单标题
+	Amount          float64                // 扣款金额
+	TotalAmount     float64                // 预授权总金额
+	Currency        string                 // 货币类型
+	Config          map[string]interface{} // 支付配置
+}
+
+// CaptureResponse 预授权扣款响应
+type CaptureResponse struct {
+	TradeNo   string  // 第三方交易号
+	CaptureID string  // 扣款ID
+	Status    string  // 扣款状态：success 表示已扣款，pending 表示等待通知
+	Amount    float64 // 扣款金额
+}
+
+// VoidRequest 撤销预授权请求
+type VoidRequest struct {
+	OutTradeNo      string                 // 商户订单号
+	TradeNo         string                 // 第三方交易号
+	AuthorizationID string                 // 预授权ID
+	Amount          float64                // 预授权金额
+	Currency        string                 // 货币类型
+	Config          map[string]interface{} // 支付配置
+}
+
+// SubscriptionRequest 创建订阅请求
+type SubscriptionRequest struct {
+	SubscriptionNo  string                 // 订阅单号
+	PlanName        string                 // 订阅计划名称
+	PlanDescription string                 // 订阅计划描述
+	ProviderPlanID  string                 // 提供商侧计划ID，为空时由提供商按需创建
+	Amount          float64                // 每期金额
+	Currency        string                 // 货币类型
+	Interval        string                 // 计费周期：day/week/month/year
+	IntervalCount   int                    // 每期包含的周期数
+	TrialDays       int                    // 试用天数
+	FirstChargeTime time.Time              // 首次扣款时间（试用结束时间）
+	NotifyURL       string                 // 本服务的通知地址
+	ReturnURL       string                 // 签约完成后的跳转地址
+	Config          map[string]interface{} // 支付配置
+}
+
+// SubscriptionResponse 订阅响应
+type SubscriptionResponse struct {
+	SubscriptionID   string     // 第三方订阅ID（支付宝为签约协议号）
+	ProviderPlanID   string     // 提供商侧计划ID，创建订阅时新建的计划由本服务保存后复用
+	PaymentURL       string     // 买家签约或授权页面
+	Status           string     // 订阅状态：pending/trialing/active/past_due/canceled
+	CurrentPeriodEnd *time.Time // 当前周期结束时间，即提供商下次扣款时间
+}
+
+// QuerySubscriptionRequest 查询订阅请求
+type QuerySubscriptionRequest struct {
+	SubscriptionNo string                 // 订阅单号
+	SubscriptionID string                 // 第三方订阅ID
+	Config         map[string]interface{} // 支付配置
+}
+
+// CancelSubscriptionRequest 取消订阅请求
+type CancelSubscriptionRequest struct {
+	SubscriptionNo string                 // 订阅单号
+	SubscriptionID string                 // 第三方订阅ID
+	Reason         string                 // 取消原因
+	Config         map[string]interface{} // 支付配置
+}
+
+// ChargeSubscriptionRequest 订阅扣款请求
+type ChargeSubscriptionRequest struct {
+	SubscriptionNo string                 // 订阅单号
+	SubscriptionID string                 // 第三方订阅ID（签约协议号）
+	OrderNo        string                 // 本期扣款的系统订单号
+	OutTradeNo     string                 // 本期扣款的商户订单号，交易通知按此关联订单
+	Subject        string                 // 扣款标题
+	Amount         float64                // 扣款金额
+	Currency       string                 // 货币类型
+	NotifyURL      string                 // 本服务的通知地址
+	Config         map[string]interface{} // 支付配置
+}
+
+// ChargeSubscriptionResponse 订阅扣款响应
+type ChargeSubscriptionResponse struct {
+	TradeNo    string // 第三方交易号
+	Status     string // 扣款状态：success/pending/failed
+	FailReason string // 失败原因
+}
+
+// SubscriptionInfo 订阅状态变更信息
+type SubscriptionInfo struct {
+	SubscriptionNo   string     // 订阅单号，部分通知只包含第三方订阅ID
+	SubscriptionID   string     // 第三方订阅ID
+	Status           string     // 订阅状态，为空表示状态未变化
+	CurrentPeriodEnd *time.Time // 当前周期结束时间，续费成功后推后
+	FailReason       string     // 扣款失败原因（扣款失败事件）
+}
+
+// SetupPaymentMethodRequest 绑定支付方式请求
+type SetupPaymentMethodRequest struct {
+	MethodNo   string                 // 支付方式单号
+	CustomerNo string                 // 客户单号
+	CustomerID string                 // 第三方客户ID，为空时由提供商按需创建
+	Name       string                 // 客户姓名
+	Email      string                 // 客户邮箱
+	Phone      string                 // 客户手机号
+	NotifyURL  string                 // 本服务的通知地址
+	ReturnURL  string                 // 绑定完成后的跳转地址
+	Config     map[string]interface{} // 支付配置
+}
+
+// SetupPaymentMethodResponse 绑定支付方式响应
+type SetupPaymentMethodResponse struct {
+	SetupURL   string // 买家绑定页面
+	CustomerID string // 第三方客户ID，新建的客户由本服务保存后复用
+	SetupID    string // 第三方绑定流程ID（Stripe Checkout Session、PayPal setup token）
+	Status     string // 支付方式状态：pending/active/canceled
+}
+
+// QueryPaymentMethodRequest 查询支付方式请求
+type QueryPaymentMethodRequest struct {
+	MethodNo string                 // 支付方式单号
+	SetupID  string                 // 第三方绑定流程ID
+	MethodID string                 // 第三方支付方式ID，为空时按绑定流程查询
+	Config   map[string]interface{} // 支付配置
+}
+
+// DeletePaymentMethodRequest 删除支付方式请求
+type DeletePaymentMethodRequest struct {
+	MethodNo string                 // 支付方式单号
+	MethodID string                 // 第三方支付方式ID
+	Config   map[string]interface{} // 支付配置
+}
+
+// ChargePaymentMethodRequest 使用已保存支付方式扣款请求
+type ChargePaymentMethodRequest struct {
+	OrderNo    string                 // 系统订单号
+	OutTradeNo string                 // 商户订单号，交易通知按此关联订单
+	Subject    string                 // 扣款标题
+	Amount     float64                // 扣款金额
+	Currency   string                 // 货币类型
+	CustomerID string                 // 第三方客户ID
+	MethodID   string                 // 第三方支付方式ID
+	NotifyURL  string                 // 本服务的通知地址
+	Config     map[string]interface{} // 支付配置
+}
+
+// ChargePaymentMethodResponse 使用已保存支付方式扣款响应
+type ChargePaymentMethodResponse struct {
+	TradeNo    string // 第三方交易号
+	Status     string // 扣款状态：success/pending/failed
+	FailReason string // 失败原因
+}
+
+// PaymentMethodInfo 支付方式信息
+type PaymentMethodInfo struct {
+	MethodNo   string // 支付方式单号，部分通知只包含第三方ID
+	CustomerID string // 第三方客户ID
+	SetupID    string // 第三方绑定流程ID
+	MethodID   string // 第三方支付方式ID
+	Status     string // 支付方式状态：pending/active/canceled
+	Type       string // 支付方式类型：card/paypal/alipay
+	Brand      string // 卡组织（银行卡）
+	Last4      string // 卡号后四位（银行卡）
+	ExpMonth   int    // 有效期月（银行卡）
+	ExpYear    int    // 有效期年（银行卡）
+	Account    string // 账户标识（PayPal 邮箱、支付宝登录号）
+}
+
 // PaymentStatus 支付状态
 const (
 	StatusPending = "pending"
 	StatusSuccess = "success"
 	StatusFailed  = "failed"
 	StatusClosed  = "closed"
+
+	StatusAuthorized = "authorized" // 已预授权
+	StatusCaptured   = "captured"   // 预授权已扣款
+	StatusVoided     = "voided"     // 预授权已撤销
+)
+
+// RefundStatus 退款状态
+const (
+	RefundStatusProcessing = "processing" // 退款处理中，等待通知或主动查询
+	RefundStatusSuccess    = "success"    // 退款成功
+	RefundStatusFailed     = "failed"     // 退款失败或关闭
+)
+
+// PayoutStatus 付款状态
+const (
+	PayoutStatusProcessing = "processing" // 付款处理中，等待通知或主动查询
+	PayoutStatusSuccess    = "success"    // 付款成功
+	PayoutStatusFailed     = "failed"     // 付款失败
+)
+
+// PayeeType 收款方账户类型
+const (
+	PayeeTypeUserID  = "user_id"  // 平台用户ID（支付宝会员ID、PayPal账户ID）
+	PayeeTypeLoginID = "login_id" // 支付宝登录号（邮箱或手机号），需填写收款方姓名
+	PayeeTypeOpenID  = "openid"   // 微信用户 openid
+	PayeeTypeEmail   = "email"    // 邮箱（PayPal）
+	PayeeTypePhone   = "phone"    // 手机号（PayPal）
+	PayeeTypeAccount = "account"  // 关联账户ID（Stripe Connect）
+)
+
+// ProfitSharingSettle 分账时机
+const (
+	ProfitSharingSettleAuto   = "auto"   // 支付成功后自动分账并完结
+	ProfitSharingSettleManual = "manual" // 由商户调用分账接口发起
+)
+
+// ProfitSharingStatus 分账及分账回退状态
+const (
+	ProfitSharingStatusProcessing = "processing" // 处理中，等待主动查询
+	ProfitSharingStatusSuccess    = "success"    // 成功
+	ProfitSharingStatusFailed     = "failed"     // 失败，部分接收方失败时整体按失败处理
+)
+
+// SubscriptionStatus 订阅状态
+const (
+	SubscriptionStatusPending  = "pending"  // 等待买家签约或授权
+	SubscriptionStatusTrialing = "trialing" // 试用中
+	SubscriptionStatusActive   = "active"   // 生效中
+	SubscriptionStatusPastDue  = "past_due" // 续费扣款失败，重试中
+	SubscriptionStatusCanceled = "canceled" // 已取消或解约
+)
+
+// SubscriptionInterval 订阅计费周期
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+	IntervalYear  = "year"
+)
+
+// PaymentMethodStatus 支付方式状态
+const (
+	PaymentMethodStatusPending  = "pending"  // 等待买家绑定
+	PaymentMethodStatusActive   = "active"   // 已绑定，可扣款
+	PaymentMethodStatusCanceled = "canceled" // 已删除、解约或绑定失败
+)
+
+// PaymentMethodType 支付方式类型
+const (
+	PaymentMethodTypeCard   = "card"
+	PaymentMethodTypePayPal = "paypal"
+	PaymentMethodTypeAlipay = "alipay"
+)
+
+// NotifyEvent 通知事件类型
+const (
+	EventPayment       = "payment"        // 支付结果
+	EventRefund        = "refund"         // 退款结果
+	EventPayout        = "payout"         // 付款结果
+	EventDispute       = "dispute"        // 争议
+	EventSubscription  = "subscription"   // 订阅状态变更或续费结果
+	EventPaymentMethod = "payment_method" // 支付方式绑定或解绑
+)
+
+// DisputeStatus 争议状态
+const (
+	DisputeStatusNeedsResponse = "needs_response" // 待商户响应
+	DisputeStatusUnderReview   = "under_review"   // 审核中
+	DisputeStatusWon           = "won"            // 商户胜诉
+	DisputeStatusLost          = "lost"           // 商户败诉，资金已退回买家
+	DisputeStatusClosed        = "closed"         // 已关闭（如买家撤回或预警关闭）
+)
+
+// PaymentScene 支付场景
+const (
+	SceneNative      = "native"       // 扫码支付
+	SceneJSAPI       = "jsapi"        // 公众号支付
+	SceneMiniProgram = "mini_program" // 小程序支付
+	SceneH5          = "h5"           // 手机网页支付
+	SceneApp         = "app"          // App支付
+	SceneEmbedded    = "embedded"     // 页面内嵌支付，前端使用 client_secret 完成支付
+)
+
+// Capability 提供商能力
+const (
+	CapabilityPayment     = "payment"      // 创建支付、接收通知
+	CapabilityQuery       = "query"        // 主动查询
+	CapabilityRefund      = "refund"       // 退款
+	CapabilityRefundQuery = "refund_query" // 查询退款
+	CapabilityClose       = "close"        // 关闭订单
+	CapabilityBill        = "bill"         // 下载对账单
+	CapabilityCapture     = "capture"      // 买家授权后由商户扣款
+	CapabilityAuthorize   = "authorize"    // 预授权
+	CapabilityPayout      = "payout"       // 付款（转账）
+	CapabilitySimulate    = "simulate"     // 沙箱模拟
+
+	CapabilityProfitSharing       = "profit_sharing"        // 分账
+	CapabilityProfitSharingReturn = "profit_sharing_return" // 分账回退
+	CapabilitySubscription        = "subscription"          // 订阅
+	CapabilityPaymentMethod       = "payment_method"        // 保存支付方式并免密扣款
+)
+
+// SimulateAction 模拟的买家操作
+const (
+	SimulatePay    = "pay"    // 支付（结果由脚本规则决定）
+	SimulateFail   = "fail"   // 支付失败
+	SimulateCancel = "cancel" // 取消支付
 )
 
 // ProviderName 提供商名称
 const (
-	ProviderAlipay = "alipay"
-	ProviderWechat = "wechat"
-	ProviderStripe = "stripe"
-	ProviderPayPal = "paypal"
+	ProviderAlipay   = "alipay"
+	ProviderWechat   = "wechat"
+	ProviderStripe   = "stripe"
+	ProviderPayPal   = "paypal"
+	ProviderAdyen    = "adyen"
+	ProviderUnionPay = "unionpay"
+	ProviderMock     = "mock"
 )
@@ -2,6 +2,7 @@ package payment
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 
 	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
@@ -62,3 +63,74 @@ func HasProvider(name string) bool {
 	_, ok := registry.providers[name]
 	return ok
 }
+
+// ProviderInfo 提供商及其支持的能力
+type ProviderInfo struct {
+	Name         string   `json:"name"`
+	Capabilities []string `json:"capabilities"`
+}
+
+// Capabilities 获取提供商支持的能力
+func Capabilities(provider Provider) []string {
+	capabilities := []string{CapabilityPayment}
+	if _, ok := provider.(Querier); ok {
+		capabilities = append(capabilities, CapabilityQuery)
+	}
+	if _, ok := provider.(Refunder); ok {
+		capabilities = append(capabilities, CapabilityRefund)
+	}
+	if _, ok := provider.(RefundQuerier); ok {
+		capabilities = append(capabilities, CapabilityRefundQuery)
+	}
+	if _, ok := provider.(Closer); ok {
+		capabilities = append(capabilities, CapabilityClose)
+	}
+	if _, ok := provider.(BillDownloader); ok {
+		capabilities = append(capabilities, CapabilityBill)
+	}
+	if _, ok := provider.(Capturer); ok {
+		capabilities = append(capabilities, CapabilityCapture)
+	}
+	if _, ok := provider.(Authorizer); ok {
+		capabilities = append(capabilities, CapabilityAuthorize)
+	}
+	if _, ok := provider.(Payouter); ok {
+		capabilities = append(capabilities, CapabilityPayout)
+	}
+	if _, ok := provider.(ProfitSharer); ok {
+		capabilities = append(capabilities, CapabilityProfitSharing)
+	}
+	if _, ok := provider.(ProfitSharingReturner); ok {
+		capabilities = append(capabilities, CapabilityProfitSharingReturn)
+	}
+	if _, ok := provider.(Subscriber); ok {
+		capabilities = append(capabilities, CapabilitySubscription)
+	}
+	if _, ok := provider.(PaymentMethodSaver); ok {
+		capabilities = append(capabilities, CapabilityPaymentMethod)
+	}
+	if _, ok := provider.(Simulator); ok {
+		capabilities = append(capabilities, CapabilitySimulate)
+	}
+	return capabilities
+}
+
+// ListProviders 获取所有提供商及其能力，按名称排序
+func ListProviders() []ProviderInfo {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	infos := make([]ProviderInfo, 0, len(registry.providers))
+	for name, provider := range registry.providers {
+		infos = append(infos, ProviderInfo{
+			Name:         name,
+			Capabilities: Capabilities(provider),
+		})
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+
+	return infos
+}
@@ -3,10 +3,22 @@ package stripe
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"strings"
+	"time"
 
 	"github.com/stripe/stripe-go/v76"
 	"github.com/stripe/stripe-go/v76/checkout/session"
+	"github.com/stripe/stripe-go/v76/customer"
+	"github.com/stripe/stripe-go/v76/paymentintent"
+	"github.com/stripe/stripe-go/v76/paymentmethod"
+	"github.com/stripe/stripe-go/v76/refund"
+	"github.com/stripe/stripe-go/v76/setupintent"
+	"github.com/stripe/stripe-go/v76/subscription"
+	"github.com/stripe/stripe-go/v76/transfer"
+	"github.com/stripe/stripe-go/v76/transferreversal"
 	"github.com/stripe/stripe-go/v76/webhook"
 	"github.com/zqdfound/go-uni-pay/internal/payment"
 	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
@@ -26,67 +38,1059 @@ func (p *Provider) GetName() string {
 }
 
 // CreatePayment 创建支付
+// 内嵌支付场景创建 PaymentIntent，其他场景创建托管的结账会话
 func (p *Provider) CreatePayment(ctx context.Context, req *payment.CreatePaymentRequest) (*payment.CreatePaymentResponse, error) {
 	if err := p.setAPIKey(req.Config); err != nil {
 		return nil, err
 	}
 
-	// 转换金额（Stripe使用最小货币单位，如美分）
-	amount := int64(req.Amount * 100)
+	if req.Scene == payment.SceneEmbedded {
+		return p.createPaymentIntent(req, stripe.PaymentIntentCaptureMethodAutomatic)
+	}
+
+	params, err := p.buildSessionParams(req)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := session.New(params)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentCreate, "failed to create stripe payment", err)
+	}
+
+	// 结账会话ID作为交易号，用于同步跳转和主动查询时查询支付结果
+	return &payment.CreatePaymentResponse{
+		PaymentURL: s.URL,
+		PaymentID:  s.ID,
+		TradeNo:    s.ID,
+		ExpireTime: sessionExpireTime(s),
+	}, nil
+}
+
+// Authorize 创建手动扣款（manual capture）的结账会话或 PaymentIntent，买家支付后仅授权资金
+func (p *Provider) Authorize(ctx context.Context, req *payment.CreatePaymentRequest) (*payment.CreatePaymentResponse, error) {
+	if err := p.setAPIKey(req.Config); err != nil {
+		return nil, err
+	}
+
+	if req.Scene == payment.SceneEmbedded {
+		return p.createPaymentIntent(req, stripe.PaymentIntentCaptureMethodManual)
+	}
+
+	params, err := p.buildSessionParams(req)
+	if err != nil {
+		return nil, err
+	}
+	params.PaymentIntentData.CaptureMethod = stripe.String(string(stripe.PaymentIntentCaptureMethodManual))
+	params.AddMetadata("capture_method", string(stripe.PaymentIntentCaptureMethodManual))
+
+	s, err := session.New(params)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentCreate, "failed to create stripe authorization", err)
+	}
+
+	return &payment.CreatePaymentResponse{
+		PaymentURL: s.URL,
+		PaymentID:  s.ID,
+		TradeNo:    s.ID,
+		ExpireTime: sessionExpireTime(s),
+	}, nil
+}
+
+// createPaymentIntent 创建启用自动支付方式的 PaymentIntent，前端使用 client_secret 完成支付
+// extra_params.payment_method 不为空时由服务端直接确认，需要 3DS 验证时返回验证跳转地址
+func (p *Provider) createPaymentIntent(req *payment.CreatePaymentRequest, captureMethod stripe.PaymentIntentCaptureMethod) (*payment.CreatePaymentResponse, error) {
+	params := &stripe.PaymentIntentParams{
+		Amount:   stripe.Int64(int64(math.Round(req.Amount * 100))),
+		Currency: stripe.String(strings.ToLower(req.Currency)),
+		AutomaticPaymentMethods: &stripe.PaymentIntentAutomaticPaymentMethodsParams{
+			Enabled: stripe.Bool(true),
+		},
+		CaptureMethod: stripe.String(string(captureMethod)),
+		Description:   stripe.String(req.Subject),
+		Shipping:      shippingDetails(req.Shipping),
+	}
+	params.AddMetadata("out_trade_no", req.OutTradeNo)
+	if req.Buyer != nil && req.Buyer.Email != "" {
+		params.ReceiptEmail = stripe.String(req.Buyer.Email)
+	}
+
+	if receiver := destinationReceiver(req.ProfitSharing); receiver != nil {
+		params.TransferData = &stripe.PaymentIntentTransferDataParams{
+			Destination: stripe.String(receiver.Account),
+		}
+		fee, err := applicationFee(*params.Amount, receiver)
+		if err != nil {
+			return nil, err
+		}
+		params.ApplicationFeeAmount = stripe.Int64(fee)
+	} else if req.ProfitSharing != nil {
+		params.TransferGroup = stripe.String(req.OrderNo)
+	}
+
+	if paymentMethod, ok := req.ExtraParams["payment_method"].(string); ok && paymentMethod != "" {
+		params.PaymentMethod = stripe.String(paymentMethod)
+		params.Confirm = stripe.Bool(true)
+		params.ReturnURL = stripe.String(req.ReturnURL)
+	}
+
+	pi, err := paymentintent.New(params)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentCreate, "failed to create stripe payment intent", err)
+	}
+
+	extraData := map[string]interface{}{
+		"client_secret":     pi.ClientSecret,
+		"payment_intent_id": pi.ID,
+		"status":            string(pi.Status),
+	}
+	if publishableKey, ok := req.Config["publishable_key"].(string); ok {
+		extraData["publishable_key"] = publishableKey
+	}
+
+	// 需要买家完成 3DS 等验证
+	var paymentURL string
+	if pi.Status == stripe.PaymentIntentStatusRequiresAction && pi.NextAction != nil {
+		extraData["next_action"] = string(pi.NextAction.Type)
+		if pi.NextAction.RedirectToURL != nil {
+			paymentURL = pi.NextAction.RedirectToURL.URL
+		}
+	}
+
+	return &payment.CreatePaymentResponse{
+		PaymentURL: paymentURL,
+		PaymentID:  pi.ID,
+		TradeNo:    pi.ID,
+		ExtraData:  extraData,
+	}, nil
+}
+
+// Capture 对已授权的 PaymentIntent 发起扣款，金额为0时全额扣款
+func (p *Provider) Capture(ctx context.Context, req *payment.CaptureRequest) (*payment.CaptureResponse, error) {
+	if err := p.setAPIKey(req.Config); err != nil {
+		return nil, err
+	}
+
+	paymentIntentID, err := p.resolvePaymentIntent(req.AuthorizationID, req.TradeNo)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentCapture, "failed to resolve stripe payment intent", err)
+	}
+
+	params := &stripe.PaymentIntentCaptureParams{}
+	if req.Amount > 0 {
+		params.AmountToCapture = stripe.Int64(int64(math.Round(req.Amount * 100)))
+	}
+
+	pi, err := paymentintent.Capture(paymentIntentID, params)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentCapture, "failed to capture stripe payment intent", err)
+	}
+
+	status := payment.StatusPending
+	if pi.Status == stripe.PaymentIntentStatusSucceeded {
+		status = payment.StatusSuccess
+	}
+
+	var captureID string
+	if pi.LatestCharge != nil {
+		captureID = pi.LatestCharge.ID
+	}
+
+	return &payment.CaptureResponse{
+		TradeNo:   pi.ID,
+		CaptureID: captureID,
+		Status:    status,
+		Amount:    float64(pi.AmountReceived) / 100,
+	}, nil
+}
+
+// Void 取消已授权的 PaymentIntent，释放冻结资金
+func (p *Provider) Void(ctx context.Context, req *payment.VoidRequest) error {
+	if err := p.setAPIKey(req.Config); err != nil {
+		return err
+	}
+
+	paymentIntentID, err := p.resolvePaymentIntent(req.AuthorizationID, req.TradeNo)
+	if err != nil {
+		return apperrors.Wrap(apperrors.ErrPaymentVoid, "failed to resolve stripe payment intent", err)
+	}
+
+	if _, err := paymentintent.Cancel(paymentIntentID, nil); err != nil {
+		return apperrors.Wrap(apperrors.ErrPaymentVoid, "failed to cancel stripe payment intent", err)
+	}
+
+	return nil
+}
+
+// resolvePaymentIntent 获取 PaymentIntent ID，未收到授权通知时通过结账会话查询
+func (p *Provider) resolvePaymentIntent(authorizationID, sessionID string) (string, error) {
+	if authorizationID != "" {
+		return authorizationID, nil
+	}
+
+	// 内嵌支付的交易号即为 PaymentIntent ID
+	if strings.HasPrefix(sessionID, "pi_") {
+		return sessionID, nil
+	}
+
+	if sessionID == "" {
+		return "", fmt.Errorf("payment intent id and session id are both empty")
+	}
+
+	s, err := session.Get(sessionID, nil)
+	if err != nil {
+		return "", err
+	}
+	if s.PaymentIntent == nil || s.PaymentIntent.ID == "" {
+		return "", fmt.Errorf("checkout session %s has no payment intent", sessionID)
+	}
+
+	return s.PaymentIntent.ID, nil
+}
+
+// buildSessionParams 构造结账会话参数
+func (p *Provider) buildSessionParams(req *payment.CreatePaymentRequest) (*stripe.CheckoutSessionParams, error) {
+	// 转换金额（Stripe使用最小货币单位，如美分），四舍五入避免浮点误差
+	amount := int64(math.Round(req.Amount * 100))
+
+	// 不指定支付方式，由 Stripe 按 Dashboard 中启用的支付方式展示
+	params := &stripe.CheckoutSessionParams{
+		LineItems:  sessionLineItems(req, amount),
+		Mode:       stripe.String(string(stripe.CheckoutSessionModePayment)),
+		SuccessURL: stripe.String(req.ReturnURL),
+		CancelURL:  stripe.String(req.ReturnURL),
+	}
+
+	params.ClientReferenceID = stripe.String(req.OutTradeNo)
+
+	// PaymentIntent 的通知通过 metadata 关联商户订单
+	params.PaymentIntentData = &stripe.CheckoutSessionPaymentIntentDataParams{
+		Metadata: map[string]string{
+			"out_trade_no": req.OutTradeNo,
+		},
+		Shipping: shippingDetails(req.Shipping),
+	}
+
+	if req.Buyer != nil && req.Buyer.Email != "" {
+		params.CustomerEmail = stripe.String(req.Buyer.Email)
+	}
+
+	// 运费作为固定金额的配送方式展示
+	if req.Shipping != nil && req.Shipping.Fee > 0 && len(req.Items) > 0 {
+		params.ShippingOptions = []*stripe.CheckoutSessionShippingOptionParams{
+			{
+				ShippingRateData: &stripe.CheckoutSessionShippingOptionShippingRateDataParams{
+					Type:        stripe.String("fixed_amount"),
+					DisplayName: stripe.String("Shipping"),
+					FixedAmount: &stripe.CheckoutSessionShippingOptionShippingRateDataFixedAmountParams{
+						Amount:   stripe.Int64(int64(math.Round(req.Shipping.Fee * 100))),
+						Currency: stripe.String(req.Currency),
+					},
+				},
+			},
+		}
+	}
+
+	// 分账订单：单个关联账户自动分账时支付即转账给接收方，平台保留差额作为佣金；
+	// 其余情况支付成功后按接收方分别转账
+	if receiver := destinationReceiver(req.ProfitSharing); receiver != nil {
+		params.PaymentIntentData.TransferData = &stripe.CheckoutSessionPaymentIntentDataTransferDataParams{
+			Destination: stripe.String(receiver.Account),
+		}
+		fee, err := applicationFee(amount, receiver)
+		if err != nil {
+			return nil, err
+		}
+		params.PaymentIntentData.ApplicationFeeAmount = stripe.Int64(fee)
+	} else if req.ProfitSharing != nil {
+		params.PaymentIntentData.TransferGroup = stripe.String(req.OrderNo)
+	}
+
+	return params, nil
+}
+
+// sessionLineItems 结账会话的商品明细，单价包含税额；没有商品明细时以订单标题和金额作为一项
+func sessionLineItems(req *payment.CreatePaymentRequest, amount int64) []*stripe.CheckoutSessionLineItemParams {
+	if len(req.Items) == 0 {
+		return []*stripe.CheckoutSessionLineItemParams{
+			{
+				PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
+					Currency: stripe.String(req.Currency),
+					ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
+						Name: stripe.String(req.Subject),
+					},
+					UnitAmount: stripe.Int64(amount),
+				},
+				Quantity: stripe.Int64(1),
+			},
+		}
+	}
+
+	lineItems := make([]*stripe.CheckoutSessionLineItemParams, 0, len(req.Items))
+	for _, item := range req.Items {
+		productData := &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
+			Name: stripe.String(item.Name),
+		}
+		if item.SKU != "" {
+			productData.Metadata = map[string]string{"sku": item.SKU}
+		}
+		lineItems = append(lineItems, &stripe.CheckoutSessionLineItemParams{
+			PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
+				Currency:    stripe.String(req.Currency),
+				ProductData: productData,
+				UnitAmount:  stripe.Int64(int64(math.Round((item.UnitPrice + item.Tax) * 100))),
+			},
+			Quantity: stripe.Int64(item.Quantity),
+		})
+	}
+	return lineItems
+}
+
+// shippingDetails 转换为 Stripe 的收货信息
+func shippingDetails(shipping *payment.Shipping) *stripe.ShippingDetailsParams {
+	if shipping == nil {
+		return nil
+	}
+	return &stripe.ShippingDetailsParams{
+		Name:  stripe.String(shipping.Name),
+		Phone: stripe.String(shipping.Phone),
+		Address: &stripe.AddressParams{
+			Country:    stripe.String(shipping.Country),
+			State:      stripe.String(shipping.State),
+			City:       stripe.String(shipping.City),
+			Line1:      stripe.String(shipping.Line1),
+			Line2:      stripe.String(shipping.Line2),
+			PostalCode: stripe.String(shipping.PostalCode),
+		},
+	}
+}
+
+// QueryPayment 查询支付
+func (p *Provider) QueryPayment(ctx context.Context, req *payment.QueryPaymentRequest) (*payment.QueryPaymentResponse, error) {
+	if err := p.setAPIKey(req.Config); err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(req.TradeNo, "pi_") {
+		pi, err := paymentintent.Get(req.TradeNo, nil)
+		if err != nil {
+			return nil, apperrors.Wrap(apperrors.ErrPaymentQuery, "failed to query stripe payment intent", err)
+		}
+
+		return &payment.QueryPaymentResponse{
+			TradeNo:    pi.ID,
+			OutTradeNo: pi.Metadata["out_trade_no"],
+			Status:     p.convertIntentStatus(pi.Status),
+			Amount:     float64(pi.Amount) / 100,
+		}, nil
+	}
+
+	s, err := session.Get(req.TradeNo, nil)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentQuery, "failed to query stripe payment", err)
+	}
+
+	status := p.convertStatus(s.PaymentStatus)
+
+	return &payment.QueryPaymentResponse{
+		TradeNo:    s.ID,
+		OutTradeNo: s.ClientReferenceID,
+		Status:     status,
+		Amount:     float64(s.AmountTotal) / 100,
+	}, nil
+}
+
+// VerifyReturn 买家从 Checkout 页面跳转回来时查询 Checkout Session 的支付状态
+// Stripe 的跳转地址不携带签名，支付结果以查询结果为准
+func (p *Provider) VerifyReturn(ctx context.Context, req *payment.VerifyReturnRequest) (*payment.QueryPaymentResponse, error) {
+	if req.TradeNo == "" {
+		return nil, apperrors.New(apperrors.ErrPaymentQuery, "stripe session not found")
+	}
+
+	return p.QueryPayment(ctx, &payment.QueryPaymentRequest{
+		OutTradeNo: req.OutTradeNo,
+		TradeNo:    req.TradeNo,
+		Config:     req.Config,
+	})
+}
+
+// AccountID 返回配置中的 Stripe 账号ID（acct_ 开头），未配置 webhook_secret 时不能校验通知签名，返回空
+func (p *Provider) AccountID(config map[string]interface{}) string {
+	if webhookSecret, _ := config["webhook_secret"].(string); webhookSecret == "" {
+		return ""
+	}
+	accountID, _ := config["account_id"].(string)
+	return accountID
+}
+
+// NotifyAccountID 读取事件所属的账号，只有 Connect 事件携带 account 字段
+func (p *Provider) NotifyAccountID(req *payment.NotifyRequest) string {
+	var event struct {
+		Account string `json:"account"`
+	}
+	if err := json.Unmarshal(req.RawData, &event); err != nil {
+		return ""
+	}
+	return event.Account
+}
+
+// RefundPayment 退款，退款单号记录在 Refund 的 metadata 中，用于关联退款通知
+func (p *Provider) RefundPayment(ctx context.Context, req *payment.RefundRequest) (*payment.RefundResponse, error) {
+	if err := p.setAPIKey(req.Config); err != nil {
+		return nil, err
+	}
+
+	params := &stripe.RefundParams{
+		Amount: stripe.Int64(int64(math.Round(req.RefundAmount * 100))),
+	}
+	if strings.HasPrefix(req.CaptureID, "ch_") {
+		params.Charge = stripe.String(req.CaptureID)
+	} else {
+		paymentIntentID, err := p.resolvePaymentIntent("", req.TradeNo)
+		if err != nil {
+			return nil, apperrors.Wrap(apperrors.ErrPaymentRefund, "failed to resolve stripe payment intent", err)
+		}
+		params.PaymentIntent = stripe.String(paymentIntentID)
+	}
+	params.AddMetadata("refund_no", req.RefundNo)
+	params.AddMetadata("out_trade_no", req.OutTradeNo)
+	if req.Reason != "" {
+		params.AddMetadata("reason", req.Reason)
+	}
+
+	r, err := refund.New(params)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentRefund, "failed to refund stripe payment", err)
+	}
+
+	return &payment.RefundResponse{
+		RefundNo: req.RefundNo,
+		RefundID: r.ID,
+		TradeNo:  req.TradeNo,
+		Status:   p.convertRefundStatus(r.Status),
+	}, nil
+}
+
+// QueryRefund 查询退款
+func (p *Provider) QueryRefund(ctx context.Context, req *payment.QueryRefundRequest) (*payment.QueryRefundResponse, error) {
+	if req.RefundID == "" {
+		return nil, apperrors.New(apperrors.ErrInvalidParam, "stripe refund id is required for refund query")
+	}
+
+	if err := p.setAPIKey(req.Config); err != nil {
+		return nil, err
+	}
+
+	r, err := refund.Get(req.RefundID, nil)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentQuery, "failed to query stripe refund", err)
+	}
+
+	info := p.toRefundInfo(r)
+
+	return &payment.QueryRefundResponse{
+		RefundNo:   info.RefundNo,
+		RefundID:   info.RefundID,
+		Amount:     info.Amount,
+		Status:     info.Status,
+		RefundTime: info.RefundTime,
+	}, nil
+}
+
+// CreatePayout 通过 Connect 转账将平台余额付给关联账户，转账创建即完成
+// 付款单号作为幂等键，重复提交返回同一笔转账
+func (p *Provider) CreatePayout(ctx context.Context, req *payment.PayoutRequest) (*payment.PayoutResponse, error) {
+	if req.PayeeType != "" && req.PayeeType != payment.PayeeTypeAccount {
+		return nil, apperrors.New(apperrors.ErrInvalidParam, fmt.Sprintf("unsupported stripe payee type: %s", req.PayeeType))
+	}
+
+	if err := p.setAPIKey(req.Config); err != nil {
+		return nil, err
+	}
+
+	currency := req.Currency
+	if currency == "" {
+		currency = "usd"
+	}
+
+	params := &stripe.TransferParams{
+		Amount:        stripe.Int64(int64(math.Round(req.Amount * 100))),
+		Currency:      stripe.String(strings.ToLower(currency)),
+		Destination:   stripe.String(req.PayeeAccount),
+		TransferGroup: stripe.String(req.PayoutNo),
+	}
+	if req.Remark != "" {
+		params.Description = stripe.String(req.Remark)
+	}
+	params.AddMetadata("payout_no", req.PayoutNo)
+	params.SetIdempotencyKey(req.PayoutNo)
+
+	t, err := transfer.New(params)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPayout, "failed to create stripe transfer", err)
+	}
+
+	return p.toPayoutResponse(t), nil
+}
+
+// QueryPayout 查询转账
+func (p *Provider) QueryPayout(ctx context.Context, req *payment.QueryPayoutRequest) (*payment.PayoutResponse, error) {
+	if req.PayoutID == "" {
+		return nil, apperrors.New(apperrors.ErrInvalidParam, "stripe transfer id is required for payout query")
+	}
+
+	if err := p.setAPIKey(req.Config); err != nil {
+		return nil, err
+	}
+
+	t, err := transfer.Get(req.PayoutID, nil)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentQuery, "failed to query stripe transfer", err)
+	}
+
+	return p.toPayoutResponse(t), nil
+}
+
+// toPayoutResponse 转换转账结果，全额冲正的转账按失败处理
+func (p *Provider) toPayoutResponse(t *stripe.Transfer) *payment.PayoutResponse {
+	response := &payment.PayoutResponse{
+		PayoutNo: t.Metadata["payout_no"],
+		PayoutID: t.ID,
+		Status:   payment.PayoutStatusSuccess,
+	}
+	if t.Reversed {
+		response.Status = payment.PayoutStatusFailed
+		response.FailReason = "transfer reversed"
+	} else {
+		response.FinishTime = time.Unix(t.Created, 0).Format("2006-01-02 15:04:05")
+	}
+	return response
+}
+
+// ShareProfit 基于支付的 Charge 向接收方的关联账户转账（separate charges and transfers）
+// 创建支付时已使用 destination charge 的订单资金已转入接收方账户，直接返回转账结果
+func (p *Provider) ShareProfit(ctx context.Context, req *payment.ProfitSharingRequest) (*payment.ProfitSharingResponse, error) {
+	if err := p.setAPIKey(req.Config); err != nil {
+		return nil, err
+	}
+
+	paymentIntentID, err := p.resolvePaymentIntent("", req.TradeNo)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrProfitSharing, "failed to resolve stripe payment intent", err)
+	}
+
+	params := &stripe.PaymentIntentParams{}
+	params.AddExpand("latest_charge")
+	pi, err := paymentintent.Get(paymentIntentID, params)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrProfitSharing, "failed to get stripe payment intent", err)
+	}
+	if pi.LatestCharge == nil {
+		return nil, apperrors.New(apperrors.ErrProfitSharing, fmt.Sprintf("stripe payment intent %s has no charge", pi.ID))
+	}
+	charge := pi.LatestCharge
+
+	response := &payment.ProfitSharingResponse{
+		SharingNo: req.SharingNo,
+		SharingID: charge.ID,
+		Status:    payment.ProfitSharingStatusSuccess,
+	}
+
+	if pi.TransferData != nil && pi.TransferData.Destination != nil {
+		for _, r := range req.Receivers {
+			result := payment.ProfitSharingResult{
+				Account: r.Account,
+				Amount:  r.Amount,
+				Status:  payment.ProfitSharingStatusSuccess,
+			}
+			if r.Account != pi.TransferData.Destination.ID {
+				result.Status = payment.ProfitSharingStatusFailed
+				result.FailReason = "payment already transferred to destination account"
+				response.Status = payment.ProfitSharingStatusFailed
+			} else if charge.Transfer != nil {
+				result.DetailID = charge.Transfer.ID
+			}
+			response.Receivers = append(response.Receivers, result)
+		}
+		return response, nil
+	}
+
+	// 每个接收方一笔转账，分账单号加序号作为幂等键；单个接收方失败不影响其他接收方
+	for i, r := range req.Receivers {
+		result := payment.ProfitSharingResult{
+			Account: r.Account,
+			Amount:  r.Amount,
+			Status:  payment.ProfitSharingStatusSuccess,
+		}
+
+		if r.Type != "" && r.Type != payment.PayeeTypeAccount {
+			result.Status = payment.ProfitSharingStatusFailed
+			result.FailReason = fmt.Sprintf("unsupported stripe receiver type: %s", r.Type)
+		} else {
+			transferParams := &stripe.TransferParams{
+				Amount:            stripe.Int64(int64(math.Round(r.Amount * 100))),
+				Currency:          stripe.String(string(pi.Currency)),
+				Destination:       stripe.String(r.Account),
+				SourceTransaction: stripe.String(charge.ID),
+				TransferGroup:     stripe.String(req.SharingNo),
+			}
+			if r.Description != "" {
+				transferParams.Description = stripe.String(r.Description)
+			}
+			transferParams.AddMetadata("sharing_no", req.SharingNo)
+			transferParams.AddMetadata("out_trade_no", req.OutTradeNo)
+			transferParams.SetIdempotencyKey(fmt.Sprintf("%s-%d", req.SharingNo, i))
+
+			t, err := transfer.New(transferParams)
+			if err != nil {
+				result.Status = payment.ProfitSharingStatusFailed
+				result.FailReason = err.Error()
+			} else {
+				result.DetailID = t.ID
+			}
+		}
+
+		if result.Status == payment.ProfitSharingStatusFailed {
+			response.Status = payment.ProfitSharingStatusFailed
+		}
+		response.Receivers = append(response.Receivers, result)
+	}
+
+	return response, nil
+}
+
+// QueryProfitSharing 按分账单号（transfer_group）查询转账
+func (p *Provider) QueryProfitSharing(ctx context.Context, req *payment.QueryProfitSharingRequest) (*payment.ProfitSharingResponse, error) {
+	if err := p.setAPIKey(req.Config); err != nil {
+		return nil, err
+	}
+
+	response := &payment.ProfitSharingResponse{
+		SharingNo: req.SharingNo,
+		SharingID: req.SharingID,
+		Status:    payment.ProfitSharingStatusSuccess,
+	}
+
+	iter := transfer.List(&stripe.TransferListParams{
+		TransferGroup: stripe.String(req.SharingNo),
+	})
+	for iter.Next() {
+		t := iter.Transfer()
+		result := payment.ProfitSharingResult{
+			Amount:   float64(t.Amount) / 100,
+			DetailID: t.ID,
+			Status:   payment.ProfitSharingStatusSuccess,
+		}
+		if t.Destination != nil {
+			result.Account = t.Destination.ID
+		}
+		response.Receivers = append(response.Receivers, result)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentQuery, "failed to list stripe transfers", err)
+	}
+
+	if len(response.Receivers) == 0 {
+		return nil, apperrors.New(apperrors.ErrPaymentQuery, fmt.Sprintf("no stripe transfers found for %s", req.SharingNo))
+	}
+
+	return response, nil
+}
+
+// ReturnProfitSharing 冲正接收方的转账，资金从关联账户退回平台账户
+func (p *Provider) ReturnProfitSharing(ctx context.Context, req *payment.ProfitSharingReturnRequest) (*payment.ProfitSharingReturnResponse, error) {
+	if req.DetailID == "" {
+		return nil, apperrors.New(apperrors.ErrInvalidParam, "stripe transfer id is required for profit sharing return")
+	}
+
+	if err := p.setAPIKey(req.Config); err != nil {
+		return nil, err
+	}
+
+	params := &stripe.TransferReversalParams{
+		ID:     stripe.String(req.DetailID),
+		Amount: stripe.Int64(int64(math.Round(req.Amount * 100))),
+	}
+	if req.Description != "" {
+		params.Description = stripe.String(req.Description)
+	}
+	params.AddMetadata("return_no", req.ReturnNo)
+	params.SetIdempotencyKey(req.ReturnNo)
+
+	r, err := transferreversal.New(params)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrProfitSharing, "failed to reverse stripe transfer", err)
+	}
+
+	return p.toProfitSharingReturnResponse(r), nil
+}
+
+// QueryProfitSharingReturn 查询转账冲正
+func (p *Provider) QueryProfitSharingReturn(ctx context.Context, req *payment.QueryProfitSharingReturnRequest) (*payment.ProfitSharingReturnResponse, error) {
+	if req.ReturnID == "" || req.DetailID == "" {
+		return nil, apperrors.New(apperrors.ErrInvalidParam, "stripe transfer id and reversal id are required for profit sharing return query")
+	}
+
+	if err := p.setAPIKey(req.Config); err != nil {
+		return nil, err
+	}
+
+	r, err := transferreversal.Get(req.ReturnID, &stripe.TransferReversalParams{
+		ID: stripe.String(req.DetailID),
+	})
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentQuery, "failed to query stripe transfer reversal", err)
+	}
+
+	return p.toProfitSharingReturnResponse(r), nil
+}
+
+// toProfitSharingReturnResponse 转换转账冲正结果，冲正创建即完成
+func (p *Provider) toProfitSharingReturnResponse(r *stripe.TransferReversal) *payment.ProfitSharingReturnResponse {
+	return &payment.ProfitSharingReturnResponse{
+		ReturnNo:   r.Metadata["return_no"],
+		ReturnID:   r.ID,
+		Status:     payment.ProfitSharingStatusSuccess,
+		FinishTime: time.Unix(r.Created, 0).Format("2006-01-02 15:04:05"),
+	}
+}
+
+// destinationReceiver 自动分账且只有一个关联账户接收方时返回该接收方，使用 destination charge 在支付时直接转账
+func destinationReceiver(plan *payment.ProfitSharingPlan) *payment.ProfitSharingReceiver {
+	if plan == nil || plan.Settle != payment.ProfitSharingSettleAuto || len(plan.Receivers) != 1 {
+		return nil
+	}
+	receiver := &plan.Receivers[0]
+	if receiver.Type != "" && receiver.Type != payment.PayeeTypeAccount {
+		return nil
+	}
+	return receiver
+}
+
+// applicationFee 计算 destination charge 中平台保留的佣金（最小货币单位），接收方金额不能超过订单金额
+func applicationFee(amount int64, receiver *payment.ProfitSharingReceiver) (int64, error) {
+	share := int64(math.Round(receiver.Amount * 100))
+	if share > amount {
+		return 0, apperrors.New(apperrors.ErrAmountInvalid, fmt.Sprintf("profit sharing amount for receiver %s exceeds order amount", receiver.Account))
+	}
+	return amount - share, nil
+}
+
+// CreateSubscription 创建订阅模式的结账会话，买家完成结账后由 Stripe Billing 按周期扣款
+// 按订阅计划即时创建价格，订阅单号记录在订阅的 metadata 中，用于关联订阅通知
+func (p *Provider) CreateSubscription(ctx context.Context, req *payment.SubscriptionRequest) (*payment.SubscriptionResponse, error) {
+	if err := p.setAPIKey(req.Config); err != nil {
+		return nil, err
+	}
 
 	params := &stripe.CheckoutSessionParams{
-		PaymentMethodTypes: stripe.StringSlice([]string{"card"}),
 		LineItems: []*stripe.CheckoutSessionLineItemParams{
 			{
 				PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
 					Currency: stripe.String(req.Currency),
 					ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
-						Name: stripe.String(req.Subject),
+						Name: stripe.String(req.PlanName),
+					},
+					UnitAmount: stripe.Int64(int64(math.Round(req.Amount * 100))),
+					Recurring: &stripe.CheckoutSessionLineItemPriceDataRecurringParams{
+						Interval:      stripe.String(req.Interval),
+						IntervalCount: stripe.Int64(int64(req.IntervalCount)),
 					},
-					UnitAmount: stripe.Int64(amount),
 				},
 				Quantity: stripe.Int64(1),
 			},
 		},
-		Mode:       stripe.String(string(stripe.CheckoutSessionModePayment)),
-		SuccessURL: stripe.String(req.ReturnURL),
-		CancelURL:  stripe.String(req.ReturnURL),
+		Mode:              stripe.String(string(stripe.CheckoutSessionModeSubscription)),
+		SuccessURL:        stripe.String(req.ReturnURL),
+		CancelURL:         stripe.String(req.ReturnURL),
+		ClientReferenceID: stripe.String(req.SubscriptionNo),
+		SubscriptionData: &stripe.CheckoutSessionSubscriptionDataParams{
+			Metadata: map[string]string{
+				"subscription_no": req.SubscriptionNo,
+			},
+		},
+	}
+	if req.TrialDays > 0 {
+		params.SubscriptionData.TrialPeriodDays = stripe.Int64(int64(req.TrialDays))
 	}
-
-	params.ClientReferenceID = stripe.String(req.OutTradeNo)
 
 	s, err := session.New(params)
 	if err != nil {
-		return nil, apperrors.Wrap(apperrors.ErrPaymentCreate, "failed to create stripe payment", err)
+		return nil, apperrors.Wrap(apperrors.ErrSubscription, "failed to create stripe subscription session", err)
 	}
 
-	return &payment.CreatePaymentResponse{
-		PaymentURL: s.URL,
-		PaymentID:  s.ID,
+	return &payment.SubscriptionResponse{
+		SubscriptionID: s.ID,
+		PaymentURL:     s.URL,
+		Status:         payment.SubscriptionStatusPending,
 	}, nil
 }
 
-// QueryPayment 查询支付
-func (p *Provider) QueryPayment(ctx context.Context, req *payment.QueryPaymentRequest) (*payment.QueryPaymentResponse, error) {
+// QuerySubscription 查询订阅，买家完成结账前第三方订阅ID为结账会话ID
+func (p *Provider) QuerySubscription(ctx context.Context, req *payment.QuerySubscriptionRequest) (*payment.SubscriptionResponse, error) {
 	if err := p.setAPIKey(req.Config); err != nil {
 		return nil, err
 	}
 
-	s, err := session.Get(req.TradeNo, nil)
+	subscriptionID := req.SubscriptionID
+	if strings.HasPrefix(subscriptionID, "cs_") {
+		s, err := session.Get(subscriptionID, nil)
+		if err != nil {
+			return nil, apperrors.Wrap(apperrors.ErrSubscription, "failed to query stripe subscription session", err)
+		}
+		if s.Subscription == nil {
+			status := payment.SubscriptionStatusPending
+			if s.Status == stripe.CheckoutSessionStatusExpired {
+				status = payment.SubscriptionStatusCanceled
+			}
+			return &payment.SubscriptionResponse{SubscriptionID: s.ID, Status: status}, nil
+		}
+		subscriptionID = s.Subscription.ID
+	}
+
+	sub, err := subscription.Get(subscriptionID, nil)
 	if err != nil {
-		return nil, apperrors.Wrap(apperrors.ErrPaymentQuery, "failed to query stripe payment", err)
+		return nil, apperrors.Wrap(apperrors.ErrSubscription, "failed to query stripe subscription", err)
 	}
 
-	status := p.convertStatus(s.PaymentStatus)
+	info := p.toSubscriptionInfo(sub)
+	return &payment.SubscriptionResponse{
+		SubscriptionID:   info.SubscriptionID,
+		Status:           info.Status,
+		CurrentPeriodEnd: info.CurrentPeriodEnd,
+	}, nil
+}
 
-	return &payment.QueryPaymentResponse{
-		TradeNo:    s.ID,
-		OutTradeNo: s.ClientReferenceID,
-		Status:     status,
-		Amount:     float64(s.AmountTotal) / 100,
+// CancelSubscription 立即取消订阅，买家尚未完成结账时使结账会话失效
+func (p *Provider) CancelSubscription(ctx context.Context, req *payment.CancelSubscriptionRequest) error {
+	if err := p.setAPIKey(req.Config); err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(req.SubscriptionID, "cs_") {
+		if _, err := session.Expire(req.SubscriptionID, nil); err != nil {
+			return apperrors.Wrap(apperrors.ErrSubscription, "failed to expire stripe subscription session", err)
+		}
+		return nil
+	}
+
+	if _, err := subscription.Cancel(req.SubscriptionID, nil); err != nil {
+		return apperrors.Wrap(apperrors.ErrSubscription, "failed to cancel stripe subscription", err)
+	}
+	return nil
+}
+
+// toSubscriptionInfo 转换订阅对象
+func (p *Provider) toSubscriptionInfo(sub *stripe.Subscription) *payment.SubscriptionInfo {
+	info := &payment.SubscriptionInfo{
+		SubscriptionNo: sub.Metadata["subscription_no"],
+		SubscriptionID: sub.ID,
+		Status:         p.convertSubscriptionStatus(sub.Status),
+	}
+	if sub.CurrentPeriodEnd > 0 {
+		periodEnd := time.Unix(sub.CurrentPeriodEnd, 0)
+		info.CurrentPeriodEnd = &periodEnd
+	}
+	return info
+}
+
+// SetupPaymentMethod 创建绑卡模式的结账会话，买家在 Stripe 页面保存银行卡
+// 客户不存在时先创建 Stripe Customer，支付方式单号记录在 SetupIntent 的 metadata 中
+func (p *Provider) SetupPaymentMethod(ctx context.Context, req *payment.SetupPaymentMethodRequest) (*payment.SetupPaymentMethodResponse, error) {
+	if err := p.setAPIKey(req.Config); err != nil {
+		return nil, err
+	}
+
+	customerID := req.CustomerID
+	if customerID == "" {
+		params := &stripe.CustomerParams{}
+		if req.Name != "" {
+			params.Name = stripe.String(req.Name)
+		}
+		if req.Email != "" {
+			params.Email = stripe.String(req.Email)
+		}
+		if req.Phone != "" {
+			params.Phone = stripe.String(req.Phone)
+		}
+		params.AddMetadata("customer_no", req.CustomerNo)
+
+		c, err := customer.New(params)
+		if err != nil {
+			return nil, apperrors.Wrap(apperrors.ErrPaymentMethod, "failed to create stripe customer", err)
+		}
+		customerID = c.ID
+	}
+
+	params := &stripe.CheckoutSessionParams{
+		Mode:               stripe.String(string(stripe.CheckoutSessionModeSetup)),
+		Customer:           stripe.String(customerID),
+		PaymentMethodTypes: stripe.StringSlice([]string{"card"}),
+		SuccessURL:         stripe.String(req.ReturnURL),
+		CancelURL:          stripe.String(req.ReturnURL),
+		ClientReferenceID:  stripe.String(req.MethodNo),
+		SetupIntentData: &stripe.CheckoutSessionSetupIntentDataParams{
+			Metadata: map[string]string{
+				"method_no": req.MethodNo,
+			},
+		},
+	}
+
+	s, err := session.New(params)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentMethod, "failed to create stripe setup session", err)
+	}
+
+	return &payment.SetupPaymentMethodResponse{
+		SetupURL:   s.URL,
+		CustomerID: customerID,
+		SetupID:    s.ID,
+		Status:     payment.PaymentMethodStatusPending,
 	}, nil
 }
 
+// QueryPaymentMethod 查询支付方式，绑定完成前按结账会话查询 SetupIntent 获取支付方式
+func (p *Provider) QueryPaymentMethod(ctx context.Context, req *payment.QueryPaymentMethodRequest) (*payment.PaymentMethodInfo, error) {
+	if err := p.setAPIKey(req.Config); err != nil {
+		return nil, err
+	}
+
+	var info *payment.PaymentMethodInfo
+	if req.MethodID != "" {
+		pm, err := paymentmethod.Get(req.MethodID, nil)
+		if err != nil {
+			return nil, apperrors.Wrap(apperrors.ErrPaymentMethod, "failed to query stripe payment method", err)
+		}
+		info = p.toPaymentMethodInfo(pm)
+	} else {
+		s, err := session.Get(req.SetupID, nil)
+		if err != nil {
+			return nil, apperrors.Wrap(apperrors.ErrPaymentMethod, "failed to query stripe setup session", err)
+		}
+		if s.Status != stripe.CheckoutSessionStatusComplete || s.SetupIntent == nil {
+			status := payment.PaymentMethodStatusPending
+			if s.Status == stripe.CheckoutSessionStatusExpired {
+				status = payment.PaymentMethodStatusCanceled
+			}
+			return &payment.PaymentMethodInfo{MethodNo: req.MethodNo, SetupID: s.ID, Status: status}, nil
+		}
+		info, err = p.setupIntentPaymentMethod(s.SetupIntent.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	info.MethodNo = req.MethodNo
+	info.SetupID = req.SetupID
+	return info, nil
+}
+
+// DeletePaymentMethod 将支付方式从客户解绑，之后不能再扣款
+func (p *Provider) DeletePaymentMethod(ctx context.Context, req *payment.DeletePaymentMethodRequest) error {
+	if err := p.setAPIKey(req.Config); err != nil {
+		return err
+	}
+
+	if _, err := paymentmethod.Detach(req.MethodID, nil); err != nil {
+		return apperrors.Wrap(apperrors.ErrPaymentMethod, "failed to detach stripe payment method", err)
+	}
+	return nil
+}
+
+// ChargePaymentMethod 使用客户保存的银行卡创建并确认 off-session 的 PaymentIntent
+// 卡被拒绝等银行卡错误按扣款失败返回，不作为接口错误
+func (p *Provider) ChargePaymentMethod(ctx context.Context, req *payment.ChargePaymentMethodRequest) (*payment.ChargePaymentMethodResponse, error) {
+	if err := p.setAPIKey(req.Config); err != nil {
+		return nil, err
+	}
+
+	params := &stripe.PaymentIntentParams{
+		Amount:        stripe.Int64(int64(math.Round(req.Amount * 100))),
+		Currency:      stripe.String(strings.ToLower(req.Currency)),
+		Customer:      stripe.String(req.CustomerID),
+		PaymentMethod: stripe.String(req.MethodID),
+		Description:   stripe.String(req.Subject),
+		OffSession:    stripe.Bool(true),
+		Confirm:       stripe.Bool(true),
+	}
+	params.AddMetadata("out_trade_no", req.OutTradeNo)
+
+	pi, err := paymentintent.New(params)
+	if err != nil {
+		var stripeErr *stripe.Error
+		if errors.As(err, &stripeErr) && stripeErr.Type == stripe.ErrorTypeCard {
+			resp := &payment.ChargePaymentMethodResponse{
+				Status:     payment.StatusFailed,
+				FailReason: stripeErr.Msg,
+			}
+			if stripeErr.PaymentIntent != nil {
+				resp.TradeNo = stripeErr.PaymentIntent.ID
+			}
+			return resp, nil
+		}
+		return nil, apperrors.Wrap(apperrors.ErrPaymentMethod, "failed to charge stripe payment method", err)
+	}
+
+	resp := &payment.ChargePaymentMethodResponse{
+		TradeNo: pi.ID,
+		Status:  p.convertIntentStatus(pi.Status),
+	}
+	if pi.Status == stripe.PaymentIntentStatusRequiresPaymentMethod {
+		resp.Status = payment.StatusFailed
+		if pi.LastPaymentError != nil {
+			resp.FailReason = pi.LastPaymentError.Msg
+		}
+	}
+	return resp, nil
+}
+
+// setupIntentPaymentMethod 获取 SetupIntent 保存的支付方式，SetupIntent 未成功时为等待中
+func (p *Provider) setupIntentPaymentMethod(setupIntentID string) (*payment.PaymentMethodInfo, error) {
+	si, err := setupintent.Get(setupIntentID, nil)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentMethod, "failed to query stripe setup intent", err)
+	}
+	if si.Status != stripe.SetupIntentStatusSucceeded || si.PaymentMethod == nil {
+		status := payment.PaymentMethodStatusPending
+		if si.Status == stripe.SetupIntentStatusCanceled {
+			status = payment.PaymentMethodStatusCanceled
+		}
+		return &payment.PaymentMethodInfo{MethodNo: si.Metadata["method_no"], Status: status}, nil
+	}
+
+	pm, err := paymentmethod.Get(si.PaymentMethod.ID, nil)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentMethod, "failed to query stripe payment method", err)
+	}
+	info := p.toPaymentMethodInfo(pm)
+	info.MethodNo = si.Metadata["method_no"]
+	return info, nil
+}
+
+// toPaymentMethodInfo 转换支付方式，已从客户解绑的支付方式为已删除
+func (p *Provider) toPaymentMethodInfo(pm *stripe.PaymentMethod) *payment.PaymentMethodInfo {
+	info := &payment.PaymentMethodInfo{
+		MethodID: pm.ID,
+		Status:   payment.PaymentMethodStatusActive,
+		Type:     string(pm.Type),
+	}
+	if pm.Customer != nil {
+		info.CustomerID = pm.Customer.ID
+	} else {
+		info.Status = payment.PaymentMethodStatusCanceled
+	}
+	if pm.Card != nil {
+		info.Brand = string(pm.Card.Brand)
+		info.Last4 = pm.Card.Last4
+		info.ExpMonth = int(pm.Card.ExpMonth)
+		info.ExpYear = int(pm.Card.ExpYear)
+	}
+	if pm.BillingDetails != nil {
+		info.Account = pm.BillingDetails.Email
+	}
+	return info
+}
+
 // HandleNotify 处理支付通知
 func (p *Provider) HandleNotify(ctx context.Context, req *payment.NotifyRequest) (*payment.NotifyResponse, error) {
 	if err := p.setAPIKey(req.Config); err != nil {
@@ -129,11 +1133,50 @@ func (p *Provider) HandleNotify(ctx context.Context, req *payment.NotifyRequest)
 			return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to parse checkout.session.completed event", err)
 		}
 
+		// 订阅模式的会话完成即订阅已创建，查询订阅获取状态
+		if sess.Mode == stripe.CheckoutSessionModeSubscription {
+			if sess.Subscription == nil {
+				break
+			}
+			sub, err := subscription.Get(sess.Subscription.ID, nil)
+			if err != nil {
+				return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to get stripe subscription", err)
+			}
+			response.Event = payment.EventSubscription
+			response.Subscription = p.toSubscriptionInfo(sub)
+			response.Subscription.SubscriptionNo = sess.ClientReferenceID
+			break
+		}
+
+		// 绑卡模式的会话完成即银行卡已保存，查询 SetupIntent 获取支付方式
+		if sess.Mode == stripe.CheckoutSessionModeSetup {
+			if sess.SetupIntent == nil {
+				break
+			}
+			info, err := p.setupIntentPaymentMethod(sess.SetupIntent.ID)
+			if err != nil {
+				return nil, err
+			}
+			info.MethodNo = sess.ClientReferenceID
+			info.SetupID = sess.ID
+			response.Event = payment.EventPaymentMethod
+			response.PaymentMethod = info
+			break
+		}
+
 		response.TradeNo = sess.ID
 		response.OutTradeNo = sess.ClientReferenceID
 		response.Status = p.convertStatus(sess.PaymentStatus)
 		response.Amount = float64(sess.AmountTotal) / 100
 
+		// 手动扣款的会话完成即表示资金已授权
+		if sess.Metadata["capture_method"] == string(stripe.PaymentIntentCaptureMethodManual) {
+			response.Status = payment.StatusAuthorized
+			if sess.PaymentIntent != nil {
+				response.AuthorizationID = sess.PaymentIntent.ID
+			}
+		}
+
 		// 获取支付时间
 		if sess.Created > 0 {
 			response.PaymentTime = fmt.Sprintf("%d", sess.Created)
@@ -172,6 +1215,33 @@ func (p *Provider) HandleNotify(ctx context.Context, req *payment.NotifyRequest)
 			response.BuyerInfo = pi.ReceiptEmail
 		}
 
+	case "payment_intent.amount_capturable_updated":
+		// 手动扣款的支付意图已授权，等待扣款
+		var pi stripe.PaymentIntent
+		if err := json.Unmarshal(event.Data.Raw, &pi); err != nil {
+			return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to parse payment_intent.amount_capturable_updated event", err)
+		}
+
+		response.AuthorizationID = pi.ID
+		response.Status = payment.StatusAuthorized
+		response.Amount = float64(pi.AmountCapturable) / 100
+		response.OutTradeNo = pi.Metadata["out_trade_no"]
+
+	case "payment_intent.canceled":
+		// 支付意图已取消，手动扣款的支付意图视为撤销预授权
+		var pi stripe.PaymentIntent
+		if err := json.Unmarshal(event.Data.Raw, &pi); err != nil {
+			return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to parse payment_intent.canceled event", err)
+		}
+
+		response.AuthorizationID = pi.ID
+		response.Status = payment.StatusClosed
+		if pi.CaptureMethod == stripe.PaymentIntentCaptureMethodManual {
+			response.Status = payment.StatusVoided
+		}
+		response.Amount = float64(pi.Amount) / 100
+		response.OutTradeNo = pi.Metadata["out_trade_no"]
+
 	case "payment_intent.payment_failed":
 		// 支付意图失败
 		var pi stripe.PaymentIntent
@@ -236,6 +1306,121 @@ func (p *Provider) HandleNotify(ctx context.Context, req *payment.NotifyRequest)
 			}
 		}
 
+	case "refund.created", "refund.updated", "refund.failed":
+		// 退款状态变更
+		var r stripe.Refund
+		if err := json.Unmarshal(event.Data.Raw, &r); err != nil {
+			return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, fmt.Sprintf("failed to parse %s event", event.Type), err)
+		}
+
+		response.Event = payment.EventRefund
+		response.Refund = p.toRefundInfo(&r)
+
+	case "charge.refunded":
+		// 扣款已退款（全额或部分），通知中的退款列表可能未展开，此时查询该扣款最近的退款
+		var charge stripe.Charge
+		if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
+			return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to parse charge.refunded event", err)
+		}
+
+		var latest *stripe.Refund
+		if charge.Refunds != nil && len(charge.Refunds.Data) > 0 {
+			latest = charge.Refunds.Data[0]
+		} else {
+			params := &stripe.RefundListParams{Charge: stripe.String(charge.ID)}
+			params.Limit = stripe.Int64(1)
+			iter := refund.List(params)
+			if iter.Next() {
+				latest = iter.Refund()
+			}
+			if err := iter.Err(); err != nil {
+				return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to list stripe refunds", err)
+			}
+		}
+
+		if latest != nil {
+			response.Event = payment.EventRefund
+			response.Refund = p.toRefundInfo(latest)
+		}
+
+	case "charge.dispute.created", "charge.dispute.updated", "charge.dispute.closed",
+		"charge.dispute.funds_withdrawn", "charge.dispute.funds_reinstated":
+		// 争议（拒付），不影响订单状态
+		var dispute stripe.Dispute
+		if err := json.Unmarshal(event.Data.Raw, &dispute); err != nil {
+			return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, fmt.Sprintf("failed to parse %s event", event.Type), err)
+		}
+
+		outTradeNo, err := p.resolveDisputeOrder(&dispute)
+		if err != nil {
+			return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to resolve stripe dispute order", err)
+		}
+
+		response.OutTradeNo = outTradeNo
+		response.Amount = float64(dispute.Amount) / 100
+		if dispute.PaymentIntent != nil {
+			response.TradeNo = dispute.PaymentIntent.ID
+		}
+
+		response.Event = payment.EventDispute
+		response.Dispute = &payment.DisputeInfo{
+			DisputeID: dispute.ID,
+			Reason:    string(dispute.Reason),
+			Amount:    float64(dispute.Amount) / 100,
+			Currency:  strings.ToUpper(string(dispute.Currency)),
+			Status:    p.convertDisputeStatus(dispute.Status),
+		}
+		if dispute.EvidenceDetails != nil && dispute.EvidenceDetails.DueBy > 0 {
+			dueBy := time.Unix(dispute.EvidenceDetails.DueBy, 0)
+			response.Dispute.EvidenceDueBy = &dueBy
+		}
+
+	case "customer.subscription.created", "customer.subscription.updated", "customer.subscription.deleted":
+		// 订阅状态变更，续费成功后当前周期结束时间推后
+		var sub stripe.Subscription
+		if err := json.Unmarshal(event.Data.Raw, &sub); err != nil {
+			return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, fmt.Sprintf("failed to parse %s event", event.Type), err)
+		}
+
+		response.Event = payment.EventSubscription
+		response.Subscription = p.toSubscriptionInfo(&sub)
+
+	case "invoice.payment_failed":
+		// 订阅账单扣款失败，Stripe 按 Dashboard 中的重试规则继续扣款
+		var invoice stripe.Invoice
+		if err := json.Unmarshal(event.Data.Raw, &invoice); err != nil {
+			return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to parse invoice.payment_failed event", err)
+		}
+		if invoice.Subscription == nil {
+			break
+		}
+
+		response.Event = payment.EventSubscription
+		response.Subscription = &payment.SubscriptionInfo{
+			SubscriptionID: invoice.Subscription.ID,
+			Status:         payment.SubscriptionStatusPastDue,
+			FailReason:     "invoice payment failed",
+		}
+		if invoice.SubscriptionDetails != nil {
+			response.Subscription.SubscriptionNo = invoice.SubscriptionDetails.Metadata["subscription_no"]
+		}
+		if invoice.LastFinalizationError != nil && invoice.LastFinalizationError.Msg != "" {
+			response.Subscription.FailReason = invoice.LastFinalizationError.Msg
+		}
+
+	case "payment_method.detached":
+		// 支付方式已从客户解绑（含在 Dashboard 中删除）
+		var pm stripe.PaymentMethod
+		if err := json.Unmarshal(event.Data.Raw, &pm); err != nil {
+			return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to parse payment_method.detached event", err)
+		}
+
+		response.Event = payment.EventPaymentMethod
+		response.PaymentMethod = &payment.PaymentMethodInfo{
+			MethodID: pm.ID,
+			Status:   payment.PaymentMethodStatusCanceled,
+		}
+
 	default:
 		// 其他事件类型,只记录但不更新状态
 		response.Status = ""
@@ -244,17 +1429,18 @@ func (p *Provider) HandleNotify(ctx context.Context, req *payment.NotifyRequest)
 	return response, nil
 }
 
-// RefundPayment 退款
-func (p *Provider) RefundPayment(ctx context.Context, req *payment.RefundRequest) (*payment.RefundResponse, error) {
-	return &payment.RefundResponse{
-		RefundNo: req.RefundNo,
-		Status:   "success",
-	}, nil
-}
+// resolveDisputeOrder 获取争议对应的商户订单号，通知中仅包含 PaymentIntent ID，需查询其 metadata
+func (p *Provider) resolveDisputeOrder(dispute *stripe.Dispute) (string, error) {
+	if dispute.PaymentIntent == nil || dispute.PaymentIntent.ID == "" {
+		return "", fmt.Errorf("dispute %s has no payment intent", dispute.ID)
+	}
 
-// ClosePayment 关闭支付
-func (p *Provider) ClosePayment(ctx context.Context, req *payment.ClosePaymentRequest) error {
-	return nil
+	pi, err := paymentintent.Get(dispute.PaymentIntent.ID, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return pi.Metadata["out_trade_no"], nil
 }
 
 // setAPIKey 设置API密钥
@@ -279,6 +1465,87 @@ func (p *Provider) convertStatus(stripeStatus stripe.CheckoutSessionPaymentStatu
 	}
 }
 
+// convertIntentStatus 转换 PaymentIntent 状态，等待买家操作（含 3DS 验证）时为处理中
+func (p *Provider) convertIntentStatus(status stripe.PaymentIntentStatus) string {
+	switch status {
+	case stripe.PaymentIntentStatusSucceeded:
+		return payment.StatusSuccess
+	case stripe.PaymentIntentStatusRequiresCapture:
+		return payment.StatusAuthorized
+	case stripe.PaymentIntentStatusCanceled:
+		return payment.StatusClosed
+	default:
+		return payment.StatusPending
+	}
+}
+
+// convertSubscriptionStatus 转换订阅状态，未完成首期扣款时为等待中，欠费（unpaid）按扣款失败处理
+func (p *Provider) convertSubscriptionStatus(status stripe.SubscriptionStatus) string {
+	switch status {
+	case stripe.SubscriptionStatusTrialing:
+		return payment.SubscriptionStatusTrialing
+	case stripe.SubscriptionStatusActive:
+		return payment.SubscriptionStatusActive
+	case stripe.SubscriptionStatusPastDue, stripe.SubscriptionStatusUnpaid, stripe.SubscriptionStatusPaused:
+		return payment.SubscriptionStatusPastDue
+	case stripe.SubscriptionStatusCanceled, stripe.SubscriptionStatusIncompleteExpired:
+		return payment.SubscriptionStatusCanceled
+	default:
+		return payment.SubscriptionStatusPending
+	}
+}
+
+// toRefundInfo 转换退款对象
+func (p *Provider) toRefundInfo(r *stripe.Refund) *payment.RefundInfo {
+	info := &payment.RefundInfo{
+		RefundNo: r.Metadata["refund_no"],
+		RefundID: r.ID,
+		Amount:   float64(r.Amount) / 100,
+		Status:   p.convertRefundStatus(r.Status),
+	}
+	if info.Status == payment.RefundStatusSuccess && r.Created > 0 {
+		info.RefundTime = time.Unix(r.Created, 0).Format("2006-01-02 15:04:05")
+	}
+	return info
+}
+
+// convertRefundStatus 转换退款状态
+func (p *Provider) convertRefundStatus(status stripe.RefundStatus) string {
+	switch status {
+	case stripe.RefundStatusSucceeded:
+		return payment.RefundStatusSuccess
+	case stripe.RefundStatusFailed, stripe.RefundStatusCanceled:
+		return payment.RefundStatusFailed
+	default:
+		return payment.RefundStatusProcessing
+	}
+}
+
+// convertDisputeStatus 转换争议状态，预警（warning_*）状态按对应的正式状态处理
+func (p *Provider) convertDisputeStatus(status stripe.DisputeStatus) string {
+	switch status {
+	case stripe.DisputeStatusNeedsResponse, stripe.DisputeStatusWarningNeedsResponse:
+		return payment.DisputeStatusNeedsResponse
+	case stripe.DisputeStatusUnderReview, stripe.DisputeStatusWarningUnderReview:
+		return payment.DisputeStatusUnderReview
+	case stripe.DisputeStatusWon:
+		return payment.DisputeStatusWon
+	case stripe.DisputeStatusLost:
+		return payment.DisputeStatusLost
+	default:
+		return payment.DisputeStatusClosed
+	}
+}
+
+// sessionExpireTime 结账会话的过期时间，过期后支付链接失效
+func sessionExpireTime(s *stripe.CheckoutSession) *time.Time {
+	if s.ExpiresAt == 0 {
+		return nil
+	}
+	expireTime := time.Unix(s.ExpiresAt, 0)
+	return &expireTime
+}
+
 // getFirstValue 从表单数据中获取第一个值
 func getFirstValue(formData map[string][]string, key string) string {
 	if values, ok := formData[key]; ok && len(values) > 0 {
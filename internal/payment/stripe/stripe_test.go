@@ -0,0 +1,139 @@
+package stripe
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/webhook"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+)
+
+// autoSharing 单个关联账户接收方的自动分账计划
+func autoSharing(amount float64) *payment.ProfitSharingPlan {
+	return &payment.ProfitSharingPlan{
+		Settle:    payment.ProfitSharingSettleAuto,
+		Receivers: []payment.ProfitSharingReceiver{{Account: "acct_123", Amount: amount}},
+	}
+}
+
+// TestBuildSessionParams_RoundsAmounts 测试金额四舍五入为最小货币单位，平台佣金为订单金额与分账金额之差
+func TestBuildSessionParams_RoundsAmounts(t *testing.T) {
+	params, err := NewProvider().buildSessionParams(&payment.CreatePaymentRequest{
+		OutTradeNo:    "ORDER1",
+		Subject:       "Order",
+		Amount:        19.99,
+		Currency:      "usd",
+		ProfitSharing: autoSharing(17.29),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1999), *params.LineItems[0].PriceData.UnitAmount)
+	assert.Equal(t, "acct_123", *params.PaymentIntentData.TransferData.Destination)
+	assert.Equal(t, int64(270), *params.PaymentIntentData.ApplicationFeeAmount)
+}
+
+// TestBuildSessionParams_ShareExceedsAmount 测试分账金额超过订单金额时拒绝创建
+func TestBuildSessionParams_ShareExceedsAmount(t *testing.T) {
+	_, err := NewProvider().buildSessionParams(&payment.CreatePaymentRequest{
+		OutTradeNo:    "ORDER1",
+		Subject:       "Order",
+		Amount:        10,
+		Currency:      "usd",
+		ProfitSharing: autoSharing(10.01),
+	})
+	require.Error(t, err)
+	assert.Equal(t, apperrors.ErrAmountInvalid, err.(*apperrors.AppError).Code)
+}
+
+// stripeDisputeEvent 生成已签名的争议事件，争议的 PaymentIntent 为 pi_123
+func stripeDisputeEvent(t *testing.T, eventType, status string) *payment.NotifyRequest {
+	t.Helper()
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"id":          "evt_1",
+		"object":      "event",
+		"type":        eventType,
+		"api_version": stripe.APIVersion,
+		"data": map[string]interface{}{
+			"object": map[string]interface{}{
+				"id":               "dp_123",
+				"object":           "dispute",
+				"amount":           1999,
+				"currency":         "usd",
+				"reason":           "fraudulent",
+				"status":           status,
+				"payment_intent":   "pi_123",
+				"evidence_details": map[string]interface{}{"due_by": 1793491200},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	signed := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{Payload: payload, Secret: "whsec_test"})
+	return &payment.NotifyRequest{
+		RawData:  payload,
+		FormData: map[string][]string{"Stripe-Signature": {signed.Header}},
+		Config:   map[string]interface{}{"secret_key": "sk_test_123", "webhook_secret": "whsec_test"},
+	}
+}
+
+// TestHandleNotify_Dispute 测试争议事件解析争议信息，并查询 PaymentIntent 的 metadata 获取商户订单号
+func TestHandleNotify_Dispute(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/payment_intents/pi_123" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"pi_123","object":"payment_intent","metadata":{"out_trade_no":"ORDER1"}}`))
+	}))
+	defer server.Close()
+
+	backend := stripe.GetBackend(stripe.APIBackend)
+	stripe.SetBackend(stripe.APIBackend, stripe.GetBackendWithConfig(stripe.APIBackend, &stripe.BackendConfig{
+		URL:               stripe.String(server.URL),
+		MaxNetworkRetries: stripe.Int64(0),
+		LeveledLogger:     &stripe.LeveledLogger{Level: stripe.LevelNull},
+	}))
+	defer stripe.SetBackend(stripe.APIBackend, backend)
+
+	tests := []struct {
+		eventType string
+		status    string
+		want      string
+	}{
+		{"charge.dispute.created", "needs_response", payment.DisputeStatusNeedsResponse},
+		{"charge.dispute.created", "warning_needs_response", payment.DisputeStatusNeedsResponse},
+		{"charge.dispute.updated", "under_review", payment.DisputeStatusUnderReview},
+		{"charge.dispute.funds_withdrawn", "warning_under_review", payment.DisputeStatusUnderReview},
+		{"charge.dispute.closed", "won", payment.DisputeStatusWon},
+		{"charge.dispute.closed", "lost", payment.DisputeStatusLost},
+		{"charge.dispute.closed", "warning_closed", payment.DisputeStatusClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.eventType+"/"+tt.status, func(t *testing.T) {
+			resp, err := NewProvider().HandleNotify(context.Background(), stripeDisputeEvent(t, tt.eventType, tt.status))
+			require.NoError(t, err)
+
+			assert.Equal(t, payment.EventDispute, resp.Event)
+			assert.Equal(t, "ORDER1", resp.OutTradeNo)
+			assert.Equal(t, "pi_123", resp.TradeNo)
+			assert.Empty(t, resp.Status)
+			require.NotNil(t, resp.Dispute)
+			assert.Equal(t, "dp_123", resp.Dispute.DisputeID)
+			assert.Equal(t, "fraudulent", resp.Dispute.Reason)
+			assert.Equal(t, 19.99, resp.Dispute.Amount)
+			assert.Equal(t, "USD", resp.Dispute.Currency)
+			assert.Equal(t, tt.want, resp.Dispute.Status)
+			require.NotNil(t, resp.Dispute.EvidenceDueBy)
+			assert.Equal(t, int64(1793491200), resp.Dispute.EvidenceDueBy.Unix())
+		})
+	}
+}
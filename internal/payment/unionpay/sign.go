@@ -0,0 +1,207 @@
+package unionpay
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// unionPayCN 银联签名证书的机构名称
+const unionPayCN = "中国银联股份有限公司"
+
+// testCN 银联测试环境签名证书的机构名称
+const testCN = "00040000:SIGN"
+
+// signer 商户签名与银联验签
+type signer struct {
+	certID        string
+	privateKey    *rsa.PrivateKey
+	roots         *x509.CertPool
+	intermediates *x509.CertPool
+	isProduction  bool
+}
+
+// newSigner 创建签名器
+// signCert 为商户签名证书，certId 取其序列号；rootCert、middleCert 为银联根证书和中级证书
+func newSigner(privateKey, signCert, rootCert, middleCert string, isProduction bool) (*signer, error) {
+	key, err := parsePrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := parseCertificate(signCert)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sign_cert: %w", err)
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM([]byte(rootCert)) {
+		return nil, errors.New("invalid root_cert")
+	}
+
+	intermediates := x509.NewCertPool()
+	if !intermediates.AppendCertsFromPEM([]byte(middleCert)) {
+		return nil, errors.New("invalid middle_cert")
+	}
+
+	return &signer{
+		certID:        cert.SerialNumber.String(),
+		privateKey:    key,
+		roots:         roots,
+		intermediates: intermediates,
+		isProduction:  isProduction,
+	}, nil
+}
+
+// Sign 计算签名（signMethod=01）：对待签名串的 SHA-256 十六进制摘要做 SHA256withRSA 签名
+func (s *signer) Sign(params map[string]string) (string, error) {
+	hashed := sha256.Sum256([]byte(digest(params)))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.privateKey, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// Verify 验证银联报文签名
+// 报文中的 signPubKeyCert 需由银联根证书和中级证书签发，且属于银联
+func (s *signer) Verify(params map[string]string) error {
+	cert, err := parseCertificate(params["signPubKeyCert"])
+	if err != nil {
+		return fmt.Errorf("invalid signPubKeyCert: %w", err)
+	}
+
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:         s.roots,
+		Intermediates: s.intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		CurrentTime:   time.Now(),
+	}); err != nil {
+		return fmt.Errorf("untrusted signPubKeyCert: %w", err)
+	}
+
+	cn := certOwner(cert)
+	if cn != unionPayCN && (s.isProduction || cn != testCN) {
+		return fmt.Errorf("signPubKeyCert is not issued to unionpay: %s", cn)
+	}
+
+	publicKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return errors.New("signPubKeyCert is not an rsa certificate")
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(params["signature"])
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %w", err)
+	}
+
+	hashed := sha256.Sum256([]byte(digest(params)))
+	return rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hashed[:], signature)
+}
+
+// digest 待签名串的 SHA-256 十六进制摘要
+// 待签名串为除 signature 外的参数按key排序拼接为 k=v&k=v
+func digest(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		if key != "signature" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+params[key])
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(pairs, "&")))
+	return hex.EncodeToString(sum[:])
+}
+
+// certOwner 获取证书所属机构，证书CN格式为 041@Z2014@机构名称@序号
+func certOwner(cert *x509.Certificate) string {
+	parts := strings.Split(cert.Subject.CommonName, "@")
+	if len(parts) > 2 {
+		return parts[2]
+	}
+	return cert.Subject.CommonName
+}
+
+// parseCertificate 解析PEM格式的证书
+func parseCertificate(certPEM string) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil {
+		return nil, errors.New("failed to decode certificate pem")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// parsePrivateKey 解析PEM格式的私钥，支持 PKCS#1 和 PKCS#8
+func parsePrivateKey(keyPEM string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(keyPEM))
+	if block == nil {
+		return nil, errors.New("failed to decode private key pem")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an rsa key")
+	}
+	return rsaKey, nil
+}
+
+// parseResponse 解析银联后台应答报文
+// 应答为未编码的 k=v&k=v，保留域的值可能包含被 {} 或 [] 包裹的 & 和 =
+func parseResponse(body string) map[string]string {
+	params := make(map[string]string)
+
+	depth := 0
+	start := 0
+	for i := 0; i <= len(body); i++ {
+		if i < len(body) {
+			switch body[i] {
+			case '{', '[':
+				depth++
+				continue
+			case '}', ']':
+				depth--
+				continue
+			case '&':
+				if depth > 0 {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+
+		if pair := body[start:i]; pair != "" {
+			if idx := strings.Index(pair, "="); idx > 0 {
+				params[pair[:idx]] = pair[idx+1:]
+			}
+		}
+		start = i + 1
+	}
+
+	return params
+}
@@ -0,0 +1,493 @@
+package unionpay
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+)
+
+// 银联全渠道网关地址
+const (
+	productionGateway = "https://gateway.95516.com/gateway/api"
+	sandboxGateway    = "https://gateway.test.95516.com/gateway/api"
+)
+
+// 交易类型
+const (
+	txnTypeQuery   = "00" // 交易状态查询
+	txnTypeConsume = "01" // 消费
+	txnTypeRefund  = "04" // 退货
+	txnTypeRevoke  = "31" // 消费撤销
+)
+
+// txnTimeLayout 订单发送时间格式
+const txnTimeLayout = "20060102150405"
+
+// orderIDPattern 银联商户订单号：8-40位字母和数字
+var orderIDPattern = regexp.MustCompile(`^[0-9A-Za-z]{8,40}$`)
+
+// Provider 银联全渠道支付提供商
+type Provider struct {
+	httpClient *http.Client
+}
+
+// client 银联商户客户端
+type client struct {
+	merID   string
+	gateway string
+	signer  *signer
+}
+
+// NewProvider 创建银联提供商
+func NewProvider() *Provider {
+	return &Provider{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// GetName 获取提供商名称
+func (p *Provider) GetName() string {
+	return payment.ProviderUnionPay
+}
+
+// CreatePayment 创建前台消费交易，返回自动提交到银联网关的表单
+// 交易号暂存订单发送时间，查询时需要；支付完成后替换为银联交易流水号 queryId
+func (p *Provider) CreatePayment(ctx context.Context, req *payment.CreatePaymentRequest) (*payment.CreatePaymentResponse, error) {
+	c, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	if !orderIDPattern.MatchString(req.OutTradeNo) {
+		return nil, apperrors.New(apperrors.ErrInvalidParam, "unionpay out_trade_no must be 8-40 letters or digits")
+	}
+
+	currencyCode, err := getCurrencyCode(req.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	// 手机网页使用移动渠道
+	channelType := "07"
+	if req.Scene == payment.SceneH5 {
+		channelType = "08"
+	}
+
+	txnTime := time.Now().Format(txnTimeLayout)
+	params := map[string]string{
+		"txnType":      txnTypeConsume,
+		"txnSubType":   "01",
+		"bizType":      "000201",
+		"channelType":  channelType,
+		"orderId":      req.OutTradeNo,
+		"txnTime":      txnTime,
+		"txnAmt":       toFen(req.Amount),
+		"currencyCode": currencyCode,
+		"frontUrl":     req.ReturnURL,
+		"backUrl":      req.NotifyURL,
+	}
+	if req.Subject != "" {
+		params["riskRateInfo"] = fmt.Sprintf("{commodityName=%s}", req.Subject)
+	}
+
+	if err := c.sign(params); err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentCreate, "failed to sign unionpay request", err)
+	}
+
+	gatewayURL := c.gateway + "/frontTransReq.do"
+	fields := make(map[string]interface{}, len(params))
+	for key, value := range params {
+		fields[key] = value
+	}
+
+	return &payment.CreatePaymentResponse{
+		PaymentURL: gatewayURL,
+		PaymentID:  req.OutTradeNo,
+		TradeNo:    txnTime,
+		ExtraData: map[string]interface{}{
+			"method":      http.MethodPost,
+			"form_fields": fields,
+			"form_html":   buildForm(gatewayURL, params),
+		},
+	}, nil
+}
+
+// QueryPayment 查询消费交易状态
+func (p *Provider) QueryPayment(ctx context.Context, req *payment.QueryPaymentRequest) (*payment.QueryPaymentResponse, error) {
+	c, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	// 交易号为订单发送时间时才能查询，支付完成后订单已为最终状态
+	if len(req.TradeNo) != len(txnTimeLayout) {
+		return nil, apperrors.New(apperrors.ErrPaymentQuery, "unionpay txn time not found in trade_no")
+	}
+
+	params := map[string]string{
+		"txnType":    txnTypeQuery,
+		"txnSubType": "00",
+		"bizType":    "000000",
+		"orderId":    req.OutTradeNo,
+		"txnTime":    req.TradeNo,
+	}
+
+	resp, err := p.post(ctx, c, "/queryTrans.do", params)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentQuery, "failed to query unionpay transaction", err)
+	}
+
+	var status string
+	switch resp["respCode"] {
+	case "00":
+		status = convertStatus(resp["origRespCode"])
+	case "34":
+		// 交易不存在，买家尚未提交支付
+		status = payment.StatusPending
+	default:
+		return nil, apperrors.New(apperrors.ErrPaymentQuery, fmt.Sprintf("unionpay query failed: %s %s", resp["respCode"], resp["respMsg"]))
+	}
+
+	// 未支付完成时保留订单发送时间，用于后续查询
+	tradeNo := req.TradeNo
+	if status == payment.StatusSuccess {
+		tradeNo = resp["queryId"]
+	}
+
+	return &payment.QueryPaymentResponse{
+		TradeNo:     tradeNo,
+		OutTradeNo:  req.OutTradeNo,
+		Status:      status,
+		Amount:      fromFen(resp["txnAmt"]),
+		PaymentTime: resp["traceTime"],
+	}, nil
+}
+
+// AccountID 银联通知总是校验签名，以商户号识别支付配置
+func (p *Provider) AccountID(config map[string]interface{}) string {
+	merID, _ := config["mer_id"].(string)
+	return merID
+}
+
+// NotifyAccountID 读取通知中的商户号
+func (p *Provider) NotifyAccountID(req *payment.NotifyRequest) string {
+	values, err := url.ParseQuery(string(req.RawData))
+	if err != nil {
+		return ""
+	}
+	return values.Get("merId")
+}
+
+// HandleNotify 处理后台通知，使用银联签名证书验签
+func (p *Provider) HandleNotify(ctx context.Context, req *payment.NotifyRequest) (*payment.NotifyResponse, error) {
+	c, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := url.ParseQuery(string(req.RawData))
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to parse unionpay notification", err)
+	}
+
+	params := make(map[string]string, len(values))
+	for key := range values {
+		params[key] = values.Get(key)
+	}
+
+	if err := c.signer.Verify(params); err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to verify unionpay notification", err)
+	}
+
+	// 退货、撤销的结果通知更新退款记录，不影响支付订单状态
+	// orderId 为退款单号，全额退款撤销失败后改走退货时带有后缀 R
+	switch params["txnType"] {
+	case txnTypeRefund, txnTypeRevoke:
+		refundStatus := payment.RefundStatusFailed
+		if params["respCode"] == "00" || params["respCode"] == "A6" {
+			refundStatus = payment.RefundStatusSuccess
+		}
+
+		return &payment.NotifyResponse{
+			Event:   payment.EventRefund,
+			TradeNo: params["origQryId"],
+			Refund: &payment.RefundInfo{
+				RefundNo:   strings.TrimSuffix(params["orderId"], "R"),
+				RefundID:   params["queryId"],
+				Amount:     fromFen(params["txnAmt"]),
+				Status:     refundStatus,
+				RefundTime: params["traceTime"],
+			},
+			ReturnData: []byte("ok"),
+		}, nil
+	}
+
+	var status string
+	if params["txnType"] == txnTypeConsume {
+		status = convertStatus(params["respCode"])
+	}
+
+	return &payment.NotifyResponse{
+		TradeNo:     params["queryId"],
+		OutTradeNo:  params["orderId"],
+		Status:      status,
+		Amount:      fromFen(params["txnAmt"]),
+		PaymentTime: params["traceTime"],
+		ReturnData:  []byte("ok"),
+	}, nil
+}
+
+// RefundPayment 退款
+// 全额退款优先发起消费撤销（仅限当日、不收手续费），撤销被拒绝时改为退货，退货单号追加后缀 R
+// 退款结果以银联的后台通知为准
+func (p *Provider) RefundPayment(ctx context.Context, req *payment.RefundRequest) (*payment.RefundResponse, error) {
+	c, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.NotifyURL == "" {
+		return nil, apperrors.New(apperrors.ErrInvalidParam, "unionpay refund requires notify url")
+	}
+
+	orderID := req.RefundNo
+	if req.TotalAmount > 0 && toFen(req.RefundAmount) == toFen(req.TotalAmount) {
+		resp, err := p.backTrans(ctx, c, txnTypeRevoke, req.RefundNo, req)
+		if err != nil {
+			return nil, apperrors.Wrap(apperrors.ErrPaymentRefund, "failed to revoke unionpay transaction", err)
+		}
+		if isAccepted(resp["respCode"]) {
+			return &payment.RefundResponse{
+				RefundNo: req.RefundNo,
+				TradeNo:  req.TradeNo,
+				Status:   payment.RefundStatusProcessing,
+			}, nil
+		}
+		orderID = req.RefundNo + "R"
+	}
+
+	resp, err := p.backTrans(ctx, c, txnTypeRefund, orderID, req)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentRefund, "failed to refund unionpay transaction", err)
+	}
+	if !isAccepted(resp["respCode"]) {
+		return nil, apperrors.New(apperrors.ErrPaymentRefund, fmt.Sprintf("unionpay refund rejected: %s %s", resp["respCode"], resp["respMsg"]))
+	}
+
+	return &payment.RefundResponse{
+		RefundNo: req.RefundNo,
+		TradeNo:  req.TradeNo,
+		Status:   payment.RefundStatusProcessing,
+	}, nil
+}
+
+// backTrans 发起后台退货或撤销交易
+func (p *Provider) backTrans(ctx context.Context, c *client, txnType, orderID string, req *payment.RefundRequest) (map[string]string, error) {
+	currencyCode, err := getCurrencyCode(req.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	params := map[string]string{
+		"txnType":      txnType,
+		"txnSubType":   "00",
+		"bizType":      "000201",
+		"channelType":  "07",
+		"orderId":      orderID,
+		"txnTime":      time.Now().Format(txnTimeLayout),
+		"txnAmt":       toFen(req.RefundAmount),
+		"currencyCode": currencyCode,
+		"origQryId":    req.TradeNo,
+		"backUrl":      req.NotifyURL,
+	}
+
+	return p.post(ctx, c, "/backTransReq.do", params)
+}
+
+// post 签名并发送后台请求，验证应答签名
+func (p *Provider) post(ctx context.Context, c *client, path string, params map[string]string) (map[string]string, error) {
+	if err := c.sign(params); err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	for key, value := range params {
+		form.Set(key, value)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.gateway+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded;charset=UTF-8")
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected http status %d", resp.StatusCode)
+	}
+
+	result := parseResponse(string(body))
+	if result["signature"] == "" {
+		// 报文格式错误等情况下银联不签名应答
+		return nil, fmt.Errorf("unsigned response: %s %s", result["respCode"], result["respMsg"])
+	}
+	if err := c.signer.Verify(result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// sign 补充公共参数并签名
+func (c *client) sign(params map[string]string) error {
+	params["version"] = "5.1.0"
+	params["encoding"] = "UTF-8"
+	params["signMethod"] = "01"
+	params["accessType"] = "0"
+	params["merId"] = c.merID
+	params["certId"] = c.signer.certID
+
+	// 空值不参与签名和提交
+	for key, value := range params {
+		if value == "" {
+			delete(params, key)
+		}
+	}
+
+	signature, err := c.signer.Sign(params)
+	if err != nil {
+		return err
+	}
+	params["signature"] = signature
+	return nil
+}
+
+// getClient 获取银联商户客户端
+func (p *Provider) getClient(config map[string]interface{}) (*client, error) {
+	merID, ok := config["mer_id"].(string)
+	if !ok {
+		return nil, apperrors.New(apperrors.ErrConfigNotFound, "mer_id not found in config")
+	}
+
+	privateKey, ok := config["private_key"].(string)
+	if !ok {
+		return nil, apperrors.New(apperrors.ErrConfigNotFound, "private_key not found in config")
+	}
+
+	signCert, ok := config["sign_cert"].(string)
+	if !ok {
+		return nil, apperrors.New(apperrors.ErrConfigNotFound, "sign_cert not found in config")
+	}
+
+	rootCert, ok := config["root_cert"].(string)
+	if !ok {
+		return nil, apperrors.New(apperrors.ErrConfigNotFound, "root_cert not found in config")
+	}
+
+	middleCert, ok := config["middle_cert"].(string)
+	if !ok {
+		return nil, apperrors.New(apperrors.ErrConfigNotFound, "middle_cert not found in config")
+	}
+
+	isProduction, _ := config["is_production"].(bool)
+
+	s, err := newSigner(privateKey, signCert, rootCert, middleCert, isProduction)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrConfigNotFound, "invalid unionpay certificate config", err)
+	}
+
+	gateway := sandboxGateway
+	if isProduction {
+		gateway = productionGateway
+	}
+
+	return &client{
+		merID:   merID,
+		gateway: gateway,
+		signer:  s,
+	}, nil
+}
+
+// convertStatus 转换交易应答码为支付状态
+func convertStatus(respCode string) string {
+	switch respCode {
+	case "00", "A6":
+		return payment.StatusSuccess
+	case "03", "04", "05":
+		return payment.StatusPending
+	default:
+		return payment.StatusFailed
+	}
+}
+
+// isAccepted 后台交易是否已受理，03/04/05 表示处理中，结果以通知为准
+func isAccepted(respCode string) bool {
+	switch respCode {
+	case "00", "03", "04", "05":
+		return true
+	default:
+		return false
+	}
+}
+
+// buildForm 生成自动提交到银联网关的表单
+func buildForm(action string, params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(`<form id="unionpay_form" action="` + html.EscapeString(action) + `" method="post">`)
+	for _, key := range keys {
+		b.WriteString(`<input type="hidden" name="` + html.EscapeString(key) + `" value="` + html.EscapeString(params[key]) + `"/>`)
+	}
+	b.WriteString(`</form><script>document.getElementById("unionpay_form").submit();</script>`)
+	return b.String()
+}
+
+// getCurrencyCode 获取ISO 4217数字货币代码
+func getCurrencyCode(currency string) (string, error) {
+	if currency == "" || strings.EqualFold(currency, "CNY") {
+		return "156", nil
+	}
+	return "", apperrors.New(apperrors.ErrInvalidParam, fmt.Sprintf("unionpay does not support currency %s", currency))
+}
+
+// toFen 将金额转换为分
+func toFen(amount float64) string {
+	return strconv.FormatInt(int64(math.Round(amount*100)), 10)
+}
+
+// fromFen 将分转换为金额
+func fromFen(amount string) float64 {
+	fen, _ := strconv.ParseInt(amount, 10, 64)
+	return float64(fen) / 100
+}
+
+// init 注册支付提供商
+func init() {
+	payment.Register(NewProvider())
+}
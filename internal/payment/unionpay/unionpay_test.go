@@ -0,0 +1,77 @@
+package unionpay
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+)
+
+// testConfig 使用 testdata 中证书的银联配置
+func testConfig(t *testing.T) map[string]interface{} {
+	return map[string]interface{}{
+		"mer_id":        "777290058110048",
+		"private_key":   readFixture(t, "merchant.key"),
+		"sign_cert":     readFixture(t, "merchant.crt"),
+		"root_cert":     readFixture(t, "root.crt"),
+		"middle_cert":   readFixture(t, "middle.crt"),
+		"is_production": true,
+	}
+}
+
+// TestSign_ConsumeRequest 测试商户签名与 testdata 中的消费请求报文一致
+func TestSign_ConsumeRequest(t *testing.T) {
+	c, err := NewProvider().getClient(testConfig(t))
+	require.NoError(t, err)
+	assert.Equal(t, "69629715588", c.signer.certID)
+
+	values, err := url.ParseQuery(readFixture(t, "consume_request.txt"))
+	require.NoError(t, err)
+
+	params := make(map[string]string, len(values))
+	for key := range values {
+		params[key] = values.Get(key)
+	}
+
+	signature, err := c.signer.Sign(params)
+	require.NoError(t, err)
+	assert.Equal(t, params["signature"], signature)
+}
+
+// TestHandleNotify 测试银联后台通知验签
+func TestHandleNotify(t *testing.T) {
+	resp, err := NewProvider().HandleNotify(context.Background(), &payment.NotifyRequest{
+		RawData: []byte(readFixture(t, "notify.txt")),
+		Config:  testConfig(t),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "ORDER20240101120000", resp.OutTradeNo)
+	assert.Equal(t, "622401011200001234567", resp.TradeNo)
+	assert.Equal(t, payment.StatusSuccess, resp.Status)
+	assert.Equal(t, 100.00, resp.Amount)
+	assert.Equal(t, "ok", string(resp.ReturnData))
+}
+
+// TestHandleNotify_Tampered 测试篡改金额后验签失败
+func TestHandleNotify_Tampered(t *testing.T) {
+	rawData := strings.Replace(readFixture(t, "notify.txt"), "txnAmt=10000", "txnAmt=1", 1)
+
+	_, err := NewProvider().HandleNotify(context.Background(), &payment.NotifyRequest{
+		RawData: []byte(rawData),
+		Config:  testConfig(t),
+	})
+	assert.Error(t, err)
+}
+
+// readFixture 读取测试数据
+func readFixture(t *testing.T, name string) string {
+	data, err := os.ReadFile("testdata/" + name)
+	require.NoError(t, err)
+	return string(data)
+}
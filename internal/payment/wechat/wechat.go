@@ -5,21 +5,37 @@ import (
 	"context"
 	"crypto/rsa"
 	"crypto/x509"
+	"encoding/json"
 	"encoding/pem"
 	"fmt"
+	"math"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/wechatpay-apiv3/wechatpay-go/core"
 	"github.com/wechatpay-apiv3/wechatpay-go/core/notify"
 	"github.com/wechatpay-apiv3/wechatpay-go/core/option"
 	"github.com/wechatpay-apiv3/wechatpay-go/services/payments"
+	"github.com/wechatpay-apiv3/wechatpay-go/services/payments/app"
+	"github.com/wechatpay-apiv3/wechatpay-go/services/payments/h5"
+	"github.com/wechatpay-apiv3/wechatpay-go/services/payments/jsapi"
 	"github.com/wechatpay-apiv3/wechatpay-go/services/payments/native"
+	"github.com/wechatpay-apiv3/wechatpay-go/services/profitsharing"
+	"github.com/wechatpay-apiv3/wechatpay-go/services/refunddomestic"
+	"github.com/wechatpay-apiv3/wechatpay-go/services/transferbatch"
+	"github.com/wechatpay-apiv3/wechatpay-go/utils"
 	"github.com/zqdfound/go-uni-pay/internal/payment"
 	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
 )
 
+// 预支付交易会话标识（prepay_id）和二维码链接的有效期为2小时，H5支付链接的有效期为5分钟
+const (
+	prepayValidity = 2 * time.Hour
+	h5URLValidity  = 5 * time.Minute
+)
+
 // Provider 微信支付提供商
 type Provider struct{}
 
@@ -33,48 +49,287 @@ func (p *Provider) GetName() string {
 	return payment.ProviderWechat
 }
 
-// CreatePayment 创建支付（Native扫码支付）
+// CreatePayment 创建支付
+// 根据 req.Scene 选择支付方式：native（默认）/jsapi/mini_program/h5/app
 func (p *Provider) CreatePayment(ctx context.Context, req *payment.CreatePaymentRequest) (*payment.CreatePaymentResponse, error) {
 	client, mchID, err := p.getClient(req.Config)
 	if err != nil {
 		return nil, err
 	}
 
+	return p.createPayment(ctx, client, mchID, req)
+}
+
+// createPayment 按支付场景使用对应的接口创建支付
+func (p *Provider) createPayment(ctx context.Context, client *core.Client, mchID string, req *payment.CreatePaymentRequest) (*payment.CreatePaymentResponse, error) {
+	switch req.Scene {
+	case "", payment.SceneNative:
+		return p.createNativePayment(ctx, client, mchID, req)
+	case payment.SceneJSAPI, payment.SceneMiniProgram:
+		return p.createJSAPIPayment(ctx, client, mchID, req)
+	case payment.SceneH5:
+		return p.createH5Payment(ctx, client, mchID, req)
+	case payment.SceneApp:
+		return p.createAppPayment(ctx, client, mchID, req)
+	default:
+		return nil, apperrors.New(apperrors.ErrInvalidParam, fmt.Sprintf("unsupported wechat scene: %s", req.Scene))
+	}
+}
+
+// createNativePayment 创建Native扫码支付
+func (p *Provider) createNativePayment(ctx context.Context, client *core.Client, mchID string, req *payment.CreatePaymentRequest) (*payment.CreatePaymentResponse, error) {
+	appID, err := p.getAppID(req.Config, req.Scene)
+	if err != nil {
+		return nil, err
+	}
+
 	svc := native.NativeApiService{Client: client}
 
-	// 创建支付请求
-	amount := int64(req.Amount * 100) // 转换为分
 	resp, _, err := svc.Prepay(ctx, native.PrepayRequest{
-		Appid:       core.String(req.Config["app_id"].(string)),
+		Appid:       core.String(appID),
 		Mchid:       core.String(mchID),
 		Description: core.String(req.Subject),
 		OutTradeNo:  core.String(req.OutTradeNo),
 		NotifyUrl:   core.String(req.NotifyURL),
 		Amount: &native.Amount{
-			Total:    core.Int64(amount),
-			Currency: core.String("CNY"),
+			Total:    core.Int64(toFen(req.Amount)),
+			Currency: core.String(getCurrency(req.Currency)),
+		},
+		// 指定分账的订单支付后资金冻结，分账完结后解冻
+		SettleInfo: &native.SettleInfo{
+			ProfitSharing: core.Bool(req.ProfitSharing != nil),
 		},
+		Detail: nativeDetail(req.Items),
 	})
 
 	if err != nil {
 		return nil, apperrors.Wrap(apperrors.ErrPaymentCreate, "failed to create wechat payment", err)
 	}
 
+	expireTime := time.Now().Add(prepayValidity)
 	return &payment.CreatePaymentResponse{
-		QRCode:    *resp.CodeUrl,
-		PaymentID: req.OutTradeNo,
+		QRCode:     *resp.CodeUrl,
+		PaymentID:  req.OutTradeNo,
+		ExpireTime: &expireTime,
 	}, nil
 }
 
-// QueryPayment 查询支付
-func (p *Provider) QueryPayment(ctx context.Context, req *payment.QueryPaymentRequest) (*payment.QueryPaymentResponse, error) {
-	// 注意：微信支付查询需要使用对应的API，这里简化实现
-	return &payment.QueryPaymentResponse{
-		OutTradeNo: req.OutTradeNo,
-		Status:     payment.StatusPending,
+// createJSAPIPayment 创建公众号/小程序支付，返回前端调起支付所需的签名参数
+func (p *Provider) createJSAPIPayment(ctx context.Context, client *core.Client, mchID string, req *payment.CreatePaymentRequest) (*payment.CreatePaymentResponse, error) {
+	appID, err := p.getAppID(req.Config, req.Scene)
+	if err != nil {
+		return nil, err
+	}
+
+	openID := getStringParam(req.ExtraParams, "openid")
+	if openID == "" {
+		return nil, apperrors.New(apperrors.ErrInvalidParam, "openid is required for wechat jsapi/mini_program payment")
+	}
+
+	svc := jsapi.JsapiApiService{Client: client}
+
+	resp, _, err := svc.PrepayWithRequestPayment(ctx, jsapi.PrepayRequest{
+		Appid:       core.String(appID),
+		Mchid:       core.String(mchID),
+		Description: core.String(req.Subject),
+		OutTradeNo:  core.String(req.OutTradeNo),
+		NotifyUrl:   core.String(req.NotifyURL),
+		Amount: &jsapi.Amount{
+			Total:    core.Int64(toFen(req.Amount)),
+			Currency: core.String(getCurrency(req.Currency)),
+		},
+		SettleInfo: &jsapi.SettleInfo{
+			ProfitSharing: core.Bool(req.ProfitSharing != nil),
+		},
+		Detail: jsapiDetail(req.Items),
+		Payer: &jsapi.Payer{
+			Openid: core.String(openID),
+		},
+	})
+
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentCreate, "failed to create wechat jsapi payment", err)
+	}
+
+	// 前端通过 WeixinJSBridge / wx.requestPayment 直接使用以下参数
+	expireTime := time.Now().Add(prepayValidity)
+	return &payment.CreatePaymentResponse{
+		PaymentID:  req.OutTradeNo,
+		ExpireTime: &expireTime,
+		ExtraData: map[string]interface{}{
+			"prepay_id": stringValue(resp.PrepayId),
+			"appId":     stringValue(resp.Appid),
+			"timeStamp": stringValue(resp.TimeStamp),
+			"nonceStr":  stringValue(resp.NonceStr),
+			"package":   stringValue(resp.Package),
+			"signType":  stringValue(resp.SignType),
+			"paySign":   stringValue(resp.PaySign),
+		},
 	}, nil
 }
 
+// createH5Payment 创建H5支付，返回跳转链接
+func (p *Provider) createH5Payment(ctx context.Context, client *core.Client, mchID string, req *payment.CreatePaymentRequest) (*payment.CreatePaymentResponse, error) {
+	appID, err := p.getAppID(req.Config, req.Scene)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ClientIP == "" {
+		return nil, apperrors.New(apperrors.ErrInvalidParam, "client ip is required for wechat h5 payment")
+	}
+
+	// 场景类型：iOS/Android/Wap，默认 Wap
+	h5Type := getStringParam(req.ExtraParams, "h5_type")
+	if h5Type == "" {
+		h5Type = "Wap"
+	}
+
+	h5Info := &h5.H5Info{
+		Type: core.String(h5Type),
+	}
+	if appName := getStringParam(req.ExtraParams, "app_name"); appName != "" {
+		h5Info.AppName = core.String(appName)
+	}
+	if appURL := getStringParam(req.ExtraParams, "app_url"); appURL != "" {
+		h5Info.AppUrl = core.String(appURL)
+	}
+	if bundleID := getStringParam(req.ExtraParams, "bundle_id"); bundleID != "" {
+		h5Info.BundleId = core.String(bundleID)
+	}
+	if packageName := getStringParam(req.ExtraParams, "package_name"); packageName != "" {
+		h5Info.PackageName = core.String(packageName)
+	}
+
+	svc := h5.H5ApiService{Client: client}
+
+	resp, _, err := svc.Prepay(ctx, h5.PrepayRequest{
+		Appid:       core.String(appID),
+		Mchid:       core.String(mchID),
+		Description: core.String(req.Subject),
+		OutTradeNo:  core.String(req.OutTradeNo),
+		NotifyUrl:   core.String(req.NotifyURL),
+		Amount: &h5.Amount{
+			Total:    core.Int64(toFen(req.Amount)),
+			Currency: core.String(getCurrency(req.Currency)),
+		},
+		SettleInfo: &h5.SettleInfo{
+			ProfitSharing: core.Bool(req.ProfitSharing != nil),
+		},
+		Detail: h5Detail(req.Items),
+		SceneInfo: &h5.SceneInfo{
+			PayerClientIp: core.String(req.ClientIP),
+			H5Info:        h5Info,
+		},
+	})
+
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentCreate, "failed to create wechat h5 payment", err)
+	}
+
+	paymentURL := stringValue(resp.H5Url)
+	// 支付完成后的跳转地址需要拼接在 h5_url 上
+	if req.ReturnURL != "" {
+		paymentURL = paymentURL + "&redirect_url=" + url.QueryEscape(req.ReturnURL)
+	}
+
+	expireTime := time.Now().Add(h5URLValidity)
+	return &payment.CreatePaymentResponse{
+		PaymentURL: paymentURL,
+		PaymentID:  req.OutTradeNo,
+		ExpireTime: &expireTime,
+	}, nil
+}
+
+// createAppPayment 创建App支付，返回App调起支付所需的签名参数
+func (p *Provider) createAppPayment(ctx context.Context, client *core.Client, mchID string, req *payment.CreatePaymentRequest) (*payment.CreatePaymentResponse, error) {
+	appID, err := p.getAppID(req.Config, req.Scene)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := app.AppApiService{Client: client}
+
+	resp, _, err := svc.PrepayWithRequestPayment(ctx, app.PrepayRequest{
+		Appid:       core.String(appID),
+		Mchid:       core.String(mchID),
+		Description: core.String(req.Subject),
+		OutTradeNo:  core.String(req.OutTradeNo),
+		NotifyUrl:   core.String(req.NotifyURL),
+		Amount: &app.Amount{
+			Total:    core.Int64(toFen(req.Amount)),
+			Currency: core.String(getCurrency(req.Currency)),
+		},
+		SettleInfo: &app.SettleInfo{
+			ProfitSharing: core.Bool(req.ProfitSharing != nil),
+		},
+		Detail: appDetail(req.Items),
+	})
+
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentCreate, "failed to create wechat app payment", err)
+	}
+
+	expireTime := time.Now().Add(prepayValidity)
+	return &payment.CreatePaymentResponse{
+		PaymentID:  req.OutTradeNo,
+		ExpireTime: &expireTime,
+		ExtraData: map[string]interface{}{
+			"appid":     appID,
+			"partnerid": stringValue(resp.PartnerId),
+			"prepayid":  stringValue(resp.PrepayId),
+			"package":   stringValue(resp.Package),
+			"noncestr":  stringValue(resp.NonceStr),
+			"timestamp": stringValue(resp.TimeStamp),
+			"sign":      stringValue(resp.Sign),
+		},
+	}, nil
+}
+
+// AccountID 返回配置中的商户号，未配置 api_v3_key 时不能解密通知，返回空
+func (p *Provider) AccountID(config map[string]interface{}) string {
+	if apiV3Key, _ := config["api_v3_key"].(string); apiV3Key == "" {
+		return ""
+	}
+	mchID, _ := config["mch_id"].(string)
+	return mchID
+}
+
+// NotifyAccountID 通知资源加密，解密前不能读取商户号，由 MatchNotify 逐个配置识别
+func (p *Provider) NotifyAccountID(req *payment.NotifyRequest) string {
+	return ""
+}
+
+// MatchNotify 使用配置的 APIv3 密钥解密通知资源，解密成功且资源中的商户号与配置一致时匹配
+// AES-GCM 解密会校验认证标签，密钥不对时解密失败
+func (p *Provider) MatchNotify(req *payment.NotifyRequest, config map[string]interface{}) bool {
+	var n struct {
+		Resource struct {
+			AssociatedData string `json:"associated_data"`
+			Nonce          string `json:"nonce"`
+			Ciphertext     string `json:"ciphertext"`
+		} `json:"resource"`
+	}
+	if err := json.Unmarshal(req.RawData, &n); err != nil || n.Resource.Ciphertext == "" {
+		return false
+	}
+
+	apiV3Key, _ := config["api_v3_key"].(string)
+	plaintext, err := utils.DecryptAES256GCM(apiV3Key, n.Resource.AssociatedData, n.Resource.Nonce, n.Resource.Ciphertext)
+	if err != nil {
+		return false
+	}
+
+	// 支付、退款、转账等通知资源都带有商户号 mchid
+	var resource struct {
+		MchID string `json:"mchid"`
+	}
+	if err := json.Unmarshal([]byte(plaintext), &resource); err != nil {
+		return false
+	}
+	return resource.MchID != "" && resource.MchID == p.AccountID(config)
+}
+
 // HandleNotify 处理支付通知
 func (p *Provider) HandleNotify(ctx context.Context, req *payment.NotifyRequest) (*payment.NotifyResponse, error) {
 	// 获取配置
@@ -104,9 +359,9 @@ func (p *Provider) HandleNotify(ctx context.Context, req *payment.NotifyRequest)
 	httpReq.Header.Set("Wechatpay-Signature", signature)
 	httpReq.Header.Set("Wechatpay-Serial", serial)
 
-	// 解析并验证通知
-	transaction := new(payments.Transaction)
-	_, err = handler.ParseNotifyRequest(ctx, httpReq, transaction)
+	// 解析并验证通知，支付通知和退款通知的资源结构不同，按事件类型解析
+	var plaintext json.RawMessage
+	notifyReq, err := handler.ParseNotifyRequest(ctx, httpReq, &plaintext)
 	if err != nil {
 		return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to parse notify request", err)
 	}
@@ -116,6 +371,64 @@ func (p *Provider) HandleNotify(ctx context.Context, req *payment.NotifyRequest)
 		ReturnData: []byte(`{"code": "SUCCESS", "message": "成功"}`),
 	}
 
+	// 退款通知：REFUND.SUCCESS/REFUND.ABNORMAL/REFUND.CLOSED
+	if strings.HasPrefix(notifyReq.EventType, "REFUND.") {
+		refund := new(refundNotify)
+		if err := json.Unmarshal(plaintext, refund); err != nil {
+			return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to parse refund notify", err)
+		}
+
+		response.Event = payment.EventRefund
+		response.OutTradeNo = refund.OutTradeNo
+		response.TradeNo = refund.TransactionID
+		response.Refund = &payment.RefundInfo{
+			RefundNo: refund.OutRefundNo,
+			RefundID: refund.RefundID,
+			Amount:   float64(refund.Amount.Refund) / 100,
+			Status:   p.convertRefundStatus(refund.RefundStatus),
+		}
+		if successTime, err := time.Parse(time.RFC3339, refund.SuccessTime); err == nil {
+			response.Refund.RefundTime = successTime.Format("2006-01-02 15:04:05")
+		}
+
+		return response, nil
+	}
+
+	// 商家转账批次通知：MCHTRANSFER.BATCH.FINISHED/MCHTRANSFER.BATCH.CLOSED
+	if strings.HasPrefix(notifyReq.EventType, "MCHTRANSFER.") {
+		batch := new(transferBatchNotify)
+		if err := json.Unmarshal(plaintext, batch); err != nil {
+			return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to parse transfer batch notify", err)
+		}
+
+		// 每个付款单对应一个只有一笔明细的批次，按成功、失败笔数判断明细结果
+		status := payment.PayoutStatusProcessing
+		switch {
+		case batch.BatchStatus == "CLOSED" || batch.FailNum > 0:
+			status = payment.PayoutStatusFailed
+		case batch.BatchStatus == "FINISHED" && batch.SuccessNum > 0:
+			status = payment.PayoutStatusSuccess
+		}
+
+		response.Event = payment.EventPayout
+		response.Payout = &payment.PayoutInfo{
+			PayoutNo:   batch.OutBatchNo,
+			PayoutID:   batch.BatchID,
+			Status:     status,
+			FailReason: batch.CloseReason,
+		}
+		if updateTime, err := time.Parse(time.RFC3339, batch.UpdateTime); err == nil && status == payment.PayoutStatusSuccess {
+			response.Payout.FinishTime = updateTime.Format("2006-01-02 15:04:05")
+		}
+
+		return response, nil
+	}
+
+	transaction := new(payments.Transaction)
+	if err := json.Unmarshal(plaintext, transaction); err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentNotify, "failed to parse transaction notify", err)
+	}
+
 	// 提取支付信息
 	if transaction.OutTradeNo != nil {
 		response.OutTradeNo = *transaction.OutTradeNo
@@ -151,6 +464,456 @@ func (p *Provider) HandleNotify(ctx context.Context, req *payment.NotifyRequest)
 	return response, nil
 }
 
+// refundNotify 退款通知解密后的资源
+type refundNotify struct {
+	OutTradeNo    string `json:"out_trade_no"`
+	TransactionID string `json:"transaction_id"`
+	OutRefundNo   string `json:"out_refund_no"`
+	RefundID      string `json:"refund_id"`
+	RefundStatus  string `json:"refund_status"`
+	SuccessTime   string `json:"success_time"`
+	Amount        struct {
+		Total  int64 `json:"total"`
+		Refund int64 `json:"refund"`
+	} `json:"amount"`
+}
+
+// RefundPayment 退款，退款结果通过退款通知或主动查询获取
+func (p *Provider) RefundPayment(ctx context.Context, req *payment.RefundRequest) (*payment.RefundResponse, error) {
+	client, _, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	refundReq := refunddomestic.CreateRequest{
+		OutTradeNo:  core.String(req.OutTradeNo),
+		OutRefundNo: core.String(req.RefundNo),
+		Amount: &refunddomestic.AmountReq{
+			Refund:   core.Int64(toFen(req.RefundAmount)),
+			Total:    core.Int64(toFen(req.TotalAmount)),
+			Currency: core.String(getCurrency(req.Currency)),
+		},
+	}
+	if req.Reason != "" {
+		refundReq.Reason = core.String(req.Reason)
+	}
+	if req.NotifyURL != "" {
+		refundReq.NotifyUrl = core.String(req.NotifyURL)
+	}
+
+	svc := refunddomestic.RefundsApiService{Client: client}
+	resp, _, err := svc.Create(ctx, refundReq)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentRefund, "failed to refund wechat payment", err)
+	}
+
+	return &payment.RefundResponse{
+		RefundNo: req.RefundNo,
+		RefundID: stringValue(resp.RefundId),
+		TradeNo:  stringValue(resp.TransactionId),
+		Status:   p.convertRefundStatus(refundStatusValue(resp.Status)),
+	}, nil
+}
+
+// QueryRefund 按退款单号查询退款
+func (p *Provider) QueryRefund(ctx context.Context, req *payment.QueryRefundRequest) (*payment.QueryRefundResponse, error) {
+	client, _, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := refunddomestic.RefundsApiService{Client: client}
+	resp, _, err := svc.QueryByOutRefundNo(ctx, refunddomestic.QueryByOutRefundNoRequest{
+		OutRefundNo: core.String(req.RefundNo),
+	})
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentQuery, "failed to query wechat refund", err)
+	}
+
+	response := &payment.QueryRefundResponse{
+		RefundNo: stringValue(resp.OutRefundNo),
+		RefundID: stringValue(resp.RefundId),
+		Status:   p.convertRefundStatus(refundStatusValue(resp.Status)),
+	}
+	if resp.Amount != nil && resp.Amount.Refund != nil {
+		response.Amount = float64(*resp.Amount.Refund) / 100
+	}
+	if resp.SuccessTime != nil {
+		response.RefundTime = resp.SuccessTime.Format("2006-01-02 15:04:05")
+	}
+
+	return response, nil
+}
+
+// convertRefundStatus 转换微信退款状态，退款异常（ABNORMAL）需商户在商户平台处理，按失败处理
+func (p *Provider) convertRefundStatus(status string) string {
+	switch status {
+	case "SUCCESS":
+		return payment.RefundStatusSuccess
+	case "CLOSED", "ABNORMAL":
+		return payment.RefundStatusFailed
+	default:
+		return payment.RefundStatusProcessing
+	}
+}
+
+// refundStatusValue 获取退款状态指针的值
+func refundStatusValue(status *refunddomestic.Status) string {
+	if status == nil {
+		return ""
+	}
+	return string(*status)
+}
+
+// transferBatchNotify 商家转账批次通知解密后的资源
+type transferBatchNotify struct {
+	OutBatchNo  string `json:"out_batch_no"`
+	BatchID     string `json:"batch_id"`
+	BatchStatus string `json:"batch_status"`
+	CloseReason string `json:"close_reason"`
+	SuccessNum  int64  `json:"success_num"`
+	FailNum     int64  `json:"fail_num"`
+	UpdateTime  string `json:"update_time"`
+}
+
+// CreatePayout 商家转账到零钱，每个付款单发起一个只有一笔明细的转账批次
+// 批次单号和明细单号均使用付款单号；转账结果通知地址需在商户平台配置
+func (p *Provider) CreatePayout(ctx context.Context, req *payment.PayoutRequest) (*payment.PayoutResponse, error) {
+	if req.PayeeType != "" && req.PayeeType != payment.PayeeTypeOpenID {
+		return nil, apperrors.New(apperrors.ErrInvalidParam, fmt.Sprintf("unsupported wechat payee type: %s", req.PayeeType))
+	}
+
+	client, _, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	appID, err := p.getAppID(req.Config, "")
+	if err != nil {
+		return nil, err
+	}
+
+	remark := req.Remark
+	if remark == "" {
+		remark = "付款"
+	}
+
+	detail := transferbatch.TransferDetailInput{
+		OutDetailNo:    core.String(req.PayoutNo),
+		TransferAmount: core.Int64(toFen(req.Amount)),
+		TransferRemark: core.String(remark),
+		Openid:         core.String(req.PayeeAccount),
+	}
+	if req.PayeeName != "" {
+		// 收款方姓名由 SDK 使用微信支付平台证书加密
+		detail.UserName = core.String(req.PayeeName)
+	}
+
+	batchReq := transferbatch.InitiateBatchTransferRequest{
+		Appid:              core.String(appID),
+		OutBatchNo:         core.String(req.PayoutNo),
+		BatchName:          core.String(remark),
+		BatchRemark:        core.String(remark),
+		TotalAmount:        core.Int64(toFen(req.Amount)),
+		TotalNum:           core.Int64(1),
+		TransferDetailList: []transferbatch.TransferDetailInput{detail},
+	}
+	if sceneID := getStringParam(req.Config, "transfer_scene_id"); sceneID != "" {
+		batchReq.TransferSceneId = core.String(sceneID)
+	}
+
+	svc := transferbatch.TransferBatchApiService{Client: client}
+	resp, _, err := svc.InitiateBatchTransfer(ctx, batchReq)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPayout, "failed to create wechat transfer", err)
+	}
+
+	return &payment.PayoutResponse{
+		PayoutNo: stringValue(resp.OutBatchNo),
+		PayoutID: stringValue(resp.BatchId),
+		Status:   payment.PayoutStatusProcessing,
+	}, nil
+}
+
+// QueryPayout 按批次单号查询转账，批次完成后以明细状态为准
+func (p *Provider) QueryPayout(ctx context.Context, req *payment.QueryPayoutRequest) (*payment.PayoutResponse, error) {
+	client, _, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	batchSvc := transferbatch.TransferBatchApiService{Client: client}
+	batch, _, err := batchSvc.GetTransferBatchByOutNo(ctx, transferbatch.GetTransferBatchByOutNoRequest{
+		OutBatchNo:      core.String(req.PayoutNo),
+		NeedQueryDetail: core.Bool(true),
+		DetailStatus:    core.String("ALL"),
+	})
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentQuery, "failed to query wechat transfer batch", err)
+	}
+
+	response := &payment.PayoutResponse{
+		PayoutNo: req.PayoutNo,
+		PayoutID: req.PayoutID,
+		Status:   payment.PayoutStatusProcessing,
+	}
+	if batch.TransferBatch == nil {
+		return response, nil
+	}
+
+	response.PayoutID = stringValue(batch.TransferBatch.BatchId)
+	batchStatus := stringValue(batch.TransferBatch.BatchStatus)
+	if batchStatus == "CLOSED" {
+		response.Status = payment.PayoutStatusFailed
+		if batch.TransferBatch.CloseReason != nil {
+			response.FailReason = string(*batch.TransferBatch.CloseReason)
+		}
+		return response, nil
+	}
+
+	if batchStatus != "FINISHED" || len(batch.TransferDetailList) == 0 {
+		return response, nil
+	}
+
+	switch stringValue(batch.TransferDetailList[0].DetailStatus) {
+	case "SUCCESS":
+		response.Status = payment.PayoutStatusSuccess
+		if batch.TransferBatch.UpdateTime != nil {
+			response.FinishTime = batch.TransferBatch.UpdateTime.Format("2006-01-02 15:04:05")
+		}
+	case "FAIL":
+		response.Status = payment.PayoutStatusFailed
+		detailSvc := transferbatch.TransferDetailApiService{Client: client}
+		detail, _, err := detailSvc.GetTransferDetailByOutNo(ctx, transferbatch.GetTransferDetailByOutNoRequest{
+			OutBatchNo:  core.String(req.PayoutNo),
+			OutDetailNo: core.String(req.PayoutNo),
+		})
+		if err == nil && detail.FailReason != nil {
+			response.FailReason = string(*detail.FailReason)
+		}
+	}
+
+	return response, nil
+}
+
+// ShareProfit 请求分账，先添加分账接收方再创建分账单
+// 分账单号作为商户分账单号；完结分账时剩余冻结资金解冻给商户
+func (p *Provider) ShareProfit(ctx context.Context, req *payment.ProfitSharingRequest) (*payment.ProfitSharingResponse, error) {
+	client, mchID, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	appID, err := p.getAppID(req.Config, "")
+	if err != nil {
+		return nil, err
+	}
+
+	receiverSvc := profitsharing.ReceiversApiService{Client: client}
+	receivers := make([]profitsharing.CreateOrderReceiver, 0, len(req.Receivers))
+	for _, r := range req.Receivers {
+		receiverType, err := p.receiverType(r.Type)
+		if err != nil {
+			return nil, err
+		}
+
+		addReq := profitsharing.AddReceiverRequest{
+			Appid:        core.String(appID),
+			Type:         receiverType.Ptr(),
+			Account:      core.String(r.Account),
+			RelationType: profitsharing.RECEIVERRELATIONTYPE_PARTNER.Ptr(),
+		}
+		if r.Name != "" {
+			// 接收方名称由 SDK 使用微信支付平台证书加密
+			addReq.Name = core.String(r.Name)
+		}
+		// 重复添加接收方不会报错
+		if _, _, err := receiverSvc.AddReceiver(ctx, addReq); err != nil {
+			return nil, apperrors.Wrap(apperrors.ErrProfitSharing, "failed to add wechat profit sharing receiver", err)
+		}
+
+		description := r.Description
+		if description == "" {
+			description = "分账"
+		}
+		receivers = append(receivers, profitsharing.CreateOrderReceiver{
+			Type:        core.String(string(receiverType)),
+			Account:     core.String(r.Account),
+			Amount:      core.Int64(toFen(r.Amount)),
+			Description: core.String(description),
+		})
+	}
+
+	orderSvc := profitsharing.OrdersApiService{Client: client}
+	order, _, err := orderSvc.CreateOrder(ctx, profitsharing.CreateOrderRequest{
+		Appid:           core.String(appID),
+		TransactionId:   core.String(req.TradeNo),
+		OutOrderNo:      core.String(req.SharingNo),
+		Receivers:       receivers,
+		UnfreezeUnsplit: core.Bool(req.Finish),
+	})
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrProfitSharing, "failed to create wechat profit sharing order", err)
+	}
+
+	return p.toProfitSharingResponse(order, mchID), nil
+}
+
+// QueryProfitSharing 查询分账单
+func (p *Provider) QueryProfitSharing(ctx context.Context, req *payment.QueryProfitSharingRequest) (*payment.ProfitSharingResponse, error) {
+	client, mchID, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := profitsharing.OrdersApiService{Client: client}
+	order, _, err := svc.QueryOrder(ctx, profitsharing.QueryOrderRequest{
+		TransactionId: core.String(req.TradeNo),
+		OutOrderNo:    core.String(req.SharingNo),
+	})
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentQuery, "failed to query wechat profit sharing order", err)
+	}
+
+	return p.toProfitSharingResponse(order, mchID), nil
+}
+
+// ReturnProfitSharing 请求分账回退，只有商户类型的接收方支持回退
+func (p *Provider) ReturnProfitSharing(ctx context.Context, req *payment.ProfitSharingReturnRequest) (*payment.ProfitSharingReturnResponse, error) {
+	if req.AccountType != payment.PayeeTypeAccount {
+		return nil, apperrors.New(apperrors.ErrNotSupported, "wechat profit sharing return only supports merchant receivers")
+	}
+
+	client, _, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	description := req.Description
+	if description == "" {
+		description = "分账回退"
+	}
+
+	svc := profitsharing.ReturnOrdersApiService{Client: client}
+	resp, _, err := svc.CreateReturnOrder(ctx, profitsharing.CreateReturnOrderRequest{
+		OutOrderNo:  core.String(req.SharingNo),
+		OutReturnNo: core.String(req.ReturnNo),
+		ReturnMchid: core.String(req.Account),
+		Amount:      core.Int64(toFen(req.Amount)),
+		Description: core.String(description),
+	})
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrProfitSharing, "failed to create wechat profit sharing return order", err)
+	}
+
+	return p.toProfitSharingReturnResponse(resp), nil
+}
+
+// QueryProfitSharingReturn 查询分账回退单
+func (p *Provider) QueryProfitSharingReturn(ctx context.Context, req *payment.QueryProfitSharingReturnRequest) (*payment.ProfitSharingReturnResponse, error) {
+	client, _, err := p.getClient(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := profitsharing.ReturnOrdersApiService{Client: client}
+	resp, _, err := svc.QueryReturnOrder(ctx, profitsharing.QueryReturnOrderRequest{
+		OutOrderNo:  core.String(req.SharingNo),
+		OutReturnNo: core.String(req.ReturnNo),
+	})
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPaymentQuery, "failed to query wechat profit sharing return order", err)
+	}
+
+	return p.toProfitSharingReturnResponse(resp), nil
+}
+
+// receiverType 转换分账接收方类型，默认为商户号
+func (p *Provider) receiverType(accountType string) (profitsharing.ReceiverType, error) {
+	switch accountType {
+	case "", payment.PayeeTypeAccount:
+		return profitsharing.RECEIVERTYPE_MERCHANT_ID, nil
+	case payment.PayeeTypeOpenID:
+		return profitsharing.RECEIVERTYPE_PERSONAL_OPENID, nil
+	default:
+		return "", apperrors.New(apperrors.ErrInvalidParam, fmt.Sprintf("unsupported wechat receiver type: %s", accountType))
+	}
+}
+
+// toProfitSharingResponse 转换分账单结果
+// 完结分账时微信会返回一条解冻给本商户的明细，不计入接收方结果
+func (p *Provider) toProfitSharingResponse(order *profitsharing.OrdersEntity, mchID string) *payment.ProfitSharingResponse {
+	response := &payment.ProfitSharingResponse{
+		SharingNo: stringValue(order.OutOrderNo),
+		SharingID: stringValue(order.OrderId),
+		Status:    payment.ProfitSharingStatusProcessing,
+	}
+
+	finished := order.State != nil && *order.State == profitsharing.ORDERSTATUS_FINISHED
+	failed := false
+	for _, r := range order.Receivers {
+		account := stringValue(r.Account)
+		if account == mchID {
+			continue
+		}
+
+		result := payment.ProfitSharingResult{
+			Account:  account,
+			DetailID: stringValue(r.DetailId),
+			Status:   payment.ProfitSharingStatusProcessing,
+		}
+		if r.Amount != nil {
+			result.Amount = float64(*r.Amount) / 100
+		}
+		if r.Result != nil {
+			switch *r.Result {
+			case profitsharing.DETAILSTATUS_SUCCESS:
+				result.Status = payment.ProfitSharingStatusSuccess
+			case profitsharing.DETAILSTATUS_CLOSED:
+				result.Status = payment.ProfitSharingStatusFailed
+				failed = true
+			}
+		}
+		if r.FailReason != nil {
+			result.FailReason = string(*r.FailReason)
+		}
+		response.Receivers = append(response.Receivers, result)
+	}
+
+	if finished {
+		response.Status = payment.ProfitSharingStatusSuccess
+		if failed {
+			response.Status = payment.ProfitSharingStatusFailed
+		}
+	}
+
+	return response
+}
+
+// toProfitSharingReturnResponse 转换分账回退单结果
+func (p *Provider) toProfitSharingReturnResponse(r *profitsharing.ReturnOrdersEntity) *payment.ProfitSharingReturnResponse {
+	response := &payment.ProfitSharingReturnResponse{
+		ReturnNo: stringValue(r.OutReturnNo),
+		ReturnID: stringValue(r.ReturnId),
+		Status:   payment.ProfitSharingStatusProcessing,
+	}
+	if r.Result != nil {
+		switch *r.Result {
+		case profitsharing.RETURNORDERSTATUS_SUCCESS:
+			response.Status = payment.ProfitSharingStatusSuccess
+		case profitsharing.RETURNORDERSTATUS_FAILED:
+			response.Status = payment.ProfitSharingStatusFailed
+		}
+	}
+	if r.FailReason != nil {
+		response.FailReason = string(*r.FailReason)
+	}
+	if r.FinishTime != nil {
+		response.FinishTime = r.FinishTime.Format("2006-01-02 15:04:05")
+	}
+	return response
+}
+
 // convertTradeState 转换微信支付交易状态
 func (p *Provider) convertTradeState(tradeState *string) string {
 	if tradeState == nil {
@@ -177,17 +940,29 @@ func (p *Provider) convertTradeState(tradeState *string) string {
 	}
 }
 
-// RefundPayment 退款
-func (p *Provider) RefundPayment(ctx context.Context, req *payment.RefundRequest) (*payment.RefundResponse, error) {
-	return &payment.RefundResponse{
-		RefundNo: req.RefundNo,
-		Status:   "success",
-	}, nil
-}
+// getAppID 根据支付场景获取AppID
+// 小程序和App支付使用各自的AppID，未配置时回退到 app_id
+func (p *Provider) getAppID(config map[string]interface{}, scene string) (string, error) {
+	var key string
+	switch scene {
+	case payment.SceneMiniProgram:
+		key = "mini_program_app_id"
+	case payment.SceneApp:
+		key = "open_app_id"
+	}
+
+	if key != "" {
+		if appID, ok := config[key].(string); ok && appID != "" {
+			return appID, nil
+		}
+	}
+
+	appID, ok := config["app_id"].(string)
+	if !ok || appID == "" {
+		return "", apperrors.New(apperrors.ErrConfigNotFound, "app_id not found in config")
+	}
 
-// ClosePayment 关闭支付
-func (p *Provider) ClosePayment(ctx context.Context, req *payment.ClosePaymentRequest) error {
-	return nil
+	return appID, nil
 }
 
 // getClient 获取微信支付客户端
@@ -246,6 +1021,98 @@ func parsePrivateKey(privateKeyStr string) (*rsa.PrivateKey, error) {
 	return privateKey.(*rsa.PrivateKey), nil
 }
 
+// toFen 将金额转换为分
+func toFen(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// goodsDetail 转换商品明细，微信支付的商品单价包含税额，单位为分，商品编码为空时使用商品名称
+// 各支付方式的商品明细结构相同，可直接转换为对应包的类型
+func goodsDetail(items []payment.Item) []native.GoodsDetail {
+	goods := make([]native.GoodsDetail, 0, len(items))
+	for _, item := range items {
+		goodsID := item.SKU
+		if goodsID == "" {
+			goodsID = item.Name
+		}
+		goods = append(goods, native.GoodsDetail{
+			MerchantGoodsId: core.String(goodsID),
+			GoodsName:       core.String(item.Name),
+			Quantity:        core.Int64(item.Quantity),
+			UnitPrice:       core.Int64(toFen(item.UnitPrice + item.Tax)),
+		})
+	}
+	return goods
+}
+
+// nativeDetail Native支付的商品明细
+func nativeDetail(items []payment.Item) *native.Detail {
+	if len(items) == 0 {
+		return nil
+	}
+	return &native.Detail{GoodsDetail: goodsDetail(items)}
+}
+
+// jsapiDetail JSAPI支付的商品明细
+func jsapiDetail(items []payment.Item) *jsapi.Detail {
+	if len(items) == 0 {
+		return nil
+	}
+	detail := &jsapi.Detail{}
+	for _, goods := range goodsDetail(items) {
+		detail.GoodsDetail = append(detail.GoodsDetail, jsapi.GoodsDetail(goods))
+	}
+	return detail
+}
+
+// h5Detail H5支付的商品明细
+func h5Detail(items []payment.Item) *h5.Detail {
+	if len(items) == 0 {
+		return nil
+	}
+	detail := &h5.Detail{}
+	for _, goods := range goodsDetail(items) {
+		detail.GoodsDetail = append(detail.GoodsDetail, h5.GoodsDetail(goods))
+	}
+	return detail
+}
+
+// appDetail App支付的商品明细
+func appDetail(items []payment.Item) *app.Detail {
+	if len(items) == 0 {
+		return nil
+	}
+	detail := &app.Detail{}
+	for _, goods := range goodsDetail(items) {
+		detail.GoodsDetail = append(detail.GoodsDetail, app.GoodsDetail(goods))
+	}
+	return detail
+}
+
+// getCurrency 获取货币类型，默认人民币
+func getCurrency(currency string) string {
+	if currency == "" {
+		return "CNY"
+	}
+	return currency
+}
+
+// stringValue 获取字符串指针的值
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// getStringParam 从额外参数中获取字符串值
+func getStringParam(params map[string]interface{}, key string) string {
+	if v, ok := params[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
 // getFirstValue 获取第一个值
 func getFirstValue(values url.Values, key string) string {
 	if values == nil {
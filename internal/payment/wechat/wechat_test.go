@@ -0,0 +1,342 @@
+package wechat
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wechatpay-apiv3/wechatpay-go/core"
+	"github.com/wechatpay-apiv3/wechatpay-go/core/option"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+)
+
+const testAPIV3Key = "0123456789abcdef0123456789abcdef"
+
+// encryptNotify 按微信支付 V3 通知格式，使用 APIv3 密钥加密通知资源
+func encryptNotify(t *testing.T, apiV3Key string, resource map[string]interface{}) []byte {
+	t.Helper()
+
+	plaintext, err := json.Marshal(resource)
+	require.NoError(t, err)
+
+	block, err := aes.NewCipher([]byte(apiV3Key))
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+
+	nonce := "abcdefghijkl"
+	ciphertext := gcm.Seal(nil, []byte(nonce), plaintext, []byte("transaction"))
+
+	body, err := json.Marshal(map[string]interface{}{
+		"id":            "EV-2018022511223320873",
+		"event_type":    "TRANSACTION.SUCCESS",
+		"resource_type": "encrypt-resource",
+		"resource": map[string]string{
+			"algorithm":       "AEAD_AES_256_GCM",
+			"associated_data": "transaction",
+			"nonce":           nonce,
+			"ciphertext":      base64.StdEncoding.EncodeToString(ciphertext),
+		},
+	})
+	require.NoError(t, err)
+	return body
+}
+
+// TestMatchNotify 测试使用配置的 APIv3 密钥解密通知，并按资源中的商户号识别配置
+func TestMatchNotify(t *testing.T) {
+	p := NewProvider()
+	req := &payment.NotifyRequest{RawData: encryptNotify(t, testAPIV3Key, map[string]interface{}{
+		"mchid":        "1900000001",
+		"out_trade_no": "ORDER1",
+		"trade_state":  "SUCCESS",
+	})}
+
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		want   bool
+	}{
+		{"matching key and mch_id", map[string]interface{}{"mch_id": "1900000001", "api_v3_key": testAPIV3Key}, true},
+		{"other mch_id", map[string]interface{}{"mch_id": "1900000002", "api_v3_key": testAPIV3Key}, false},
+		{"other key", map[string]interface{}{"mch_id": "1900000001", "api_v3_key": "fedcba9876543210fedcba9876543210"}, false},
+		{"missing key", map[string]interface{}{"mch_id": "1900000001"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, p.MatchNotify(req, tt.config))
+		})
+	}
+
+	assert.False(t, p.MatchNotify(&payment.NotifyRequest{RawData: []byte("not json")}, tests[0].config))
+	assert.Empty(t, p.NotifyAccountID(req))
+	assert.Equal(t, "1900000001", p.AccountID(tests[0].config))
+	assert.Empty(t, p.AccountID(tests[3].config))
+}
+
+// TestGetAppID 测试小程序和App场景优先使用对应的 AppID，未配置时使用 app_id
+func TestGetAppID(t *testing.T) {
+	full := map[string]interface{}{"app_id": "wx_mp", "mini_program_app_id": "wx_mini", "open_app_id": "wx_open"}
+	base := map[string]interface{}{"app_id": "wx_mp"}
+
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		scene  string
+		want   string
+	}{
+		{"native", full, payment.SceneNative, "wx_mp"},
+		{"default scene", full, "", "wx_mp"},
+		{"jsapi ignores mini program app id", full, payment.SceneJSAPI, "wx_mp"},
+		{"h5", full, payment.SceneH5, "wx_mp"},
+		{"mini program", full, payment.SceneMiniProgram, "wx_mini"},
+		{"mini program fallback", base, payment.SceneMiniProgram, "wx_mp"},
+		{"app", full, payment.SceneApp, "wx_open"},
+		{"app fallback", base, payment.SceneApp, "wx_mp"},
+		{"empty app id fallback", map[string]interface{}{"app_id": "wx_mp", "open_app_id": ""}, payment.SceneApp, "wx_mp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appID, err := NewProvider().getAppID(tt.config, tt.scene)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, appID)
+		})
+	}
+
+	_, err := NewProvider().getAppID(map[string]interface{}{"mini_program_app_id": "wx_mini"}, payment.SceneApp)
+	require.Error(t, err)
+	assert.Equal(t, apperrors.ErrConfigNotFound, err.(*apperrors.AppError).Code)
+}
+
+// wechatAPI 记录发往微信支付的下单请求，按接口返回预支付结果
+type wechatAPI struct {
+	path string
+	body map[string]interface{}
+}
+
+func (a *wechatAPI) RoundTrip(req *http.Request) (*http.Response, error) {
+	a.path = req.URL.Path
+	a.body = nil
+	if req.Body != nil {
+		if err := json.NewDecoder(req.Body).Decode(&a.body); err != nil {
+			return nil, err
+		}
+	}
+
+	var body string
+	switch a.path {
+	case "/v3/pay/transactions/native":
+		body = `{"code_url":"weixin://wxpay/bizpayurl?pr=abc"}`
+	case "/v3/pay/transactions/h5":
+		body = `{"h5_url":"https://wx.tenpay.com/cgi-bin/mmpayweb-bin/checkmweb?prepay_id=wx123"}`
+	default:
+		body = `{"prepay_id":"wx123"}`
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+// newTestClient 创建请求发往 api 的微信支付客户端，不校验应答签名
+func newTestClient(t *testing.T, api *wechatAPI) *core.Client {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	client, err := core.NewClient(context.Background(),
+		option.WithMerchantCredential("1900000001", "SERIAL1", key),
+		option.WithoutValidator(),
+		option.WithHTTPClient(&http.Client{Transport: api}),
+	)
+	require.NoError(t, err)
+	return client
+}
+
+// TestCreatePayment_Scenes 测试按支付场景调用对应的下单接口并映射请求参数
+func TestCreatePayment_Scenes(t *testing.T) {
+	api := &wechatAPI{}
+	client := newTestClient(t, api)
+	config := map[string]interface{}{"app_id": "wx_mp", "mini_program_app_id": "wx_mini", "open_app_id": "wx_open"}
+
+	tests := []struct {
+		name  string
+		scene string
+		extra map[string]interface{}
+		ip    string
+		path  string
+		appID string
+		check func(t *testing.T, body map[string]interface{}, resp *payment.CreatePaymentResponse)
+	}{
+		{
+			name:  "native",
+			path:  "/v3/pay/transactions/native",
+			appID: "wx_mp",
+			check: func(t *testing.T, body map[string]interface{}, resp *payment.CreatePaymentResponse) {
+				assert.Equal(t, "weixin://wxpay/bizpayurl?pr=abc", resp.QRCode)
+			},
+		},
+		{
+			name:  "jsapi",
+			scene: payment.SceneJSAPI,
+			extra: map[string]interface{}{"openid": "o_user"},
+			path:  "/v3/pay/transactions/jsapi",
+			appID: "wx_mp",
+			check: func(t *testing.T, body map[string]interface{}, resp *payment.CreatePaymentResponse) {
+				assert.Equal(t, map[string]interface{}{"openid": "o_user"}, body["payer"])
+				assert.Equal(t, "wx123", resp.ExtraData["prepay_id"])
+				assert.Equal(t, "prepay_id=wx123", resp.ExtraData["package"])
+				assert.NotEmpty(t, resp.ExtraData["paySign"])
+			},
+		},
+		{
+			name:  "mini program",
+			scene: payment.SceneMiniProgram,
+			extra: map[string]interface{}{"openid": "o_user"},
+			path:  "/v3/pay/transactions/jsapi",
+			appID: "wx_mini",
+			check: func(t *testing.T, body map[string]interface{}, resp *payment.CreatePaymentResponse) {
+				assert.Equal(t, "wx_mini", resp.ExtraData["appId"])
+			},
+		},
+		{
+			name:  "h5",
+			scene: payment.SceneH5,
+			extra: map[string]interface{}{"h5_type": "iOS", "app_name": "Shop", "app_url": "https://shop.example.com", "bundle_id": "com.example.shop"},
+			ip:    "203.0.113.5",
+			path:  "/v3/pay/transactions/h5",
+			appID: "wx_mp",
+			check: func(t *testing.T, body map[string]interface{}, resp *payment.CreatePaymentResponse) {
+				assert.Equal(t, map[string]interface{}{
+					"payer_client_ip": "203.0.113.5",
+					"h5_info": map[string]interface{}{
+						"type":      "iOS",
+						"app_name":  "Shop",
+						"app_url":   "https://shop.example.com",
+						"bundle_id": "com.example.shop",
+					},
+				}, body["scene_info"])
+				assert.Equal(t, "https://wx.tenpay.com/cgi-bin/mmpayweb-bin/checkmweb?prepay_id=wx123&redirect_url="+
+					"https%3A%2F%2Fmerchant.example.com%2Freturn", resp.PaymentURL)
+			},
+		},
+		{
+			name:  "h5 default type",
+			scene: payment.SceneH5,
+			ip:    "203.0.113.5",
+			path:  "/v3/pay/transactions/h5",
+			appID: "wx_mp",
+			check: func(t *testing.T, body map[string]interface{}, resp *payment.CreatePaymentResponse) {
+				assert.Equal(t, map[string]interface{}{
+					"payer_client_ip": "203.0.113.5",
+					"h5_info":         map[string]interface{}{"type": "Wap"},
+				}, body["scene_info"])
+			},
+		},
+		{
+			name:  "app",
+			scene: payment.SceneApp,
+			path:  "/v3/pay/transactions/app",
+			appID: "wx_open",
+			check: func(t *testing.T, body map[string]interface{}, resp *payment.CreatePaymentResponse) {
+				assert.Equal(t, "wx_open", resp.ExtraData["appid"])
+				assert.Equal(t, "wx123", resp.ExtraData["prepayid"])
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := NewProvider().createPayment(context.Background(), client, "1900000001", &payment.CreatePaymentRequest{
+				OutTradeNo:  "ORDER1",
+				Subject:     "Order",
+				Amount:      19.99,
+				Scene:       tt.scene,
+				NotifyURL:   "https://pay.example.com/notify",
+				ReturnURL:   "https://merchant.example.com/return",
+				ClientIP:    tt.ip,
+				Config:      config,
+				ExtraParams: tt.extra,
+			})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.path, api.path)
+			assert.Equal(t, tt.appID, api.body["appid"])
+			assert.Equal(t, "1900000001", api.body["mchid"])
+			assert.Equal(t, "ORDER1", api.body["out_trade_no"])
+			assert.Equal(t, map[string]interface{}{"total": float64(1999), "currency": "CNY"}, api.body["amount"])
+			assert.Equal(t, "ORDER1", resp.PaymentID)
+			require.NotNil(t, resp.ExpireTime)
+			tt.check(t, api.body, resp)
+		})
+	}
+}
+
+// TestCreatePayment_InvalidParams 测试缺少场景必需的参数或场景不支持时不调用下单接口
+func TestCreatePayment_InvalidParams(t *testing.T) {
+	api := &wechatAPI{}
+	client := newTestClient(t, api)
+
+	tests := []struct {
+		name   string
+		scene  string
+		ip     string
+		config map[string]interface{}
+		code   apperrors.ErrorCode
+	}{
+		{"jsapi without openid", payment.SceneJSAPI, "", map[string]interface{}{"app_id": "wx_mp"}, apperrors.ErrInvalidParam},
+		{"mini program without openid", payment.SceneMiniProgram, "", map[string]interface{}{"app_id": "wx_mp"}, apperrors.ErrInvalidParam},
+		{"h5 without client ip", payment.SceneH5, "", map[string]interface{}{"app_id": "wx_mp"}, apperrors.ErrInvalidParam},
+		{"unsupported scene", "pc", "", map[string]interface{}{"app_id": "wx_mp"}, apperrors.ErrInvalidParam},
+		{"missing app id", payment.SceneNative, "", map[string]interface{}{}, apperrors.ErrConfigNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api.path = ""
+			_, err := NewProvider().createPayment(context.Background(), client, "1900000001", &payment.CreatePaymentRequest{
+				OutTradeNo: "ORDER1",
+				Subject:    "Order",
+				Amount:     10,
+				Scene:      tt.scene,
+				ClientIP:   tt.ip,
+				Config:     tt.config,
+			})
+			require.Error(t, err)
+			assert.Equal(t, tt.code, err.(*apperrors.AppError).Code)
+			assert.Empty(t, api.path)
+		})
+	}
+}
+
+// TestToFen 测试金额四舍五入为分，未指定币种时使用人民币
+func TestToFen(t *testing.T) {
+	tests := []struct {
+		amount float64
+		want   int64
+	}{
+		{0.01, 1},
+		{0.07, 7},
+		{0.29, 29},
+		{1.005, 100},
+		{19.99, 1999},
+		{100, 10000},
+		{1234567.89, 123456789},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, toFen(tt.amount), "amount %v", tt.amount)
+	}
+
+	assert.Equal(t, "CNY", getCurrency(""))
+	assert.Equal(t, "USD", getCurrency("USD"))
+}
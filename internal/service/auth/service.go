@@ -13,6 +13,7 @@ import (
 	"github.com/zqdfound/go-uni-pay/internal/infrastructure/cache"
 	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
 	"github.com/zqdfound/go-uni-pay/pkg/logger"
+	"github.com/zqdfound/go-uni-pay/pkg/utils"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -49,6 +50,7 @@ func (s *Service) CreateUser(ctx context.Context, username, email string) (*enti
 		Email:     email,
 		APIKey:    apiKey,
 		APISecret: hashedSecret,
+		SignKey:   utils.RandomHex(32),
 		Status:    1,
 	}
 
@@ -0,0 +1,251 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zqdfound/go-uni-pay/internal/domain/entity"
+	"github.com/zqdfound/go-uni-pay/internal/infrastructure/cache"
+	"github.com/zqdfound/go-uni-pay/internal/infrastructure/lock"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+	"github.com/zqdfound/go-uni-pay/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// CreateCheckoutRequest 创建收银台请求
+// Providers 为空时买家可选择商户所有启用了支付配置的提供商
+type CreateCheckoutRequest struct {
+	UserID        uint64
+	OutTradeNo    string
+	Subject       string
+	Body          string
+	Amount        float64
+	Currency      string
+	Providers     []string
+	NotifyURL     string
+	ReturnURL     string
+	ExpireMinutes int
+}
+
+// CheckoutPage 收银台页面数据
+type CheckoutPage struct {
+	Checkout  *entity.Checkout
+	Providers []string
+	Order     *entity.PaymentOrder
+}
+
+// CreateCheckout 创建托管收银台，返回买家访问的收银台地址
+// 同一 out_trade_no 重复请求返回已有收银台
+func (s *Service) CreateCheckout(ctx context.Context, req *CreateCheckoutRequest) (*entity.Checkout, error) {
+	if s.baseURL == "" {
+		return nil, apperrors.New(apperrors.ErrNotSupported, "base url is required for hosted checkout")
+	}
+
+	if err := s.validateProviders(ctx, req.UserID, req.Providers); err != nil {
+		return nil, err
+	}
+
+	var checkout *entity.Checkout
+	lockKey := fmt.Sprintf("checkout:create:%d:%s", req.UserID, req.OutTradeNo)
+	err := lock.WithLock(ctx, cache.Client, lockKey, 30*time.Second, func() error {
+		// 检查收银台是否已存在（幂等性保证）
+		if existing, err := s.checkoutRepo.GetByUserAndOutTradeNo(ctx, req.UserID, req.OutTradeNo); err == nil {
+			checkout = existing
+			return nil
+		}
+
+		// 收银台的订单使用同一 out_trade_no，不能与已有订单冲突
+		if _, err := s.orderRepo.GetByUserAndOutTradeNo(ctx, req.UserID, req.OutTradeNo); err == nil {
+			return apperrors.New(apperrors.ErrInvalidParam, fmt.Sprintf("out_trade_no %s is already used by an order", req.OutTradeNo))
+		}
+
+		checkoutNo := fmt.Sprintf("CO%d%s", time.Now().UnixNano(), uuid.New().String()[:8])
+		checkout = &entity.Checkout{
+			CheckoutNo: checkoutNo,
+			UserID:     req.UserID,
+			OutTradeNo: req.OutTradeNo,
+			Subject:    req.Subject,
+			Body:       req.Body,
+			Amount:     req.Amount,
+			Currency:   req.Currency,
+			Providers:  req.Providers,
+			Status:     entity.CheckoutStatusOpen,
+			URL:        fmt.Sprintf("%s/api/v1/public/checkout/%s", s.baseURL, checkoutNo),
+			NotifyURL:  req.NotifyURL,
+			ReturnURL:  req.ReturnURL,
+		}
+		if req.ExpireMinutes > 0 {
+			expireAt := time.Now().Add(time.Duration(req.ExpireMinutes) * time.Minute)
+			checkout.ExpireAt = &expireAt
+		}
+		return s.checkoutRepo.Create(ctx, checkout)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return checkout, nil
+}
+
+// QueryCheckout 查询收银台，支付结果通过 order_no 查询订单
+func (s *Service) QueryCheckout(ctx context.Context, userID uint64, checkoutNo string) (*entity.Checkout, error) {
+	checkout, err := s.checkoutRepo.GetByCheckoutNo(ctx, checkoutNo)
+	if err != nil {
+		return nil, err
+	}
+
+	// 验证收银台归属（数据隔离）
+	if checkout.UserID != userID {
+		return nil, apperrors.New(apperrors.ErrNotFound, "checkout not found")
+	}
+
+	s.expireCheckout(ctx, checkout)
+	return checkout, nil
+}
+
+// GetCheckoutPage 获取买家访问收银台时展示的数据
+func (s *Service) GetCheckoutPage(ctx context.Context, checkoutNo string) (*CheckoutPage, error) {
+	checkout, err := s.checkoutRepo.GetByCheckoutNo(ctx, checkoutNo)
+	if err != nil {
+		return nil, err
+	}
+
+	s.expireCheckout(ctx, checkout)
+
+	page := &CheckoutPage{Checkout: checkout}
+	switch checkout.Status {
+	case entity.CheckoutStatusOpen:
+		page.Providers = s.availableProviders(ctx, checkout.UserID, checkout.Providers)
+	case entity.CheckoutStatusPaying:
+		if page.Order, err = s.orderRepo.GetByOrderNo(ctx, checkout.OrderNo); err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
+}
+
+// PayCheckout 买家在收银台选择提供商后创建支付订单
+// mobile 为 true 时使用手机网页支付，否则使用提供商的默认场景（如微信扫码）
+func (s *Service) PayCheckout(ctx context.Context, checkoutNo, provider, clientIP string, mobile bool) (*entity.Checkout, error) {
+	var checkout *entity.Checkout
+	lockKey := fmt.Sprintf("checkout:pay:%s", checkoutNo)
+	err := lock.WithLock(ctx, cache.Client, lockKey, 30*time.Second, func() error {
+		var err error
+		if checkout, err = s.checkoutRepo.GetByCheckoutNo(ctx, checkoutNo); err != nil {
+			return err
+		}
+
+		s.expireCheckout(ctx, checkout)
+		switch checkout.Status {
+		case entity.CheckoutStatusPaying:
+			// 重复提交，返回已创建的支付
+			return nil
+		case entity.CheckoutStatusExpired:
+			return apperrors.New(apperrors.ErrOrderStatus, "checkout has expired")
+		}
+
+		if err := s.checkProviderAvailable(ctx, checkout.UserID, checkout.Providers, provider); err != nil {
+			return err
+		}
+
+		scene := ""
+		if mobile {
+			scene = payment.SceneH5
+		}
+
+		// 买家支付后先回到收银台展示结果，支付成功后再跳转到商户页面
+		resp, err := s.CreatePayment(ctx, &CreatePaymentRequest{
+			UserID:     checkout.UserID,
+			Provider:   provider,
+			OutTradeNo: checkout.OutTradeNo,
+			Subject:    checkout.Subject,
+			Body:       checkout.Body,
+			Amount:     checkout.Amount,
+			Currency:   checkout.Currency,
+			Scene:      scene,
+			NotifyURL:  checkout.NotifyURL,
+			ReturnURL:  checkout.URL,
+			ClientIP:   clientIP,
+		})
+		if err != nil {
+			// 创建支付失败时收银台保持待支付，买家可以重新选择提供商，失败的订单在重试时重新创建
+			return err
+		}
+
+		checkout.Provider = provider
+		checkout.OrderNo = resp.OrderNo
+		checkout.PaymentURL = resp.PaymentURL
+		checkout.QRCode = resp.QRCode
+		checkout.Status = entity.CheckoutStatusPaying
+		return s.checkoutRepo.Update(ctx, checkout)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return checkout, nil
+}
+
+// validateProviders 校验商户允许买家选择的提供商均已启用支付配置
+func (s *Service) validateProviders(ctx context.Context, userID uint64, providers []string) error {
+	for _, name := range providers {
+		if _, err := payment.GetProvider(name); err != nil {
+			return err
+		}
+		if _, err := s.getConfigWithCache(ctx, userID, name); err != nil {
+			return apperrors.New(apperrors.ErrInvalidParam, fmt.Sprintf("provider %s has no active payment config", name))
+		}
+	}
+	return nil
+}
+
+// availableProviders 买家可选择的提供商：商户允许且启用了支付配置的提供商
+// allowed 为空时为所有启用了支付配置的提供商，按名称排序
+func (s *Service) availableProviders(ctx context.Context, userID uint64, allowed []string) []string {
+	candidates := allowed
+	if len(candidates) == 0 {
+		for name := range payment.GetAllProviders() {
+			candidates = append(candidates, name)
+		}
+		sort.Strings(candidates)
+	}
+
+	providers := make([]string, 0, len(candidates))
+	for _, name := range candidates {
+		if !payment.HasProvider(name) {
+			continue
+		}
+		if _, err := s.getConfigWithCache(ctx, userID, name); err != nil {
+			continue
+		}
+		providers = append(providers, name)
+	}
+	return providers
+}
+
+// checkProviderAvailable 校验买家选择的提供商可用
+func (s *Service) checkProviderAvailable(ctx context.Context, userID uint64, allowed []string, provider string) error {
+	for _, name := range s.availableProviders(ctx, userID, allowed) {
+		if name == provider {
+			return nil
+		}
+	}
+	return apperrors.New(apperrors.ErrInvalidParam, fmt.Sprintf("provider %s is not available", provider))
+}
+
+// expireCheckout 买家未选择提供商且已过期的收银台标记为过期
+func (s *Service) expireCheckout(ctx context.Context, checkout *entity.Checkout) {
+	if checkout.Status != entity.CheckoutStatusOpen || checkout.ExpireAt == nil || time.Now().Before(*checkout.ExpireAt) {
+		return
+	}
+
+	checkout.Status = entity.CheckoutStatusExpired
+	if err := s.checkoutRepo.Update(ctx, checkout); err != nil {
+		logger.Error("failed to expire checkout", zap.String("checkout_no", checkout.CheckoutNo), zap.Error(err))
+	}
+}
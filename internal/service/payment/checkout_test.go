@@ -0,0 +1,72 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zqdfound/go-uni-pay/internal/domain/entity"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+)
+
+// TestPayCheckout_CreateFailure 测试创建支付失败时收银台保持待支付，买家可以重新选择提供商
+func TestPayCheckout_CreateFailure(t *testing.T) {
+	env := newTestEnv(t)
+	failing := newMockProvider(t)
+	failing.name += "_failing"
+	payment.Register(failing)
+	env.addConfig(failing.name, entity.ConfigData{})
+	working := newMockProvider(t)
+	payment.Register(working)
+	env.addConfig(working.name, entity.ConfigData{})
+
+	checkout := &entity.Checkout{
+		CheckoutNo: "CO1",
+		UserID:     testUserID,
+		OutTradeNo: "ORDER1",
+		Subject:    "Order",
+		Amount:     10,
+		Currency:   "CNY",
+		Providers:  entity.StringList{failing.name, working.name},
+		Status:     entity.CheckoutStatusOpen,
+		URL:        "https://pay.example.com/api/v1/public/checkout/CO1",
+	}
+	env.checkouts.create(checkout)
+
+	failing.On("CreatePayment", mock.Anything, mock.Anything).Return(nil, errors.New("provider unavailable")).Once()
+	working.On("CreatePayment", mock.Anything, mock.Anything).
+		Return(&payment.CreatePaymentResponse{PaymentURL: "https://provider.example.com/pay"}, nil).Once()
+
+	ctx := context.Background()
+	_, err := env.svc.PayCheckout(ctx, checkout.CheckoutNo, failing.name, "127.0.0.1", false)
+	require.Error(t, err)
+
+	// 失败的订单不绑定到收银台，页面仍展示可选择的提供商
+	page, err := env.svc.GetCheckoutPage(ctx, checkout.CheckoutNo)
+	require.NoError(t, err)
+	assert.Equal(t, entity.CheckoutStatusOpen, page.Checkout.Status)
+	assert.Empty(t, page.Checkout.OrderNo)
+	assert.Nil(t, page.Order)
+	assert.ElementsMatch(t, []string{failing.name, working.name}, page.Providers)
+	failed, err := env.orders.GetByUserAndOutTradeNo(ctx, testUserID, checkout.OutTradeNo)
+	require.NoError(t, err)
+	assert.Equal(t, entity.OrderStatusFailed, failed.Status)
+
+	// 重新选择其他提供商时复用失败的订单记录
+	paid, err := env.svc.PayCheckout(ctx, checkout.CheckoutNo, working.name, "127.0.0.1", false)
+	require.NoError(t, err)
+	assert.Equal(t, entity.CheckoutStatusPaying, paid.Status)
+	assert.Equal(t, working.name, paid.Provider)
+	assert.Equal(t, "https://provider.example.com/pay", paid.PaymentURL)
+
+	order := env.order(t, paid.OrderNo)
+	assert.Equal(t, failed.ID, order.ID)
+	assert.Equal(t, working.name, order.Provider)
+	assert.Equal(t, entity.OrderStatusPending, order.Status)
+	assert.Len(t, env.orders.list(func(o *entity.PaymentOrder) bool { return true }), 1)
+	failing.AssertExpectations(t)
+	working.AssertExpectations(t)
+}
@@ -0,0 +1,517 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zqdfound/go-uni-pay/internal/domain/entity"
+	"github.com/zqdfound/go-uni-pay/internal/infrastructure/cache"
+	"github.com/zqdfound/go-uni-pay/internal/infrastructure/lock"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+	"github.com/zqdfound/go-uni-pay/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// CreateCustomerRequest 创建客户请求
+type CreateCustomerRequest struct {
+	UserID        uint64
+	OutCustomerNo string
+	Name          string
+	Email         string
+	Phone         string
+}
+
+// CreateCustomer 创建客户，同一商户客户号重复请求返回已有客户
+// 各提供商的客户在首次绑定支付方式时按需创建
+func (s *Service) CreateCustomer(ctx context.Context, req *CreateCustomerRequest) (*entity.Customer, error) {
+	var customer *entity.Customer
+	lockKey := fmt.Sprintf("customer:create:%d:%s", req.UserID, req.OutCustomerNo)
+	err := lock.WithLock(ctx, cache.Client, lockKey, 30*time.Second, func() error {
+		// 检查客户是否已存在（幂等性保证）
+		if existing, err := s.customerRepo.GetByUserAndOutCustomerNo(ctx, req.UserID, req.OutCustomerNo); err == nil {
+			customer = existing
+			return nil
+		}
+
+		customer = &entity.Customer{
+			CustomerNo:    fmt.Sprintf("CUS%d%s", time.Now().UnixNano(), uuid.New().String()[:8]),
+			UserID:        req.UserID,
+			OutCustomerNo: req.OutCustomerNo,
+			Name:          req.Name,
+			Email:         req.Email,
+			Phone:         req.Phone,
+		}
+		return s.customerRepo.Create(ctx, customer)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return customer, nil
+}
+
+// QueryCustomer 查询客户
+func (s *Service) QueryCustomer(ctx context.Context, userID uint64, customerNo string) (*entity.Customer, error) {
+	customer, err := s.customerRepo.GetByCustomerNo(ctx, customerNo)
+	if err != nil {
+		return nil, err
+	}
+
+	// 验证客户归属（数据隔离）
+	if customer.UserID != userID {
+		return nil, apperrors.New(apperrors.ErrNotFound, "customer not found")
+	}
+
+	return customer, nil
+}
+
+// CreatePaymentMethodRequest 绑定支付方式请求
+type CreatePaymentMethodRequest struct {
+	UserID     uint64
+	CustomerNo string
+	Provider   string
+	NotifyURL  string
+	ReturnURL  string
+}
+
+// CreatePaymentMethod 发起绑定支付方式，返回买家绑定页面
+// 绑定结果通过第三方通知或查询支付方式列表时同步
+func (s *Service) CreatePaymentMethod(ctx context.Context, req *CreatePaymentMethodRequest) (*entity.PaymentMethod, error) {
+	prov, err := payment.GetProvider(req.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	saver, ok := prov.(payment.PaymentMethodSaver)
+	if !ok {
+		return nil, apperrors.New(apperrors.ErrNotSupported, fmt.Sprintf("provider %s does not support saved payment methods", req.Provider))
+	}
+
+	customer, err := s.QueryCustomer(ctx, req.UserID, req.CustomerNo)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := s.getConfigWithCache(ctx, req.UserID, req.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	notifyURL, err := s.notifyURL(req.Provider, config.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	method := &entity.PaymentMethod{
+		MethodNo:   fmt.Sprintf("PM%d%s", time.Now().UnixNano(), uuid.New().String()[:8]),
+		UserID:     req.UserID,
+		CustomerID: customer.ID,
+		CustomerNo: customer.CustomerNo,
+		Provider:   req.Provider,
+		ConfigID:   config.ID,
+		Status:     entity.PaymentMethodStatusPending,
+		NotifyURL:  req.NotifyURL,
+		ReturnURL:  req.ReturnURL,
+	}
+	if err := s.paymentMethodRepo.Create(ctx, method); err != nil {
+		return nil, err
+	}
+
+	customerKey := fmt.Sprintf("%s:%d", req.Provider, config.ID)
+	setupReq := &payment.SetupPaymentMethodRequest{
+		MethodNo:   method.MethodNo,
+		CustomerNo: customer.CustomerNo,
+		CustomerID: customer.ProviderCustomers[customerKey],
+		Name:       customer.Name,
+		Email:      customer.Email,
+		Phone:      customer.Phone,
+		NotifyURL:  notifyURL,
+		ReturnURL:  req.ReturnURL,
+		Config:     config.ConfigData,
+	}
+
+	setupResp, err := saver.SetupPaymentMethod(ctx, setupReq)
+	if err != nil {
+		s.logPayment(ctx, 0, method.MethodNo, "payment_method_setup", req.Provider, setupReq, nil, "failed", err.Error())
+		method.Status = entity.PaymentMethodStatusCanceled
+		if updateErr := s.paymentMethodRepo.Update(ctx, method); updateErr != nil {
+			logger.Error("failed to update payment method", zap.String("method_no", method.MethodNo), zap.Error(updateErr))
+		}
+		return nil, err
+	}
+
+	s.logPayment(ctx, 0, method.MethodNo, "payment_method_setup", req.Provider, setupReq, setupResp, "success", "")
+
+	lockKey := fmt.Sprintf("payment_method:process:%s", method.MethodNo)
+	err = lock.WithLock(ctx, cache.Client, lockKey, 30*time.Second, func() error {
+		// 绑定通知可能先于同步应答到达，以数据库中的状态为准
+		if current, err := s.paymentMethodRepo.GetByMethodNo(ctx, method.MethodNo); err == nil {
+			*method = *current
+		}
+		method.SetupURL = setupResp.SetupURL
+		if method.SetupID == "" {
+			method.SetupID = setupResp.SetupID
+		}
+
+		return s.applyPaymentMethodInfo(ctx, method, &payment.PaymentMethodInfo{
+			CustomerID: setupResp.CustomerID,
+			Status:     setupResp.Status,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return method, nil
+}
+
+// ListPaymentMethods 查询客户的支付方式，等待绑定的支付方式会向第三方查询并同步状态
+func (s *Service) ListPaymentMethods(ctx context.Context, userID uint64, customerNo string) ([]*entity.PaymentMethod, error) {
+	customer, err := s.QueryCustomer(ctx, userID, customerNo)
+	if err != nil {
+		return nil, err
+	}
+
+	methods, err := s.paymentMethodRepo.ListByCustomer(ctx, customer.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, method := range methods {
+		if method.Status != entity.PaymentMethodStatusPending {
+			continue
+		}
+		if err := s.syncPaymentMethod(ctx, method); err != nil {
+			logger.Warn("failed to sync payment method", zap.String("method_no", method.MethodNo), zap.Error(err))
+		}
+	}
+
+	return methods, nil
+}
+
+// DeletePaymentMethod 删除支付方式，在第三方解绑后不能再扣款
+func (s *Service) DeletePaymentMethod(ctx context.Context, userID uint64, methodNo string) (*entity.PaymentMethod, error) {
+	method, err := s.getPaymentMethod(ctx, userID, methodNo)
+	if err != nil {
+		return nil, err
+	}
+
+	lockKey := fmt.Sprintf("payment_method:process:%s", method.MethodNo)
+	err = lock.WithLock(ctx, cache.Client, lockKey, 30*time.Second, func() error {
+		if current, err := s.paymentMethodRepo.GetByMethodNo(ctx, method.MethodNo); err == nil {
+			*method = *current
+		}
+		if method.Status == entity.PaymentMethodStatusCanceled {
+			return nil
+		}
+
+		// 买家尚未完成绑定时没有可删除的第三方支付方式
+		if method.MethodID != "" {
+			if err := s.deleteAtProvider(ctx, method); err != nil {
+				return err
+			}
+		}
+
+		method.Status = entity.PaymentMethodStatusCanceled
+		if err := s.paymentMethodRepo.Update(ctx, method); err != nil {
+			return err
+		}
+		s.notifyPaymentMethod(ctx, method, "payment_method.canceled")
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return method, nil
+}
+
+// getPaymentMethod 获取支付方式并验证归属
+func (s *Service) getPaymentMethod(ctx context.Context, userID uint64, methodNo string) (*entity.PaymentMethod, error) {
+	method, err := s.paymentMethodRepo.GetByMethodNo(ctx, methodNo)
+	if err != nil {
+		return nil, err
+	}
+
+	// 验证支付方式归属（数据隔离）
+	if method.UserID != userID {
+		return nil, apperrors.New(apperrors.ErrNotFound, "payment method not found")
+	}
+
+	return method, nil
+}
+
+// deleteAtProvider 在第三方删除支付方式
+func (s *Service) deleteAtProvider(ctx context.Context, method *entity.PaymentMethod) error {
+	prov, err := payment.GetProvider(method.Provider)
+	if err != nil {
+		return err
+	}
+
+	saver, ok := prov.(payment.PaymentMethodSaver)
+	if !ok {
+		return apperrors.New(apperrors.ErrNotSupported, fmt.Sprintf("provider %s does not support saved payment methods", method.Provider))
+	}
+
+	config, err := s.configRepo.GetByID(ctx, method.ConfigID)
+	if err != nil {
+		return err
+	}
+
+	deleteReq := &payment.DeletePaymentMethodRequest{
+		MethodNo: method.MethodNo,
+		MethodID: method.MethodID,
+		Config:   config.ConfigData,
+	}
+	if err := saver.DeletePaymentMethod(ctx, deleteReq); err != nil {
+		s.logPayment(ctx, 0, method.MethodNo, "payment_method_delete", method.Provider, deleteReq, nil, "failed", err.Error())
+		return err
+	}
+
+	s.logPayment(ctx, 0, method.MethodNo, "payment_method_delete", method.Provider, deleteReq, nil, "success", "")
+	return nil
+}
+
+// syncPaymentMethod 向第三方查询绑定结果并更新支付方式
+func (s *Service) syncPaymentMethod(ctx context.Context, method *entity.PaymentMethod) error {
+	prov, err := payment.GetProvider(method.Provider)
+	if err != nil {
+		return err
+	}
+
+	saver, ok := prov.(payment.PaymentMethodSaver)
+	if !ok {
+		return nil
+	}
+
+	config, err := s.configRepo.GetByID(ctx, method.ConfigID)
+	if err != nil {
+		return err
+	}
+
+	queryReq := &payment.QueryPaymentMethodRequest{
+		MethodNo: method.MethodNo,
+		SetupID:  method.SetupID,
+		MethodID: method.MethodID,
+		Config:   config.ConfigData,
+	}
+
+	info, err := saver.QueryPaymentMethod(ctx, queryReq)
+	if err != nil {
+		s.logPayment(ctx, 0, method.MethodNo, "payment_method_query", method.Provider, queryReq, nil, "failed", err.Error())
+		return err
+	}
+
+	s.logPayment(ctx, 0, method.MethodNo, "payment_method_query", method.Provider, queryReq, info, "success", "")
+
+	return s.applyPaymentMethodInfo(ctx, method, info)
+}
+
+// handlePaymentMethodNotify 处理支付方式绑定、解绑通知
+// 优先按支付方式单号查找，部分通知只包含第三方支付方式ID；通知不含状态时向第三方查询
+func (s *Service) handlePaymentMethodNotify(ctx context.Context, provider string, req *payment.NotifyRequest, info *payment.PaymentMethodInfo) error {
+	var method *entity.PaymentMethod
+	if info.MethodNo != "" {
+		if found, err := s.paymentMethodRepo.GetByMethodNo(ctx, info.MethodNo); err == nil && found.Provider == provider {
+			method = found
+		}
+	}
+	if method == nil && info.MethodID != "" {
+		if found, err := s.paymentMethodRepo.GetByProviderMethodID(ctx, provider, info.MethodID); err == nil {
+			method = found
+		}
+	}
+	if method == nil {
+		// 非本系统绑定的支付方式，忽略
+		logger.Warn("payment method not found",
+			zap.String("provider", provider),
+			zap.String("method_no", info.MethodNo),
+			zap.String("method_id", info.MethodID))
+		return nil
+	}
+
+	s.logPayment(ctx, 0, method.MethodNo, "payment_method_notify", provider, req, info, "success", "")
+
+	lockKey := fmt.Sprintf("payment_method:process:%s", method.MethodNo)
+	return lock.WithLock(ctx, cache.Client, lockKey, 30*time.Second, func() error {
+		if current, err := s.paymentMethodRepo.GetByMethodNo(ctx, method.MethodNo); err == nil {
+			*method = *current
+		}
+		if info.Status == "" {
+			return s.syncPaymentMethod(ctx, method)
+		}
+		return s.applyPaymentMethodInfo(ctx, method, info)
+	})
+}
+
+// applyPaymentMethodInfo 根据第三方返回的信息更新支付方式，已删除的支付方式不再恢复
+// 第三方新建的客户ID保存到客户，后续绑定复用
+func (s *Service) applyPaymentMethodInfo(ctx context.Context, method *entity.PaymentMethod, info *payment.PaymentMethodInfo) error {
+	if method.Status == entity.PaymentMethodStatusCanceled {
+		return nil
+	}
+
+	if info.MethodID != "" {
+		method.MethodID = info.MethodID
+	}
+	if info.SetupID != "" && method.SetupID == "" {
+		method.SetupID = info.SetupID
+	}
+	if info.Type != "" {
+		method.Type = info.Type
+	}
+	if info.Brand != "" {
+		method.Brand = info.Brand
+		method.Last4 = info.Last4
+		method.ExpMonth = info.ExpMonth
+		method.ExpYear = info.ExpYear
+	}
+	if info.Account != "" {
+		method.Account = info.Account
+	}
+
+	event := ""
+	if info.Status != "" && info.Status != method.Status {
+		method.Status = info.Status
+		event = "payment_method." + info.Status
+	}
+
+	if err := s.paymentMethodRepo.Update(ctx, method); err != nil {
+		logger.Error("failed to update payment method", zap.String("method_no", method.MethodNo), zap.Error(err))
+		return err
+	}
+
+	if info.CustomerID != "" {
+		s.saveProviderCustomer(ctx, method, info.CustomerID)
+	}
+
+	if event != "" {
+		s.notifyPaymentMethod(ctx, method, event)
+	}
+
+	return nil
+}
+
+// saveProviderCustomer 保存第三方客户ID
+func (s *Service) saveProviderCustomer(ctx context.Context, method *entity.PaymentMethod, customerID string) {
+	customer, err := s.customerRepo.GetByCustomerNo(ctx, method.CustomerNo)
+	if err != nil {
+		logger.Error("failed to get customer", zap.String("customer_no", method.CustomerNo), zap.Error(err))
+		return
+	}
+
+	customerKey := fmt.Sprintf("%s:%d", method.Provider, method.ConfigID)
+	if customer.ProviderCustomers[customerKey] == customerID {
+		return
+	}
+	if customer.ProviderCustomers == nil {
+		customer.ProviderCustomers = entity.ProviderIDs{}
+	}
+	customer.ProviderCustomers[customerKey] = customerID
+	if err := s.customerRepo.Update(ctx, customer); err != nil {
+		logger.Error("failed to update customer", zap.String("customer_no", customer.CustomerNo), zap.Error(err))
+	}
+}
+
+// notifyPaymentMethod 支付方式有通知URL时，添加 payment_method.* 通知任务
+func (s *Service) notifyPaymentMethod(ctx context.Context, method *entity.PaymentMethod, event string) {
+	if method.NotifyURL == "" {
+		return
+	}
+
+	notifyData := map[string]interface{}{
+		"event":             event,
+		"payment_method_no": method.MethodNo,
+		"customer_no":       method.CustomerNo,
+		"provider":          method.Provider,
+		"type":              method.Type,
+		"brand":             method.Brand,
+		"last4":             method.Last4,
+		"account":           method.Account,
+		"status":            method.Status,
+	}
+
+	if err := s.notifyService.AddNotify(ctx, 0, method.MethodNo, method.NotifyURL, notifyData); err != nil {
+		logger.Error("failed to add payment method notify task",
+			zap.String("method_no", method.MethodNo),
+			zap.Error(err))
+	}
+}
+
+// resolvePaymentMethod 校验用于扣款的支付方式：归属于商户的客户、与支付提供商一致且已绑定
+func (s *Service) resolvePaymentMethod(ctx context.Context, req *CreatePaymentRequest) (*entity.PaymentMethod, *entity.Customer, error) {
+	if req.CustomerNo == "" {
+		return nil, nil, apperrors.New(apperrors.ErrInvalidParam, "customer_no is required when payment_method_no is set")
+	}
+
+	customer, err := s.QueryCustomer(ctx, req.UserID, req.CustomerNo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	method, err := s.getPaymentMethod(ctx, req.UserID, req.PaymentMethodNo)
+	if err != nil {
+		return nil, nil, err
+	}
+	if method.CustomerID != customer.ID {
+		return nil, nil, apperrors.New(apperrors.ErrNotFound, "payment method not found")
+	}
+	if method.Provider != req.Provider {
+		return nil, nil, apperrors.New(apperrors.ErrInvalidParam, fmt.Sprintf("payment method belongs to provider %s", method.Provider))
+	}
+
+	if method.Status == entity.PaymentMethodStatusPending {
+		if err := s.syncPaymentMethod(ctx, method); err != nil {
+			logger.Warn("failed to sync payment method", zap.String("method_no", method.MethodNo), zap.Error(err))
+		}
+	}
+	if method.Status != entity.PaymentMethodStatusActive {
+		return nil, nil, apperrors.New(apperrors.ErrPaymentMethod, fmt.Sprintf("payment method is %s", method.Status))
+	}
+
+	return method, customer, nil
+}
+
+// chargePaymentMethod 使用已保存的支付方式为订单扣款，扣款失败时订单为失败状态
+func (s *Service) chargePaymentMethod(ctx context.Context, saver payment.PaymentMethodSaver, order *entity.PaymentOrder, method *entity.PaymentMethod, customer *entity.Customer, config map[string]interface{}) error {
+	notifyURL, err := s.notifyURL(order.Provider, order.ConfigID)
+	if err != nil {
+		return err
+	}
+
+	chargeReq := &payment.ChargePaymentMethodRequest{
+		OrderNo:    order.OrderNo,
+		OutTradeNo: order.OutTradeNo,
+		Subject:    order.Subject,
+		Amount:     order.Amount,
+		Currency:   order.Currency,
+		CustomerID: customer.ProviderCustomers[fmt.Sprintf("%s:%d", method.Provider, method.ConfigID)],
+		MethodID:   method.MethodID,
+		NotifyURL:  notifyURL,
+		Config:     config,
+	}
+
+	chargeResp, err := saver.ChargePaymentMethod(ctx, chargeReq)
+	if err != nil {
+		s.logPayment(ctx, order.ID, order.OrderNo, "payment_method_charge", order.Provider, chargeReq, nil, "failed", err.Error())
+		if updateErr := s.updateOrderStatus(ctx, order, entity.OrderStatusFailed); updateErr != nil {
+			return updateErr
+		}
+		return err
+	}
+
+	s.logPayment(ctx, order.ID, order.OrderNo, "payment_method_charge", order.Provider, chargeReq, chargeResp, "success", chargeResp.FailReason)
+
+	order.TradeNo = chargeResp.TradeNo
+	switch chargeResp.Status {
+	case payment.StatusSuccess:
+		return s.updateOrderStatus(ctx, order, entity.OrderStatusSuccess)
+	case payment.StatusFailed:
+		return s.updateOrderStatus(ctx, order, entity.OrderStatusFailed)
+	default:
+		return s.updateOrderStatus(ctx, order, entity.OrderStatusProcessing)
+	}
+}
@@ -0,0 +1,156 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zqdfound/go-uni-pay/internal/domain/entity"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+)
+
+// mockSaver 支持保存支付方式的提供商
+type mockSaver struct {
+	*mockProvider
+}
+
+func (p *mockSaver) SetupPaymentMethod(ctx context.Context, req *payment.SetupPaymentMethodRequest) (*payment.SetupPaymentMethodResponse, error) {
+	args := p.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*payment.SetupPaymentMethodResponse), args.Error(1)
+}
+
+func (p *mockSaver) QueryPaymentMethod(ctx context.Context, req *payment.QueryPaymentMethodRequest) (*payment.PaymentMethodInfo, error) {
+	args := p.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*payment.PaymentMethodInfo), args.Error(1)
+}
+
+func (p *mockSaver) DeletePaymentMethod(ctx context.Context, req *payment.DeletePaymentMethodRequest) error {
+	return p.Called(ctx, req).Error(0)
+}
+
+func (p *mockSaver) ChargePaymentMethod(ctx context.Context, req *payment.ChargePaymentMethodRequest) (*payment.ChargePaymentMethodResponse, error) {
+	args := p.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*payment.ChargePaymentMethodResponse), args.Error(1)
+}
+
+// addSavedMethod 为测试商户添加客户及已绑定的支付方式
+func (e *testEnv) addSavedMethod(config *entity.PaymentConfig, customerNo, methodNo, status string) (*entity.Customer, *entity.PaymentMethod) {
+	customer := &entity.Customer{
+		CustomerNo:        customerNo,
+		UserID:            testUserID,
+		OutCustomerNo:     "OUT" + customerNo,
+		ProviderCustomers: entity.ProviderIDs{fmt.Sprintf("%s:%d", config.Provider, config.ID): "cus_" + customerNo},
+	}
+	e.customer.create(customer)
+
+	method := &entity.PaymentMethod{
+		MethodNo:   methodNo,
+		UserID:     testUserID,
+		CustomerID: customer.ID,
+		CustomerNo: customer.CustomerNo,
+		Provider:   config.Provider,
+		ConfigID:   config.ID,
+		MethodID:   "pm_" + methodNo,
+		Status:     status,
+	}
+	e.methods.create(method)
+	return customer, method
+}
+
+// TestCreatePayment_SavedMethod 测试使用已保存的支付方式扣款，按客户的第三方客户ID和支付方式ID发起
+func TestCreatePayment_SavedMethod(t *testing.T) {
+	env := newTestEnv(t)
+	prov := &mockSaver{newMockProvider(t)}
+	payment.Register(prov)
+
+	config := env.addConfig(prov.name, entity.ConfigData{})
+	env.addSavedMethod(config, "CUS1", "PM1", entity.PaymentMethodStatusActive)
+
+	prov.On("ChargePaymentMethod", mock.Anything, mock.MatchedBy(func(req *payment.ChargePaymentMethodRequest) bool {
+		return req.CustomerID == "cus_CUS1" && req.MethodID == "pm_PM1" && req.Amount == 15 && req.OutTradeNo == "ORDER1"
+	})).Return(&payment.ChargePaymentMethodResponse{TradeNo: "pi_1", Status: payment.StatusSuccess}, nil).Once()
+
+	resp, err := env.svc.CreatePayment(context.Background(), &CreatePaymentRequest{
+		UserID:          testUserID,
+		Provider:        prov.name,
+		OutTradeNo:      "ORDER1",
+		Subject:         "Order",
+		Amount:          15,
+		Currency:        "USD",
+		NotifyURL:       "https://merchant.example.com/notify",
+		CustomerNo:      "CUS1",
+		PaymentMethodNo: "PM1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, entity.OrderStatusSuccess, resp.Status)
+	assert.Empty(t, resp.PaymentURL)
+
+	order := env.order(t, resp.OrderNo)
+	assert.Equal(t, "pi_1", order.TradeNo)
+	assert.Equal(t, "PM1", order.PaymentMethodNo)
+	assert.Equal(t, []string{entity.OrderStatusSuccess}, env.notifier.events())
+
+	// 重复请求返回已有订单，不再扣款
+	again, err := env.svc.CreatePayment(context.Background(), &CreatePaymentRequest{
+		UserID:          testUserID,
+		Provider:        prov.name,
+		OutTradeNo:      "ORDER1",
+		Amount:          15,
+		CustomerNo:      "CUS1",
+		PaymentMethodNo: "PM1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, resp.OrderNo, again.OrderNo)
+	prov.AssertExpectations(t)
+}
+
+// TestCreatePayment_SavedMethodRejected 测试支付方式不属于该客户或未生效时不扣款
+func TestCreatePayment_SavedMethodRejected(t *testing.T) {
+	env := newTestEnv(t)
+	prov := &mockSaver{newMockProvider(t)}
+	payment.Register(prov)
+
+	config := env.addConfig(prov.name, entity.ConfigData{})
+	env.addSavedMethod(config, "CUS1", "PM1", entity.PaymentMethodStatusActive)
+	env.addSavedMethod(config, "CUS2", "PM2", entity.PaymentMethodStatusCanceled)
+
+	tests := []struct {
+		name       string
+		customerNo string
+		methodNo   string
+		code       apperrors.ErrorCode
+	}{
+		{"missing customer", "", "PM1", apperrors.ErrInvalidParam},
+		{"other customer's method", "CUS2", "PM1", apperrors.ErrNotFound},
+		{"canceled method", "CUS2", "PM2", apperrors.ErrPaymentMethod},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := env.svc.CreatePayment(context.Background(), &CreatePaymentRequest{
+				UserID:          testUserID,
+				Provider:        prov.name,
+				OutTradeNo:      fmt.Sprintf("ORDER%d", i),
+				Amount:          15,
+				CustomerNo:      tt.customerNo,
+				PaymentMethodNo: tt.methodNo,
+			})
+			require.Error(t, err)
+			assert.Equal(t, tt.code, err.(*apperrors.AppError).Code)
+		})
+	}
+	prov.AssertNotCalled(t, "ChargePaymentMethod", mock.Anything, mock.Anything)
+}
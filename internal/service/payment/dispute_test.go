@@ -0,0 +1,159 @@
+package payment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zqdfound/go-uni-pay/internal/domain/entity"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+)
+
+// disputeNotify 模拟提供商返回的争议通知，outTradeNo 为提供商解析出的商户订单号
+func disputeNotify(prov *mockProvider, outTradeNo string, info *payment.DisputeInfo) {
+	prov.On("HandleNotify", mock.Anything, mock.Anything).Return(&payment.NotifyResponse{
+		Event:      payment.EventDispute,
+		OutTradeNo: outTradeNo,
+		Amount:     info.Amount,
+		Dispute:    info,
+		ReturnData: []byte("OK"),
+	}, nil).Once()
+}
+
+// lastNotify 返回最后一次添加的商户通知内容
+func (n *recordingNotifier) lastNotify() map[string]interface{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.notifies) == 0 {
+		return nil
+	}
+	return n.notifies[len(n.notifies)-1]
+}
+
+// TestHandleDispute_Lifecycle 测试争议创建、更新、关闭时保存争议并通知商户，重复通知不重复处理，订单状态不变
+func TestHandleDispute_Lifecycle(t *testing.T) {
+	env := newTestEnv(t)
+	prov := newMockProvider(t)
+	payment.Register(prov)
+	config := env.addConfig(prov.name, entity.ConfigData{})
+	order := env.addOrder(config, &entity.PaymentOrder{
+		OutTradeNo: "ORDER1",
+		Amount:     100,
+		Currency:   "EUR",
+		Status:     entity.OrderStatusSuccess,
+		NotifyURL:  "https://merchant.example.com/notify",
+	})
+
+	ctx := context.Background()
+	dueBy := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+
+	// 争议创建，未返回币种时使用订单币种
+	disputeNotify(prov, "ORDER1", &payment.DisputeInfo{
+		DisputeID:     "dp_1",
+		Reason:        "fraudulent",
+		Amount:        100,
+		Status:        payment.DisputeStatusNeedsResponse,
+		EvidenceDueBy: &dueBy,
+	})
+	data, err := env.svc.HandleNotify(ctx, prov.name, &payment.NotifyRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "OK", string(data))
+
+	dispute, err := env.disputes.GetByProviderDisputeID(ctx, prov.name, "dp_1")
+	require.NoError(t, err)
+	assert.Equal(t, order.ID, dispute.OrderID)
+	assert.Equal(t, order.OrderNo, dispute.OrderNo)
+	assert.Equal(t, uint64(testUserID), dispute.UserID)
+	assert.Equal(t, "fraudulent", dispute.Reason)
+	assert.Equal(t, "EUR", dispute.Currency)
+	assert.Equal(t, entity.DisputeStatusNeedsResponse, dispute.Status)
+	require.NotNil(t, dispute.EvidenceDueBy)
+	assert.True(t, dueBy.Equal(*dispute.EvidenceDueBy))
+
+	assert.Equal(t, []string{"dispute.created"}, env.notifier.events())
+	notify := env.notifier.lastNotify()
+	assert.Equal(t, order.OrderNo, notify["order_no"])
+	assert.Equal(t, "ORDER1", notify["out_trade_no"])
+	assert.Equal(t, "dp_1", notify["dispute_id"])
+	assert.Equal(t, "fraudulent", notify["reason"])
+	assert.Equal(t, float64(100), notify["amount"])
+	assert.Equal(t, "EUR", notify["currency"])
+	assert.Equal(t, entity.DisputeStatusNeedsResponse, notify["status"])
+
+	// 状态和金额未变化的重复通知不更新也不通知商户
+	disputeNotify(prov, "ORDER1", &payment.DisputeInfo{DisputeID: "dp_1", Amount: 100, Status: payment.DisputeStatusNeedsResponse})
+	_, err = env.svc.HandleNotify(ctx, prov.name, &payment.NotifyRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dispute.created"}, env.notifier.events())
+
+	// 争议更新，通知中没有原因时保留已有原因
+	disputeNotify(prov, "ORDER1", &payment.DisputeInfo{DisputeID: "dp_1", Amount: 100, Currency: "EUR", Status: payment.DisputeStatusUnderReview})
+	_, err = env.svc.HandleNotify(ctx, prov.name, &payment.NotifyRequest{})
+	require.NoError(t, err)
+
+	dispute, err = env.disputes.GetByProviderDisputeID(ctx, prov.name, "dp_1")
+	require.NoError(t, err)
+	assert.Equal(t, entity.DisputeStatusUnderReview, dispute.Status)
+	assert.Equal(t, "fraudulent", dispute.Reason)
+	assert.NotNil(t, dispute.EvidenceDueBy)
+	assert.Equal(t, []string{"dispute.created", "dispute.updated"}, env.notifier.events())
+	assert.Equal(t, entity.DisputeStatusUnderReview, env.notifier.lastNotify()["status"])
+
+	// 争议结束
+	disputeNotify(prov, "ORDER1", &payment.DisputeInfo{DisputeID: "dp_1", Amount: 100, Currency: "EUR", Status: payment.DisputeStatusLost})
+	_, err = env.svc.HandleNotify(ctx, prov.name, &payment.NotifyRequest{})
+	require.NoError(t, err)
+
+	dispute, err = env.disputes.GetByProviderDisputeID(ctx, prov.name, "dp_1")
+	require.NoError(t, err)
+	assert.Equal(t, entity.DisputeStatusLost, dispute.Status)
+	assert.Equal(t, []string{"dispute.created", "dispute.updated", "dispute.closed"}, env.notifier.events())
+	assert.Equal(t, entity.DisputeStatusLost, env.notifier.lastNotify()["status"])
+
+	assert.Len(t, env.disputes.list(func(d *entity.Dispute) bool { return true }), 1)
+	assert.Equal(t, entity.OrderStatusSuccess, env.order(t, order.OrderNo).Status)
+	prov.AssertExpectations(t)
+}
+
+// TestHandleDispute_OrderResolution 测试争议按提供商解析出的商户订单号关联订单，首次通知即已结束的争议按创建事件通知
+func TestHandleDispute_OrderResolution(t *testing.T) {
+	env := newTestEnv(t)
+	prov := newMockProvider(t)
+	payment.Register(prov)
+	config := env.addConfig(prov.name, entity.ConfigData{})
+	env.addOrder(config, &entity.PaymentOrder{OutTradeNo: "ORDER1", Amount: 50, Status: entity.OrderStatusSuccess, NotifyURL: "https://merchant.example.com/notify"})
+	order := env.addOrder(config, &entity.PaymentOrder{OutTradeNo: "ORDER2", Amount: 80, Status: entity.OrderStatusSuccess, NotifyURL: "https://merchant.example.com/notify"})
+	unnotified := env.addOrder(config, &entity.PaymentOrder{OutTradeNo: "ORDER3", Amount: 20, Status: entity.OrderStatusSuccess})
+
+	ctx := context.Background()
+	disputeNotify(prov, "ORDER2", &payment.DisputeInfo{DisputeID: "dp_2", Amount: 80, Currency: "USD", Status: payment.DisputeStatusClosed})
+	_, err := env.svc.HandleNotify(ctx, prov.name, &payment.NotifyRequest{})
+	require.NoError(t, err)
+
+	dispute, err := env.disputes.GetByProviderDisputeID(ctx, prov.name, "dp_2")
+	require.NoError(t, err)
+	assert.Equal(t, order.ID, dispute.OrderID)
+	assert.Equal(t, order.OrderNo, dispute.OrderNo)
+	assert.Equal(t, []string{"dispute.created"}, env.notifier.events())
+	assert.Equal(t, "ORDER2", env.notifier.lastNotify()["out_trade_no"])
+
+	// 找不到订单的争议不保存
+	disputeNotify(prov, "UNKNOWN", &payment.DisputeInfo{DisputeID: "dp_3", Amount: 10, Status: payment.DisputeStatusNeedsResponse})
+	_, err = env.svc.HandleNotify(ctx, prov.name, &payment.NotifyRequest{})
+	require.NoError(t, err)
+	_, err = env.disputes.GetByProviderDisputeID(ctx, prov.name, "dp_3")
+	assert.Error(t, err)
+
+	// 订单没有通知地址时只保存争议
+	disputeNotify(prov, "ORDER3", &payment.DisputeInfo{DisputeID: "dp_4", Amount: 20, Status: payment.DisputeStatusNeedsResponse})
+	_, err = env.svc.HandleNotify(ctx, prov.name, &payment.NotifyRequest{})
+	require.NoError(t, err)
+	dispute, err = env.disputes.GetByProviderDisputeID(ctx, prov.name, "dp_4")
+	require.NoError(t, err)
+	assert.Equal(t, unnotified.OrderNo, dispute.OrderNo)
+	assert.Equal(t, []string{"dispute.created"}, env.notifier.events())
+	prov.AssertExpectations(t)
+}
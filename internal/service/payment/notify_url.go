@@ -0,0 +1,184 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zqdfound/go-uni-pay/internal/domain/entity"
+	"github.com/zqdfound/go-uni-pay/internal/infrastructure/cache"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+	"github.com/zqdfound/go-uni-pay/pkg/logger"
+	"github.com/zqdfound/go-uni-pay/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// notifyAccountSyncInterval 通知未命中账号索引时重建索引的最小间隔，避免伪造的通知频繁扫描支付配置
+const notifyAccountSyncInterval = time.Minute
+
+// SetNotifySecret 设置通知地址的签名密钥，通知地址中的配置令牌为「配置ID.签名」
+// legacy 为 true 时仍接受只有配置ID的旧通知地址，用于在提供商后台更新通知地址期间过渡
+func (s *Service) SetNotifySecret(secret string, legacy bool) error {
+	if secret == "" {
+		return apperrors.New(apperrors.ErrInvalidParam, "notify secret is required")
+	}
+	s.notifySecret = secret
+	s.legacyNotify = legacy
+	return nil
+}
+
+// notifyToken 生成通知地址中的配置令牌
+func (s *Service) notifyToken(provider string, configID uint64) string {
+	id := strconv.FormatUint(configID, 10)
+	return id + "." + s.notifySignature(provider, id)
+}
+
+// notifySignature 计算配置令牌的签名，签名包含提供商，令牌不能用于其他提供商
+func (s *Service) notifySignature(provider, id string) string {
+	return utils.HMACSHA256(s.notifySecret, provider+":"+id)[:32]
+}
+
+// ResolveNotifyConfig 解析通知地址中的配置令牌，返回对应的支付配置
+// 签名在查询配置之前校验，伪造的令牌不会触发数据库查询和提供商验签
+func (s *Service) ResolveNotifyConfig(ctx context.Context, provider, token string) (map[string]interface{}, error) {
+	id, signature, signed := strings.Cut(token, ".")
+	configID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, apperrors.New(apperrors.ErrInvalidParam, "invalid notify token")
+	}
+
+	switch {
+	case signed:
+		if s.notifySecret == "" || !hmac.Equal([]byte(signature), []byte(s.notifySignature(provider, id))) {
+			return nil, apperrors.New(apperrors.ErrUnauthorized, "invalid notify token")
+		}
+	case !s.legacyNotify:
+		return nil, apperrors.New(apperrors.ErrUnauthorized, "notify url without signature is disabled")
+	}
+
+	config, err := s.configRepo.GetByID(ctx, configID)
+	if err != nil {
+		return nil, err
+	}
+	if config.Provider != provider {
+		return nil, apperrors.New(apperrors.ErrConfigNotFound, "payment config not found")
+	}
+
+	return config.ConfigData, nil
+}
+
+// ResolveProviderNotifyConfig 从未验签的通知内容识别支付配置，用于不带配置令牌的通知地址
+// 识别结果只用于选择验签使用的配置，通知仍由提供商校验签名
+func (s *Service) ResolveProviderNotifyConfig(ctx context.Context, provider string, req *payment.NotifyRequest) (map[string]interface{}, error) {
+	prov, err := payment.GetProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	identifier, ok := prov.(payment.NotifyIdentifier)
+	if !ok {
+		return nil, apperrors.New(apperrors.ErrNotSupported, "provider requires notify url with config token")
+	}
+
+	configs, err := s.matchNotifyConfigs(ctx, provider, identifier, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(configs) == 0 && s.syncNotifyAccounts(ctx, provider, identifier) {
+		if configs, err = s.matchNotifyConfigs(ctx, provider, identifier, req); err != nil {
+			return nil, err
+		}
+	}
+
+	switch len(configs) {
+	case 0:
+		return nil, apperrors.New(apperrors.ErrConfigNotFound, "payment config not found")
+	case 1:
+		return configs[0].ConfigData, nil
+	default:
+		return nil, apperrors.New(apperrors.ErrConfigNotFound, "multiple payment configs match the notification, use notify url with config token")
+	}
+}
+
+// matchNotifyConfigs 按通知中的提供商账号查询支付配置，提供商支持时再逐个配置确认通知归属
+func (s *Service) matchNotifyConfigs(ctx context.Context, provider string, identifier payment.NotifyIdentifier, req *payment.NotifyRequest) ([]*entity.PaymentConfig, error) {
+	configs, err := s.findNotifyConfigs(ctx, provider, identifier.NotifyAccountID(req))
+	if err != nil {
+		return nil, err
+	}
+
+	matcher, ok := identifier.(payment.NotifyMatcher)
+	if !ok {
+		return configs, nil
+	}
+
+	matched := configs[:0]
+	for _, config := range configs {
+		if matcher.MatchNotify(req, config.ConfigData) {
+			matched = append(matched, config)
+		}
+	}
+	return matched, nil
+}
+
+// findNotifyConfigs 按提供商账号查询支付配置，通知中没有账号时返回该提供商所有可识别的配置
+func (s *Service) findNotifyConfigs(ctx context.Context, provider, accountID string) ([]*entity.PaymentConfig, error) {
+	if accountID != "" {
+		return s.configRepo.GetActiveByProviderAccount(ctx, provider, accountID)
+	}
+
+	configs, err := s.configRepo.GetActiveByProvider(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	identified := configs[:0]
+	for _, config := range configs {
+		if config.AccountID != "" {
+			identified = append(identified, config)
+		}
+	}
+	return identified, nil
+}
+
+// syncNotifyAccounts 根据配置数据重建提供商的账号索引，返回是否执行了重建
+// 支付配置可直接写入数据库，账号索引在通知未命中时补齐
+func (s *Service) syncNotifyAccounts(ctx context.Context, provider string, identifier payment.NotifyIdentifier) bool {
+	acquired, err := cache.Client.SetNX(ctx, "notify:account_sync:"+provider, 1, notifyAccountSyncInterval).Result()
+	if err != nil || !acquired {
+		return false
+	}
+
+	configs, err := s.configRepo.GetActiveByProvider(ctx, provider)
+	if err != nil {
+		logger.Error("failed to get payment configs", zap.String("provider", provider), zap.Error(err))
+		return false
+	}
+
+	for _, config := range configs {
+		accountID := identifier.AccountID(config.ConfigData)
+		if accountID == config.AccountID {
+			continue
+		}
+
+		config.AccountID = accountID
+		if err := s.configRepo.Update(ctx, config); err != nil {
+			logger.Error("failed to update payment config", zap.Uint64("config_id", config.ID), zap.Error(err))
+		}
+	}
+
+	return true
+}
+
+// GetNotifyURL 获取商户支付配置的通知地址，用于在提供商后台（如 Stripe、PayPal Webhook）配置
+func (s *Service) GetNotifyURL(ctx context.Context, userID uint64, provider string) (string, error) {
+	config, err := s.getConfigWithCache(ctx, userID, provider)
+	if err != nil {
+		return "", err
+	}
+
+	return s.notifyURL(provider, config.ID)
+}
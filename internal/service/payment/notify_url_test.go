@@ -0,0 +1,177 @@
+package payment
+
+import (
+	"context"
+	"net/url"
+	"path"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zqdfound/go-uni-pay/internal/domain/entity"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+)
+
+// TestSetNotifySecret_Required 测试未配置通知签名密钥时不能启动
+func TestSetNotifySecret_Required(t *testing.T) {
+	env := newTestEnv(t)
+	err := env.svc.SetNotifySecret("", true)
+	require.Error(t, err)
+	assert.Equal(t, apperrors.ErrInvalidParam, err.(*apperrors.AppError).Code)
+}
+
+// TestResolveNotifyConfig 测试通知地址中的配置令牌必须带有正确的签名，旧地址只在兼容模式下接受
+func TestResolveNotifyConfig(t *testing.T) {
+	env := newTestEnv(t)
+	config := env.addConfig("stripe", entity.ConfigData{"secret_key": "sk_test_1"})
+	ctx := context.Background()
+
+	notifyURL, err := env.svc.notifyURL("stripe", config.ID)
+	require.NoError(t, err)
+	u, err := url.Parse(notifyURL)
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v1/public/notify/stripe", path.Dir(u.Path))
+	token := path.Base(u.Path)
+
+	data, err := env.svc.ResolveNotifyConfig(ctx, "stripe", token)
+	require.NoError(t, err)
+	assert.Equal(t, "sk_test_1", data["secret_key"])
+
+	id := strconv.FormatUint(config.ID, 10)
+	tests := []struct {
+		name     string
+		provider string
+		token    string
+		code     apperrors.ErrorCode
+	}{
+		{"unsigned", "stripe", id, apperrors.ErrUnauthorized},
+		{"wrong signature", "stripe", id + ".0123456789abcdef0123456789abcdef", apperrors.ErrUnauthorized},
+		{"other provider", "paypal", token, apperrors.ErrUnauthorized},
+		{"other config", "stripe", strconv.FormatUint(config.ID+1, 10) + token[len(id):], apperrors.ErrUnauthorized},
+		{"malformed", "stripe", "abc", apperrors.ErrInvalidParam},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := env.svc.ResolveNotifyConfig(ctx, tt.provider, tt.token)
+			require.Error(t, err)
+			assert.Equal(t, tt.code, err.(*apperrors.AppError).Code)
+		})
+	}
+
+	// 兼容模式下仍接受只有配置ID的旧地址，签名错误仍拒绝
+	require.NoError(t, env.svc.SetNotifySecret("test-notify-secret", true))
+	data, err = env.svc.ResolveNotifyConfig(ctx, "stripe", id)
+	require.NoError(t, err)
+	assert.Equal(t, "sk_test_1", data["secret_key"])
+	_, err = env.svc.ResolveNotifyConfig(ctx, "paypal", id)
+	require.Error(t, err)
+	assert.Equal(t, apperrors.ErrConfigNotFound, err.(*apperrors.AppError).Code)
+	_, err = env.svc.ResolveNotifyConfig(ctx, "paypal", token)
+	require.Error(t, err)
+	assert.Equal(t, apperrors.ErrUnauthorized, err.(*apperrors.AppError).Code)
+}
+
+// TestCreatePayment_RequiresBaseURL 测试未配置 baseURL 时无法生成通知地址，不创建订单也不调用提供商
+func TestCreatePayment_RequiresBaseURL(t *testing.T) {
+	env := newTestEnv(t)
+	env.svc.baseURL = ""
+	prov := newMockProvider(t)
+	payment.Register(prov)
+	env.addConfig(prov.name, entity.ConfigData{})
+
+	_, err := env.svc.CreatePayment(context.Background(), &CreatePaymentRequest{
+		UserID:     testUserID,
+		Provider:   prov.name,
+		OutTradeNo: "ORDER1",
+		Subject:    "Order",
+		Amount:     10,
+	})
+	require.Error(t, err)
+	assert.Equal(t, apperrors.ErrNotSupported, err.(*apperrors.AppError).Code)
+
+	_, err = env.orders.GetByOutTradeNo(context.Background(), "ORDER1")
+	assert.Error(t, err)
+	prov.AssertNotCalled(t, "CreatePayment")
+}
+
+// mockIdentifier 可从通知内容读取账号的提供商，通知内容即为账号
+type mockIdentifier struct {
+	*mockProvider
+}
+
+func (p *mockIdentifier) AccountID(config map[string]interface{}) string {
+	accountID, _ := config["account_id"].(string)
+	return accountID
+}
+
+func (p *mockIdentifier) NotifyAccountID(req *payment.NotifyRequest) string {
+	return string(req.RawData)
+}
+
+// mockMatcher 通知内容加密的提供商，通知内容为加密使用的密钥
+type mockMatcher struct {
+	*mockIdentifier
+}
+
+func (p *mockMatcher) NotifyAccountID(req *payment.NotifyRequest) string {
+	return ""
+}
+
+func (p *mockMatcher) MatchNotify(req *payment.NotifyRequest, config map[string]interface{}) bool {
+	return config["key"] == string(req.RawData)
+}
+
+// TestResolveProviderNotifyConfig 测试不带配置令牌的通知按通知内容识别支付配置，账号索引在未命中时重建
+func TestResolveProviderNotifyConfig(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	identifier := &mockIdentifier{newMockProvider(t)}
+	payment.Register(identifier)
+	env.addConfig(identifier.name, entity.ConfigData{"account_id": "acct_1"})
+	env.addConfig(identifier.name, entity.ConfigData{"account_id": "acct_1"})
+	env.addConfig(identifier.name, entity.ConfigData{"account_id": "acct_2", "name": "second"})
+	env.addConfig(identifier.name, entity.ConfigData{})
+
+	matcher := &mockMatcher{&mockIdentifier{newMockProvider(t)}}
+	matcher.name += "_matcher"
+	payment.Register(matcher)
+	env.addConfig(matcher.name, entity.ConfigData{"account_id": "mch_1", "key": "key_1"})
+	env.addConfig(matcher.name, entity.ConfigData{"account_id": "mch_2", "key": "key_2", "name": "second"})
+	env.addConfig(matcher.name, entity.ConfigData{"key": "key_2"})
+
+	plain := newMockProvider(t)
+	plain.name += "_plain"
+	payment.Register(plain)
+
+	data, err := env.svc.ResolveProviderNotifyConfig(ctx, identifier.name, &payment.NotifyRequest{RawData: []byte("acct_2")})
+	require.NoError(t, err)
+	assert.Equal(t, "second", data["name"])
+
+	// 配置中没有账号的不参与识别，只有解密成功的配置匹配
+	data, err = env.svc.ResolveProviderNotifyConfig(ctx, matcher.name, &payment.NotifyRequest{RawData: []byte("key_2")})
+	require.NoError(t, err)
+	assert.Equal(t, "second", data["name"])
+
+	tests := []struct {
+		name     string
+		provider string
+		raw      string
+		code     apperrors.ErrorCode
+	}{
+		{"unknown account", identifier.name, "acct_9", apperrors.ErrConfigNotFound},
+		{"ambiguous account", identifier.name, "acct_1", apperrors.ErrConfigNotFound},
+		{"no account in notify", identifier.name, "", apperrors.ErrConfigNotFound},
+		{"no config decrypts", matcher.name, "key_9", apperrors.ErrConfigNotFound},
+		{"not identifiable", plain.name, "acct_2", apperrors.ErrNotSupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := env.svc.ResolveProviderNotifyConfig(ctx, tt.provider, &payment.NotifyRequest{RawData: []byte(tt.raw)})
+			require.Error(t, err)
+			assert.Equal(t, tt.code, err.(*apperrors.AppError).Code)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/zqdfound/go-uni-pay/internal/domain/entity"
+	"github.com/zqdfound/go-uni-pay/internal/infrastructure/cache"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+	"github.com/zqdfound/go-uni-pay/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// OrderStatusEvent 订单状态变更事件
+type OrderStatusEvent struct {
+	OrderNo     string     `json:"order_no"`
+	OutTradeNo  string     `json:"out_trade_no"`
+	Status      string     `json:"status"`
+	Amount      float64    `json:"amount"`
+	Currency    string     `json:"currency"`
+	PaymentTime *time.Time `json:"payment_time,omitempty"`
+}
+
+// orderStatusChannel 订单状态变更的 Redis 频道，多个实例通过该频道共享订单状态变更
+func orderStatusChannel(orderNo string) string {
+	return fmt.Sprintf("order:status:%s", orderNo)
+}
+
+// newOrderStatusEvent 根据订单生成状态事件
+func newOrderStatusEvent(order *entity.PaymentOrder) *OrderStatusEvent {
+	return &OrderStatusEvent{
+		OrderNo:     order.OrderNo,
+		OutTradeNo:  order.OutTradeNo,
+		Status:      order.Status,
+		Amount:      order.Amount,
+		Currency:    order.Currency,
+		PaymentTime: order.PaymentTime,
+	}
+}
+
+// publishOrderStatus 发布订单状态变更，失败时仅记录日志，订阅方可通过查询接口获取最新状态
+func (s *Service) publishOrderStatus(ctx context.Context, order *entity.PaymentOrder) {
+	data, err := json.Marshal(newOrderStatusEvent(order))
+	if err != nil {
+		return
+	}
+
+	if err := cache.Publish(ctx, orderStatusChannel(order.OrderNo), data); err != nil {
+		logger.Error("failed to publish order status",
+			zap.String("order_no", order.OrderNo),
+			zap.Error(err))
+	}
+}
+
+// streamTerminal 订单状态推送在该状态后结束：最终状态或支付失败
+// 失败的订单不会再变更，商户需使用相同商户订单号重新创建订单
+func streamTerminal(status string) bool {
+	return status == entity.OrderStatusFailed || isFinalStatus(status)
+}
+
+// WatchOrderStatus 订阅订单状态变更
+// 首个事件为订单当前状态，订单进入最终状态、支付失败或 ctx 结束时关闭返回的通道
+func (s *Service) WatchOrderStatus(ctx context.Context, userID uint64, orderNo string) (<-chan *OrderStatusEvent, error) {
+	// 先订阅再读取订单，避免遗漏两者之间发生的状态变更
+	pubsub := cache.Subscribe(ctx, orderStatusChannel(orderNo))
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, apperrors.Wrap(apperrors.ErrInternalServer, "failed to subscribe order status", err)
+	}
+
+	order, err := s.orderRepo.GetByOrderNo(ctx, orderNo)
+	if err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	// 验证订单归属（数据隔离）
+	if order.UserID != userID {
+		pubsub.Close()
+		return nil, apperrors.New(apperrors.ErrOrderNotFound, "order not found")
+	}
+
+	events := make(chan *OrderStatusEvent, 1)
+	events <- newOrderStatusEvent(order)
+	if streamTerminal(order.Status) {
+		pubsub.Close()
+		close(events)
+		return events, nil
+	}
+
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				var event OrderStatusEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					continue
+				}
+
+				select {
+				case events <- &event:
+				case <-ctx.Done():
+					return
+				}
+				if streamTerminal(event.Status) {
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// WaitPayment 长轮询查询支付：订单未进入最终状态且未失败时等待状态变更，最长等待 wait 后返回订单
+// 等待时间包含首次查询提供商的耗时
+func (s *Service) WaitPayment(ctx context.Context, userID uint64, orderNo string, wait time.Duration) (interface{}, error) {
+	waitCtx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+
+	result, err := s.QueryPayment(ctx, userID, orderNo)
+	if err != nil || wait <= 0 {
+		return result, err
+	}
+
+	order, ok := result.(*entity.PaymentOrder)
+	if !ok || streamTerminal(order.Status) {
+		return result, nil
+	}
+
+	events, err := s.WatchOrderStatus(waitCtx, userID, orderNo)
+	if err != nil {
+		// 订阅失败时直接返回当前状态，由客户端重新查询
+		logger.Error("failed to watch order status", zap.String("order_no", orderNo), zap.Error(err))
+		return result, nil
+	}
+	// 通道在订单进入最终状态、支付失败或等待超时时关闭
+	for range events {
+	}
+
+	return s.orderRepo.GetByOrderNo(ctx, orderNo)
+}
@@ -0,0 +1,126 @@
+package payment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zqdfound/go-uni-pay/internal/domain/entity"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+)
+
+// nextEvent 读取下一个订单状态事件，通道关闭时 ok 为 false
+func nextEvent(t *testing.T, events <-chan *OrderStatusEvent) (*OrderStatusEvent, bool) {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		return event, ok
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for order status event")
+		return nil, false
+	}
+}
+
+// TestWatchOrderStatus_ClosesOnTerminal 测试订单支付成功或失败后推送状态并关闭通道
+func TestWatchOrderStatus_ClosesOnTerminal(t *testing.T) {
+	for _, status := range []string{entity.OrderStatusSuccess, entity.OrderStatusFailed} {
+		t.Run(status, func(t *testing.T) {
+			env := newTestEnv(t)
+			config := env.addConfig("watch", entity.ConfigData{})
+			order := env.addOrder(config, &entity.PaymentOrder{OutTradeNo: "ORDER1", Amount: 10, Status: entity.OrderStatusPending})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			events, err := env.svc.WatchOrderStatus(ctx, testUserID, order.OrderNo)
+			require.NoError(t, err)
+
+			event, ok := nextEvent(t, events)
+			require.True(t, ok)
+			assert.Equal(t, entity.OrderStatusPending, event.Status)
+
+			require.NoError(t, env.svc.updateOrderStatus(ctx, order, status))
+
+			event, ok = nextEvent(t, events)
+			require.True(t, ok)
+			assert.Equal(t, status, event.Status)
+			assert.Equal(t, order.OrderNo, event.OrderNo)
+
+			_, ok = nextEvent(t, events)
+			assert.False(t, ok)
+		})
+	}
+}
+
+// TestWatchOrderStatus_AlreadyTerminal 测试订单已失败时只推送当前状态
+func TestWatchOrderStatus_AlreadyTerminal(t *testing.T) {
+	env := newTestEnv(t)
+	config := env.addConfig("watch", entity.ConfigData{})
+	order := env.addOrder(config, &entity.PaymentOrder{OutTradeNo: "ORDER1", Amount: 10, Status: entity.OrderStatusFailed})
+
+	events, err := env.svc.WatchOrderStatus(context.Background(), testUserID, order.OrderNo)
+	require.NoError(t, err)
+
+	event, ok := nextEvent(t, events)
+	require.True(t, ok)
+	assert.Equal(t, entity.OrderStatusFailed, event.Status)
+
+	_, ok = nextEvent(t, events)
+	assert.False(t, ok)
+}
+
+// TestWaitPayment 测试长轮询在订单失败时立即返回，状态变更时被唤醒，超时后返回当前订单
+func TestWaitPayment(t *testing.T) {
+	tests := []struct {
+		name        string
+		queryStatus string
+		update      string
+		wantStatus  string
+	}{
+		{"failed on query", payment.StatusFailed, "", entity.OrderStatusFailed},
+		{"wakes on success", payment.StatusPending, entity.OrderStatusSuccess, entity.OrderStatusSuccess},
+		{"wakes on failed", payment.StatusPending, entity.OrderStatusFailed, entity.OrderStatusFailed},
+		{"times out", payment.StatusPending, "", entity.OrderStatusPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			prov := newMockProvider(t)
+			payment.Register(prov)
+			prov.On("QueryPayment", mock.Anything, mock.Anything).
+				Return(&payment.QueryPaymentResponse{Status: tt.queryStatus}, nil)
+
+			config := env.addConfig(prov.name, entity.ConfigData{})
+			order := env.addOrder(config, &entity.PaymentOrder{OutTradeNo: "ORDER1", Amount: 10, Status: entity.OrderStatusPending})
+
+			wait := time.Second
+			if tt.update != "" {
+				// 订单状态在等待期间由支付通知更新
+				wait = 5 * time.Second
+				go func() {
+					time.Sleep(100 * time.Millisecond)
+					current, err := env.orders.GetByOrderNo(context.Background(), order.OrderNo)
+					if err == nil {
+						env.svc.updateOrderStatus(context.Background(), current, tt.update)
+					}
+				}()
+			}
+
+			start := time.Now()
+			result, err := env.svc.WaitPayment(context.Background(), testUserID, order.OrderNo, wait)
+			elapsed := time.Since(start)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, result.(*entity.PaymentOrder).Status)
+
+			if tt.wantStatus == entity.OrderStatusPending {
+				assert.GreaterOrEqual(t, elapsed, wait)
+			} else {
+				assert.Less(t, elapsed, time.Second)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zqdfound/go-uni-pay/internal/domain/entity"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+	"github.com/zqdfound/go-uni-pay/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// CreatePaymentLinkRequest 创建支付链接请求
+// CustomAmount 为 true 时由买家填写金额；Providers 为空时买家可选择所有启用了支付配置的提供商
+type CreatePaymentLinkRequest struct {
+	UserID       uint64
+	Subject      string
+	Description  string
+	Amount       float64
+	CustomAmount bool
+	Currency     string
+	Providers    []string
+	ExpireAt     *time.Time
+	MaxUses      int
+	NotifyURL    string
+	ReturnURL    string
+}
+
+// UpdatePaymentLinkRequest 更新支付链接请求，为 nil 的字段不更新
+type UpdatePaymentLinkRequest struct {
+	UserID       uint64
+	LinkNo       string
+	Subject      *string
+	Description  *string
+	Amount       *float64
+	CustomAmount *bool
+	Providers    []string
+	ExpireAt     *time.Time
+	MaxUses      *int
+	Status       *string
+	NotifyURL    *string
+	ReturnURL    *string
+}
+
+// PaymentLinkPage 支付链接页面数据，Unavailable 不为空时链接不可支付
+type PaymentLinkPage struct {
+	Link        *entity.PaymentLink
+	Providers   []string
+	Unavailable string
+}
+
+// CreatePaymentLink 创建支付链接，返回可分享给买家的链接地址
+func (s *Service) CreatePaymentLink(ctx context.Context, req *CreatePaymentLinkRequest) (*entity.PaymentLink, error) {
+	if s.baseURL == "" {
+		return nil, apperrors.New(apperrors.ErrNotSupported, "base url is required for payment links")
+	}
+
+	if !req.CustomAmount && req.Amount <= 0 {
+		return nil, apperrors.New(apperrors.ErrInvalidParam, "amount is required unless custom_amount is enabled")
+	}
+
+	if err := s.validateProviders(ctx, req.UserID, req.Providers); err != nil {
+		return nil, err
+	}
+
+	linkNo := fmt.Sprintf("LNK%d%s", time.Now().UnixNano(), uuid.New().String()[:8])
+	link := &entity.PaymentLink{
+		LinkNo:       linkNo,
+		UserID:       req.UserID,
+		Subject:      req.Subject,
+		Description:  req.Description,
+		Amount:       req.Amount,
+		CustomAmount: req.CustomAmount,
+		Currency:     req.Currency,
+		Providers:    req.Providers,
+		ExpireAt:     req.ExpireAt,
+		MaxUses:      req.MaxUses,
+		Status:       entity.PaymentLinkStatusActive,
+		URL:          fmt.Sprintf("%s/pay/%s", s.baseURL, linkNo),
+		NotifyURL:    req.NotifyURL,
+		ReturnURL:    req.ReturnURL,
+	}
+	if link.CustomAmount {
+		link.Amount = 0
+	}
+	if err := s.paymentLinkRepo.Create(ctx, link); err != nil {
+		return nil, err
+	}
+
+	return link, nil
+}
+
+// QueryPaymentLink 查询支付链接
+func (s *Service) QueryPaymentLink(ctx context.Context, userID uint64, linkNo string) (*entity.PaymentLink, error) {
+	link, err := s.paymentLinkRepo.GetByLinkNo(ctx, linkNo)
+	if err != nil {
+		return nil, err
+	}
+
+	// 验证支付链接归属（数据隔离）
+	if link.UserID != userID {
+		return nil, apperrors.New(apperrors.ErrNotFound, "payment link not found")
+	}
+
+	return link, nil
+}
+
+// ListPaymentLinks 分页查询商户的支付链接
+func (s *Service) ListPaymentLinks(ctx context.Context, userID uint64, page, pageSize int) ([]*entity.PaymentLink, int64, error) {
+	return s.paymentLinkRepo.List(ctx, userID, page, pageSize)
+}
+
+// UpdatePaymentLink 更新支付链接，已完成的链接不能再启用
+func (s *Service) UpdatePaymentLink(ctx context.Context, req *UpdatePaymentLinkRequest) (*entity.PaymentLink, error) {
+	link, err := s.QueryPaymentLink(ctx, req.UserID, req.LinkNo)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Subject != nil {
+		link.Subject = *req.Subject
+	}
+	if req.Description != nil {
+		link.Description = *req.Description
+	}
+	if req.CustomAmount != nil {
+		link.CustomAmount = *req.CustomAmount
+	}
+	if req.Amount != nil {
+		link.Amount = *req.Amount
+	}
+	if link.CustomAmount {
+		link.Amount = 0
+	} else if link.Amount <= 0 {
+		return nil, apperrors.New(apperrors.ErrInvalidParam, "amount is required unless custom_amount is enabled")
+	}
+	if req.Providers != nil {
+		if err := s.validateProviders(ctx, req.UserID, req.Providers); err != nil {
+			return nil, err
+		}
+		link.Providers = req.Providers
+	}
+	if req.ExpireAt != nil {
+		link.ExpireAt = req.ExpireAt
+	}
+	if req.MaxUses != nil {
+		link.MaxUses = *req.MaxUses
+	}
+	if req.NotifyURL != nil {
+		link.NotifyURL = *req.NotifyURL
+	}
+	if req.ReturnURL != nil {
+		link.ReturnURL = *req.ReturnURL
+	}
+	if req.Status != nil && *req.Status != link.Status {
+		if link.Status == entity.PaymentLinkStatusCompleted {
+			return nil, apperrors.New(apperrors.ErrOrderStatus, "completed payment link cannot be changed")
+		}
+		link.Status = *req.Status
+	}
+
+	if err := s.paymentLinkRepo.Update(ctx, link); err != nil {
+		return nil, err
+	}
+
+	return link, nil
+}
+
+// DeletePaymentLink 删除支付链接，已创建的订单保留
+func (s *Service) DeletePaymentLink(ctx context.Context, userID uint64, linkNo string) error {
+	link, err := s.QueryPaymentLink(ctx, userID, linkNo)
+	if err != nil {
+		return err
+	}
+
+	return s.paymentLinkRepo.Delete(ctx, link.ID)
+}
+
+// ListPaymentLinkOrders 分页查询通过支付链接创建的订单
+func (s *Service) ListPaymentLinkOrders(ctx context.Context, userID uint64, linkNo string, page, pageSize int) ([]*entity.PaymentOrder, int64, error) {
+	link, err := s.QueryPaymentLink(ctx, userID, linkNo)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return s.orderRepo.ListByPaymentLink(ctx, link.LinkNo, page, pageSize)
+}
+
+// GetPaymentLinkPage 获取买家访问支付链接时展示的数据
+func (s *Service) GetPaymentLinkPage(ctx context.Context, linkNo string) (*PaymentLinkPage, error) {
+	link, err := s.paymentLinkRepo.GetByLinkNo(ctx, linkNo)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &PaymentLinkPage{Link: link}
+	if err := checkPaymentLink(link); err != nil {
+		page.Unavailable = err.Message
+		return page, nil
+	}
+
+	page.Providers = s.availableProviders(ctx, link.UserID, link.Providers)
+	return page, nil
+}
+
+// PayPaymentLink 买家通过支付链接选择提供商后创建支付订单
+// 每次支付生成新的商户订单号，订单通过 PaymentLinkNo 关联支付链接；amount 仅在链接允许买家填写金额时生效
+func (s *Service) PayPaymentLink(ctx context.Context, linkNo, provider string, amount float64, clientIP string, mobile bool) (*CreatePaymentResponse, error) {
+	link, err := s.paymentLinkRepo.GetByLinkNo(ctx, linkNo)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkPaymentLink(link); err != nil {
+		return nil, err
+	}
+
+	if !link.CustomAmount {
+		amount = link.Amount
+	} else if amount <= 0 {
+		return nil, apperrors.New(apperrors.ErrInvalidParam, "amount must be greater than 0")
+	}
+
+	if err := s.checkProviderAvailable(ctx, link.UserID, link.Providers, provider); err != nil {
+		return nil, err
+	}
+
+	scene := ""
+	if mobile {
+		scene = payment.SceneH5
+	}
+
+	return s.CreatePayment(ctx, &CreatePaymentRequest{
+		UserID:        link.UserID,
+		Provider:      provider,
+		OutTradeNo:    s.generateLinkOrderNo(),
+		Subject:       link.Subject,
+		Body:          link.Description,
+		Amount:        amount,
+		Currency:      link.Currency,
+		Scene:         scene,
+		NotifyURL:     link.NotifyURL,
+		ReturnURL:     link.ReturnURL,
+		ClientIP:      clientIP,
+		PaymentLinkNo: link.LinkNo,
+	})
+}
+
+// generateLinkOrderNo 生成支付链接订单的商户订单号
+// 只包含字母和数字且不超过32位，满足微信支付 out_trade_no 和银联 orderId 的格式要求
+func (s *Service) generateLinkOrderNo() string {
+	return fmt.Sprintf("PL%d%s", time.Now().UnixNano(), uuid.New().String()[:8])
+}
+
+// recordPaymentLinkOrder 将支付成功的订单汇总到支付链接
+func (s *Service) recordPaymentLinkOrder(ctx context.Context, order *entity.PaymentOrder) {
+	if order.PaymentLinkNo == "" {
+		return
+	}
+
+	link, err := s.paymentLinkRepo.GetByLinkNo(ctx, order.PaymentLinkNo)
+	if err != nil {
+		// 链接已删除时不再汇总
+		return
+	}
+
+	if err := s.paymentLinkRepo.RecordPayment(ctx, link.ID, order.Amount); err != nil {
+		logger.Error("failed to record payment link order",
+			zap.String("link_no", link.LinkNo),
+			zap.String("order_no", order.OrderNo),
+			zap.Error(err))
+	}
+}
+
+// checkPaymentLink 检查支付链接是否可以支付
+// 并发访问时最大支付次数按支付成功的订单计算，可能略有超出
+func checkPaymentLink(link *entity.PaymentLink) *apperrors.AppError {
+	if link.Status != entity.PaymentLinkStatusActive {
+		return apperrors.New(apperrors.ErrOrderStatus, fmt.Sprintf("payment link is %s", link.Status))
+	}
+	if link.ExpireAt != nil && time.Now().After(*link.ExpireAt) {
+		return apperrors.New(apperrors.ErrOrderStatus, "payment link has expired")
+	}
+	if link.MaxUses > 0 && link.PaidCount >= link.MaxUses {
+		return apperrors.New(apperrors.ErrOrderStatus, "payment link has reached its maximum uses")
+	}
+	return nil
+}
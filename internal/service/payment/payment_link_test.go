@@ -0,0 +1,49 @@
+package payment
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zqdfound/go-uni-pay/internal/domain/entity"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+)
+
+// TestPayPaymentLink_OutTradeNo 测试每次通过支付链接支付生成新的商户订单号，只包含字母和数字且不超过32位，订单关联支付链接
+func TestPayPaymentLink_OutTradeNo(t *testing.T) {
+	env := newTestEnv(t)
+	prov := newMockProvider(t)
+	payment.Register(prov)
+	env.addConfig(prov.name, entity.ConfigData{})
+
+	link := &entity.PaymentLink{
+		LinkNo:   "LNK1700000000000000000abcdef12",
+		UserID:   testUserID,
+		Subject:  "Donation",
+		Amount:   10,
+		Currency: "CNY",
+		Status:   entity.PaymentLinkStatusActive,
+	}
+	env.links.create(link)
+
+	outTradeNo := regexp.MustCompile(`^[A-Za-z0-9]{1,32}$`)
+	prov.On("CreatePayment", mock.Anything, mock.MatchedBy(func(req *payment.CreatePaymentRequest) bool {
+		return outTradeNo.MatchString(req.OutTradeNo) && req.Amount == 10
+	})).Return(&payment.CreatePaymentResponse{PaymentURL: "https://provider.example.com/pay"}, nil).Twice()
+
+	ctx := context.Background()
+	first, err := env.svc.PayPaymentLink(ctx, link.LinkNo, prov.name, 0, "127.0.0.1", false)
+	require.NoError(t, err)
+	second, err := env.svc.PayPaymentLink(ctx, link.LinkNo, prov.name, 0, "127.0.0.1", false)
+	require.NoError(t, err)
+
+	firstOrder := env.order(t, first.OrderNo)
+	secondOrder := env.order(t, second.OrderNo)
+	assert.NotEqual(t, firstOrder.OutTradeNo, secondOrder.OutTradeNo)
+	assert.Equal(t, link.LinkNo, firstOrder.PaymentLinkNo)
+	assert.Equal(t, link.LinkNo, secondOrder.PaymentLinkNo)
+	prov.AssertExpectations(t)
+}
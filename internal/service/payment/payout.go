@@ -0,0 +1,335 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zqdfound/go-uni-pay/internal/domain/entity"
+	"github.com/zqdfound/go-uni-pay/internal/infrastructure/cache"
+	"github.com/zqdfound/go-uni-pay/internal/infrastructure/lock"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+	"github.com/zqdfound/go-uni-pay/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// payoutSyncDelay 付款创建后等待通知的时间，超过后才主动查询
+const payoutSyncDelay = time.Minute
+
+// payoutSyncBatch 每次同步的处理中付款数量
+const payoutSyncBatch = 100
+
+// CreatePayoutRequest 创建付款请求
+type CreatePayoutRequest struct {
+	UserID       uint64
+	Provider     string
+	OutPayoutNo  string
+	Amount       float64
+	Currency     string
+	PayeeType    string
+	PayeeAccount string
+	PayeeName    string
+	Remark       string
+	NotifyURL    string
+}
+
+// CreatePayout 发起付款
+// 同一商户付款单号只会付款一次，重复请求返回已有付款；付款失败后需使用新的商户付款单号重新发起
+func (s *Service) CreatePayout(ctx context.Context, req *CreatePayoutRequest) (*entity.Payout, error) {
+	if req.Amount <= 0 {
+		return nil, apperrors.New(apperrors.ErrAmountInvalid, "payout amount must be positive")
+	}
+
+	prov, err := payment.GetProvider(req.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	payouter, ok := prov.(payment.Payouter)
+	if !ok {
+		return nil, apperrors.New(apperrors.ErrNotSupported, fmt.Sprintf("provider %s does not support payouts", req.Provider))
+	}
+
+	var payout *entity.Payout
+	lockKey := fmt.Sprintf("payout:create:%d:%s", req.UserID, req.OutPayoutNo)
+	err = lock.WithLock(ctx, cache.Client, lockKey, 30*time.Second, func() error {
+		// 检查付款是否已存在（幂等性保证）
+		if existing, err := s.payoutRepo.GetByUserAndOutPayoutNo(ctx, req.UserID, req.OutPayoutNo); err == nil {
+			logger.Info("payout already exists",
+				zap.String("out_payout_no", req.OutPayoutNo),
+				zap.String("payout_no", existing.PayoutNo),
+				zap.String("status", existing.Status))
+			payout = existing
+			return nil
+		}
+
+		config, err := s.getConfigWithCache(ctx, req.UserID, req.Provider)
+		if err != nil {
+			return err
+		}
+
+		notifyURL, err := s.notifyURL(req.Provider, config.ID)
+		if err != nil {
+			return err
+		}
+
+		payout = &entity.Payout{
+			PayoutNo:     s.generatePayoutNo(),
+			UserID:       req.UserID,
+			OutPayoutNo:  req.OutPayoutNo,
+			Provider:     req.Provider,
+			ConfigID:     config.ID,
+			Amount:       req.Amount,
+			Currency:     req.Currency,
+			PayeeType:    req.PayeeType,
+			PayeeAccount: req.PayeeAccount,
+			PayeeName:    req.PayeeName,
+			Remark:       req.Remark,
+			NotifyURL:    req.NotifyURL,
+			Status:       entity.PayoutStatusProcessing,
+		}
+		if err := s.payoutRepo.Create(ctx, payout); err != nil {
+			return err
+		}
+
+		payoutReq := &payment.PayoutRequest{
+			PayoutNo:     payout.PayoutNo,
+			Amount:       payout.Amount,
+			Currency:     payout.Currency,
+			PayeeType:    payout.PayeeType,
+			PayeeAccount: payout.PayeeAccount,
+			PayeeName:    payout.PayeeName,
+			Remark:       payout.Remark,
+			NotifyURL:    notifyURL,
+			Config:       config.ConfigData,
+		}
+
+		payoutResp, err := payouter.CreatePayout(ctx, payoutReq)
+		if err != nil {
+			s.logPayment(ctx, 0, payout.PayoutNo, "payout", payout.Provider, payoutReq, nil, "failed", err.Error())
+			payout.Status = entity.PayoutStatusFailed
+			payout.FailReason = truncate(err.Error(), 256)
+			if updateErr := s.payoutRepo.Update(ctx, payout); updateErr != nil {
+				logger.Error("failed to update payout", zap.String("payout_no", payout.PayoutNo), zap.Error(updateErr))
+			}
+			return err
+		}
+
+		s.logPayment(ctx, 0, payout.PayoutNo, "payout", payout.Provider, payoutReq, payoutResp, "success", "")
+
+		// 付款通知可能先于同步应答到达，以数据库中的状态为准
+		if current, err := s.payoutRepo.GetByPayoutNo(ctx, payout.PayoutNo); err == nil {
+			*payout = *current
+		}
+
+		return s.updatePayoutStatus(ctx, payout, payoutResp.Status, payoutResp.PayoutID, payoutResp.FailReason)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return payout, nil
+}
+
+// QueryPayout 查询付款，处理中的付款会向第三方查询并同步状态
+func (s *Service) QueryPayout(ctx context.Context, userID uint64, payoutNo string) (*entity.Payout, error) {
+	payout, err := s.payoutRepo.GetByPayoutNo(ctx, payoutNo)
+	if err != nil {
+		return nil, err
+	}
+
+	// 验证付款归属（数据隔离）
+	if payout.UserID != userID {
+		return nil, apperrors.New(apperrors.ErrNotFound, "payout not found")
+	}
+
+	if payout.Status == entity.PayoutStatusProcessing {
+		if err := s.syncPayout(ctx, payout); err != nil {
+			logger.Warn("failed to sync payout", zap.String("payout_no", payout.PayoutNo), zap.Error(err))
+		}
+	}
+
+	return payout, nil
+}
+
+// StartPayoutSync 启动处理中付款的定时同步，用于补偿丢失的付款通知
+func (s *Service) StartPayoutSync(interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	logger.Info("payout sync started", zap.Duration("interval", interval))
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stopCh:
+				logger.Info("payout sync stopped")
+				return
+			case <-ticker.C:
+				s.SyncProcessingPayouts(context.Background())
+			}
+		}
+	}()
+}
+
+// SyncProcessingPayouts 向第三方同步处理中的付款
+func (s *Service) SyncProcessingPayouts(ctx context.Context) {
+	payouts, err := s.payoutRepo.ListProcessing(ctx, time.Now().Add(-payoutSyncDelay), payoutSyncBatch)
+	if err != nil {
+		logger.Error("failed to list processing payouts", zap.Error(err))
+		return
+	}
+
+	for _, payout := range payouts {
+		if err := s.syncPayout(ctx, payout); err != nil {
+			logger.Warn("failed to sync payout", zap.String("payout_no", payout.PayoutNo), zap.Error(err))
+		}
+	}
+}
+
+// syncPayout 向第三方查询付款状态
+func (s *Service) syncPayout(ctx context.Context, payout *entity.Payout) error {
+	prov, err := payment.GetProvider(payout.Provider)
+	if err != nil {
+		return err
+	}
+
+	payouter, ok := prov.(payment.Payouter)
+	if !ok {
+		return nil
+	}
+
+	config, err := s.configRepo.GetByID(ctx, payout.ConfigID)
+	if err != nil {
+		return err
+	}
+
+	queryReq := &payment.QueryPayoutRequest{
+		PayoutNo: payout.PayoutNo,
+		PayoutID: payout.PayoutID,
+		Config:   config.ConfigData,
+	}
+
+	queryResp, err := payouter.QueryPayout(ctx, queryReq)
+	if err != nil {
+		s.logPayment(ctx, 0, payout.PayoutNo, "payout_query", payout.Provider, queryReq, nil, "failed", err.Error())
+		// 更新时间用于轮转同步顺序，避免查询失败的付款一直排在最前
+		s.payoutRepo.Update(ctx, payout)
+		return err
+	}
+
+	s.logPayment(ctx, 0, payout.PayoutNo, "payout_query", payout.Provider, queryReq, queryResp, "success", "")
+
+	if queryResp.Status == payout.Status {
+		return s.payoutRepo.Update(ctx, payout)
+	}
+
+	return s.updatePayoutStatus(ctx, payout, queryResp.Status, queryResp.PayoutID, queryResp.FailReason)
+}
+
+// handlePayoutNotify 处理付款结果通知
+// 优先按付款单号查找付款记录，部分通知只包含第三方付款单号
+func (s *Service) handlePayoutNotify(ctx context.Context, provider string, req *payment.NotifyRequest, info *payment.PayoutInfo) error {
+	var payout *entity.Payout
+	if info.PayoutNo != "" {
+		if p, err := s.payoutRepo.GetByPayoutNo(ctx, info.PayoutNo); err == nil && p.Provider == provider {
+			payout = p
+		}
+	}
+	if payout == nil && info.PayoutID != "" {
+		if p, err := s.payoutRepo.GetByProviderPayoutID(ctx, provider, info.PayoutID); err == nil {
+			payout = p
+		}
+	}
+	if payout == nil {
+		// 非本系统发起的付款，忽略
+		logger.Warn("payout not found",
+			zap.String("provider", provider),
+			zap.String("payout_no", info.PayoutNo),
+			zap.String("payout_id", info.PayoutID))
+		return nil
+	}
+
+	s.logPayment(ctx, 0, payout.PayoutNo, "payout_notify", provider, req, info, "success", "")
+
+	if info.Status == payout.Status && (info.PayoutID == "" || info.PayoutID == payout.PayoutID) {
+		return nil
+	}
+
+	return s.updatePayoutStatus(ctx, payout, info.Status, info.PayoutID, info.FailReason)
+}
+
+// updatePayoutStatus 更新付款状态，付款成功或失败后不再变更，并通知商户
+func (s *Service) updatePayoutStatus(ctx context.Context, payout *entity.Payout, status, payoutID, failReason string) error {
+	if payoutID != "" {
+		payout.PayoutID = payoutID
+	}
+
+	oldStatus := payout.Status
+	if oldStatus == entity.PayoutStatusProcessing && status != "" {
+		payout.Status = status
+		if status == entity.PayoutStatusFailed {
+			payout.FailReason = truncate(failReason, 256)
+		}
+	}
+	if payout.Status == entity.PayoutStatusSuccess && payout.FinishTime == nil {
+		now := time.Now()
+		payout.FinishTime = &now
+	}
+
+	if err := s.payoutRepo.Update(ctx, payout); err != nil {
+		logger.Error("failed to update payout", zap.String("payout_no", payout.PayoutNo), zap.Error(err))
+		return err
+	}
+
+	if payout.Status != oldStatus {
+		s.notifyPayout(ctx, payout)
+	}
+
+	return nil
+}
+
+// notifyPayout 付款成功或失败，且付款有通知URL时，添加 payout.* 通知任务
+func (s *Service) notifyPayout(ctx context.Context, payout *entity.Payout) {
+	if payout.NotifyURL == "" || payout.Status == entity.PayoutStatusProcessing {
+		return
+	}
+
+	notifyData := map[string]interface{}{
+		"event":         "payout." + payout.Status,
+		"payout_no":     payout.PayoutNo,
+		"out_payout_no": payout.OutPayoutNo,
+		"payout_id":     payout.PayoutID,
+		"amount":        payout.Amount,
+		"currency":      payout.Currency,
+		"status":        payout.Status,
+		"fail_reason":   payout.FailReason,
+		"finish_time":   payout.FinishTime,
+	}
+
+	if err := s.notifyService.AddNotify(ctx, 0, payout.PayoutNo, payout.NotifyURL, notifyData); err != nil {
+		logger.Error("failed to add payout notify task",
+			zap.String("payout_no", payout.PayoutNo),
+			zap.Error(err))
+	}
+}
+
+// generatePayoutNo 生成付款单号，只包含字母和数字以满足各提供商的单号要求
+func (s *Service) generatePayoutNo() string {
+	return fmt.Sprintf("PO%d%s", time.Now().UnixNano(), uuid.New().String()[:8])
+}
+
+// truncate 截断字符串，避免超出字段长度
+func truncate(s string, maxLen int) string {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
+		return s
+	}
+	return string(runes[:maxLen])
+}
@@ -0,0 +1,143 @@
+package payment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zqdfound/go-uni-pay/internal/domain/entity"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+)
+
+// mockPayouter 支持付款的提供商
+type mockPayouter struct {
+	*mockProvider
+}
+
+func (p *mockPayouter) CreatePayout(ctx context.Context, req *payment.PayoutRequest) (*payment.PayoutResponse, error) {
+	args := p.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*payment.PayoutResponse), args.Error(1)
+}
+
+func (p *mockPayouter) QueryPayout(ctx context.Context, req *payment.QueryPayoutRequest) (*payment.PayoutResponse, error) {
+	args := p.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*payment.PayoutResponse), args.Error(1)
+}
+
+// payoutNotify 模拟提供商返回的付款通知
+func payoutNotify(prov *mockPayouter, info *payment.PayoutInfo) {
+	prov.On("HandleNotify", mock.Anything, mock.Anything).Return(&payment.NotifyResponse{
+		Event:      payment.EventPayout,
+		Payout:     info,
+		ReturnData: []byte("OK"),
+	}, nil).Once()
+}
+
+// TestPayoutStatusFromNotify 测试付款状态由通知更新，成功后不再变更
+func TestPayoutStatusFromNotify(t *testing.T) {
+	env := newTestEnv(t)
+	prov := &mockPayouter{newMockProvider(t)}
+	payment.Register(prov)
+	env.addConfig(prov.name, entity.ConfigData{})
+
+	ctx := context.Background()
+	prov.On("CreatePayout", mock.Anything, mock.MatchedBy(func(req *payment.PayoutRequest) bool {
+		return req.Amount == 25.5 && req.PayeeAccount == "acct_123" && req.NotifyURL != ""
+	})).Return(&payment.PayoutResponse{PayoutID: "PAYOUT1", Status: payment.PayoutStatusProcessing}, nil).Once()
+
+	payout, err := env.svc.CreatePayout(ctx, &CreatePayoutRequest{
+		UserID:       testUserID,
+		Provider:     prov.name,
+		OutPayoutNo:  "OUT1",
+		Amount:       25.5,
+		Currency:     "USD",
+		PayeeAccount: "acct_123",
+		NotifyURL:    "https://merchant.example.com/notify",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, entity.PayoutStatusProcessing, payout.Status)
+	assert.Equal(t, "PAYOUT1", payout.PayoutID)
+	assert.Empty(t, env.notifier.events())
+
+	// 通知只带第三方付款单号
+	payoutNotify(prov, &payment.PayoutInfo{PayoutID: "PAYOUT1", Status: payment.PayoutStatusSuccess})
+	data, err := env.svc.HandleNotify(ctx, prov.name, &payment.NotifyRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "OK", string(data))
+
+	stored, err := env.payouts.GetByPayoutNo(ctx, payout.PayoutNo)
+	require.NoError(t, err)
+	assert.Equal(t, entity.PayoutStatusSuccess, stored.Status)
+	assert.NotNil(t, stored.FinishTime)
+	assert.Equal(t, []string{"payout.success"}, env.notifier.events())
+
+	// 成功后的失败通知不改变状态，也不重复通知商户
+	payoutNotify(prov, &payment.PayoutInfo{PayoutNo: payout.PayoutNo, Status: payment.PayoutStatusFailed, FailReason: "account closed"})
+	_, err = env.svc.HandleNotify(ctx, prov.name, &payment.NotifyRequest{})
+	require.NoError(t, err)
+
+	stored, err = env.payouts.GetByPayoutNo(ctx, payout.PayoutNo)
+	require.NoError(t, err)
+	assert.Equal(t, entity.PayoutStatusSuccess, stored.Status)
+	assert.Empty(t, stored.FailReason)
+	assert.Equal(t, []string{"payout.success"}, env.notifier.events())
+
+	// 重复的商户付款单号返回已有付款，不再发起付款
+	again, err := env.svc.CreatePayout(ctx, &CreatePayoutRequest{UserID: testUserID, Provider: prov.name, OutPayoutNo: "OUT1", Amount: 25.5})
+	require.NoError(t, err)
+	assert.Equal(t, payout.PayoutNo, again.PayoutNo)
+	prov.AssertExpectations(t)
+}
+
+// TestPayoutFailedFromNotify 测试付款失败通知记录失败原因
+func TestPayoutFailedFromNotify(t *testing.T) {
+	env := newTestEnv(t)
+	prov := &mockPayouter{newMockProvider(t)}
+	payment.Register(prov)
+	env.addConfig(prov.name, entity.ConfigData{})
+
+	ctx := context.Background()
+	prov.On("CreatePayout", mock.Anything, mock.Anything).
+		Return(&payment.PayoutResponse{Status: payment.PayoutStatusProcessing}, nil).Once()
+
+	payout, err := env.svc.CreatePayout(ctx, &CreatePayoutRequest{
+		UserID:      testUserID,
+		Provider:    prov.name,
+		OutPayoutNo: "OUT2",
+		Amount:      10,
+		NotifyURL:   "https://merchant.example.com/notify",
+	})
+	require.NoError(t, err)
+
+	payoutNotify(prov, &payment.PayoutInfo{PayoutNo: payout.PayoutNo, PayoutID: "PAYOUT2", Status: payment.PayoutStatusFailed, FailReason: "account closed"})
+	_, err = env.svc.HandleNotify(ctx, prov.name, &payment.NotifyRequest{})
+	require.NoError(t, err)
+
+	stored, err := env.payouts.GetByPayoutNo(ctx, payout.PayoutNo)
+	require.NoError(t, err)
+	assert.Equal(t, entity.PayoutStatusFailed, stored.Status)
+	assert.Equal(t, "PAYOUT2", stored.PayoutID)
+	assert.Equal(t, "account closed", stored.FailReason)
+	assert.Nil(t, stored.FinishTime)
+	assert.Equal(t, []string{"payout.failed"}, env.notifier.events())
+}
+
+// TestCreatePayout_NotSupported 测试提供商不支持付款
+func TestCreatePayout_NotSupported(t *testing.T) {
+	env := newTestEnv(t)
+	prov := newMockProvider(t)
+	payment.Register(prov)
+
+	_, err := env.svc.CreatePayout(context.Background(), &CreatePayoutRequest{UserID: testUserID, Provider: prov.name, OutPayoutNo: "OUT3", Amount: 10})
+	require.Error(t, err)
+	assert.Equal(t, apperrors.ErrNotSupported, err.(*apperrors.AppError).Code)
+}
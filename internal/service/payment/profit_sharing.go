@@ -0,0 +1,689 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zqdfound/go-uni-pay/internal/domain/entity"
+	"github.com/zqdfound/go-uni-pay/internal/infrastructure/cache"
+	"github.com/zqdfound/go-uni-pay/internal/infrastructure/lock"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+	"github.com/zqdfound/go-uni-pay/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// profitSharingSyncDelay 分账创建后等待处理的时间，超过后才主动查询
+const profitSharingSyncDelay = time.Minute
+
+// profitSharingSyncBatch 每次同步的处理中分账数量
+const profitSharingSyncBatch = 100
+
+// ShareProfitRequest 分账请求
+type ShareProfitRequest struct {
+	UserID       uint64
+	OrderNo      string
+	OutSharingNo string
+	Receivers    []entity.ProfitSharingReceiver // 为空时使用订单的分账计划
+	Finish       bool
+}
+
+// ReturnProfitSharingRequest 分账回退请求
+type ReturnProfitSharingRequest struct {
+	UserID      uint64
+	SharingNo   string
+	OutReturnNo string
+	Account     string
+	Amount      float64 // 为0时回退该接收方剩余可回退金额
+	Description string
+}
+
+// ShareProfit 对已支付订单发起分账，同一商户分账单号重复请求返回已有分账
+func (s *Service) ShareProfit(ctx context.Context, req *ShareProfitRequest) (*entity.ProfitSharing, error) {
+	order, err := s.orderRepo.GetByOrderNo(ctx, req.OrderNo)
+	if err != nil {
+		return nil, err
+	}
+
+	// 验证订单归属（数据隔离）
+	if order.UserID != req.UserID {
+		return nil, apperrors.New(apperrors.ErrOrderNotFound, "order not found")
+	}
+
+	receivers := req.Receivers
+	if len(receivers) == 0 && order.ProfitSharing != nil {
+		receivers = order.ProfitSharing.Receivers
+	}
+	if len(receivers) == 0 {
+		return nil, apperrors.New(apperrors.ErrInvalidParam, "profit sharing receivers are required")
+	}
+
+	return s.shareProfit(ctx, order, req.OutSharingNo, receivers, req.Finish)
+}
+
+// autoShareProfit 订单支付成功后按分账计划自动分账并完结，以订单号作为商户分账单号
+// 分账失败只记录日志，商户可通过分账接口重新发起
+func (s *Service) autoShareProfit(ctx context.Context, order *entity.PaymentOrder) {
+	if order.ProfitSharing == nil || order.ProfitSharing.Settle != entity.ProfitSharingSettleAuto {
+		return
+	}
+
+	if _, err := s.shareProfit(ctx, order, order.OrderNo, order.ProfitSharing.Receivers, true); err != nil {
+		logger.Error("auto profit sharing failed",
+			zap.String("order_no", order.OrderNo),
+			zap.Error(err))
+	}
+}
+
+// shareProfit 发起分账，分账记录先以处理中状态保存，第三方同步返回结果或后续查询时更新
+func (s *Service) shareProfit(ctx context.Context, order *entity.PaymentOrder, outSharingNo string, receivers []entity.ProfitSharingReceiver, finish bool) (*entity.ProfitSharing, error) {
+	if order.Status != entity.OrderStatusSuccess && order.Status != entity.OrderStatusCaptured {
+		return nil, apperrors.New(apperrors.ErrOrderStatus, fmt.Sprintf("order in status %s cannot be shared", order.Status))
+	}
+
+	prov, err := payment.GetProvider(order.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	sharer, ok := prov.(payment.ProfitSharer)
+	if !ok {
+		return nil, apperrors.New(apperrors.ErrNotSupported, fmt.Sprintf("provider %s does not support profit sharing", order.Provider))
+	}
+
+	config, err := s.configRepo.GetByID(ctx, order.ConfigID)
+	if err != nil {
+		return nil, err
+	}
+
+	var sharing *entity.ProfitSharing
+	lockKey := fmt.Sprintf("payment:profit_sharing:%s", order.OrderNo)
+	err = lock.WithLock(ctx, cache.Client, lockKey, 30*time.Second, func() error {
+		// 检查分账是否已存在（幂等性保证）
+		if existing, err := s.profitSharingRepo.GetByUserAndOutSharingNo(ctx, order.UserID, outSharingNo); err == nil {
+			sharing = existing
+			return nil
+		}
+
+		shareable, err := s.shareableAmount(ctx, order)
+		if err != nil {
+			return err
+		}
+
+		resolved, total, err := resolveReceivers(receivers, paidAmount(order))
+		if err != nil {
+			return err
+		}
+		if toCents(total) > toCents(shareable) {
+			return apperrors.New(apperrors.ErrAmountInvalid, fmt.Sprintf("profit sharing amount must not exceed %.2f", shareable))
+		}
+
+		sharing = &entity.ProfitSharing{
+			SharingNo:    s.generateSharingNo(),
+			UserID:       order.UserID,
+			OutSharingNo: outSharingNo,
+			OrderID:      order.ID,
+			OrderNo:      order.OrderNo,
+			Provider:     order.Provider,
+			ConfigID:     order.ConfigID,
+			Amount:       total,
+			Currency:     order.Currency,
+			Receivers:    resolved,
+			Finish:       finish,
+			Status:       entity.ProfitSharingStatusProcessing,
+		}
+		if err := s.profitSharingRepo.Create(ctx, sharing); err != nil {
+			return err
+		}
+
+		sharingReq := &payment.ProfitSharingRequest{
+			SharingNo:  sharing.SharingNo,
+			OutTradeNo: order.OutTradeNo,
+			TradeNo:    order.TradeNo,
+			CaptureID:  order.CaptureID,
+			Currency:   order.Currency,
+			Receivers:  toPaymentReceivers(resolved),
+			Finish:     finish,
+			Config:     config.ConfigData,
+		}
+
+		sharingResp, err := sharer.ShareProfit(ctx, sharingReq)
+		if err != nil {
+			s.logPayment(ctx, order.ID, order.OrderNo, "profit_sharing", order.Provider, sharingReq, nil, "failed", err.Error())
+			if updateErr := s.updateProfitSharingStatus(ctx, sharing, entity.ProfitSharingStatusFailed, err.Error()); updateErr != nil {
+				logger.Error("failed to update profit sharing", zap.String("sharing_no", sharing.SharingNo), zap.Error(updateErr))
+			}
+			return err
+		}
+
+		s.logPayment(ctx, order.ID, order.OrderNo, "profit_sharing", order.Provider, sharingReq, sharingResp, "success", "")
+
+		return s.applyProfitSharingResult(ctx, sharing, sharingResp)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sharing, nil
+}
+
+// QueryProfitSharing 查询分账，处理中的分账会向第三方查询并同步状态
+func (s *Service) QueryProfitSharing(ctx context.Context, userID uint64, sharingNo string) (*entity.ProfitSharing, error) {
+	sharing, err := s.profitSharingRepo.GetBySharingNo(ctx, sharingNo)
+	if err != nil {
+		return nil, err
+	}
+
+	// 验证分账归属（数据隔离）
+	if sharing.UserID != userID {
+		return nil, apperrors.New(apperrors.ErrNotFound, "profit sharing not found")
+	}
+
+	if sharing.Status == entity.ProfitSharingStatusProcessing {
+		if err := s.syncProfitSharing(ctx, sharing); err != nil {
+			logger.Warn("failed to sync profit sharing", zap.String("sharing_no", sharing.SharingNo), zap.Error(err))
+		}
+	}
+
+	return sharing, nil
+}
+
+// StartProfitSharingSync 启动处理中分账的定时同步
+func (s *Service) StartProfitSharingSync(interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	logger.Info("profit sharing sync started", zap.Duration("interval", interval))
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stopCh:
+				logger.Info("profit sharing sync stopped")
+				return
+			case <-ticker.C:
+				s.SyncProcessingProfitSharings(context.Background())
+			}
+		}
+	}()
+}
+
+// SyncProcessingProfitSharings 向第三方同步处理中的分账
+func (s *Service) SyncProcessingProfitSharings(ctx context.Context) {
+	sharings, err := s.profitSharingRepo.ListProcessing(ctx, time.Now().Add(-profitSharingSyncDelay), profitSharingSyncBatch)
+	if err != nil {
+		logger.Error("failed to list processing profit sharings", zap.Error(err))
+		return
+	}
+
+	for _, sharing := range sharings {
+		if err := s.syncProfitSharing(ctx, sharing); err != nil {
+			logger.Warn("failed to sync profit sharing", zap.String("sharing_no", sharing.SharingNo), zap.Error(err))
+		}
+	}
+}
+
+// syncProfitSharing 向第三方查询分账结果
+func (s *Service) syncProfitSharing(ctx context.Context, sharing *entity.ProfitSharing) error {
+	prov, err := payment.GetProvider(sharing.Provider)
+	if err != nil {
+		return err
+	}
+
+	sharer, ok := prov.(payment.ProfitSharer)
+	if !ok {
+		return nil
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, sharing.OrderID)
+	if err != nil {
+		return err
+	}
+
+	config, err := s.configRepo.GetByID(ctx, sharing.ConfigID)
+	if err != nil {
+		return err
+	}
+
+	queryReq := &payment.QueryProfitSharingRequest{
+		SharingNo:  sharing.SharingNo,
+		SharingID:  sharing.SharingID,
+		OutTradeNo: order.OutTradeNo,
+		TradeNo:    order.TradeNo,
+		Config:     config.ConfigData,
+	}
+
+	queryResp, err := sharer.QueryProfitSharing(ctx, queryReq)
+	if err != nil {
+		s.logPayment(ctx, order.ID, order.OrderNo, "profit_sharing_query", sharing.Provider, queryReq, nil, "failed", err.Error())
+		// 更新时间用于轮转同步顺序，避免查询失败的分账一直排在最前
+		s.profitSharingRepo.Update(ctx, sharing)
+		return err
+	}
+
+	s.logPayment(ctx, order.ID, order.OrderNo, "profit_sharing_query", sharing.Provider, queryReq, queryResp, "success", "")
+
+	return s.applyProfitSharingResult(ctx, sharing, queryResp)
+}
+
+// applyProfitSharingResult 记录各接收方的分账结果并更新分账状态
+func (s *Service) applyProfitSharingResult(ctx context.Context, sharing *entity.ProfitSharing, resp *payment.ProfitSharingResponse) error {
+	if resp.SharingID != "" {
+		sharing.SharingID = resp.SharingID
+	}
+
+	var failReason string
+	matched := make([]bool, len(sharing.Receivers))
+	for _, result := range resp.Receivers {
+		for i := range sharing.Receivers {
+			receiver := &sharing.Receivers[i]
+			if matched[i] || receiver.Account != result.Account {
+				continue
+			}
+			matched[i] = true
+			receiver.Status = result.Status
+			if result.DetailID != "" {
+				receiver.DetailID = result.DetailID
+			}
+			receiver.FailReason = result.FailReason
+			if failReason == "" && result.FailReason != "" {
+				failReason = result.FailReason
+			}
+			break
+		}
+	}
+
+	return s.updateProfitSharingStatus(ctx, sharing, resp.Status, failReason)
+}
+
+// updateProfitSharingStatus 更新分账状态，分账成功或失败后不再变更，并通知商户
+func (s *Service) updateProfitSharingStatus(ctx context.Context, sharing *entity.ProfitSharing, status, failReason string) error {
+	oldStatus := sharing.Status
+	if oldStatus == entity.ProfitSharingStatusProcessing && status != "" {
+		sharing.Status = status
+		if status == entity.ProfitSharingStatusFailed {
+			sharing.FailReason = truncate(failReason, 256)
+		}
+	}
+	if sharing.Status != entity.ProfitSharingStatusProcessing && sharing.FinishTime == nil {
+		now := time.Now()
+		sharing.FinishTime = &now
+	}
+
+	if err := s.profitSharingRepo.Update(ctx, sharing); err != nil {
+		logger.Error("failed to update profit sharing", zap.String("sharing_no", sharing.SharingNo), zap.Error(err))
+		return err
+	}
+
+	if sharing.Status != oldStatus {
+		s.notifyProfitSharing(ctx, sharing)
+	}
+
+	return nil
+}
+
+// notifyProfitSharing 分账成功或失败，且订单有通知URL时，添加 profit_sharing.* 通知任务
+func (s *Service) notifyProfitSharing(ctx context.Context, sharing *entity.ProfitSharing) {
+	if sharing.Status == entity.ProfitSharingStatusProcessing {
+		return
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, sharing.OrderID)
+	if err != nil || order.NotifyURL == "" {
+		return
+	}
+
+	notifyData := map[string]interface{}{
+		"event":          "profit_sharing." + sharing.Status,
+		"order_no":       order.OrderNo,
+		"out_trade_no":   order.OutTradeNo,
+		"sharing_no":     sharing.SharingNo,
+		"out_sharing_no": sharing.OutSharingNo,
+		"amount":         sharing.Amount,
+		"currency":       sharing.Currency,
+		"receivers":      sharing.Receivers,
+		"status":         sharing.Status,
+		"fail_reason":    sharing.FailReason,
+		"finish_time":    sharing.FinishTime,
+	}
+
+	if err := s.notifyService.AddNotify(ctx, order.ID, order.OrderNo, order.NotifyURL, notifyData); err != nil {
+		logger.Error("failed to add profit sharing notify task",
+			zap.String("sharing_no", sharing.SharingNo),
+			zap.Error(err))
+	}
+}
+
+// ReturnProfitSharing 将已分给接收方的资金回退给商户，同一商户回退单号重复请求返回已有回退
+func (s *Service) ReturnProfitSharing(ctx context.Context, req *ReturnProfitSharingRequest) (*entity.ProfitSharingReturn, error) {
+	if req.Amount < 0 {
+		return nil, apperrors.New(apperrors.ErrAmountInvalid, "return amount must not be negative")
+	}
+
+	sharing, err := s.profitSharingRepo.GetBySharingNo(ctx, req.SharingNo)
+	if err != nil {
+		return nil, err
+	}
+
+	// 验证分账归属（数据隔离）
+	if sharing.UserID != req.UserID {
+		return nil, apperrors.New(apperrors.ErrNotFound, "profit sharing not found")
+	}
+
+	prov, err := payment.GetProvider(sharing.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	returner, ok := prov.(payment.ProfitSharingReturner)
+	if !ok {
+		return nil, apperrors.New(apperrors.ErrNotSupported, fmt.Sprintf("provider %s does not support profit sharing returns", sharing.Provider))
+	}
+
+	var receiver *entity.ProfitSharingReceiver
+	for i := range sharing.Receivers {
+		r := &sharing.Receivers[i]
+		if r.Account == req.Account && r.Status == entity.ProfitSharingStatusSuccess {
+			receiver = r
+			break
+		}
+	}
+	if receiver == nil {
+		return nil, apperrors.New(apperrors.ErrInvalidParam, fmt.Sprintf("no successful profit sharing to receiver %s", req.Account))
+	}
+
+	config, err := s.configRepo.GetByID(ctx, sharing.ConfigID)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret *entity.ProfitSharingReturn
+	lockKey := fmt.Sprintf("payment:profit_sharing_return:%s", sharing.SharingNo)
+	err = lock.WithLock(ctx, cache.Client, lockKey, 30*time.Second, func() error {
+		// 检查回退是否已存在（幂等性保证）
+		if existing, err := s.profitSharingReturnRepo.GetByUserAndOutReturnNo(ctx, req.UserID, req.OutReturnNo); err == nil {
+			ret = existing
+			return nil
+		}
+
+		returnable, err := s.returnableAmount(ctx, sharing, receiver)
+		if err != nil {
+			return err
+		}
+
+		amount := req.Amount
+		if amount == 0 {
+			amount = returnable
+		}
+		if amount <= 0 || toCents(amount) > toCents(returnable) {
+			return apperrors.New(apperrors.ErrAmountInvalid, fmt.Sprintf("return amount must be between 0 and %.2f", returnable))
+		}
+
+		ret = &entity.ProfitSharingReturn{
+			ReturnNo:    s.generateSharingReturnNo(),
+			UserID:      req.UserID,
+			OutReturnNo: req.OutReturnNo,
+			SharingNo:   sharing.SharingNo,
+			OrderNo:     sharing.OrderNo,
+			Provider:    sharing.Provider,
+			ConfigID:    sharing.ConfigID,
+			Account:     receiver.Account,
+			Amount:      amount,
+			Currency:    sharing.Currency,
+			Description: req.Description,
+			Status:      entity.ProfitSharingStatusProcessing,
+		}
+		if err := s.profitSharingReturnRepo.Create(ctx, ret); err != nil {
+			return err
+		}
+
+		returnReq := &payment.ProfitSharingReturnRequest{
+			ReturnNo:    ret.ReturnNo,
+			SharingNo:   sharing.SharingNo,
+			SharingID:   sharing.SharingID,
+			DetailID:    receiver.DetailID,
+			AccountType: receiver.Type,
+			Account:     receiver.Account,
+			Amount:      amount,
+			Currency:    sharing.Currency,
+			Description: req.Description,
+			Config:      config.ConfigData,
+		}
+
+		returnResp, err := returner.ReturnProfitSharing(ctx, returnReq)
+		if err != nil {
+			s.logPayment(ctx, sharing.OrderID, sharing.OrderNo, "profit_sharing_return", sharing.Provider, returnReq, nil, "failed", err.Error())
+			ret.Status = entity.ProfitSharingStatusFailed
+			ret.FailReason = truncate(err.Error(), 256)
+			if updateErr := s.profitSharingReturnRepo.Update(ctx, ret); updateErr != nil {
+				logger.Error("failed to update profit sharing return", zap.String("return_no", ret.ReturnNo), zap.Error(updateErr))
+			}
+			return err
+		}
+
+		s.logPayment(ctx, sharing.OrderID, sharing.OrderNo, "profit_sharing_return", sharing.Provider, returnReq, returnResp, "success", "")
+
+		return s.updateSharingReturnStatus(ctx, ret, returnResp)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// QueryProfitSharingReturn 查询分账回退，处理中的回退会向第三方查询并同步状态
+func (s *Service) QueryProfitSharingReturn(ctx context.Context, userID uint64, returnNo string) (*entity.ProfitSharingReturn, error) {
+	ret, err := s.profitSharingReturnRepo.GetByReturnNo(ctx, returnNo)
+	if err != nil {
+		return nil, err
+	}
+
+	// 验证回退归属（数据隔离）
+	if ret.UserID != userID {
+		return nil, apperrors.New(apperrors.ErrNotFound, "profit sharing return not found")
+	}
+
+	if ret.Status != entity.ProfitSharingStatusProcessing {
+		return ret, nil
+	}
+
+	prov, err := payment.GetProvider(ret.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	returner, ok := prov.(payment.ProfitSharingReturner)
+	if !ok {
+		return ret, nil
+	}
+
+	sharing, err := s.profitSharingRepo.GetBySharingNo(ctx, ret.SharingNo)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := s.configRepo.GetByID(ctx, ret.ConfigID)
+	if err != nil {
+		return nil, err
+	}
+
+	queryReq := &payment.QueryProfitSharingReturnRequest{
+		ReturnNo:  ret.ReturnNo,
+		ReturnID:  ret.ReturnID,
+		SharingNo: ret.SharingNo,
+		Config:    config.ConfigData,
+	}
+	for _, r := range sharing.Receivers {
+		if r.Account == ret.Account && r.Status == entity.ProfitSharingStatusSuccess {
+			queryReq.DetailID = r.DetailID
+			break
+		}
+	}
+
+	queryResp, err := returner.QueryProfitSharingReturn(ctx, queryReq)
+	if err != nil {
+		s.logPayment(ctx, sharing.OrderID, sharing.OrderNo, "profit_sharing_return_query", ret.Provider, queryReq, nil, "failed", err.Error())
+		logger.Warn("failed to sync profit sharing return", zap.String("return_no", ret.ReturnNo), zap.Error(err))
+		return ret, nil
+	}
+
+	s.logPayment(ctx, sharing.OrderID, sharing.OrderNo, "profit_sharing_return_query", ret.Provider, queryReq, queryResp, "success", "")
+
+	if err := s.updateSharingReturnStatus(ctx, ret, queryResp); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// updateSharingReturnStatus 更新分账回退状态，回退成功或失败后不再变更
+func (s *Service) updateSharingReturnStatus(ctx context.Context, ret *entity.ProfitSharingReturn, resp *payment.ProfitSharingReturnResponse) error {
+	if resp.ReturnID != "" {
+		ret.ReturnID = resp.ReturnID
+	}
+
+	if ret.Status == entity.ProfitSharingStatusProcessing && resp.Status != "" {
+		ret.Status = resp.Status
+		if resp.Status == entity.ProfitSharingStatusFailed {
+			ret.FailReason = truncate(resp.FailReason, 256)
+		}
+	}
+	if ret.Status != entity.ProfitSharingStatusProcessing && ret.FinishTime == nil {
+		now := time.Now()
+		ret.FinishTime = &now
+	}
+
+	if err := s.profitSharingReturnRepo.Update(ctx, ret); err != nil {
+		logger.Error("failed to update profit sharing return", zap.String("return_no", ret.ReturnNo), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// shareableAmount 计算订单剩余可分账金额
+// 失败的分账只扣除其中已分账成功的接收方金额
+func (s *Service) shareableAmount(ctx context.Context, order *entity.PaymentOrder) (float64, error) {
+	sharings, err := s.profitSharingRepo.ListByOrder(ctx, order.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	shared := int64(0)
+	for _, sharing := range sharings {
+		if sharing.Status != entity.ProfitSharingStatusFailed {
+			shared += toCents(sharing.Amount)
+			continue
+		}
+		for _, r := range sharing.Receivers {
+			if r.Status == entity.ProfitSharingStatusSuccess {
+				shared += toCents(r.Amount)
+			}
+		}
+	}
+
+	return float64(toCents(paidAmount(order))-shared) / 100, nil
+}
+
+// returnableAmount 计算接收方剩余可回退金额
+func (s *Service) returnableAmount(ctx context.Context, sharing *entity.ProfitSharing, receiver *entity.ProfitSharingReceiver) (float64, error) {
+	returns, err := s.profitSharingReturnRepo.ListBySharing(ctx, sharing.SharingNo)
+	if err != nil {
+		return 0, err
+	}
+
+	returned := int64(0)
+	for _, ret := range returns {
+		if ret.Account == receiver.Account && ret.Status != entity.ProfitSharingStatusFailed {
+			returned += toCents(ret.Amount)
+		}
+	}
+
+	return float64(toCents(receiver.Amount)-returned) / 100, nil
+}
+
+// resolveReceivers 校验分账接收方，按比例计算未指定金额的接收方分账金额，返回接收方及分账总额
+func resolveReceivers(receivers []entity.ProfitSharingReceiver, baseAmount float64) (entity.ProfitSharingReceivers, float64, error) {
+	if len(receivers) == 0 {
+		return nil, 0, apperrors.New(apperrors.ErrInvalidParam, "profit sharing receivers are required")
+	}
+
+	resolved := make(entity.ProfitSharingReceivers, 0, len(receivers))
+	total := int64(0)
+	for _, r := range receivers {
+		if r.Account == "" {
+			return nil, 0, apperrors.New(apperrors.ErrInvalidParam, "profit sharing receiver account is required")
+		}
+		if r.Amount < 0 || r.Ratio < 0 || r.Ratio > 1 {
+			return nil, 0, apperrors.New(apperrors.ErrAmountInvalid, fmt.Sprintf("invalid amount or ratio for receiver %s", r.Account))
+		}
+
+		amount := toCents(r.Amount)
+		if amount == 0 {
+			amount = int64(math.Floor(float64(toCents(baseAmount)) * r.Ratio))
+		}
+		if amount <= 0 {
+			return nil, 0, apperrors.New(apperrors.ErrAmountInvalid, fmt.Sprintf("profit sharing amount for receiver %s must be positive", r.Account))
+		}
+		total += amount
+
+		resolved = append(resolved, entity.ProfitSharingReceiver{
+			Type:        r.Type,
+			Account:     r.Account,
+			Name:        r.Name,
+			Amount:      float64(amount) / 100,
+			Ratio:       r.Ratio,
+			Description: r.Description,
+		})
+	}
+
+	if total > toCents(baseAmount) {
+		return nil, 0, apperrors.New(apperrors.ErrAmountInvalid, fmt.Sprintf("profit sharing amount must not exceed %.2f", baseAmount))
+	}
+
+	return resolved, float64(total) / 100, nil
+}
+
+// toPaymentPlan 转换为提供商使用的分账计划
+func toPaymentPlan(plan *entity.ProfitSharingPlan) *payment.ProfitSharingPlan {
+	if plan == nil {
+		return nil
+	}
+	return &payment.ProfitSharingPlan{
+		Settle:    plan.Settle,
+		Receivers: toPaymentReceivers(plan.Receivers),
+	}
+}
+
+// toPaymentReceivers 转换为提供商使用的分账接收方
+func toPaymentReceivers(receivers entity.ProfitSharingReceivers) []payment.ProfitSharingReceiver {
+	result := make([]payment.ProfitSharingReceiver, 0, len(receivers))
+	for _, r := range receivers {
+		result = append(result, payment.ProfitSharingReceiver{
+			Type:        r.Type,
+			Account:     r.Account,
+			Name:        r.Name,
+			Amount:      r.Amount,
+			Description: r.Description,
+		})
+	}
+	return result
+}
+
+// generateSharingNo 生成分账单号，只包含字母和数字以满足各提供商的单号要求
+func (s *Service) generateSharingNo() string {
+	return fmt.Sprintf("PS%d%s", time.Now().UnixNano(), uuid.New().String()[:8])
+}
+
+// generateSharingReturnNo 生成分账回退单号
+func (s *Service) generateSharingReturnNo() string {
+	return fmt.Sprintf("PR%d%s", time.Now().UnixNano(), uuid.New().String()[:8])
+}
@@ -0,0 +1,174 @@
+package payment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zqdfound/go-uni-pay/internal/domain/entity"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+)
+
+// mockSharer 支持分账的提供商
+type mockSharer struct {
+	*mockProvider
+}
+
+func (p *mockSharer) ShareProfit(ctx context.Context, req *payment.ProfitSharingRequest) (*payment.ProfitSharingResponse, error) {
+	args := p.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	if fn, ok := args.Get(0).(func(*payment.ProfitSharingRequest) *payment.ProfitSharingResponse); ok {
+		return fn(req), args.Error(1)
+	}
+	return args.Get(0).(*payment.ProfitSharingResponse), args.Error(1)
+}
+
+func (p *mockSharer) QueryProfitSharing(ctx context.Context, req *payment.QueryProfitSharingRequest) (*payment.ProfitSharingResponse, error) {
+	args := p.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*payment.ProfitSharingResponse), args.Error(1)
+}
+
+// sharingSucceeded 模拟提供商分账成功，各接收方按请求金额返回结果
+func sharingSucceeded(req *payment.ProfitSharingRequest) *payment.ProfitSharingResponse {
+	resp := &payment.ProfitSharingResponse{SharingNo: req.SharingNo, SharingID: "SHARE-" + req.SharingNo, Status: payment.ProfitSharingStatusSuccess}
+	for _, r := range req.Receivers {
+		resp.Receivers = append(resp.Receivers, payment.ProfitSharingResult{Account: r.Account, Amount: r.Amount, Status: payment.ProfitSharingStatusSuccess, DetailID: "tr_" + r.Account})
+	}
+	return resp
+}
+
+// TestShareProfit_SplitAmounts 测试按比例分账向下取整到分，且累计分账不超过订单金额
+func TestShareProfit_SplitAmounts(t *testing.T) {
+	env := newTestEnv(t)
+	prov := &mockSharer{newMockProvider(t)}
+	payment.Register(prov)
+
+	config := env.addConfig(prov.name, entity.ConfigData{})
+	order := env.addOrder(config, &entity.PaymentOrder{
+		OutTradeNo: "ORDER1",
+		Subject:    "Order",
+		Amount:     99.99,
+		TradeNo:    "TRADE1",
+		Status:     entity.OrderStatusSuccess,
+	})
+
+	var requested *payment.ProfitSharingRequest
+	prov.On("ShareProfit", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		requested = args.Get(1).(*payment.ProfitSharingRequest)
+	}).Return(sharingSucceeded, nil).Once()
+
+	ctx := context.Background()
+	sharing, err := env.svc.ShareProfit(ctx, &ShareProfitRequest{
+		UserID:       testUserID,
+		OrderNo:      order.OrderNo,
+		OutSharingNo: "PS1",
+		Receivers: []entity.ProfitSharingReceiver{
+			{Account: "acct_seller", Ratio: 0.333},
+			{Account: "acct_agent", Amount: 20},
+		},
+	})
+	require.NoError(t, err)
+
+	// 99.99 * 0.333 = 33.29667，向下取整为 33.29
+	require.Len(t, requested.Receivers, 2)
+	assert.Equal(t, 33.29, requested.Receivers[0].Amount)
+	assert.Equal(t, 20.0, requested.Receivers[1].Amount)
+	assert.Equal(t, 53.29, sharing.Amount)
+	assert.Equal(t, entity.ProfitSharingStatusSuccess, sharing.Status)
+	assert.Equal(t, "tr_acct_seller", sharing.Receivers[0].DetailID)
+
+	// 剩余可分账金额为 46.70，超过时拒绝且不请求提供商
+	_, err = env.svc.ShareProfit(ctx, &ShareProfitRequest{
+		UserID:       testUserID,
+		OrderNo:      order.OrderNo,
+		OutSharingNo: "PS2",
+		Receivers:    []entity.ProfitSharingReceiver{{Account: "acct_seller", Amount: 46.71}},
+	})
+	require.Error(t, err)
+	assert.Equal(t, apperrors.ErrAmountInvalid, err.(*apperrors.AppError).Code)
+	prov.AssertExpectations(t)
+}
+
+// TestCreatePayment_ProfitSharingExceedsAmount 测试分账计划金额超过订单金额时拒绝创建订单
+func TestCreatePayment_ProfitSharingExceedsAmount(t *testing.T) {
+	env := newTestEnv(t)
+	prov := &mockSharer{newMockProvider(t)}
+	payment.Register(prov)
+	env.addConfig(prov.name, entity.ConfigData{})
+
+	_, err := env.svc.CreatePayment(context.Background(), &CreatePaymentRequest{
+		UserID:     testUserID,
+		Provider:   prov.name,
+		OutTradeNo: "ORDER2",
+		Subject:    "Order",
+		Amount:     10,
+		Currency:   "USD",
+		ProfitSharing: &entity.ProfitSharingPlan{Receivers: entity.ProfitSharingReceivers{
+			{Account: "acct_seller", Amount: 8},
+			{Account: "acct_agent", Amount: 2.01},
+		}},
+	})
+	require.Error(t, err)
+	assert.Equal(t, apperrors.ErrAmountInvalid, err.(*apperrors.AppError).Code)
+	prov.AssertNotCalled(t, "CreatePayment", mock.Anything, mock.Anything)
+}
+
+// TestAutoShareProfitOnSuccess 测试自动分账订单支付成功后以订单号作为分账单号完成分账
+func TestAutoShareProfitOnSuccess(t *testing.T) {
+	env := newTestEnv(t)
+	prov := &mockSharer{newMockProvider(t)}
+	payment.Register(prov)
+
+	config := env.addConfig(prov.name, entity.ConfigData{})
+	order := env.addOrder(config, &entity.PaymentOrder{
+		OutTradeNo: "ORDER3",
+		Subject:    "Order",
+		Amount:     50,
+		NotifyURL:  "https://merchant.example.com/notify",
+		Status:     entity.OrderStatusPending,
+		ProfitSharing: &entity.ProfitSharingPlan{
+			Settle:    entity.ProfitSharingSettleAuto,
+			Receivers: entity.ProfitSharingReceivers{{Account: "acct_seller", Amount: 45}},
+		},
+	})
+
+	prov.On("HandleNotify", mock.Anything, mock.Anything).Return(&payment.NotifyResponse{
+		TradeNo:    "TRADE3",
+		OutTradeNo: "ORDER3",
+		Status:     payment.StatusSuccess,
+		Amount:     50,
+	}, nil).Once()
+	prov.On("ShareProfit", mock.Anything, mock.MatchedBy(func(req *payment.ProfitSharingRequest) bool {
+		return req.TradeNo == "TRADE3" && req.Finish && len(req.Receivers) == 1 && req.Receivers[0].Amount == 45
+	})).Return(sharingSucceeded, nil).Once()
+
+	ctx := context.Background()
+	_, err := env.svc.HandleNotify(ctx, prov.name, &payment.NotifyRequest{})
+	require.NoError(t, err)
+
+	assert.Equal(t, entity.OrderStatusSuccess, env.order(t, order.OrderNo).Status)
+	sharing, err := env.sharings.GetByUserAndOutSharingNo(ctx, testUserID, order.OrderNo)
+	require.NoError(t, err)
+	assert.Equal(t, entity.ProfitSharingStatusSuccess, sharing.Status)
+	assert.Equal(t, 45.0, sharing.Amount)
+	assert.True(t, sharing.Finish)
+	assert.Equal(t, []string{entity.OrderStatusSuccess, "profit_sharing.success"}, env.notifier.events())
+
+	// 重复的成功通知不会再次分账
+	prov.On("HandleNotify", mock.Anything, mock.Anything).Return(&payment.NotifyResponse{
+		TradeNo:    "TRADE3",
+		OutTradeNo: "ORDER3",
+		Status:     payment.StatusSuccess,
+	}, nil).Once()
+	_, err = env.svc.HandleNotify(ctx, prov.name, &payment.NotifyRequest{})
+	require.NoError(t, err)
+	prov.AssertExpectations(t)
+}
@@ -0,0 +1,372 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zqdfound/go-uni-pay/internal/domain/entity"
+	"github.com/zqdfound/go-uni-pay/internal/infrastructure/cache"
+	"github.com/zqdfound/go-uni-pay/internal/infrastructure/lock"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+	"github.com/zqdfound/go-uni-pay/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// refundSyncDelay 退款创建后等待通知的时间，超过后才主动查询
+const refundSyncDelay = time.Minute
+
+// refundSyncBatch 每次同步的处理中退款数量
+const refundSyncBatch = 100
+
+// Refund 发起退款，amount 为0时退还剩余可退金额
+// 退款记录先以处理中状态保存，第三方同步返回结果或后续通知、查询时更新
+func (s *Service) Refund(ctx context.Context, userID uint64, orderNo string, amount float64, reason string) (*entity.Refund, error) {
+	order, err := s.orderRepo.GetByOrderNo(ctx, orderNo)
+	if err != nil {
+		return nil, err
+	}
+
+	// 验证订单归属（数据隔离）
+	if order.UserID != userID {
+		return nil, apperrors.New(apperrors.ErrOrderNotFound, "order not found")
+	}
+
+	if order.Status != entity.OrderStatusSuccess && order.Status != entity.OrderStatusCaptured {
+		return nil, apperrors.New(apperrors.ErrOrderStatus, fmt.Sprintf("order in status %s cannot be refunded", order.Status))
+	}
+
+	if amount < 0 {
+		return nil, apperrors.New(apperrors.ErrAmountInvalid, "refund amount must not be negative")
+	}
+
+	prov, err := payment.GetProvider(order.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	refunder, ok := prov.(payment.Refunder)
+	if !ok {
+		return nil, apperrors.New(apperrors.ErrNotSupported, fmt.Sprintf("provider %s does not support refunds", order.Provider))
+	}
+
+	config, err := s.configRepo.GetByID(ctx, order.ConfigID)
+	if err != nil {
+		return nil, err
+	}
+
+	notifyURL, err := s.notifyURL(order.Provider, order.ConfigID)
+	if err != nil {
+		return nil, err
+	}
+
+	var refund *entity.Refund
+	lockKey := fmt.Sprintf("payment:refund:%s", order.OrderNo)
+	err = lock.WithLock(ctx, cache.Client, lockKey, 30*time.Second, func() error {
+		refundable, err := s.refundableAmount(ctx, order)
+		if err != nil {
+			return err
+		}
+
+		refundAmount := amount
+		if refundAmount == 0 {
+			refundAmount = refundable
+		}
+		if refundAmount <= 0 || toCents(refundAmount) > toCents(refundable) {
+			return apperrors.New(apperrors.ErrAmountInvalid, fmt.Sprintf("refund amount must be between 0 and %.2f", refundable))
+		}
+
+		refund = &entity.Refund{
+			RefundNo: s.generateRefundNo(),
+			OrderID:  order.ID,
+			OrderNo:  order.OrderNo,
+			UserID:   order.UserID,
+			Provider: order.Provider,
+			ConfigID: order.ConfigID,
+			Amount:   refundAmount,
+			Currency: order.Currency,
+			Reason:   reason,
+			Status:   entity.RefundStatusProcessing,
+		}
+		if err := s.refundRepo.Create(ctx, refund); err != nil {
+			return err
+		}
+
+		refundReq := &payment.RefundRequest{
+			OutTradeNo:   order.OutTradeNo,
+			TradeNo:      order.TradeNo,
+			CaptureID:    order.CaptureID,
+			RefundNo:     refund.RefundNo,
+			RefundAmount: refundAmount,
+			TotalAmount:  paidAmount(order),
+			Currency:     order.Currency,
+			Reason:       reason,
+			NotifyURL:    notifyURL,
+			Config:       config.ConfigData,
+		}
+
+		refundResp, err := refunder.RefundPayment(ctx, refundReq)
+		if err != nil {
+			s.logPayment(ctx, order.ID, order.OrderNo, "refund", order.Provider, refundReq, nil, "failed", err.Error())
+			refund.Status = entity.RefundStatusFailed
+			if updateErr := s.refundRepo.Update(ctx, refund); updateErr != nil {
+				logger.Error("failed to update refund", zap.String("refund_no", refund.RefundNo), zap.Error(updateErr))
+			}
+			return err
+		}
+
+		s.logPayment(ctx, order.ID, order.OrderNo, "refund", order.Provider, refundReq, refundResp, "success", "")
+
+		// 退款通知可能先于同步应答到达，以数据库中的状态为准
+		if current, err := s.refundRepo.GetByRefundNo(ctx, refund.RefundNo); err == nil {
+			*refund = *current
+		}
+
+		return s.updateRefundStatus(ctx, refund, order, refundResp.Status, refundResp.RefundID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+// QueryRefund 查询退款，处理中的退款会向第三方查询并同步状态
+func (s *Service) QueryRefund(ctx context.Context, userID uint64, refundNo string) (*entity.Refund, error) {
+	refund, err := s.refundRepo.GetByRefundNo(ctx, refundNo)
+	if err != nil {
+		return nil, err
+	}
+
+	// 验证退款归属（数据隔离）
+	if refund.UserID != userID {
+		return nil, apperrors.New(apperrors.ErrNotFound, "refund not found")
+	}
+
+	if refund.Status == entity.RefundStatusProcessing {
+		if err := s.syncRefund(ctx, refund); err != nil {
+			logger.Warn("failed to sync refund", zap.String("refund_no", refund.RefundNo), zap.Error(err))
+		}
+	}
+
+	return refund, nil
+}
+
+// StartRefundSync 启动处理中退款的定时同步，用于补偿丢失的退款通知
+func (s *Service) StartRefundSync(interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	logger.Info("refund sync started", zap.Duration("interval", interval))
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stopCh:
+				logger.Info("refund sync stopped")
+				return
+			case <-ticker.C:
+				s.SyncProcessingRefunds(context.Background())
+			}
+		}
+	}()
+}
+
+// StopSync 停止处理中退款、付款的定时同步
+func (s *Service) StopSync() {
+	close(s.stopCh)
+}
+
+// SyncProcessingRefunds 向支持退款查询的提供商同步处理中的退款
+func (s *Service) SyncProcessingRefunds(ctx context.Context) {
+	refunds, err := s.refundRepo.ListProcessing(ctx, time.Now().Add(-refundSyncDelay), refundSyncBatch)
+	if err != nil {
+		logger.Error("failed to list processing refunds", zap.Error(err))
+		return
+	}
+
+	for _, refund := range refunds {
+		if err := s.syncRefund(ctx, refund); err != nil {
+			logger.Warn("failed to sync refund", zap.String("refund_no", refund.RefundNo), zap.Error(err))
+		}
+	}
+}
+
+// syncRefund 向第三方查询退款状态，不支持退款查询的提供商以通知为准
+func (s *Service) syncRefund(ctx context.Context, refund *entity.Refund) error {
+	prov, err := payment.GetProvider(refund.Provider)
+	if err != nil {
+		return err
+	}
+
+	querier, ok := prov.(payment.RefundQuerier)
+	if !ok {
+		return nil
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, refund.OrderID)
+	if err != nil {
+		return err
+	}
+
+	config, err := s.configRepo.GetByID(ctx, refund.ConfigID)
+	if err != nil {
+		return err
+	}
+
+	queryReq := &payment.QueryRefundRequest{
+		OutTradeNo: order.OutTradeNo,
+		TradeNo:    order.TradeNo,
+		RefundNo:   refund.RefundNo,
+		RefundID:   refund.RefundID,
+		Config:     config.ConfigData,
+	}
+
+	queryResp, err := querier.QueryRefund(ctx, queryReq)
+	if err != nil {
+		s.logPayment(ctx, order.ID, order.OrderNo, "refund_query", refund.Provider, queryReq, nil, "failed", err.Error())
+		// 更新时间用于轮转同步顺序，避免查询失败的退款一直排在最前
+		s.refundRepo.Update(ctx, refund)
+		return err
+	}
+
+	s.logPayment(ctx, order.ID, order.OrderNo, "refund_query", refund.Provider, queryReq, queryResp, "success", "")
+
+	if queryResp.Status == refund.Status {
+		return s.refundRepo.Update(ctx, refund)
+	}
+
+	return s.updateRefundStatus(ctx, refund, order, queryResp.Status, queryResp.RefundID)
+}
+
+// handleRefundNotify 处理退款结果通知
+// 优先按退款单号查找退款记录，部分提供商的通知只包含第三方退款单号
+func (s *Service) handleRefundNotify(ctx context.Context, provider string, req *payment.NotifyRequest, info *payment.RefundInfo) error {
+	var refund *entity.Refund
+	if info.RefundNo != "" {
+		if r, err := s.refundRepo.GetByRefundNo(ctx, info.RefundNo); err == nil && r.Provider == provider {
+			refund = r
+		}
+	}
+	if refund == nil && info.RefundID != "" {
+		if r, err := s.refundRepo.GetByProviderRefundID(ctx, provider, info.RefundID); err == nil {
+			refund = r
+		}
+	}
+	if refund == nil {
+		// 非本系统发起的退款（如在商户后台操作），忽略
+		logger.Warn("refund not found",
+			zap.String("provider", provider),
+			zap.String("refund_no", info.RefundNo),
+			zap.String("refund_id", info.RefundID))
+		return nil
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, refund.OrderID)
+	if err != nil {
+		return err
+	}
+
+	s.logPayment(ctx, order.ID, order.OrderNo, "refund_notify", provider, req, info, "success", "")
+
+	if info.Status == refund.Status && (info.RefundID == "" || info.RefundID == refund.RefundID) {
+		return nil
+	}
+
+	return s.updateRefundStatus(ctx, refund, order, info.Status, info.RefundID)
+}
+
+// updateRefundStatus 更新退款状态，退款成功或失败后不再变更，并通知商户
+func (s *Service) updateRefundStatus(ctx context.Context, refund *entity.Refund, order *entity.PaymentOrder, status, refundID string) error {
+	if refundID != "" {
+		refund.RefundID = refundID
+	}
+
+	oldStatus := refund.Status
+	if oldStatus == entity.RefundStatusProcessing && status != "" {
+		refund.Status = status
+	}
+	if refund.Status == entity.RefundStatusSuccess && refund.RefundTime == nil {
+		now := time.Now()
+		refund.RefundTime = &now
+	}
+
+	if err := s.refundRepo.Update(ctx, refund); err != nil {
+		logger.Error("failed to update refund", zap.String("refund_no", refund.RefundNo), zap.Error(err))
+		return err
+	}
+
+	if refund.Status != oldStatus {
+		s.notifyRefund(ctx, refund, order)
+	}
+
+	return nil
+}
+
+// notifyRefund 退款成功或失败，且订单有通知URL时，添加 refund.* 通知任务
+func (s *Service) notifyRefund(ctx context.Context, refund *entity.Refund, order *entity.PaymentOrder) {
+	if order.NotifyURL == "" || refund.Status == entity.RefundStatusProcessing {
+		return
+	}
+
+	notifyData := map[string]interface{}{
+		"event":        "refund." + refund.Status,
+		"order_no":     order.OrderNo,
+		"out_trade_no": order.OutTradeNo,
+		"refund_no":    refund.RefundNo,
+		"amount":       refund.Amount,
+		"currency":     refund.Currency,
+		"status":       refund.Status,
+		"reason":       refund.Reason,
+		"refund_time":  refund.RefundTime,
+	}
+
+	if err := s.notifyService.AddNotify(ctx, order.ID, order.OrderNo, order.NotifyURL, notifyData); err != nil {
+		logger.Error("failed to add refund notify task",
+			zap.Uint64("order_id", order.ID),
+			zap.String("refund_no", refund.RefundNo),
+			zap.Error(err))
+	}
+}
+
+// refundableAmount 剩余可退金额，处理中和已成功的退款都计入已退金额
+func (s *Service) refundableAmount(ctx context.Context, order *entity.PaymentOrder) (float64, error) {
+	refunds, err := s.refundRepo.ListByOrder(ctx, order.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	refunded := int64(0)
+	for _, refund := range refunds {
+		if refund.Status != entity.RefundStatusFailed {
+			refunded += toCents(refund.Amount)
+		}
+	}
+
+	return float64(toCents(paidAmount(order))-refunded) / 100, nil
+}
+
+// paidAmount 订单实付金额，预授权订单为实际扣款金额
+func paidAmount(order *entity.PaymentOrder) float64 {
+	if order.PreAuth && order.CaptureAmount > 0 {
+		return order.CaptureAmount
+	}
+	return order.Amount
+}
+
+// toCents 将金额转换为分，避免浮点误差
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// generateRefundNo 生成退款单号
+func (s *Service) generateRefundNo() string {
+	return fmt.Sprintf("RF%d%s", time.Now().UnixNano(), uuid.New().String()[:8])
+}
@@ -0,0 +1,196 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zqdfound/go-uni-pay/internal/domain/entity"
+	"github.com/zqdfound/go-uni-pay/internal/infrastructure/cache"
+	"github.com/zqdfound/go-uni-pay/internal/infrastructure/lock"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+	"github.com/zqdfound/go-uni-pay/pkg/logger"
+	"github.com/zqdfound/go-uni-pay/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// returnOrderParam 同步跳转地址中的系统订单号参数，校验提供商参数前移除
+const returnOrderParam = "order_no"
+
+// returnURL 生成提供商同步跳转到本服务的地址
+func (s *Service) returnURL(provider string, configID uint64, orderNo string) string {
+	return fmt.Sprintf("%s/api/v1/public/return/%s/%d?%s=%s", s.baseURL, provider, configID, returnOrderParam, url.QueryEscape(orderNo))
+}
+
+// HandleReturn 处理买家支付后的同步跳转
+// 向提供商确认支付结果并更新订单，返回携带本服务签名参数的商户跳转地址，订单没有商户跳转地址时返回空
+func (s *Service) HandleReturn(ctx context.Context, provider string, configID uint64, params map[string][]string) (*entity.PaymentOrder, string, error) {
+	values := url.Values{}
+	for key, value := range params {
+		if key != returnOrderParam {
+			values[key] = value
+		}
+	}
+
+	order, err := s.orderRepo.GetByOrderNo(ctx, url.Values(params).Get(returnOrderParam))
+	if err != nil {
+		return nil, "", err
+	}
+	if order.Provider != provider || order.ConfigID != configID {
+		return nil, "", apperrors.New(apperrors.ErrOrderNotFound, "order not found")
+	}
+
+	if !isFinalStatus(order.Status) {
+		s.verifyReturn(ctx, order, values)
+	}
+
+	if order.ReturnURL == "" {
+		return order, "", nil
+	}
+
+	redirectURL, err := s.signedReturnURL(ctx, order)
+	if err != nil {
+		return nil, "", err
+	}
+	return order, redirectURL, nil
+}
+
+// verifyReturn 向提供商确认支付结果并更新订单，确认失败时订单状态不变
+func (s *Service) verifyReturn(ctx context.Context, order *entity.PaymentOrder, params url.Values) {
+	config, err := s.configRepo.GetByID(ctx, order.ConfigID)
+	if err != nil {
+		return
+	}
+
+	prov, err := payment.GetProvider(order.Provider)
+	if err != nil {
+		return
+	}
+
+	// 需要商户发起扣款的提供商（如 PayPal）在买家授权后先扣款
+	// 跳转地址可被任何人访问，只有携带订单交易号（PayPal 为 token 参数）时才扣款
+	if _, ok := prov.(payment.Capturer); ok {
+		if order.TradeNo == "" || !hmac.Equal([]byte(params.Get("token")), []byte(order.TradeNo)) {
+			logger.Warn("capture on return skipped, token mismatch", zap.String("order_no", order.OrderNo))
+		} else if err := s.capturePayment(ctx, prov, order, config.ConfigData); err != nil {
+			logger.Warn("capture on return failed",
+				zap.String("order_no", order.OrderNo),
+				zap.Error(err))
+		}
+	}
+
+	verifier, ok := prov.(payment.ReturnVerifier)
+	if !ok {
+		// 其余提供商以查询结果为准
+		if result, err := s.QueryPayment(ctx, order.UserID, order.OrderNo); err == nil {
+			if current, ok := result.(*entity.PaymentOrder); ok {
+				*order = *current
+			}
+		}
+		return
+	}
+
+	verifyReq := &payment.VerifyReturnRequest{
+		OutTradeNo: order.OutTradeNo,
+		TradeNo:    order.TradeNo,
+		Params:     params,
+		Config:     config.ConfigData,
+	}
+
+	verifyResp, err := verifier.VerifyReturn(ctx, verifyReq)
+	if err != nil {
+		s.logPayment(ctx, order.ID, order.OrderNo, "return", order.Provider, verifyReq, nil, "failed", err.Error())
+		return
+	}
+
+	s.logPayment(ctx, order.ID, order.OrderNo, "return", order.Provider, verifyReq, verifyResp, "success", "")
+
+	if status := resolveStatus(order, verifyResp.Status); status != order.Status {
+		if verifyResp.TradeNo != "" {
+			order.TradeNo = verifyResp.TradeNo
+		}
+		if err := s.updateOrderStatus(ctx, order, status); err != nil {
+			logger.Error("failed to update order on return",
+				zap.String("order_no", order.OrderNo),
+				zap.Error(err))
+		}
+	}
+}
+
+// signedReturnURL 在商户跳转地址上附加订单结果和本服务的签名
+// 签名为 HMAC-SHA256(sign_key, 按参数名排序的 key=value&...)，不包含 sig 本身
+func (s *Service) signedReturnURL(ctx context.Context, order *entity.PaymentOrder) (string, error) {
+	key, err := s.GetSignKey(ctx, order.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(order.ReturnURL)
+	if err != nil {
+		return "", apperrors.Wrap(apperrors.ErrInvalidParam, "invalid return url", err)
+	}
+
+	params := map[string]string{
+		"order_no":     order.OrderNo,
+		"out_trade_no": order.OutTradeNo,
+		"status":       order.Status,
+		"amount":       strconv.FormatFloat(order.Amount, 'f', 2, 64),
+		"timestamp":    strconv.FormatInt(time.Now().Unix(), 10),
+	}
+
+	query := u.Query()
+	for k, v := range params {
+		query.Set(k, v)
+	}
+	query.Set("sig", SignReturnParams(key, params))
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// SignReturnParams 计算跳转参数的签名，商户可使用相同算法校验
+func SignReturnParams(key string, params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+params[k])
+	}
+	return utils.HMACSHA256(key, strings.Join(pairs, "&"))
+}
+
+// GetSignKey 获取商户的签名密钥，未生成时生成并保存
+func (s *Service) GetSignKey(ctx context.Context, userID uint64) (string, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if user.SignKey != "" {
+		return user.SignKey, nil
+	}
+
+	lockKey := fmt.Sprintf("user:sign_key:%d", userID)
+	err = lock.WithLock(ctx, cache.Client, lockKey, 10*time.Second, func() error {
+		// 重新加载用户，避免并发时生成不同的密钥
+		if user, err = s.userRepo.GetByID(ctx, userID); err != nil || user.SignKey != "" {
+			return err
+		}
+		user.SignKey = utils.RandomHex(32)
+		return s.userRepo.Update(ctx, user)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return user.SignKey, nil
+}
@@ -0,0 +1,218 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	stripego "github.com/stripe/stripe-go/v76"
+	"github.com/zqdfound/go-uni-pay/internal/domain/entity"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+	_ "github.com/zqdfound/go-uni-pay/internal/payment/stripe"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+)
+
+// fakeStripe 模拟 Stripe API：创建结账会话，查询时返回设置的支付状态
+func fakeStripe(t *testing.T, paymentStatus *string) {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/checkout/sessions":
+			require.NoError(t, r.ParseForm())
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"id":                  "cs_test_1",
+				"object":              "checkout.session",
+				"url":                 "https://checkout.stripe.com/c/pay/cs_test_1",
+				"success_url":         r.PostForm.Get("success_url"),
+				"client_reference_id": r.PostForm.Get("client_reference_id"),
+			})
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/checkout/sessions/cs_test_1":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"id":                  "cs_test_1",
+				"object":              "checkout.session",
+				"client_reference_id": "ORDER1",
+				"payment_status":      *paymentStatus,
+				"amount_total":        1999,
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]string{"type": "invalid_request_error"}})
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	stripego.SetBackend(stripego.APIBackend, stripego.GetBackendWithConfig(stripego.APIBackend, &stripego.BackendConfig{
+		URL:               stripego.String(srv.URL),
+		MaxNetworkRetries: stripego.Int64(0),
+		LeveledLogger:     &stripego.LeveledLogger{Level: stripego.LevelNull},
+	}))
+	t.Cleanup(func() { stripego.SetBackend(stripego.APIBackend, nil) })
+}
+
+// assertSignedReturn 校验商户跳转地址携带的订单结果和签名
+func assertSignedReturn(t *testing.T, redirectURL string, order *entity.PaymentOrder) {
+	t.Helper()
+
+	u, err := url.Parse(redirectURL)
+	require.NoError(t, err)
+	query := u.Query()
+	assert.Equal(t, "merchant.example.com", u.Host)
+	assert.Equal(t, "42", query.Get("ref"))
+	assert.Equal(t, order.OrderNo, query.Get("order_no"))
+	assert.Equal(t, order.Status, query.Get("status"))
+
+	params := map[string]string{}
+	for _, key := range []string{"order_no", "out_trade_no", "status", "amount", "timestamp"} {
+		params[key] = query.Get(key)
+	}
+	assert.Equal(t, SignReturnParams("test-sign-key", params), query.Get("sig"))
+}
+
+// TestHandleReturn_Stripe 测试 Stripe 结账后跳转回本服务，按结账会话查询支付结果并签名跳转到商户
+func TestHandleReturn_Stripe(t *testing.T) {
+	env := newTestEnv(t)
+	paymentStatus := "unpaid"
+	fakeStripe(t, &paymentStatus)
+
+	config := env.addConfig("stripe", entity.ConfigData{"secret_key": "sk_test_1"})
+	ctx := context.Background()
+
+	resp, err := env.svc.CreatePayment(ctx, &CreatePaymentRequest{
+		UserID:     testUserID,
+		Provider:   "stripe",
+		OutTradeNo: "ORDER1",
+		Subject:    "Order",
+		Amount:     19.99,
+		Currency:   "usd",
+		ReturnURL:  "https://merchant.example.com/done?ref=42",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "https://checkout.stripe.com/c/pay/cs_test_1", resp.PaymentURL)
+
+	// 结账会话ID保存为交易号，跳转地址指向本服务
+	order := env.order(t, resp.OrderNo)
+	assert.Equal(t, "cs_test_1", order.TradeNo)
+	params := map[string][]string{"order_no": {order.OrderNo}}
+
+	// 跳转地址的提供商或配置与订单不一致
+	_, _, err = env.svc.HandleReturn(ctx, "stripe", config.ID+1, params)
+	require.Error(t, err)
+	assert.Equal(t, apperrors.ErrOrderNotFound, err.(*apperrors.AppError).Code)
+	_, _, err = env.svc.HandleReturn(ctx, "paypal", config.ID, params)
+	require.Error(t, err)
+
+	// 买家未完成支付时订单仍待支付
+	result, redirectURL, err := env.svc.HandleReturn(ctx, "stripe", config.ID, params)
+	require.NoError(t, err)
+	assert.Equal(t, entity.OrderStatusPending, result.Status)
+	assertSignedReturn(t, redirectURL, result)
+
+	paymentStatus = "paid"
+	result, redirectURL, err = env.svc.HandleReturn(ctx, "stripe", config.ID, params)
+	require.NoError(t, err)
+	assert.Equal(t, entity.OrderStatusSuccess, result.Status)
+	assert.Equal(t, entity.OrderStatusSuccess, env.order(t, order.OrderNo).Status)
+	assertSignedReturn(t, redirectURL, result)
+}
+
+// TestHandleReturn_Capture 测试需要商户扣款的提供商在跳转时先扣款，再以查询结果为准
+func TestHandleReturn_Capture(t *testing.T) {
+	env := newTestEnv(t)
+	prov := &mockCapturer{newMockProvider(t)}
+	payment.Register(prov)
+
+	config := env.addConfig(prov.name, entity.ConfigData{})
+	order := env.addOrder(config, &entity.PaymentOrder{
+		OutTradeNo: "ORDER1",
+		Amount:     10,
+		TradeNo:    "5O190127TN364715T",
+		ReturnURL:  "https://merchant.example.com/done?ref=42",
+		Status:     entity.OrderStatusProcessing,
+	})
+
+	prov.On("CapturePayment", mock.Anything, mock.MatchedBy(func(req *payment.CapturePaymentRequest) bool {
+		return req.TradeNo == "5O190127TN364715T"
+	})).Return(&payment.CapturePaymentResponse{CaptureID: "CAPTURE1", Status: payment.StatusPending}, nil).Once()
+	prov.On("QueryPayment", mock.Anything, mock.Anything).
+		Return(&payment.QueryPaymentResponse{TradeNo: "5O190127TN364715T", Status: payment.StatusSuccess}, nil).Once()
+
+	result, redirectURL, err := env.svc.HandleReturn(context.Background(), prov.name, config.ID, map[string][]string{
+		"order_no": {order.OrderNo},
+		"token":    {"5O190127TN364715T"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, entity.OrderStatusSuccess, result.Status)
+	assert.Equal(t, "CAPTURE1", result.CaptureID)
+	assertSignedReturn(t, redirectURL, result)
+	prov.AssertExpectations(t)
+}
+
+// TestHandleReturn_QueryFallback 测试不能校验跳转参数的提供商以查询结果为准，订单没有商户跳转地址时不跳转
+func TestHandleReturn_QueryFallback(t *testing.T) {
+	env := newTestEnv(t)
+	prov := newMockProvider(t)
+	payment.Register(prov)
+
+	config := env.addConfig(prov.name, entity.ConfigData{})
+	order := env.addOrder(config, &entity.PaymentOrder{OutTradeNo: "ORDER1", Amount: 10, Status: entity.OrderStatusPending})
+
+	prov.On("QueryPayment", mock.Anything, mock.Anything).
+		Return(&payment.QueryPaymentResponse{TradeNo: "TRADE1", Status: payment.StatusSuccess}, nil).Once()
+
+	result, redirectURL, err := env.svc.HandleReturn(context.Background(), prov.name, config.ID, map[string][]string{"order_no": {order.OrderNo}})
+	require.NoError(t, err)
+	assert.Equal(t, entity.OrderStatusSuccess, result.Status)
+	assert.Equal(t, "TRADE1", result.TradeNo)
+	assert.Empty(t, redirectURL)
+
+	// 已是最终状态时不再查询
+	_, _, err = env.svc.HandleReturn(context.Background(), prov.name, config.ID, map[string][]string{"order_no": {order.OrderNo}})
+	require.NoError(t, err)
+	prov.AssertExpectations(t)
+}
+
+// TestHandleReturn_CaptureRequiresToken 测试跳转参数不带订单交易号或交易号不一致时不扣款，以查询结果为准
+func TestHandleReturn_CaptureRequiresToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string][]string
+	}{
+		{"missing token", map[string][]string{}},
+		{"wrong token", map[string][]string{"token": {"8AB12345CD678901E"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			prov := &mockCapturer{newMockProvider(t)}
+			payment.Register(prov)
+
+			config := env.addConfig(prov.name, entity.ConfigData{})
+			order := env.addOrder(config, &entity.PaymentOrder{
+				OutTradeNo: "ORDER1",
+				Amount:     10,
+				TradeNo:    "5O190127TN364715T",
+				Status:     entity.OrderStatusProcessing,
+			})
+
+			prov.On("QueryPayment", mock.Anything, mock.Anything).
+				Return(&payment.QueryPaymentResponse{TradeNo: "5O190127TN364715T", Status: payment.StatusPending}, nil).Once()
+
+			tt.params["order_no"] = []string{order.OrderNo}
+			result, _, err := env.svc.HandleReturn(context.Background(), prov.name, config.ID, tt.params)
+			require.NoError(t, err)
+			assert.Empty(t, result.CaptureID)
+			assert.Empty(t, env.order(t, order.OrderNo).CaptureID)
+			prov.AssertNotCalled(t, "CapturePayment", mock.Anything, mock.Anything)
+			prov.AssertExpectations(t)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -24,53 +25,125 @@ type NotifyService interface {
 
 // Service 支付服务
 type Service struct {
-	orderRepo     repository.PaymentOrderRepository
-	configRepo    repository.PaymentConfigRepository
-	logRepo       repository.PaymentLogRepository
-	notifyService NotifyService
+	orderRepo               repository.PaymentOrderRepository
+	configRepo              repository.PaymentConfigRepository
+	logRepo                 repository.PaymentLogRepository
+	refundRepo              repository.RefundRepository
+	payoutRepo              repository.PayoutRepository
+	profitSharingRepo       repository.ProfitSharingRepository
+	profitSharingReturnRepo repository.ProfitSharingReturnRepository
+	planRepo                repository.PlanRepository
+	subscriptionRepo        repository.SubscriptionRepository
+	customerRepo            repository.CustomerRepository
+	paymentMethodRepo       repository.PaymentMethodRepository
+	checkoutRepo            repository.CheckoutRepository
+	paymentLinkRepo         repository.PaymentLinkRepository
+	disputeRepo             repository.DisputeRepository
+	userRepo                repository.UserRepository
+	notifyService           NotifyService
+	baseURL                 string
+	notifySecret            string
+	legacyNotify            bool
+	dunningIntervals        []time.Duration
+	stopCh                  chan struct{}
 }
 
 // NewService 创建支付服务
+// baseURL 为本服务对外访问地址，用于生成需要回到本服务的跳转URL
 func NewService(
 	orderRepo repository.PaymentOrderRepository,
 	configRepo repository.PaymentConfigRepository,
 	logRepo repository.PaymentLogRepository,
+	refundRepo repository.RefundRepository,
+	payoutRepo repository.PayoutRepository,
+	profitSharingRepo repository.ProfitSharingRepository,
+	profitSharingReturnRepo repository.ProfitSharingReturnRepository,
+	planRepo repository.PlanRepository,
+	subscriptionRepo repository.SubscriptionRepository,
+	customerRepo repository.CustomerRepository,
+	paymentMethodRepo repository.PaymentMethodRepository,
+	checkoutRepo repository.CheckoutRepository,
+	paymentLinkRepo repository.PaymentLinkRepository,
+	disputeRepo repository.DisputeRepository,
+	userRepo repository.UserRepository,
 	notifyService NotifyService,
+	baseURL string,
 ) *Service {
 	return &Service{
-		orderRepo:     orderRepo,
-		configRepo:    configRepo,
-		logRepo:       logRepo,
-		notifyService: notifyService,
+		orderRepo:               orderRepo,
+		configRepo:              configRepo,
+		logRepo:                 logRepo,
+		refundRepo:              refundRepo,
+		payoutRepo:              payoutRepo,
+		profitSharingRepo:       profitSharingRepo,
+		profitSharingReturnRepo: profitSharingReturnRepo,
+		planRepo:                planRepo,
+		subscriptionRepo:        subscriptionRepo,
+		customerRepo:            customerRepo,
+		paymentMethodRepo:       paymentMethodRepo,
+		checkoutRepo:            checkoutRepo,
+		paymentLinkRepo:         paymentLinkRepo,
+		disputeRepo:             disputeRepo,
+		userRepo:                userRepo,
+		notifyService:           notifyService,
+		baseURL:                 strings.TrimRight(baseURL, "/"),
+		dunningIntervals:        defaultDunningIntervals,
+		stopCh:                  make(chan struct{}),
 	}
 }
 
 // CreatePaymentRequest 创建支付请求
+// PaymentMethodNo 不为空时使用客户已保存的支付方式直接扣款，买家无需在场
 type CreatePaymentRequest struct {
-	UserID      uint64
-	Provider    string
-	OutTradeNo  string
-	Subject     string
-	Body        string
-	Amount      float64
-	Currency    string
-	NotifyURL   string
-	ReturnURL   string
-	ClientIP    string
-	ExtraParams map[string]interface{}
+	UserID          uint64
+	Provider        string
+	OutTradeNo      string
+	Subject         string
+	Body            string
+	Amount          float64
+	Currency        string
+	Scene           string
+	NotifyURL       string
+	ReturnURL       string
+	ClientIP        string
+	ExtraParams     map[string]interface{}
+	Items           entity.OrderItems
+	Buyer           *entity.Buyer
+	Shipping        *entity.Shipping
+	ProfitSharing   *entity.ProfitSharingPlan
+	CustomerNo      string
+	PaymentMethodNo string
+	PaymentLinkNo   string
 }
 
 // CreatePaymentResponse 创建支付响应
+// 使用已保存支付方式扣款时没有支付链接，Status 为扣款后的订单状态
+// QRCodeURL 为本服务生成的二维码图片地址，仅在返回二维码内容时提供
+// ExpireTime 为支付链接、二维码等的过期时间，为空表示提供商未说明
 type CreatePaymentResponse struct {
 	OrderNo    string
 	PaymentURL string
 	PaymentID  string
 	QRCode     string
+	QRCodeURL  string
+	FormData   string
 	ExtraData  map[string]interface{}
+	ExpireTime *time.Time
+	Status     string
 }
 
 // CreatePayment 创建支付
 func (s *Service) CreatePayment(ctx context.Context, req *CreatePaymentRequest) (*CreatePaymentResponse, error) {
+	return s.createPayment(ctx, req, false)
+}
+
+// Authorize 创建预授权支付，买家支付后仅冻结资金，由商户后续扣款或撤销
+func (s *Service) Authorize(ctx context.Context, req *CreatePaymentRequest) (*CreatePaymentResponse, error) {
+	return s.createPayment(ctx, req, true)
+}
+
+// createPayment 创建支付或预授权
+func (s *Service) createPayment(ctx context.Context, req *CreatePaymentRequest, preAuth bool) (*CreatePaymentResponse, error) {
 	// 使用分布式锁防止重复创建，基于 out_trade_no
 	lockKey := fmt.Sprintf("payment:create:%s", req.OutTradeNo)
 	distLock := lock.NewRedisLock(cache.Client, lockKey, 30*time.Second)
@@ -94,12 +167,9 @@ func (s *Service) CreatePayment(ctx context.Context, req *CreatePaymentRequest)
 			zap.String("order_no", existingOrder.OrderNo),
 			zap.String("status", existingOrder.Status))
 
-		// 如果订单状态不是失败，返回已有订单信息
+		// 如果订单状态不是失败，返回已有订单的支付信息
 		if existingOrder.Status != entity.OrderStatusFailed {
-			return &CreatePaymentResponse{
-				OrderNo: existingOrder.OrderNo,
-				// 注意：已有订单可能没有 PaymentURL，需要特殊处理
-			}, nil
+			return s.existingPayment(ctx, existingOrder)
 		}
 		// 如果订单状态是失败，允许重试创建
 	}
@@ -110,57 +180,124 @@ func (s *Service) CreatePayment(ctx context.Context, req *CreatePaymentRequest)
 		return nil, err
 	}
 
+	// 获取支付提供商
+	provider, err := payment.GetProvider(req.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	if preAuth {
+		if _, ok := provider.(payment.Authorizer); !ok {
+			return nil, apperrors.New(apperrors.ErrNotSupported, fmt.Sprintf("provider %s does not support authorization", req.Provider))
+		}
+	}
+
+	// 使用已保存的支付方式扣款
+	var (
+		saver    payment.PaymentMethodSaver
+		method   *entity.PaymentMethod
+		customer *entity.Customer
+	)
+	if req.PaymentMethodNo != "" {
+		if preAuth {
+			return nil, apperrors.New(apperrors.ErrNotSupported, "saved payment methods do not support authorization")
+		}
+		var ok bool
+		if saver, ok = provider.(payment.PaymentMethodSaver); !ok {
+			return nil, apperrors.New(apperrors.ErrNotSupported, fmt.Sprintf("provider %s does not support saved payment methods", req.Provider))
+		}
+		if method, customer, err = s.resolvePaymentMethod(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+
+	// 校验商品明细与订单金额一致
+	if err := validateItems(req.Items, req.Shipping, req.Amount); err != nil {
+		return nil, err
+	}
+
+	// 校验分账计划，自动分账在支付成功后按计划执行，手动分账由商户调用分账接口发起
+	profitSharing := req.ProfitSharing
+	if profitSharing != nil {
+		if _, ok := provider.(payment.ProfitSharer); !ok {
+			return nil, apperrors.New(apperrors.ErrNotSupported, fmt.Sprintf("provider %s does not support profit sharing", req.Provider))
+		}
+		settle := profitSharing.Settle
+		if settle == "" {
+			settle = entity.ProfitSharingSettleAuto
+		}
+		if settle != entity.ProfitSharingSettleAuto && settle != entity.ProfitSharingSettleManual {
+			return nil, apperrors.New(apperrors.ErrInvalidParam, fmt.Sprintf("invalid profit sharing settle: %s", settle))
+		}
+		receivers, total, err := resolveReceivers(profitSharing.Receivers, req.Amount)
+		if err != nil {
+			return nil, err
+		}
+		if toCents(total) > toCents(req.Amount) {
+			return nil, apperrors.New(apperrors.ErrAmountInvalid, "profit sharing amount must not exceed order amount")
+		}
+		profitSharing = &entity.ProfitSharingPlan{Settle: settle, Receivers: receivers}
+	}
+
+	// 支付结果通过通知地址回调本服务，无法生成通知地址时不创建订单
+	if _, err := s.notifyURL(req.Provider, config.ID); err != nil {
+		return nil, err
+	}
+
 	// 生成订单号
 	orderNo := s.generateOrderNo()
 
 	// 创建订单记录
 	order := &entity.PaymentOrder{
-		OrderNo:    orderNo,
-		UserID:     req.UserID,
-		Provider:   req.Provider,
-		ConfigID:   config.ID,
-		OutTradeNo: req.OutTradeNo,
-		Subject:    req.Subject,
-		Body:       req.Body,
-		Amount:     req.Amount,
-		Currency:   req.Currency,
-		Status:     entity.OrderStatusPending,
-		NotifyURL:  req.NotifyURL,
-		ReturnURL:  req.ReturnURL,
-		ClientIP:   req.ClientIP,
-		ExtraData:  req.ExtraParams,
+		OrderNo:       orderNo,
+		UserID:        req.UserID,
+		Provider:      req.Provider,
+		ConfigID:      config.ID,
+		OutTradeNo:    req.OutTradeNo,
+		Subject:       req.Subject,
+		Body:          req.Body,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		Scene:         req.Scene,
+		PreAuth:       preAuth,
+		Status:        entity.OrderStatusPending,
+		NotifyURL:     req.NotifyURL,
+		ReturnURL:     req.ReturnURL,
+		ClientIP:      req.ClientIP,
+		ExtraData:     req.ExtraParams,
+		Items:         req.Items,
+		Buyer:         req.Buyer,
+		Shipping:      req.Shipping,
+		ProfitSharing: profitSharing,
+		PaymentLinkNo: req.PaymentLinkNo,
 	}
-
-	if err := s.orderRepo.Create(ctx, order); err != nil {
-		return nil, err
+	if method != nil {
+		order.PaymentMethodNo = method.MethodNo
 	}
 
-	// 获取支付提供商
-	provider, err := payment.GetProvider(req.Provider)
-	if err != nil {
+	// 失败的订单重试时复用原订单记录（out_trade_no 唯一），使用新的订单号
+	if existingOrder != nil && existingOrder.Status == entity.OrderStatusFailed {
+		order.ID = existingOrder.ID
+		order.CreatedAt = existingOrder.CreatedAt
+		if err := s.orderRepo.Update(ctx, order); err != nil {
+			return nil, err
+		}
+	} else if err := s.orderRepo.Create(ctx, order); err != nil {
 		return nil, err
 	}
 
-	// 创建支付请求
-	payReq := &payment.CreatePaymentRequest{
-		OutTradeNo:  req.OutTradeNo,
-		Subject:     req.Subject,
-		Body:        req.Body,
-		Amount:      req.Amount,
-		Currency:    req.Currency,
-		NotifyURL:   req.NotifyURL,
-		ReturnURL:   req.ReturnURL,
-		ClientIP:    req.ClientIP,
-		Config:      config.ConfigData,
-		ExtraParams: req.ExtraParams,
+	if method != nil {
+		if err := s.chargePaymentMethod(ctx, saver, order, method, customer, config.ConfigData); err != nil {
+			return nil, err
+		}
+		return &CreatePaymentResponse{
+			OrderNo: orderNo,
+			Status:  order.Status,
+		}, nil
 	}
 
 	// 调用支付提供商创建支付
-	payResp, err := provider.CreatePayment(ctx, payReq)
-	if err != nil {
-		// 记录错误日志
-		s.logPayment(ctx, order.ID, orderNo, "create", req.Provider, payReq, nil, "failed", err.Error())
-
+	if err := s.requestPayment(ctx, provider, order, config.ConfigData); err != nil {
 		// 更新订单状态为失败
 		order.Status = entity.OrderStatusFailed
 		s.orderRepo.Update(ctx, order)
@@ -168,23 +305,223 @@ func (s *Service) CreatePayment(ctx context.Context, req *CreatePaymentRequest)
 		return nil, err
 	}
 
+	return s.paymentResponse(order), nil
+}
+
+// requestPayment 调用支付提供商创建支付或预授权，并在订单上保存返回的支付信息
+func (s *Service) requestPayment(ctx context.Context, provider payment.Provider, order *entity.PaymentOrder, config map[string]interface{}) error {
+	notifyURL, err := s.notifyURL(order.Provider, order.ConfigID)
+	if err != nil {
+		return err
+	}
+
+	payReq := &payment.CreatePaymentRequest{
+		OrderNo:       order.OrderNo,
+		OutTradeNo:    order.OutTradeNo,
+		Subject:       order.Subject,
+		Body:          order.Body,
+		Amount:        order.Amount,
+		Currency:      order.Currency,
+		Scene:         order.Scene,
+		NotifyURL:     notifyURL,
+		ReturnURL:     order.ReturnURL,
+		ClientIP:      order.ClientIP,
+		BaseURL:       s.baseURL,
+		Config:        config,
+		ExtraParams:   order.ExtraData,
+		Items:         toPaymentItems(order.Items),
+		Buyer:         toPaymentBuyer(order.Buyer),
+		Shipping:      toPaymentShipping(order.Shipping),
+		ProfitSharing: toPaymentPlan(order.ProfitSharing),
+	}
+
+	// 需要商户发起扣款或可校验同步跳转结果的提供商，买家支付后先跳转回本服务确认支付结果，
+	// 再携带本服务签名的参数跳转到商户页面
+	if s.baseURL != "" {
+		_, capture := provider.(payment.Capturer)
+		_, verify := provider.(payment.ReturnVerifier)
+		if capture || (verify && order.ReturnURL != "") {
+			payReq.ReturnURL = s.returnURL(order.Provider, order.ConfigID, order.OrderNo)
+		}
+	}
+
+	action := "create"
+	var payResp *payment.CreatePaymentResponse
+	if order.PreAuth {
+		authorizer, ok := provider.(payment.Authorizer)
+		if !ok {
+			return apperrors.New(apperrors.ErrNotSupported, fmt.Sprintf("provider %s does not support authorization", order.Provider))
+		}
+		action = "authorize"
+		payResp, err = authorizer.Authorize(ctx, payReq)
+	} else {
+		payResp, err = provider.CreatePayment(ctx, payReq)
+	}
+	if err != nil {
+		// 记录错误日志
+		s.logPayment(ctx, order.ID, order.OrderNo, action, order.Provider, payReq, nil, "failed", err.Error())
+		return err
+	}
+
 	// 记录成功日志
-	s.logPayment(ctx, order.ID, orderNo, "create", req.Provider, payReq, payResp, "success", "")
+	s.logPayment(ctx, order.ID, order.OrderNo, action, order.Provider, payReq, payResp, "success", "")
 
-	// 更新订单信息
+	// 更新订单信息，保存二维码内容用于生成二维码图片，保存支付信息用于重复创建时返回
 	if payResp.TradeNo != "" {
 		order.TradeNo = payResp.TradeNo
 		order.Status = entity.OrderStatusProcessing
-		s.orderRepo.Update(ctx, order)
 	}
-
-	return &CreatePaymentResponse{
-		OrderNo:    orderNo,
+	order.QRCode = payResp.QRCode
+	order.PaymentData = &entity.PaymentData{
 		PaymentURL: payResp.PaymentURL,
 		PaymentID:  payResp.PaymentID,
-		QRCode:     payResp.QRCode,
+		FormData:   payResp.FormData,
 		ExtraData:  payResp.ExtraData,
-	}, nil
+		ExpireTime: payResp.ExpireTime,
+	}
+	if err := s.orderRepo.Update(ctx, order); err != nil {
+		logger.Error("failed to save payment data", zap.String("order_no", order.OrderNo), zap.Error(err))
+	}
+
+	return nil
+}
+
+// validateItems 校验商品明细，各项金额（单价加税额乘以数量）与运费之和需等于订单金额，按分比较
+func validateItems(items entity.OrderItems, shipping *entity.Shipping, amount float64) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	var total int64
+	for _, item := range items {
+		if item.Name == "" || item.Quantity <= 0 || item.UnitPrice < 0 || item.Tax < 0 {
+			return apperrors.New(apperrors.ErrInvalidParam, fmt.Sprintf("invalid item: %s", item.Name))
+		}
+		total += item.Quantity * (toCents(item.UnitPrice) + toCents(item.Tax))
+	}
+	if shipping != nil {
+		total += toCents(shipping.Fee)
+	}
+
+	if total != toCents(amount) {
+		return apperrors.New(apperrors.ErrAmountInvalid, fmt.Sprintf("items total %.2f does not match amount %.2f", float64(total)/100, amount))
+	}
+	return nil
+}
+
+// toPaymentItems 转换为提供商使用的商品明细
+func toPaymentItems(items entity.OrderItems) []payment.Item {
+	if len(items) == 0 {
+		return nil
+	}
+	result := make([]payment.Item, 0, len(items))
+	for _, item := range items {
+		result = append(result, payment.Item{
+			Name:      item.Name,
+			SKU:       item.SKU,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			Tax:       item.Tax,
+		})
+	}
+	return result
+}
+
+// toPaymentBuyer 转换为提供商使用的买家信息
+func toPaymentBuyer(buyer *entity.Buyer) *payment.Buyer {
+	if buyer == nil {
+		return nil
+	}
+	return &payment.Buyer{
+		Name:  buyer.Name,
+		Email: buyer.Email,
+		Phone: buyer.Phone,
+	}
+}
+
+// toPaymentShipping 转换为提供商使用的收货信息
+func toPaymentShipping(shipping *entity.Shipping) *payment.Shipping {
+	if shipping == nil {
+		return nil
+	}
+	return &payment.Shipping{
+		Name:       shipping.Name,
+		Phone:      shipping.Phone,
+		Country:    shipping.Country,
+		State:      shipping.State,
+		City:       shipping.City,
+		Line1:      shipping.Line1,
+		Line2:      shipping.Line2,
+		PostalCode: shipping.PostalCode,
+		Fee:        shipping.Fee,
+	}
+}
+
+// existingPayment 重复创建时返回已有订单的支付信息
+// 待支付订单的支付信息已过期或未保存时，先同步订单状态，仍未支付则向提供商重新获取
+func (s *Service) existingPayment(ctx context.Context, order *entity.PaymentOrder) (*CreatePaymentResponse, error) {
+	if !paymentDataExpired(order) {
+		return s.paymentResponse(order), nil
+	}
+
+	// 避免买家已支付后再次发起支付
+	result, err := s.QueryPayment(ctx, order.UserID, order.OrderNo)
+	if err != nil {
+		return nil, err
+	}
+	order = result.(*entity.PaymentOrder)
+	if order.Status != entity.OrderStatusPending && order.Status != entity.OrderStatusProcessing {
+		return s.paymentResponse(order), nil
+	}
+
+	config, err := s.configRepo.GetByID(ctx, order.ConfigID)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := payment.GetProvider(order.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.requestPayment(ctx, provider, order, config.ConfigData); err != nil {
+		return nil, err
+	}
+
+	return s.paymentResponse(order), nil
+}
+
+// paymentDataExpired 待支付订单的支付信息已过期或未保存时返回 true
+// 使用已保存支付方式扣款的订单没有支付信息
+func paymentDataExpired(order *entity.PaymentOrder) bool {
+	if order.Status != entity.OrderStatusPending && order.Status != entity.OrderStatusProcessing {
+		return false
+	}
+	if order.PaymentMethodNo != "" {
+		return false
+	}
+	if order.PaymentData == nil {
+		return true
+	}
+	return order.PaymentData.ExpireTime != nil && time.Now().After(*order.PaymentData.ExpireTime)
+}
+
+// paymentResponse 根据订单保存的支付信息构造创建支付响应
+func (s *Service) paymentResponse(order *entity.PaymentOrder) *CreatePaymentResponse {
+	resp := &CreatePaymentResponse{
+		OrderNo:   order.OrderNo,
+		QRCode:    order.QRCode,
+		QRCodeURL: s.qrCodeURL(order),
+		Status:    order.Status,
+	}
+	if data := order.PaymentData; data != nil {
+		resp.PaymentURL = data.PaymentURL
+		resp.PaymentID = data.PaymentID
+		resp.FormData = data.FormData
+		resp.ExtraData = data.ExtraData
+		resp.ExpireTime = data.ExpireTime
+	}
+	return resp
 }
 
 // QueryPayment 查询支付
@@ -201,18 +538,24 @@ func (s *Service) QueryPayment(ctx context.Context, userID uint64, orderNo strin
 	}
 
 	// 如果订单已经是最终状态，直接返回
-	if order.Status == entity.OrderStatusSuccess || order.Status == entity.OrderStatusClosed {
+	if isFinalStatus(order.Status) {
 		return order, nil
 	}
 
-	// 获取支付配置
-	config, err := s.configRepo.GetByID(ctx, order.ConfigID)
+	// 获取支付提供商
+	provider, err := payment.GetProvider(order.Provider)
 	if err != nil {
 		return nil, err
 	}
 
-	// 获取支付提供商
-	provider, err := payment.GetProvider(order.Provider)
+	// 不支持主动查询的提供商以异步通知结果为准，直接返回本地订单
+	querier, ok := provider.(payment.Querier)
+	if !ok {
+		return order, nil
+	}
+
+	// 获取支付配置
+	config, err := s.configRepo.GetByID(ctx, order.ConfigID)
 	if err != nil {
 		return nil, err
 	}
@@ -224,7 +567,7 @@ func (s *Service) QueryPayment(ctx context.Context, userID uint64, orderNo strin
 		Config:     config.ConfigData,
 	}
 
-	queryResp, err := provider.QueryPayment(ctx, queryReq)
+	queryResp, err := querier.QueryPayment(ctx, queryReq)
 	if err != nil {
 		s.logPayment(ctx, order.ID, orderNo, "query", order.Provider, queryReq, nil, "failed", err.Error())
 		return order, nil
@@ -234,52 +577,93 @@ func (s *Service) QueryPayment(ctx context.Context, userID uint64, orderNo strin
 	s.logPayment(ctx, order.ID, orderNo, "query", order.Provider, queryReq, queryResp, "success", "")
 
 	// 更新订单状态
-	if queryResp.Status != order.Status {
-		oldStatus := order.Status
-		order.Status = queryResp.Status
-		if queryResp.Status == entity.OrderStatusSuccess {
-			now := time.Now()
-			order.PaymentTime = &now
-		}
+	if status := resolveStatus(order, queryResp.Status); status != order.Status {
 		if queryResp.TradeNo != "" {
 			order.TradeNo = queryResp.TradeNo
 		}
-		if err := s.orderRepo.Update(ctx, order); err != nil {
-			logger.Error("failed to update order", zap.Error(err))
+		if err := s.updateOrderStatus(ctx, order, status); err != nil {
 			return order, err
 		}
+	}
 
-		// 如果订单状态变为成功，且有通知URL，添加通知任务
-		if order.Status == entity.OrderStatusSuccess && oldStatus != entity.OrderStatusSuccess && order.NotifyURL != "" {
-			notifyData := map[string]interface{}{
-				"order_no":     order.OrderNo,
-				"out_trade_no": order.OutTradeNo,
-				"trade_no":     order.TradeNo,
-				"amount":       order.Amount,
-				"currency":     order.Currency,
-				"status":       order.Status,
-				"payment_time": order.PaymentTime,
-				"subject":      order.Subject,
-			}
+	return order, nil
+}
 
-			if err := s.notifyService.AddNotify(ctx, order.ID, order.OrderNo, order.NotifyURL, notifyData); err != nil {
-				logger.Error("failed to add notify task",
-					zap.Uint64("order_id", order.ID),
-					zap.String("order_no", order.OrderNo),
-					zap.Error(err))
-				// 不影响主流程，继续返回
-			} else {
-				logger.Info("notify task added",
-					zap.Uint64("order_id", order.ID),
-					zap.String("order_no", order.OrderNo),
-					zap.String("notify_url", order.NotifyURL))
-			}
-		}
+// ClosePayment 关闭未支付订单
+func (s *Service) ClosePayment(ctx context.Context, userID uint64, orderNo string) (*entity.PaymentOrder, error) {
+	order, err := s.orderRepo.GetByOrderNo(ctx, orderNo)
+	if err != nil {
+		return nil, err
+	}
+
+	// 验证订单归属（数据隔离）
+	if order.UserID != userID {
+		return nil, apperrors.New(apperrors.ErrOrderNotFound, "order not found")
+	}
+
+	if order.Status != entity.OrderStatusPending && order.Status != entity.OrderStatusProcessing {
+		return nil, apperrors.New(apperrors.ErrOrderStatus, fmt.Sprintf("order in status %s cannot be closed", order.Status))
+	}
+
+	prov, err := payment.GetProvider(order.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	closer, ok := prov.(payment.Closer)
+	if !ok {
+		return nil, apperrors.New(apperrors.ErrNotSupported, fmt.Sprintf("provider %s does not support closing payments", order.Provider))
+	}
+
+	config, err := s.configRepo.GetByID(ctx, order.ConfigID)
+	if err != nil {
+		return nil, err
+	}
+
+	closeReq := &payment.ClosePaymentRequest{
+		OutTradeNo: order.OutTradeNo,
+		TradeNo:    order.TradeNo,
+		Config:     config.ConfigData,
+	}
+
+	if err := closer.ClosePayment(ctx, closeReq); err != nil {
+		s.logPayment(ctx, order.ID, order.OrderNo, "close", order.Provider, closeReq, nil, "failed", err.Error())
+		return nil, err
+	}
+
+	s.logPayment(ctx, order.ID, order.OrderNo, "close", order.Provider, closeReq, nil, "success", "")
+
+	if err := s.updateOrderStatus(ctx, order, entity.OrderStatusClosed); err != nil {
+		return nil, err
 	}
 
 	return order, nil
 }
 
+// DownloadBill 获取对账单下载地址
+func (s *Service) DownloadBill(ctx context.Context, userID uint64, provider, billDate, billType string) (*payment.DownloadBillResponse, error) {
+	prov, err := payment.GetProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	downloader, ok := prov.(payment.BillDownloader)
+	if !ok {
+		return nil, apperrors.New(apperrors.ErrNotSupported, fmt.Sprintf("provider %s does not support bill download", provider))
+	}
+
+	config, err := s.getConfigWithCache(ctx, userID, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	return downloader.DownloadBill(ctx, &payment.DownloadBillRequest{
+		BillDate: billDate,
+		BillType: billType,
+		Config:   config.ConfigData,
+	})
+}
+
 // HandleNotify 处理支付通知
 func (s *Service) HandleNotify(ctx context.Context, provider string, req *payment.NotifyRequest) ([]byte, error) {
 	// 获取支付提供商
@@ -295,6 +679,46 @@ func (s *Service) HandleNotify(ctx context.Context, provider string, req *paymen
 		return nil, err
 	}
 
+	// 退款通知通过退款单号关联退款记录，只更新退款状态
+	if notifyResp.Event == payment.EventRefund {
+		if notifyResp.Refund != nil {
+			if err := s.handleRefundNotify(ctx, provider, req, notifyResp.Refund); err != nil {
+				return nil, err
+			}
+		}
+		return notifyResp.ReturnData, nil
+	}
+
+	// 付款通知与订单无关，通过付款单号关联付款记录
+	if notifyResp.Event == payment.EventPayout {
+		if notifyResp.Payout != nil {
+			if err := s.handlePayoutNotify(ctx, provider, req, notifyResp.Payout); err != nil {
+				return nil, err
+			}
+		}
+		return notifyResp.ReturnData, nil
+	}
+
+	// 订阅通知通过订阅单号关联订阅，续费扣款的交易通知仍按订单处理
+	if notifyResp.Event == payment.EventSubscription {
+		if notifyResp.Subscription != nil {
+			if err := s.handleSubscriptionNotify(ctx, provider, req, notifyResp.Subscription); err != nil {
+				return nil, err
+			}
+		}
+		return notifyResp.ReturnData, nil
+	}
+
+	// 支付方式通知通过支付方式单号关联支付方式
+	if notifyResp.Event == payment.EventPaymentMethod {
+		if notifyResp.PaymentMethod != nil {
+			if err := s.handlePaymentMethodNotify(ctx, provider, req, notifyResp.PaymentMethod); err != nil {
+				return nil, err
+			}
+		}
+		return notifyResp.ReturnData, nil
+	}
+
 	// 查询订单
 	// 注意：这里使用 GetByOutTradeNo 而不是 GetByUserAndOutTradeNo
 	// 原因：支付回调中没有 user_id，但安全性通过以下方式保证：
@@ -310,61 +734,532 @@ func (s *Service) HandleNotify(ctx context.Context, provider string, req *paymen
 	// 记录日志
 	s.logPayment(ctx, order.ID, order.OrderNo, "notify", provider, req, notifyResp, "success", "")
 
-	// 更新订单状态
-	if notifyResp.Status != order.Status {
-		oldStatus := order.Status
-		order.Status = notifyResp.Status
+	// 争议事件只记录争议，不影响订单状态
+	if notifyResp.Event == payment.EventDispute && notifyResp.Dispute != nil {
+		if err := s.handleDispute(ctx, provider, order, notifyResp.Dispute); err != nil {
+			return nil, err
+		}
+		return notifyResp.ReturnData, nil
+	}
+
+	// 买家已授权，发起扣款；扣款失败时返回错误，由第三方重试通知
+	if notifyResp.NeedCapture {
+		if err := s.capturePayment(ctx, prov, order, req.Config); err != nil {
+			return nil, err
+		}
+		return notifyResp.ReturnData, nil
+	}
+
+	// 记录扣款ID和预授权ID
+	idChanged := false
+	if notifyResp.CaptureID != "" && notifyResp.CaptureID != order.CaptureID {
+		order.CaptureID = notifyResp.CaptureID
+		idChanged = true
+	}
+	if notifyResp.AuthorizationID != "" && notifyResp.AuthorizationID != order.AuthorizationID {
+		order.AuthorizationID = notifyResp.AuthorizationID
+		idChanged = true
+	}
+
+	// 更新订单状态（状态为空表示该事件不影响订单状态）
+	status := resolveStatus(order, notifyResp.Status)
+	if notifyResp.Status == "" || status == order.Status {
+		if idChanged {
+			if err := s.orderRepo.Update(ctx, order); err != nil {
+				logger.Error("failed to update order", zap.Error(err))
+				return notifyResp.ReturnData, err
+			}
+		}
+	} else {
 		if notifyResp.TradeNo != "" {
 			order.TradeNo = notifyResp.TradeNo
 		}
-		if notifyResp.Status == entity.OrderStatusSuccess {
-			now := time.Now()
-			order.PaymentTime = &now
-		}
-		if err := s.orderRepo.Update(ctx, order); err != nil {
-			logger.Error("failed to update order", zap.Error(err))
+		if err := s.updateOrderStatus(ctx, order, status); err != nil {
 			return notifyResp.ReturnData, err
 		}
+	}
 
-		// 如果订单状态变为成功，且有通知URL，添加通知任务
-		if order.Status == entity.OrderStatusSuccess && oldStatus != entity.OrderStatusSuccess && order.NotifyURL != "" {
-			notifyData := map[string]interface{}{
-				"order_no":     order.OrderNo,
-				"out_trade_no": order.OutTradeNo,
-				"trade_no":     order.TradeNo,
-				"amount":       order.Amount,
-				"currency":     order.Currency,
-				"status":       order.Status,
-				"payment_time": order.PaymentTime,
-				"subject":      order.Subject,
-			}
+	return notifyResp.ReturnData, nil
+}
+
+// SimulationOrder 获取沙箱提供商的订单，用于展示收银台模拟页面
+func (s *Service) SimulationOrder(ctx context.Context, orderNo string) (*entity.PaymentOrder, error) {
+	order, err := s.orderRepo.GetByOrderNo(ctx, orderNo)
+	if err != nil {
+		return nil, err
+	}
+
+	prov, err := payment.GetProvider(order.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := prov.(payment.Simulator); !ok {
+		return nil, apperrors.New(apperrors.ErrNotSupported, fmt.Sprintf("provider %s does not support simulation", order.Provider))
+	}
+
+	return order, nil
+}
+
+// SimulatePayment 在收银台模拟页面模拟买家操作，由提供商向本服务发送支付通知
+func (s *Service) SimulatePayment(ctx context.Context, orderNo, action string) (*entity.PaymentOrder, error) {
+	order, err := s.SimulationOrder(ctx, orderNo)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.baseURL == "" {
+		return nil, apperrors.New(apperrors.ErrNotSupported, "base url is required for payment simulation")
+	}
+
+	if order.Status != entity.OrderStatusPending && order.Status != entity.OrderStatusProcessing {
+		return nil, apperrors.New(apperrors.ErrOrderStatus, fmt.Sprintf("order in status %s cannot be simulated", order.Status))
+	}
+
+	prov, err := payment.GetProvider(order.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := s.configRepo.GetByID(ctx, order.ConfigID)
+	if err != nil {
+		return nil, err
+	}
+
+	notifyURL, err := s.notifyURL(order.Provider, order.ConfigID)
+	if err != nil {
+		return nil, err
+	}
+
+	simReq := &payment.SimulateRequest{
+		OutTradeNo: order.OutTradeNo,
+		TradeNo:    order.TradeNo,
+		Amount:     order.Amount,
+		Currency:   order.Currency,
+		Action:     action,
+		NotifyURL:  notifyURL,
+		Config:     config.ConfigData,
+	}
+
+	if err := prov.(payment.Simulator).Simulate(ctx, simReq); err != nil {
+		s.logPayment(ctx, order.ID, order.OrderNo, "simulate", order.Provider, simReq, nil, "failed", err.Error())
+		return nil, err
+	}
+
+	s.logPayment(ctx, order.ID, order.OrderNo, "simulate", order.Provider, simReq, nil, "success", "")
 
-			if err := s.notifyService.AddNotify(ctx, order.ID, order.OrderNo, order.NotifyURL, notifyData); err != nil {
-				logger.Error("failed to add notify task",
-					zap.Uint64("order_id", order.ID),
-					zap.String("order_no", order.OrderNo),
-					zap.Error(err))
-				// 不影响主流程，继续返回
-			} else {
-				logger.Info("notify task added",
-					zap.Uint64("order_id", order.ID),
-					zap.String("order_no", order.OrderNo),
-					zap.String("notify_url", order.NotifyURL))
+	return s.orderRepo.GetByOrderNo(ctx, orderNo)
+}
+
+// GetQRCode 获取待支付订单的二维码内容，用于生成二维码图片
+func (s *Service) GetQRCode(ctx context.Context, orderNo string) (string, error) {
+	order, err := s.orderRepo.GetByOrderNo(ctx, orderNo)
+	if err != nil {
+		return "", err
+	}
+
+	if order.QRCode == "" {
+		return "", apperrors.New(apperrors.ErrNotFound, "order has no qr code")
+	}
+
+	if order.Status != entity.OrderStatusPending && order.Status != entity.OrderStatusProcessing {
+		return "", apperrors.New(apperrors.ErrOrderStatus, fmt.Sprintf("order in status %s cannot be paid", order.Status))
+	}
+
+	return order.QRCode, nil
+}
+
+// capturePayment 对买家已授权的订单发起扣款并记录扣款ID
+// 订单状态仍以第三方的扣款完成通知为准
+func (s *Service) capturePayment(ctx context.Context, prov payment.Provider, order *entity.PaymentOrder, config map[string]interface{}) error {
+	capturer, ok := prov.(payment.Capturer)
+	if !ok {
+		return nil
+	}
+
+	lockKey := fmt.Sprintf("payment:capture:%s", order.OrderNo)
+	return lock.WithLock(ctx, cache.Client, lockKey, 30*time.Second, func() error {
+		// 重新加载订单，避免通知和同步跳转并发时重复扣款
+		current, err := s.orderRepo.GetByID(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		*order = *current
+
+		if order.CaptureID != "" || order.AuthorizationID != "" {
+			return nil
+		}
+
+		// 预授权订单只完成授权，由商户后续调用扣款接口
+		captureReq := &payment.CapturePaymentRequest{
+			OutTradeNo:    order.OutTradeNo,
+			TradeNo:       order.TradeNo,
+			AuthorizeOnly: order.PreAuth,
+			Config:        config,
+		}
+
+		captureResp, err := capturer.CapturePayment(ctx, captureReq)
+		if err != nil {
+			s.logPayment(ctx, order.ID, order.OrderNo, "capture", order.Provider, captureReq, nil, "failed", err.Error())
+			return err
+		}
+
+		s.logPayment(ctx, order.ID, order.OrderNo, "capture", order.Provider, captureReq, captureResp, "success", "")
+
+		order.CaptureID = captureResp.CaptureID
+		order.AuthorizationID = captureResp.AuthorizationID
+		if captureResp.TradeNo != "" {
+			order.TradeNo = captureResp.TradeNo
+		}
+		if order.PreAuth && captureResp.Status == payment.StatusAuthorized {
+			return s.updateOrderStatus(ctx, order, entity.OrderStatusAuthorized)
+		}
+		if order.Status == entity.OrderStatusPending {
+			order.Status = entity.OrderStatusProcessing
+		}
+
+		return s.orderRepo.Update(ctx, order)
+	})
+}
+
+// Capture 对已授权的预授权订单扣款，amount 为0时全额扣款
+func (s *Service) Capture(ctx context.Context, userID uint64, orderNo string, amount float64) (*entity.PaymentOrder, error) {
+	order, authorizer, err := s.getPreAuthOrder(ctx, userID, orderNo)
+	if err != nil {
+		return nil, err
+	}
+
+	if amount < 0 || amount > order.Amount {
+		return nil, apperrors.New(apperrors.ErrAmountInvalid, "capture amount must not exceed authorized amount")
+	}
+
+	config, err := s.configRepo.GetByID(ctx, order.ConfigID)
+	if err != nil {
+		return nil, err
+	}
+
+	lockKey := fmt.Sprintf("payment:capture:%s", order.OrderNo)
+	err = lock.WithLock(ctx, cache.Client, lockKey, 30*time.Second, func() error {
+		current, err := s.orderRepo.GetByID(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		*order = *current
+
+		if order.Status != entity.OrderStatusAuthorized {
+			return apperrors.New(apperrors.ErrOrderStatus, fmt.Sprintf("order in status %s cannot be captured", order.Status))
+		}
+
+		captureReq := &payment.CaptureRequest{
+			OutTradeNo:      order.OutTradeNo,
+			TradeNo:         order.TradeNo,
+			AuthorizationID: order.AuthorizationID,
+			Subject:         order.Subject,
+			Amount:          amount,
+			TotalAmount:     order.Amount,
+			Currency:        order.Currency,
+			Config:          config.ConfigData,
+		}
+
+		captureResp, err := authorizer.Capture(ctx, captureReq)
+		if err != nil {
+			s.logPayment(ctx, order.ID, order.OrderNo, "capture", order.Provider, captureReq, nil, "failed", err.Error())
+			return err
+		}
+
+		s.logPayment(ctx, order.ID, order.OrderNo, "capture", order.Provider, captureReq, captureResp, "success", "")
+
+		if captureResp.CaptureID != "" {
+			order.CaptureID = captureResp.CaptureID
+		}
+		if captureResp.TradeNo != "" {
+			order.TradeNo = captureResp.TradeNo
+		}
+		order.CaptureAmount = captureResp.Amount
+		if order.CaptureAmount == 0 {
+			order.CaptureAmount = order.Amount
+			if amount > 0 {
+				order.CaptureAmount = amount
 			}
 		}
+
+		// 扣款处理中时保持已授权状态，以第三方通知为准
+		if captureResp.Status == payment.StatusSuccess {
+			return s.updateOrderStatus(ctx, order, entity.OrderStatusCaptured)
+		}
+
+		return s.orderRepo.Update(ctx, order)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return notifyResp.ReturnData, nil
+	return order, nil
 }
 
-// GetConfigByID 根据配置ID获取支付配置
-func (s *Service) GetConfigByID(ctx context.Context, configID uint64) (map[string]interface{}, error) {
-	config, err := s.configRepo.GetByID(ctx, configID)
+// Void 撤销预授权订单，释放冻结资金
+func (s *Service) Void(ctx context.Context, userID uint64, orderNo string) (*entity.PaymentOrder, error) {
+	order, authorizer, err := s.getPreAuthOrder(ctx, userID, orderNo)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := s.configRepo.GetByID(ctx, order.ConfigID)
 	if err != nil {
 		return nil, err
 	}
 
-	return config.ConfigData, nil
+	lockKey := fmt.Sprintf("payment:capture:%s", order.OrderNo)
+	err = lock.WithLock(ctx, cache.Client, lockKey, 30*time.Second, func() error {
+		current, err := s.orderRepo.GetByID(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		*order = *current
+
+		if order.Status != entity.OrderStatusAuthorized {
+			return apperrors.New(apperrors.ErrOrderStatus, fmt.Sprintf("order in status %s cannot be voided", order.Status))
+		}
+
+		voidReq := &payment.VoidRequest{
+			OutTradeNo:      order.OutTradeNo,
+			TradeNo:         order.TradeNo,
+			AuthorizationID: order.AuthorizationID,
+			Amount:          order.Amount,
+			Currency:        order.Currency,
+			Config:          config.ConfigData,
+		}
+
+		if err := authorizer.Void(ctx, voidReq); err != nil {
+			s.logPayment(ctx, order.ID, order.OrderNo, "void", order.Provider, voidReq, nil, "failed", err.Error())
+			return err
+		}
+
+		s.logPayment(ctx, order.ID, order.OrderNo, "void", order.Provider, voidReq, nil, "success", "")
+
+		return s.updateOrderStatus(ctx, order, entity.OrderStatusVoided)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// getPreAuthOrder 获取用户的预授权订单及对应的提供商
+func (s *Service) getPreAuthOrder(ctx context.Context, userID uint64, orderNo string) (*entity.PaymentOrder, payment.Authorizer, error) {
+	order, err := s.orderRepo.GetByOrderNo(ctx, orderNo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 验证订单归属（数据隔离）
+	if order.UserID != userID {
+		return nil, nil, apperrors.New(apperrors.ErrOrderNotFound, "order not found")
+	}
+
+	if !order.PreAuth {
+		return nil, nil, apperrors.New(apperrors.ErrOrderStatus, "order is not a pre-authorization")
+	}
+
+	prov, err := payment.GetProvider(order.Provider)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	authorizer, ok := prov.(payment.Authorizer)
+	if !ok {
+		return nil, nil, apperrors.New(apperrors.ErrNotSupported, fmt.Sprintf("provider %s does not support authorization", order.Provider))
+	}
+
+	return order, authorizer, nil
+}
+
+// updateOrderStatus 更新订单状态，状态变更时通知商户
+func (s *Service) updateOrderStatus(ctx context.Context, order *entity.PaymentOrder, status string) error {
+	oldStatus := order.Status
+	order.Status = status
+	if status == entity.OrderStatusSuccess || status == entity.OrderStatusCaptured {
+		now := time.Now()
+		order.PaymentTime = &now
+	}
+
+	if err := s.orderRepo.Update(ctx, order); err != nil {
+		logger.Error("failed to update order", zap.Error(err))
+		return err
+	}
+
+	if status != oldStatus {
+		s.publishOrderStatus(ctx, order)
+		s.notifyMerchant(ctx, order)
+		if status == entity.OrderStatusSuccess || status == entity.OrderStatusCaptured {
+			s.autoShareProfit(ctx, order)
+			s.recordPaymentLinkOrder(ctx, order)
+		}
+	}
+
+	return nil
+}
+
+// notifyMerchant 订单变为成功或预授权相关状态，且有通知URL时，添加通知任务
+func (s *Service) notifyMerchant(ctx context.Context, order *entity.PaymentOrder) {
+	switch order.Status {
+	case entity.OrderStatusSuccess, entity.OrderStatusAuthorized, entity.OrderStatusCaptured, entity.OrderStatusVoided:
+	default:
+		return
+	}
+
+	if order.NotifyURL == "" {
+		return
+	}
+
+	notifyData := map[string]interface{}{
+		"order_no":     order.OrderNo,
+		"out_trade_no": order.OutTradeNo,
+		"trade_no":     order.TradeNo,
+		"amount":       order.Amount,
+		"currency":     order.Currency,
+		"status":       order.Status,
+		"payment_time": order.PaymentTime,
+		"subject":      order.Subject,
+	}
+	if order.PreAuth {
+		notifyData["authorization_id"] = order.AuthorizationID
+		notifyData["capture_amount"] = order.CaptureAmount
+	}
+
+	if err := s.notifyService.AddNotify(ctx, order.ID, order.OrderNo, order.NotifyURL, notifyData); err != nil {
+		logger.Error("failed to add notify task",
+			zap.Uint64("order_id", order.ID),
+			zap.String("order_no", order.OrderNo),
+			zap.Error(err))
+		// 不影响主流程，继续返回
+	} else {
+		logger.Info("notify task added",
+			zap.Uint64("order_id", order.ID),
+			zap.String("order_no", order.OrderNo),
+			zap.String("notify_url", order.NotifyURL))
+	}
+}
+
+// handleDispute 保存争议记录并通知商户
+func (s *Service) handleDispute(ctx context.Context, provider string, order *entity.PaymentOrder, info *payment.DisputeInfo) error {
+	event := "dispute.updated"
+	dispute, err := s.disputeRepo.GetByProviderDisputeID(ctx, provider, info.DisputeID)
+	if err != nil {
+		if appErr, ok := err.(*apperrors.AppError); !ok || appErr.Code != apperrors.ErrNotFound {
+			return err
+		}
+		event = "dispute.created"
+		dispute = &entity.Dispute{
+			OrderID:   order.ID,
+			OrderNo:   order.OrderNo,
+			UserID:    order.UserID,
+			Provider:  provider,
+			DisputeID: info.DisputeID,
+		}
+	}
+
+	if dispute.ID > 0 && dispute.Status == info.Status && dispute.Amount == info.Amount {
+		// 重复通知
+		return nil
+	}
+
+	if info.Reason != "" {
+		dispute.Reason = info.Reason
+	}
+	dispute.Amount = info.Amount
+	dispute.Currency = info.Currency
+	if dispute.Currency == "" {
+		dispute.Currency = order.Currency
+	}
+	dispute.Status = info.Status
+	if info.EvidenceDueBy != nil {
+		dispute.EvidenceDueBy = info.EvidenceDueBy
+	}
+
+	if dispute.ID == 0 {
+		err = s.disputeRepo.Create(ctx, dispute)
+	} else {
+		err = s.disputeRepo.Update(ctx, dispute)
+	}
+	if err != nil {
+		logger.Error("failed to save dispute", zap.String("dispute_id", info.DisputeID), zap.Error(err))
+		return err
+	}
+
+	switch dispute.Status {
+	case entity.DisputeStatusWon, entity.DisputeStatusLost, entity.DisputeStatusClosed:
+		if event != "dispute.created" {
+			event = "dispute.closed"
+		}
+	}
+
+	logger.Info("dispute saved",
+		zap.String("order_no", order.OrderNo),
+		zap.String("dispute_id", dispute.DisputeID),
+		zap.String("status", dispute.Status))
+
+	if order.NotifyURL == "" {
+		return nil
+	}
+
+	notifyData := map[string]interface{}{
+		"event":           event,
+		"order_no":        order.OrderNo,
+		"out_trade_no":    order.OutTradeNo,
+		"dispute_id":      dispute.DisputeID,
+		"reason":          dispute.Reason,
+		"amount":          dispute.Amount,
+		"currency":        dispute.Currency,
+		"status":          dispute.Status,
+		"evidence_due_by": dispute.EvidenceDueBy,
+	}
+	if err := s.notifyService.AddNotify(ctx, order.ID, order.OrderNo, order.NotifyURL, notifyData); err != nil {
+		logger.Error("failed to add dispute notify task",
+			zap.Uint64("order_id", order.ID),
+			zap.String("dispute_id", dispute.DisputeID),
+			zap.Error(err))
+	}
+
+	return nil
+}
+
+// isFinalStatus 是否为最终状态
+func isFinalStatus(status string) bool {
+	switch status {
+	case entity.OrderStatusSuccess, entity.OrderStatusClosed, entity.OrderStatusCaptured, entity.OrderStatusVoided:
+		return true
+	}
+	return false
+}
+
+// resolveStatus 将提供商返回的状态转换为订单状态
+// 预授权订单支付成功即已扣款、关闭即已撤销；已授权后不会回退到待支付或失败
+func resolveStatus(order *entity.PaymentOrder, status string) string {
+	if !order.PreAuth || status == "" {
+		return status
+	}
+
+	if order.Status == entity.OrderStatusCaptured || order.Status == entity.OrderStatusVoided {
+		return order.Status
+	}
+
+	switch status {
+	case payment.StatusSuccess, payment.StatusCaptured:
+		return entity.OrderStatusCaptured
+	case payment.StatusClosed, payment.StatusVoided:
+		if order.Status == entity.OrderStatusAuthorized {
+			return entity.OrderStatusVoided
+		}
+		return entity.OrderStatusClosed
+	case payment.StatusPending, payment.StatusFailed:
+		if order.Status == entity.OrderStatusAuthorized {
+			return order.Status
+		}
+	}
+
+	return status
 }
 
 // getConfigWithCache 从缓存或数据库获取支付配置
@@ -415,6 +1310,22 @@ func (s *Service) generateOrderNo() string {
 	return fmt.Sprintf("UNI%d%s", time.Now().UnixNano(), uuid.New().String()[:12])
 }
 
+// notifyURL 生成第三方回调本服务的通知地址，未配置 baseURL 时无法接收通知，返回错误
+func (s *Service) notifyURL(provider string, configID uint64) (string, error) {
+	if s.baseURL == "" {
+		return "", apperrors.New(apperrors.ErrNotSupported, "base url is required for notify url")
+	}
+	return fmt.Sprintf("%s/api/v1/public/notify/%s/%s", s.baseURL, provider, s.notifyToken(provider, configID)), nil
+}
+
+// qrCodeURL 生成订单二维码图片地址，订单没有二维码或未配置 baseURL 时返回空
+func (s *Service) qrCodeURL(order *entity.PaymentOrder) string {
+	if s.baseURL == "" || order.QRCode == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/api/v1/payment/qrcode/%s.png", s.baseURL, order.OrderNo)
+}
+
 // logPayment 记录支付日志
 func (s *Service) logPayment(ctx context.Context, orderID uint64, orderNo, action, provider string, request, response interface{}, status, errorMsg string) {
 	log := &entity.PaymentLog{
@@ -0,0 +1,655 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zqdfound/go-uni-pay/internal/domain/entity"
+	"github.com/zqdfound/go-uni-pay/internal/domain/repository"
+	"github.com/zqdfound/go-uni-pay/internal/infrastructure/cache"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+	"github.com/zqdfound/go-uni-pay/pkg/logger"
+	"go.uber.org/zap"
+)
+
+func init() {
+	// ConfigData 等 JSON 字段中的嵌套结构
+	gob.Register(map[string]interface{}{})
+	gob.Register([]interface{}{})
+}
+
+// memStore 内存存储，按主键保存记录，读写时深拷贝，模拟数据库读取到的是独立副本
+type memStore[T any] struct {
+	mu    sync.Mutex
+	items []*T
+	id    func(*T) *uint64
+}
+
+// clone 深拷贝记录
+func clone[T any](v *T) *T {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		panic(err)
+	}
+	c := new(T)
+	if err := gob.NewDecoder(&buf).Decode(c); err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func (m *memStore[T]) create(v *T) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	*m.id(v) = uint64(len(m.items) + 1)
+	m.items = append(m.items, clone(v))
+}
+
+func (m *memStore[T]) update(v *T) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := *m.id(v)
+	if id == 0 || id > uint64(len(m.items)) {
+		return apperrors.New(apperrors.ErrNotFound, "record not found")
+	}
+	m.items[id-1] = clone(v)
+	return nil
+}
+
+func (m *memStore[T]) find(match func(*T) bool) (*T, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, item := range m.items {
+		if match(item) {
+			return clone(item), true
+		}
+	}
+	return nil, false
+}
+
+func (m *memStore[T]) list(match func(*T) bool) []*T {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*T
+	for _, item := range m.items {
+		if match(item) {
+			result = append(result, clone(item))
+		}
+	}
+	return result
+}
+
+// get 查找记录，不存在时返回与 MySQL 仓储相同的错误码
+func (m *memStore[T]) get(match func(*T) bool, code apperrors.ErrorCode, message string) (*T, error) {
+	if v, ok := m.find(match); ok {
+		return v, nil
+	}
+	return nil, apperrors.New(code, message)
+}
+
+type memUserRepo struct {
+	repository.UserRepository
+	memStore[entity.User]
+}
+
+func (r *memUserRepo) GetByID(ctx context.Context, id uint64) (*entity.User, error) {
+	return r.get(func(u *entity.User) bool { return u.ID == id }, apperrors.ErrNotFound, "user not found")
+}
+
+func (r *memUserRepo) Update(ctx context.Context, user *entity.User) error {
+	return r.update(user)
+}
+
+type memConfigRepo struct {
+	repository.PaymentConfigRepository
+	memStore[entity.PaymentConfig]
+}
+
+func (r *memConfigRepo) GetByID(ctx context.Context, id uint64) (*entity.PaymentConfig, error) {
+	return r.get(func(c *entity.PaymentConfig) bool { return c.ID == id }, apperrors.ErrNotFound, "config not found")
+}
+
+func (r *memConfigRepo) GetActiveByUserAndProvider(ctx context.Context, userID uint64, provider string) (*entity.PaymentConfig, error) {
+	return r.get(func(c *entity.PaymentConfig) bool {
+		return c.UserID == userID && c.Provider == provider && c.Status == 1
+	}, apperrors.ErrConfigNotFound, "active config not found")
+}
+
+func (r *memConfigRepo) GetActiveByProvider(ctx context.Context, provider string) ([]*entity.PaymentConfig, error) {
+	return r.list(func(c *entity.PaymentConfig) bool { return c.Provider == provider && c.Status == 1 }), nil
+}
+
+func (r *memConfigRepo) GetActiveByProviderAccount(ctx context.Context, provider, accountID string) ([]*entity.PaymentConfig, error) {
+	return r.list(func(c *entity.PaymentConfig) bool {
+		return c.Provider == provider && c.AccountID == accountID && c.Status == 1
+	}), nil
+}
+
+func (r *memConfigRepo) Update(ctx context.Context, config *entity.PaymentConfig) error {
+	return r.update(config)
+}
+
+type memOrderRepo struct {
+	repository.PaymentOrderRepository
+	memStore[entity.PaymentOrder]
+}
+
+// Create 与 MySQL 唯一索引一致，同一商户的 out_trade_no 不能重复
+func (r *memOrderRepo) Create(ctx context.Context, order *entity.PaymentOrder) error {
+	if _, err := r.GetByUserAndOutTradeNo(ctx, order.UserID, order.OutTradeNo); err == nil {
+		return apperrors.New(apperrors.ErrDatabaseInsert, "failed to create order")
+	}
+	r.create(order)
+	return nil
+}
+
+func (r *memOrderRepo) GetByID(ctx context.Context, id uint64) (*entity.PaymentOrder, error) {
+	return r.get(func(o *entity.PaymentOrder) bool { return o.ID == id }, apperrors.ErrOrderNotFound, "order not found")
+}
+
+func (r *memOrderRepo) GetByOrderNo(ctx context.Context, orderNo string) (*entity.PaymentOrder, error) {
+	return r.get(func(o *entity.PaymentOrder) bool { return o.OrderNo == orderNo }, apperrors.ErrOrderNotFound, "order not found")
+}
+
+func (r *memOrderRepo) GetByOutTradeNo(ctx context.Context, outTradeNo string) (*entity.PaymentOrder, error) {
+	return r.get(func(o *entity.PaymentOrder) bool { return o.OutTradeNo == outTradeNo }, apperrors.ErrOrderNotFound, "order not found")
+}
+
+func (r *memOrderRepo) GetByUserAndOutTradeNo(ctx context.Context, userID uint64, outTradeNo string) (*entity.PaymentOrder, error) {
+	return r.get(func(o *entity.PaymentOrder) bool {
+		return o.UserID == userID && o.OutTradeNo == outTradeNo
+	}, apperrors.ErrOrderNotFound, "order not found")
+}
+
+func (r *memOrderRepo) Update(ctx context.Context, order *entity.PaymentOrder) error {
+	return r.update(order)
+}
+
+type memPayoutRepo struct {
+	repository.PayoutRepository
+	memStore[entity.Payout]
+}
+
+func (r *memPayoutRepo) Create(ctx context.Context, payout *entity.Payout) error {
+	r.create(payout)
+	return nil
+}
+
+func (r *memPayoutRepo) GetByPayoutNo(ctx context.Context, payoutNo string) (*entity.Payout, error) {
+	return r.get(func(p *entity.Payout) bool { return p.PayoutNo == payoutNo }, apperrors.ErrNotFound, "payout not found")
+}
+
+func (r *memPayoutRepo) GetByUserAndOutPayoutNo(ctx context.Context, userID uint64, outPayoutNo string) (*entity.Payout, error) {
+	return r.get(func(p *entity.Payout) bool {
+		return p.UserID == userID && p.OutPayoutNo == outPayoutNo
+	}, apperrors.ErrNotFound, "payout not found")
+}
+
+func (r *memPayoutRepo) GetByProviderPayoutID(ctx context.Context, provider, payoutID string) (*entity.Payout, error) {
+	return r.get(func(p *entity.Payout) bool {
+		return p.Provider == provider && p.PayoutID == payoutID
+	}, apperrors.ErrNotFound, "payout not found")
+}
+
+func (r *memPayoutRepo) Update(ctx context.Context, payout *entity.Payout) error {
+	return r.update(payout)
+}
+
+type memProfitSharingRepo struct {
+	repository.ProfitSharingRepository
+	memStore[entity.ProfitSharing]
+}
+
+func (r *memProfitSharingRepo) Create(ctx context.Context, sharing *entity.ProfitSharing) error {
+	r.create(sharing)
+	return nil
+}
+
+func (r *memProfitSharingRepo) GetBySharingNo(ctx context.Context, sharingNo string) (*entity.ProfitSharing, error) {
+	return r.get(func(p *entity.ProfitSharing) bool { return p.SharingNo == sharingNo }, apperrors.ErrNotFound, "profit sharing not found")
+}
+
+func (r *memProfitSharingRepo) GetByUserAndOutSharingNo(ctx context.Context, userID uint64, outSharingNo string) (*entity.ProfitSharing, error) {
+	return r.get(func(p *entity.ProfitSharing) bool {
+		return p.UserID == userID && p.OutSharingNo == outSharingNo
+	}, apperrors.ErrNotFound, "profit sharing not found")
+}
+
+func (r *memProfitSharingRepo) Update(ctx context.Context, sharing *entity.ProfitSharing) error {
+	return r.update(sharing)
+}
+
+func (r *memProfitSharingRepo) ListByOrder(ctx context.Context, orderID uint64) ([]*entity.ProfitSharing, error) {
+	return r.list(func(p *entity.ProfitSharing) bool { return p.OrderID == orderID }), nil
+}
+
+type memPlanRepo struct {
+	repository.PlanRepository
+	memStore[entity.Plan]
+}
+
+func (r *memPlanRepo) Create(ctx context.Context, plan *entity.Plan) error {
+	r.create(plan)
+	return nil
+}
+
+func (r *memPlanRepo) GetByPlanNo(ctx context.Context, planNo string) (*entity.Plan, error) {
+	return r.get(func(p *entity.Plan) bool { return p.PlanNo == planNo }, apperrors.ErrNotFound, "plan not found")
+}
+
+func (r *memPlanRepo) GetByUserAndOutPlanNo(ctx context.Context, userID uint64, outPlanNo string) (*entity.Plan, error) {
+	return r.get(func(p *entity.Plan) bool {
+		return p.UserID == userID && p.OutPlanNo == outPlanNo
+	}, apperrors.ErrNotFound, "plan not found")
+}
+
+func (r *memPlanRepo) Update(ctx context.Context, plan *entity.Plan) error {
+	return r.update(plan)
+}
+
+type memSubscriptionRepo struct {
+	repository.SubscriptionRepository
+	memStore[entity.Subscription]
+}
+
+func (r *memSubscriptionRepo) Create(ctx context.Context, sub *entity.Subscription) error {
+	r.create(sub)
+	return nil
+}
+
+func (r *memSubscriptionRepo) GetBySubscriptionNo(ctx context.Context, subscriptionNo string) (*entity.Subscription, error) {
+	return r.get(func(s *entity.Subscription) bool { return s.SubscriptionNo == subscriptionNo }, apperrors.ErrNotFound, "subscription not found")
+}
+
+func (r *memSubscriptionRepo) GetByUserAndOutSubscriptionNo(ctx context.Context, userID uint64, outSubscriptionNo string) (*entity.Subscription, error) {
+	return r.get(func(s *entity.Subscription) bool {
+		return s.UserID == userID && s.OutSubscriptionNo == outSubscriptionNo
+	}, apperrors.ErrNotFound, "subscription not found")
+}
+
+func (r *memSubscriptionRepo) GetByProviderSubscriptionID(ctx context.Context, provider, subscriptionID string) (*entity.Subscription, error) {
+	return r.get(func(s *entity.Subscription) bool {
+		return s.Provider == provider && s.SubscriptionID == subscriptionID
+	}, apperrors.ErrNotFound, "subscription not found")
+}
+
+func (r *memSubscriptionRepo) Update(ctx context.Context, sub *entity.Subscription) error {
+	return r.update(sub)
+}
+
+type memCustomerRepo struct {
+	repository.CustomerRepository
+	memStore[entity.Customer]
+}
+
+func (r *memCustomerRepo) GetByCustomerNo(ctx context.Context, customerNo string) (*entity.Customer, error) {
+	return r.get(func(c *entity.Customer) bool { return c.CustomerNo == customerNo }, apperrors.ErrNotFound, "customer not found")
+}
+
+type memPaymentMethodRepo struct {
+	repository.PaymentMethodRepository
+	memStore[entity.PaymentMethod]
+}
+
+func (r *memPaymentMethodRepo) GetByMethodNo(ctx context.Context, methodNo string) (*entity.PaymentMethod, error) {
+	return r.get(func(m *entity.PaymentMethod) bool { return m.MethodNo == methodNo }, apperrors.ErrNotFound, "payment method not found")
+}
+
+func (r *memPaymentMethodRepo) Update(ctx context.Context, method *entity.PaymentMethod) error {
+	return r.update(method)
+}
+
+type memPaymentLinkRepo struct {
+	repository.PaymentLinkRepository
+	memStore[entity.PaymentLink]
+}
+
+func (r *memPaymentLinkRepo) GetByLinkNo(ctx context.Context, linkNo string) (*entity.PaymentLink, error) {
+	return r.get(func(l *entity.PaymentLink) bool { return l.LinkNo == linkNo }, apperrors.ErrNotFound, "payment link not found")
+}
+
+type memCheckoutRepo struct {
+	repository.CheckoutRepository
+	memStore[entity.Checkout]
+}
+
+func (r *memCheckoutRepo) GetByCheckoutNo(ctx context.Context, checkoutNo string) (*entity.Checkout, error) {
+	return r.get(func(c *entity.Checkout) bool { return c.CheckoutNo == checkoutNo }, apperrors.ErrNotFound, "checkout not found")
+}
+
+func (r *memCheckoutRepo) Update(ctx context.Context, checkout *entity.Checkout) error {
+	return r.update(checkout)
+}
+
+type memDisputeRepo struct {
+	repository.DisputeRepository
+	memStore[entity.Dispute]
+}
+
+func (r *memDisputeRepo) Create(ctx context.Context, dispute *entity.Dispute) error {
+	r.create(dispute)
+	return nil
+}
+
+func (r *memDisputeRepo) GetByProviderDisputeID(ctx context.Context, provider, disputeID string) (*entity.Dispute, error) {
+	return r.get(func(d *entity.Dispute) bool {
+		return d.Provider == provider && d.DisputeID == disputeID
+	}, apperrors.ErrNotFound, "dispute not found")
+}
+
+func (r *memDisputeRepo) Update(ctx context.Context, dispute *entity.Dispute) error {
+	return r.update(dispute)
+}
+
+type memLogRepo struct {
+	repository.PaymentLogRepository
+}
+
+func (r *memLogRepo) Create(ctx context.Context, log *entity.PaymentLog) error {
+	return nil
+}
+
+// recordingNotifier 记录添加的商户通知
+type recordingNotifier struct {
+	mu       sync.Mutex
+	notifies []map[string]interface{}
+}
+
+func (n *recordingNotifier) AddNotify(ctx context.Context, orderID uint64, orderNo, notifyURL string, notifyData map[string]interface{}) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.notifies = append(n.notifies, notifyData)
+	return nil
+}
+
+// events 返回通知中的事件，订单通知没有事件时返回订单状态
+func (n *recordingNotifier) events() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	events := make([]string, 0, len(n.notifies))
+	for _, data := range n.notifies {
+		if event, ok := data["event"].(string); ok {
+			events = append(events, event)
+		} else {
+			events = append(events, data["status"].(string))
+		}
+	}
+	return events
+}
+
+// testEnv 使用内存仓储和 miniredis 的支付服务
+type testEnv struct {
+	svc       *Service
+	redis     *miniredis.Miniredis
+	users     *memUserRepo
+	configs   *memConfigRepo
+	orders    *memOrderRepo
+	payouts   *memPayoutRepo
+	sharings  *memProfitSharingRepo
+	plans     *memPlanRepo
+	subs      *memSubscriptionRepo
+	customer  *memCustomerRepo
+	methods   *memPaymentMethodRepo
+	checkouts *memCheckoutRepo
+	links     *memPaymentLinkRepo
+	disputes  *memDisputeRepo
+	notifier  *recordingNotifier
+}
+
+// testUserID 测试商户ID
+const testUserID = 1
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	logger.Log = zap.NewNop()
+
+	mr := miniredis.RunT(t)
+	cache.Client = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { cache.Client.Close() })
+
+	env := &testEnv{
+		redis:     mr,
+		users:     &memUserRepo{memStore: memStore[entity.User]{id: func(u *entity.User) *uint64 { return &u.ID }}},
+		configs:   &memConfigRepo{memStore: memStore[entity.PaymentConfig]{id: func(c *entity.PaymentConfig) *uint64 { return &c.ID }}},
+		orders:    &memOrderRepo{memStore: memStore[entity.PaymentOrder]{id: func(o *entity.PaymentOrder) *uint64 { return &o.ID }}},
+		payouts:   &memPayoutRepo{memStore: memStore[entity.Payout]{id: func(p *entity.Payout) *uint64 { return &p.ID }}},
+		sharings:  &memProfitSharingRepo{memStore: memStore[entity.ProfitSharing]{id: func(p *entity.ProfitSharing) *uint64 { return &p.ID }}},
+		plans:     &memPlanRepo{memStore: memStore[entity.Plan]{id: func(p *entity.Plan) *uint64 { return &p.ID }}},
+		subs:      &memSubscriptionRepo{memStore: memStore[entity.Subscription]{id: func(s *entity.Subscription) *uint64 { return &s.ID }}},
+		customer:  &memCustomerRepo{memStore: memStore[entity.Customer]{id: func(c *entity.Customer) *uint64 { return &c.ID }}},
+		methods:   &memPaymentMethodRepo{memStore: memStore[entity.PaymentMethod]{id: func(m *entity.PaymentMethod) *uint64 { return &m.ID }}},
+		checkouts: &memCheckoutRepo{memStore: memStore[entity.Checkout]{id: func(c *entity.Checkout) *uint64 { return &c.ID }}},
+		links:     &memPaymentLinkRepo{memStore: memStore[entity.PaymentLink]{id: func(l *entity.PaymentLink) *uint64 { return &l.ID }}},
+		disputes:  &memDisputeRepo{memStore: memStore[entity.Dispute]{id: func(d *entity.Dispute) *uint64 { return &d.ID }}},
+		notifier:  &recordingNotifier{},
+	}
+	env.svc = NewService(env.orders, env.configs, &memLogRepo{}, nil, env.payouts, env.sharings, nil, env.plans, env.subs, env.customer, env.methods, env.checkouts, env.links, env.disputes, env.users, env.notifier, "https://pay.example.com")
+	require.NoError(t, env.svc.SetNotifySecret("test-notify-secret", false))
+
+	env.users.create(&entity.User{Username: "merchant", SignKey: "test-sign-key", Status: 1})
+
+	return env
+}
+
+// addConfig 为测试商户添加启用的支付配置
+func (e *testEnv) addConfig(provider string, data entity.ConfigData) *entity.PaymentConfig {
+	config := &entity.PaymentConfig{UserID: testUserID, Provider: provider, ConfigName: provider, ConfigData: data, Status: 1}
+	e.configs.create(config)
+	return config
+}
+
+// addOrder 为测试商户添加订单
+func (e *testEnv) addOrder(config *entity.PaymentConfig, order *entity.PaymentOrder) *entity.PaymentOrder {
+	order.UserID = testUserID
+	order.Provider = config.Provider
+	order.ConfigID = config.ID
+	if order.OrderNo == "" {
+		order.OrderNo = e.svc.generateOrderNo()
+	}
+	if order.Currency == "" {
+		order.Currency = "USD"
+	}
+	e.orders.create(order)
+	return order
+}
+
+// order 读取订单的最新状态
+func (e *testEnv) order(t *testing.T, orderNo string) *entity.PaymentOrder {
+	t.Helper()
+	order, err := e.orders.GetByOrderNo(context.Background(), orderNo)
+	require.NoError(t, err)
+	return order
+}
+
+// mockProvider 模拟支付提供商，实现基础能力和主动查询，其他能力由嵌入它的类型实现
+type mockProvider struct {
+	mock.Mock
+	name string
+}
+
+// newMockProvider 创建以测试名称命名的提供商，避免不同测试注册的提供商互相覆盖
+func newMockProvider(t *testing.T) *mockProvider {
+	return &mockProvider{name: strings.ToLower(strings.NewReplacer("/", "_", " ", "_").Replace(t.Name()))}
+}
+
+func (p *mockProvider) GetName() string {
+	return p.name
+}
+
+func (p *mockProvider) CreatePayment(ctx context.Context, req *payment.CreatePaymentRequest) (*payment.CreatePaymentResponse, error) {
+	args := p.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*payment.CreatePaymentResponse), args.Error(1)
+}
+
+func (p *mockProvider) HandleNotify(ctx context.Context, req *payment.NotifyRequest) (*payment.NotifyResponse, error) {
+	args := p.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*payment.NotifyResponse), args.Error(1)
+}
+
+func (p *mockProvider) QueryPayment(ctx context.Context, req *payment.QueryPaymentRequest) (*payment.QueryPaymentResponse, error) {
+	args := p.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*payment.QueryPaymentResponse), args.Error(1)
+}
+
+// mockCapturer 买家授权后由本服务扣款的提供商（如 PayPal）
+type mockCapturer struct {
+	*mockProvider
+}
+
+func (p *mockCapturer) CapturePayment(ctx context.Context, req *payment.CapturePaymentRequest) (*payment.CapturePaymentResponse, error) {
+	args := p.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*payment.CapturePaymentResponse), args.Error(1)
+}
+
+// TestCreatePayment_Duplicate 测试重复创建时返回保存的支付信息，不再请求提供商
+func TestCreatePayment_Duplicate(t *testing.T) {
+	env := newTestEnv(t)
+	prov := newMockProvider(t)
+	payment.Register(prov)
+	env.addConfig(prov.name, entity.ConfigData{})
+
+	expireTime := time.Now().Add(time.Hour).Truncate(time.Second)
+	prov.On("CreatePayment", mock.Anything, mock.Anything).Return(&payment.CreatePaymentResponse{
+		PaymentURL: "https://provider.example.com/pay/1",
+		PaymentID:  "PAY1",
+		TradeNo:    "TRADE1",
+		ExpireTime: &expireTime,
+	}, nil).Once()
+
+	req := &CreatePaymentRequest{
+		UserID:     testUserID,
+		Provider:   prov.name,
+		OutTradeNo: "ORDER1",
+		Subject:    "Order",
+		Amount:     10,
+	}
+	first, err := env.svc.CreatePayment(context.Background(), req)
+	require.NoError(t, err)
+
+	again, err := env.svc.CreatePayment(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, first.OrderNo, again.OrderNo)
+	assert.Equal(t, entity.OrderStatusProcessing, again.Status)
+	assert.Equal(t, "https://provider.example.com/pay/1", again.PaymentURL)
+	assert.Equal(t, "PAY1", again.PaymentID)
+	assert.True(t, expireTime.Equal(*again.ExpireTime))
+	prov.AssertExpectations(t)
+	prov.AssertNotCalled(t, "QueryPayment", mock.Anything, mock.Anything)
+}
+
+// TestCreatePayment_ExpiredPaymentData 测试支付信息过期且订单仍未支付时，向提供商重新获取
+func TestCreatePayment_ExpiredPaymentData(t *testing.T) {
+	env := newTestEnv(t)
+	prov := newMockProvider(t)
+	payment.Register(prov)
+
+	config := env.addConfig(prov.name, entity.ConfigData{})
+	expired := time.Now().Add(-time.Minute)
+	order := env.addOrder(config, &entity.PaymentOrder{
+		OutTradeNo:  "ORDER1",
+		Subject:     "Order",
+		Amount:      10,
+		TradeNo:     "TRADE1",
+		Status:      entity.OrderStatusProcessing,
+		PaymentData: &entity.PaymentData{PaymentURL: "https://provider.example.com/pay/1", ExpireTime: &expired},
+	})
+
+	expireTime := time.Now().Add(3 * time.Hour).Truncate(time.Second)
+	prov.On("QueryPayment", mock.Anything, mock.Anything).
+		Return(&payment.QueryPaymentResponse{TradeNo: "TRADE1", Status: payment.StatusPending}, nil).Once()
+	prov.On("CreatePayment", mock.Anything, mock.MatchedBy(func(req *payment.CreatePaymentRequest) bool {
+		return req.OrderNo == order.OrderNo && req.NotifyURL != "" && req.BaseURL == "https://pay.example.com"
+	})).Return(&payment.CreatePaymentResponse{
+		PaymentURL: "https://provider.example.com/pay/2",
+		TradeNo:    "TRADE2",
+		ExpireTime: &expireTime,
+	}, nil).Once()
+
+	resp, err := env.svc.CreatePayment(context.Background(), &CreatePaymentRequest{
+		UserID:     testUserID,
+		Provider:   prov.name,
+		OutTradeNo: "ORDER1",
+		Amount:     10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, order.OrderNo, resp.OrderNo)
+	assert.Equal(t, "https://provider.example.com/pay/2", resp.PaymentURL)
+
+	saved := env.order(t, order.OrderNo)
+	assert.Equal(t, "TRADE2", saved.TradeNo)
+	assert.Equal(t, "https://provider.example.com/pay/2", saved.PaymentData.PaymentURL)
+	assert.True(t, expireTime.Equal(*saved.PaymentData.ExpireTime))
+	prov.AssertExpectations(t)
+}
+
+// TestCreatePayment_FinalStatusNotRequested 测试订单已是最终状态，或查询发现已支付时，不再向提供商获取支付信息
+func TestCreatePayment_FinalStatusNotRequested(t *testing.T) {
+	expired := time.Now().Add(-time.Minute)
+	tests := []struct {
+		name        string
+		status      string
+		queryStatus string
+		wantStatus  string
+	}{
+		{"already paid", entity.OrderStatusSuccess, "", entity.OrderStatusSuccess},
+		{"already closed", entity.OrderStatusClosed, "", entity.OrderStatusClosed},
+		{"paid before retry", entity.OrderStatusProcessing, payment.StatusSuccess, entity.OrderStatusSuccess},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			prov := newMockProvider(t)
+			payment.Register(prov)
+			if tt.queryStatus != "" {
+				prov.On("QueryPayment", mock.Anything, mock.Anything).
+					Return(&payment.QueryPaymentResponse{TradeNo: "TRADE1", Status: tt.queryStatus}, nil).Once()
+			}
+
+			config := env.addConfig(prov.name, entity.ConfigData{})
+			order := env.addOrder(config, &entity.PaymentOrder{
+				OutTradeNo:  "ORDER1",
+				Amount:      10,
+				TradeNo:     "TRADE1",
+				Status:      tt.status,
+				PaymentData: &entity.PaymentData{PaymentURL: "https://provider.example.com/pay/1", ExpireTime: &expired},
+			})
+
+			resp, err := env.svc.CreatePayment(context.Background(), &CreatePaymentRequest{
+				UserID:     testUserID,
+				Provider:   prov.name,
+				OutTradeNo: "ORDER1",
+				Amount:     10,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, order.OrderNo, resp.OrderNo)
+			assert.Equal(t, tt.wantStatus, resp.Status)
+			prov.AssertExpectations(t)
+			prov.AssertNotCalled(t, "CreatePayment", mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,901 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zqdfound/go-uni-pay/internal/domain/entity"
+	"github.com/zqdfound/go-uni-pay/internal/infrastructure/cache"
+	"github.com/zqdfound/go-uni-pay/internal/infrastructure/lock"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+	"github.com/zqdfound/go-uni-pay/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// subscriptionPendingCheck 等待买家签约期间主动查询的间隔
+const subscriptionPendingCheck = time.Minute
+
+// subscriptionPendingExpire 买家超过该时间未签约，订阅自动取消
+const subscriptionPendingExpire = 24 * time.Hour
+
+// subscriptionRecheck 提供商自行扣款时，账单日后未收到续费结果的重新查询间隔
+const subscriptionRecheck = time.Hour
+
+// subscriptionSyncBatch 每次处理的到期订阅数量
+const subscriptionSyncBatch = 100
+
+// defaultDunningIntervals 本服务发起扣款时，续费失败后的默认重试间隔（天），重试用尽后取消订阅
+var defaultDunningIntervals = []time.Duration{24 * time.Hour, 72 * time.Hour, 120 * time.Hour}
+
+// CreatePlanRequest 创建订阅计划请求
+type CreatePlanRequest struct {
+	UserID        uint64
+	OutPlanNo     string
+	Name          string
+	Description   string
+	Amount        float64
+	Currency      string
+	Interval      string
+	IntervalCount int
+	TrialDays     int
+}
+
+// CreatePlan 创建订阅计划，同一商户计划号重复请求返回已有计划
+func (s *Service) CreatePlan(ctx context.Context, req *CreatePlanRequest) (*entity.Plan, error) {
+	if req.Amount <= 0 {
+		return nil, apperrors.New(apperrors.ErrAmountInvalid, "plan amount must be positive")
+	}
+	switch req.Interval {
+	case payment.IntervalDay, payment.IntervalWeek, payment.IntervalMonth, payment.IntervalYear:
+	default:
+		return nil, apperrors.New(apperrors.ErrInvalidParam, fmt.Sprintf("unsupported interval: %s", req.Interval))
+	}
+	if req.IntervalCount <= 0 {
+		req.IntervalCount = 1
+	}
+	if req.TrialDays < 0 {
+		return nil, apperrors.New(apperrors.ErrInvalidParam, "trial_days must not be negative")
+	}
+
+	var plan *entity.Plan
+	lockKey := fmt.Sprintf("plan:create:%d:%s", req.UserID, req.OutPlanNo)
+	err := lock.WithLock(ctx, cache.Client, lockKey, 10*time.Second, func() error {
+		// 检查计划是否已存在（幂等性保证）
+		if existing, err := s.planRepo.GetByUserAndOutPlanNo(ctx, req.UserID, req.OutPlanNo); err == nil {
+			plan = existing
+			return nil
+		}
+
+		plan = &entity.Plan{
+			PlanNo:        fmt.Sprintf("PL%d%s", time.Now().UnixNano(), uuid.New().String()[:8]),
+			UserID:        req.UserID,
+			OutPlanNo:     req.OutPlanNo,
+			Name:          req.Name,
+			Description:   req.Description,
+			Amount:        req.Amount,
+			Currency:      req.Currency,
+			Interval:      req.Interval,
+			IntervalCount: req.IntervalCount,
+			TrialDays:     req.TrialDays,
+			Status:        entity.PlanStatusActive,
+		}
+		return s.planRepo.Create(ctx, plan)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// QueryPlan 查询订阅计划
+func (s *Service) QueryPlan(ctx context.Context, userID uint64, planNo string) (*entity.Plan, error) {
+	plan, err := s.planRepo.GetByPlanNo(ctx, planNo)
+	if err != nil {
+		return nil, err
+	}
+
+	// 验证计划归属（数据隔离）
+	if plan.UserID != userID {
+		return nil, apperrors.New(apperrors.ErrNotFound, "plan not found")
+	}
+
+	return plan, nil
+}
+
+// CreateSubscriptionRequest 创建订阅请求
+type CreateSubscriptionRequest struct {
+	UserID            uint64
+	Provider          string
+	PlanNo            string
+	OutSubscriptionNo string
+	NotifyURL         string
+	ReturnURL         string
+}
+
+// CreateSubscription 创建订阅，返回的订阅包含买家签约或授权页面
+// 同一商户订阅号只会创建一次，重复请求返回已有订阅
+func (s *Service) CreateSubscription(ctx context.Context, req *CreateSubscriptionRequest) (*entity.Subscription, error) {
+	prov, err := payment.GetProvider(req.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	subscriber, ok := prov.(payment.Subscriber)
+	if !ok {
+		return nil, apperrors.New(apperrors.ErrNotSupported, fmt.Sprintf("provider %s does not support subscriptions", req.Provider))
+	}
+
+	plan, err := s.QueryPlan(ctx, req.UserID, req.PlanNo)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Status != entity.PlanStatusActive {
+		return nil, apperrors.New(apperrors.ErrInvalidParam, "plan is not active")
+	}
+
+	var sub *entity.Subscription
+	lockKey := fmt.Sprintf("subscription:create:%d:%s", req.UserID, req.OutSubscriptionNo)
+	err = lock.WithLock(ctx, cache.Client, lockKey, 30*time.Second, func() error {
+		// 检查订阅是否已存在（幂等性保证）
+		if existing, err := s.subscriptionRepo.GetByUserAndOutSubscriptionNo(ctx, req.UserID, req.OutSubscriptionNo); err == nil {
+			sub = existing
+			return nil
+		}
+
+		config, err := s.getConfigWithCache(ctx, req.UserID, req.Provider)
+		if err != nil {
+			return err
+		}
+
+		notifyURL, err := s.notifyURL(req.Provider, config.ID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		nextCheck := now.Add(subscriptionPendingCheck)
+		sub = &entity.Subscription{
+			SubscriptionNo:    fmt.Sprintf("SUB%d%s", now.UnixNano(), uuid.New().String()[:8]),
+			UserID:            req.UserID,
+			OutSubscriptionNo: req.OutSubscriptionNo,
+			PlanID:            plan.ID,
+			PlanNo:            plan.PlanNo,
+			Provider:          req.Provider,
+			ConfigID:          config.ID,
+			Amount:            plan.Amount,
+			Currency:          plan.Currency,
+			Interval:          plan.Interval,
+			IntervalCount:     plan.IntervalCount,
+			TrialDays:         plan.TrialDays,
+			Status:            entity.SubscriptionStatusPending,
+			NextBillingTime:   &nextCheck,
+			NotifyURL:         req.NotifyURL,
+			ReturnURL:         req.ReturnURL,
+		}
+		if err := s.subscriptionRepo.Create(ctx, sub); err != nil {
+			return err
+		}
+
+		planKey := fmt.Sprintf("%s:%d", req.Provider, config.ID)
+		subReq := &payment.SubscriptionRequest{
+			SubscriptionNo:  sub.SubscriptionNo,
+			PlanName:        plan.Name,
+			PlanDescription: plan.Description,
+			ProviderPlanID:  plan.ProviderPlans[planKey],
+			Amount:          sub.Amount,
+			Currency:        sub.Currency,
+			Interval:        sub.Interval,
+			IntervalCount:   sub.IntervalCount,
+			TrialDays:       sub.TrialDays,
+			FirstChargeTime: now.AddDate(0, 0, sub.TrialDays),
+			NotifyURL:       notifyURL,
+			ReturnURL:       req.ReturnURL,
+			Config:          config.ConfigData,
+		}
+
+		subResp, err := subscriber.CreateSubscription(ctx, subReq)
+		if err != nil {
+			s.logPayment(ctx, 0, sub.SubscriptionNo, "subscription", sub.Provider, subReq, nil, "failed", err.Error())
+			sub.Status = entity.SubscriptionStatusCanceled
+			sub.FailReason = truncate(err.Error(), 256)
+			sub.NextBillingTime = nil
+			if updateErr := s.subscriptionRepo.Update(ctx, sub); updateErr != nil {
+				logger.Error("failed to update subscription", zap.String("subscription_no", sub.SubscriptionNo), zap.Error(updateErr))
+			}
+			return err
+		}
+
+		s.logPayment(ctx, 0, sub.SubscriptionNo, "subscription", sub.Provider, subReq, subResp, "success", "")
+
+		// 保存提供商新建的计划，后续订阅复用
+		if subResp.ProviderPlanID != "" && subResp.ProviderPlanID != subReq.ProviderPlanID {
+			if plan.ProviderPlans == nil {
+				plan.ProviderPlans = entity.ProviderIDs{}
+			}
+			plan.ProviderPlans[planKey] = subResp.ProviderPlanID
+			if err := s.planRepo.Update(ctx, plan); err != nil {
+				logger.Error("failed to update plan", zap.String("plan_no", plan.PlanNo), zap.Error(err))
+			}
+		}
+
+		// 签约通知可能先于同步应答到达，以数据库中的状态为准
+		if current, err := s.subscriptionRepo.GetBySubscriptionNo(ctx, sub.SubscriptionNo); err == nil {
+			*sub = *current
+		}
+		sub.PaymentURL = subResp.PaymentURL
+		if sub.SubscriptionID == "" {
+			sub.SubscriptionID = subResp.SubscriptionID
+		}
+
+		return s.applySubscriptionInfo(ctx, sub, &payment.SubscriptionInfo{
+			Status:           subResp.Status,
+			CurrentPeriodEnd: subResp.CurrentPeriodEnd,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// QuerySubscription 查询订阅，等待签约的订阅会向第三方查询并同步状态
+func (s *Service) QuerySubscription(ctx context.Context, userID uint64, subscriptionNo string) (*entity.Subscription, error) {
+	sub, err := s.getSubscription(ctx, userID, subscriptionNo)
+	if err != nil {
+		return nil, err
+	}
+
+	if sub.Status == entity.SubscriptionStatusPending {
+		if err := s.syncSubscription(ctx, sub); err != nil {
+			logger.Warn("failed to sync subscription", zap.String("subscription_no", sub.SubscriptionNo), zap.Error(err))
+		}
+	}
+
+	return sub, nil
+}
+
+// CancelSubscription 取消订阅，在第三方解约后不再续费
+func (s *Service) CancelSubscription(ctx context.Context, userID uint64, subscriptionNo, reason string) (*entity.Subscription, error) {
+	sub, err := s.getSubscription(ctx, userID, subscriptionNo)
+	if err != nil {
+		return nil, err
+	}
+
+	lockKey := fmt.Sprintf("subscription:process:%s", sub.SubscriptionNo)
+	err = lock.WithLock(ctx, cache.Client, lockKey, 30*time.Second, func() error {
+		if current, err := s.subscriptionRepo.GetBySubscriptionNo(ctx, sub.SubscriptionNo); err == nil {
+			*sub = *current
+		}
+		if sub.Status == entity.SubscriptionStatusCanceled {
+			return nil
+		}
+
+		if err := s.cancelAtProvider(ctx, sub, reason); err != nil {
+			// 买家尚未签约时没有可扣款的协议，第三方取消失败不影响本地取消
+			if sub.Status != entity.SubscriptionStatusPending {
+				return err
+			}
+			logger.Warn("failed to cancel pending subscription at provider",
+				zap.String("subscription_no", sub.SubscriptionNo),
+				zap.Error(err))
+		}
+
+		return s.markSubscriptionCanceled(ctx, sub, reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// getSubscription 获取订阅并验证归属
+func (s *Service) getSubscription(ctx context.Context, userID uint64, subscriptionNo string) (*entity.Subscription, error) {
+	sub, err := s.subscriptionRepo.GetBySubscriptionNo(ctx, subscriptionNo)
+	if err != nil {
+		return nil, err
+	}
+
+	// 验证订阅归属（数据隔离）
+	if sub.UserID != userID {
+		return nil, apperrors.New(apperrors.ErrNotFound, "subscription not found")
+	}
+
+	return sub, nil
+}
+
+// StartSubscriptionSync 启动订阅定时任务：同步等待签约的订阅，到期续费，以及续费失败后的重试
+// dunningIntervals 为续费失败后的重试间隔，为空时使用默认间隔
+func (s *Service) StartSubscriptionSync(interval time.Duration, dunningIntervals []time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	if len(dunningIntervals) > 0 {
+		s.dunningIntervals = dunningIntervals
+	}
+
+	logger.Info("subscription sync started", zap.Duration("interval", interval))
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stopCh:
+				logger.Info("subscription sync stopped")
+				return
+			case <-ticker.C:
+				s.SyncDueSubscriptions(context.Background())
+			}
+		}
+	}()
+}
+
+// SyncDueSubscriptions 处理到达下次处理时间的订阅
+func (s *Service) SyncDueSubscriptions(ctx context.Context) {
+	subs, err := s.subscriptionRepo.ListDue(ctx, time.Now(), subscriptionSyncBatch)
+	if err != nil {
+		logger.Error("failed to list due subscriptions", zap.Error(err))
+		return
+	}
+
+	for _, sub := range subs {
+		lockKey := fmt.Sprintf("subscription:process:%s", sub.SubscriptionNo)
+		err := lock.WithLock(ctx, cache.Client, lockKey, time.Minute, func() error {
+			return s.processSubscription(ctx, sub)
+		})
+		if err != nil {
+			logger.Warn("failed to process subscription", zap.String("subscription_no", sub.SubscriptionNo), zap.Error(err))
+		}
+	}
+}
+
+// processSubscription 处理到期订阅
+// 本服务发起扣款的提供商在账单日扣款，其他提供商自行扣款，到期后查询续费结果
+func (s *Service) processSubscription(ctx context.Context, sub *entity.Subscription) error {
+	if current, err := s.subscriptionRepo.GetBySubscriptionNo(ctx, sub.SubscriptionNo); err == nil {
+		*sub = *current
+	}
+	if sub.Status == entity.SubscriptionStatusCanceled || sub.NextBillingTime == nil || sub.NextBillingTime.After(time.Now()) {
+		return nil
+	}
+
+	if sub.Status == entity.SubscriptionStatusPending {
+		if time.Since(sub.CreatedAt) > subscriptionPendingExpire {
+			if err := s.cancelAtProvider(ctx, sub, "authorization expired"); err != nil {
+				logger.Warn("failed to cancel expired subscription at provider",
+					zap.String("subscription_no", sub.SubscriptionNo),
+					zap.Error(err))
+			}
+			return s.markSubscriptionCanceled(ctx, sub, "authorization expired")
+		}
+		return s.syncSubscription(ctx, sub)
+	}
+
+	prov, err := payment.GetProvider(sub.Provider)
+	if err != nil {
+		return err
+	}
+
+	if charger, ok := prov.(payment.SubscriptionCharger); ok {
+		return s.chargeSubscription(ctx, prov, charger, sub)
+	}
+
+	return s.syncSubscription(ctx, sub)
+}
+
+// syncSubscription 向第三方查询订阅状态
+func (s *Service) syncSubscription(ctx context.Context, sub *entity.Subscription) error {
+	prov, err := payment.GetProvider(sub.Provider)
+	if err != nil {
+		return err
+	}
+
+	subscriber, ok := prov.(payment.Subscriber)
+	if !ok {
+		return nil
+	}
+
+	config, err := s.configRepo.GetByID(ctx, sub.ConfigID)
+	if err != nil {
+		return err
+	}
+
+	queryReq := &payment.QuerySubscriptionRequest{
+		SubscriptionNo: sub.SubscriptionNo,
+		SubscriptionID: sub.SubscriptionID,
+		Config:         config.ConfigData,
+	}
+
+	queryResp, err := subscriber.QuerySubscription(ctx, queryReq)
+	if err != nil {
+		s.logPayment(ctx, 0, sub.SubscriptionNo, "subscription_query", sub.Provider, queryReq, nil, "failed", err.Error())
+		s.deferSubscription(ctx, sub)
+		return err
+	}
+
+	s.logPayment(ctx, 0, sub.SubscriptionNo, "subscription_query", sub.Provider, queryReq, queryResp, "success", "")
+
+	return s.applySubscriptionInfo(ctx, sub, &payment.SubscriptionInfo{
+		SubscriptionID:   queryResp.SubscriptionID,
+		Status:           queryResp.Status,
+		CurrentPeriodEnd: queryResp.CurrentPeriodEnd,
+	})
+}
+
+// handleSubscriptionNotify 处理订阅状态变更通知
+// 优先按订阅单号查找订阅，部分通知只包含第三方订阅ID；通知不含状态时向第三方查询
+func (s *Service) handleSubscriptionNotify(ctx context.Context, provider string, req *payment.NotifyRequest, info *payment.SubscriptionInfo) error {
+	var sub *entity.Subscription
+	if info.SubscriptionNo != "" {
+		if found, err := s.subscriptionRepo.GetBySubscriptionNo(ctx, info.SubscriptionNo); err == nil && found.Provider == provider {
+			sub = found
+		}
+	}
+	if sub == nil && info.SubscriptionID != "" {
+		if found, err := s.subscriptionRepo.GetByProviderSubscriptionID(ctx, provider, info.SubscriptionID); err == nil {
+			sub = found
+		}
+	}
+	if sub == nil {
+		// 非本系统创建的订阅，忽略
+		logger.Warn("subscription not found",
+			zap.String("provider", provider),
+			zap.String("subscription_no", info.SubscriptionNo),
+			zap.String("subscription_id", info.SubscriptionID))
+		return nil
+	}
+
+	s.logPayment(ctx, 0, sub.SubscriptionNo, "subscription_notify", provider, req, info, "success", "")
+
+	lockKey := fmt.Sprintf("subscription:process:%s", sub.SubscriptionNo)
+	return lock.WithLock(ctx, cache.Client, lockKey, 30*time.Second, func() error {
+		if current, err := s.subscriptionRepo.GetBySubscriptionNo(ctx, sub.SubscriptionNo); err == nil {
+			*sub = *current
+		}
+		if info.Status == "" {
+			if info.SubscriptionID != "" {
+				sub.SubscriptionID = info.SubscriptionID
+			}
+			return s.syncSubscription(ctx, sub)
+		}
+		return s.applySubscriptionInfo(ctx, sub, info)
+	})
+}
+
+// applySubscriptionInfo 根据第三方返回的订阅状态更新订阅
+// 本服务发起扣款的提供商只用于确认签约和解约，续费结果以扣款结果为准
+func (s *Service) applySubscriptionInfo(ctx context.Context, sub *entity.Subscription, info *payment.SubscriptionInfo) error {
+	if info.SubscriptionID != "" {
+		sub.SubscriptionID = info.SubscriptionID
+	}
+
+	prov, err := payment.GetProvider(sub.Provider)
+	if err != nil {
+		return err
+	}
+	_, charger := prov.(payment.SubscriptionCharger)
+
+	now := time.Now()
+	event := ""
+
+	switch info.Status {
+	case entity.SubscriptionStatusPending:
+		if sub.Status == entity.SubscriptionStatusPending {
+			next := now.Add(subscriptionPendingCheck)
+			sub.NextBillingTime = &next
+		}
+
+	case entity.SubscriptionStatusTrialing, entity.SubscriptionStatusActive:
+		if sub.Status == entity.SubscriptionStatusPending {
+			s.activateSubscription(sub, info.CurrentPeriodEnd, charger)
+			event = "subscription.activated"
+			break
+		}
+		if charger {
+			break
+		}
+
+		// 提供商自行扣款：周期结束时间推后表示续费成功
+		periodEnd := info.CurrentPeriodEnd
+		if periodEnd != nil && (sub.CurrentPeriodEnd == nil || periodEnd.After(*sub.CurrentPeriodEnd)) {
+			if sub.CurrentPeriodEnd != nil {
+				sub.CurrentPeriodStart = sub.CurrentPeriodEnd
+			}
+			sub.CurrentPeriodEnd = periodEnd
+			sub.NextBillingTime = periodEnd
+			sub.Cycles++
+			sub.RetryCount = 0
+			sub.FailReason = ""
+			sub.Status = s.periodStatus(sub, now)
+			event = "subscription.renewed"
+		} else {
+			next := now.Add(subscriptionRecheck)
+			sub.NextBillingTime = &next
+			if sub.Status == entity.SubscriptionStatusPastDue {
+				sub.Status = s.periodStatus(sub, now)
+			}
+		}
+
+	case entity.SubscriptionStatusPastDue:
+		if sub.Status != entity.SubscriptionStatusPastDue && !charger {
+			sub.Status = entity.SubscriptionStatusPastDue
+			sub.RetryCount++
+			sub.FailReason = truncate(info.FailReason, 256)
+			next := now.Add(subscriptionRecheck)
+			sub.NextBillingTime = &next
+			event = "subscription.payment_failed"
+		}
+
+	case entity.SubscriptionStatusCanceled:
+		if sub.Status != entity.SubscriptionStatusCanceled {
+			return s.markSubscriptionCanceled(ctx, sub, "canceled at provider")
+		}
+	}
+
+	if err := s.subscriptionRepo.Update(ctx, sub); err != nil {
+		logger.Error("failed to update subscription", zap.String("subscription_no", sub.SubscriptionNo), zap.Error(err))
+		return err
+	}
+
+	if event != "" {
+		s.notifySubscription(ctx, sub, event)
+	}
+
+	return nil
+}
+
+// activateSubscription 买家完成签约，开始试用或第一个计费周期
+// 本服务发起扣款时立即扣第一期；提供商自行扣款时签约即完成第一期扣款
+func (s *Service) activateSubscription(sub *entity.Subscription, periodEnd *time.Time, charger bool) {
+	now := time.Now()
+	sub.CurrentPeriodStart = &now
+	sub.FailReason = ""
+
+	if sub.TrialDays > 0 {
+		trialEnd := now.AddDate(0, 0, sub.TrialDays)
+		if periodEnd != nil && !charger {
+			trialEnd = *periodEnd
+		}
+		sub.TrialEnd = &trialEnd
+		sub.CurrentPeriodEnd = &trialEnd
+		sub.NextBillingTime = &trialEnd
+		sub.Status = entity.SubscriptionStatusTrialing
+		return
+	}
+
+	sub.Status = entity.SubscriptionStatusActive
+	if charger {
+		sub.CurrentPeriodEnd = &now
+		sub.NextBillingTime = &now
+		return
+	}
+
+	sub.Cycles = 1
+	if periodEnd == nil {
+		end := addInterval(now, sub.Interval, sub.IntervalCount, 0)
+		periodEnd = &end
+	}
+	sub.CurrentPeriodEnd = periodEnd
+	sub.NextBillingTime = periodEnd
+}
+
+// chargeSubscription 按签约协议扣取本期费用，扣款处理中时在下次处理时确认结果
+func (s *Service) chargeSubscription(ctx context.Context, prov payment.Provider, charger payment.SubscriptionCharger, sub *entity.Subscription) error {
+	config, err := s.configRepo.GetByID(ctx, sub.ConfigID)
+	if err != nil {
+		return err
+	}
+
+	notifyURL, err := s.notifyURL(sub.Provider, sub.ConfigID)
+	if err != nil {
+		return err
+	}
+
+	// 上次扣款处理中，先确认扣款结果
+	if sub.LastOrderNo != "" {
+		order, err := s.orderRepo.GetByOrderNo(ctx, sub.LastOrderNo)
+		if err != nil {
+			return err
+		}
+		if querier, ok := prov.(payment.Querier); ok && order.Status == entity.OrderStatusPending {
+			queryResp, err := querier.QueryPayment(ctx, &payment.QueryPaymentRequest{
+				OutTradeNo: order.OutTradeNo,
+				TradeNo:    order.TradeNo,
+				Config:     config.ConfigData,
+			})
+			if err != nil {
+				s.deferSubscription(ctx, sub)
+				return err
+			}
+			if queryResp.Status != entity.OrderStatusPending {
+				if queryResp.TradeNo != "" {
+					order.TradeNo = queryResp.TradeNo
+				}
+				if err := s.updateOrderStatus(ctx, order, queryResp.Status); err != nil {
+					return err
+				}
+			}
+		}
+
+		switch order.Status {
+		case entity.OrderStatusSuccess:
+			return s.renewSubscription(ctx, sub)
+		case entity.OrderStatusPending:
+			s.deferSubscription(ctx, sub)
+			return nil
+		default:
+			return s.failSubscriptionCharge(ctx, sub, "renewal payment "+order.Status)
+		}
+	}
+
+	subject := sub.PlanNo
+	if plan, err := s.planRepo.GetByPlanNo(ctx, sub.PlanNo); err == nil {
+		subject = plan.Name
+	}
+
+	order := &entity.PaymentOrder{
+		OrderNo:    s.generateOrderNo(),
+		UserID:     sub.UserID,
+		Provider:   sub.Provider,
+		ConfigID:   sub.ConfigID,
+		OutTradeNo: fmt.Sprintf("%s_%d_%d", sub.SubscriptionNo, sub.Cycles+1, sub.RetryCount),
+		Subject:    subject,
+		Amount:     sub.Amount,
+		Currency:   sub.Currency,
+		Status:     entity.OrderStatusPending,
+	}
+	if err := s.orderRepo.Create(ctx, order); err != nil {
+		return err
+	}
+
+	chargeReq := &payment.ChargeSubscriptionRequest{
+		SubscriptionNo: sub.SubscriptionNo,
+		SubscriptionID: sub.SubscriptionID,
+		OrderNo:        order.OrderNo,
+		OutTradeNo:     order.OutTradeNo,
+		Subject:        order.Subject,
+		Amount:         order.Amount,
+		Currency:       order.Currency,
+		NotifyURL:      notifyURL,
+		Config:         config.ConfigData,
+	}
+
+	chargeResp, err := charger.ChargeSubscription(ctx, chargeReq)
+	if err != nil {
+		s.logPayment(ctx, order.ID, order.OrderNo, "subscription_charge", sub.Provider, chargeReq, nil, "failed", err.Error())
+		if updateErr := s.updateOrderStatus(ctx, order, entity.OrderStatusFailed); updateErr != nil {
+			return updateErr
+		}
+		return s.failSubscriptionCharge(ctx, sub, err.Error())
+	}
+
+	s.logPayment(ctx, order.ID, order.OrderNo, "subscription_charge", sub.Provider, chargeReq, chargeResp, "success", "")
+
+	order.TradeNo = chargeResp.TradeNo
+	switch chargeResp.Status {
+	case payment.StatusSuccess:
+		if err := s.updateOrderStatus(ctx, order, entity.OrderStatusSuccess); err != nil {
+			return err
+		}
+		return s.renewSubscription(ctx, sub)
+	case payment.StatusPending:
+		if err := s.orderRepo.Update(ctx, order); err != nil {
+			return err
+		}
+		sub.LastOrderNo = order.OrderNo
+		s.deferSubscription(ctx, sub)
+		return nil
+	default:
+		if err := s.updateOrderStatus(ctx, order, entity.OrderStatusFailed); err != nil {
+			return err
+		}
+		return s.failSubscriptionCharge(ctx, sub, chargeResp.FailReason)
+	}
+}
+
+// renewSubscription 本期扣款成功，进入下一个计费周期
+// 周期按原账单日顺延，重试成功不改变账单日
+func (s *Service) renewSubscription(ctx context.Context, sub *entity.Subscription) error {
+	start := time.Now()
+	if sub.CurrentPeriodEnd != nil {
+		start = *sub.CurrentPeriodEnd
+	}
+	// 账单日为第一次续费的日期，短月取月末后下一期仍回到原账单日
+	if sub.BillingDay == 0 {
+		sub.BillingDay = start.Day()
+	}
+	end := addInterval(start, sub.Interval, sub.IntervalCount, sub.BillingDay)
+
+	sub.CurrentPeriodStart = &start
+	sub.CurrentPeriodEnd = &end
+	sub.NextBillingTime = &end
+	sub.Status = entity.SubscriptionStatusActive
+	sub.Cycles++
+	sub.RetryCount = 0
+	sub.FailReason = ""
+	sub.LastOrderNo = ""
+
+	if err := s.subscriptionRepo.Update(ctx, sub); err != nil {
+		logger.Error("failed to update subscription", zap.String("subscription_no", sub.SubscriptionNo), zap.Error(err))
+		return err
+	}
+
+	s.notifySubscription(ctx, sub, "subscription.renewed")
+	return nil
+}
+
+// failSubscriptionCharge 本期扣款失败，按重试间隔再次扣款，重试用尽后取消订阅
+func (s *Service) failSubscriptionCharge(ctx context.Context, sub *entity.Subscription, reason string) error {
+	sub.RetryCount++
+	sub.LastOrderNo = ""
+	sub.FailReason = truncate(reason, 256)
+
+	dunningIntervals := s.dunningIntervals
+	if len(dunningIntervals) == 0 {
+		dunningIntervals = defaultDunningIntervals
+	}
+
+	if sub.RetryCount > len(dunningIntervals) {
+		if err := s.cancelAtProvider(ctx, sub, "renewal payment failed"); err != nil {
+			logger.Warn("failed to cancel subscription at provider",
+				zap.String("subscription_no", sub.SubscriptionNo),
+				zap.Error(err))
+		}
+		s.notifySubscription(ctx, sub, "subscription.payment_failed")
+		return s.markSubscriptionCanceled(ctx, sub, reason)
+	}
+
+	next := time.Now().Add(dunningIntervals[sub.RetryCount-1])
+	sub.NextBillingTime = &next
+	sub.Status = entity.SubscriptionStatusPastDue
+
+	if err := s.subscriptionRepo.Update(ctx, sub); err != nil {
+		logger.Error("failed to update subscription", zap.String("subscription_no", sub.SubscriptionNo), zap.Error(err))
+		return err
+	}
+
+	s.notifySubscription(ctx, sub, "subscription.payment_failed")
+	return nil
+}
+
+// cancelAtProvider 在第三方取消订阅，买家尚未签约且没有第三方订阅ID时无需取消
+func (s *Service) cancelAtProvider(ctx context.Context, sub *entity.Subscription, reason string) error {
+	if sub.Status == entity.SubscriptionStatusPending && sub.SubscriptionID == "" {
+		return nil
+	}
+
+	prov, err := payment.GetProvider(sub.Provider)
+	if err != nil {
+		return err
+	}
+
+	subscriber, ok := prov.(payment.Subscriber)
+	if !ok {
+		return nil
+	}
+
+	config, err := s.configRepo.GetByID(ctx, sub.ConfigID)
+	if err != nil {
+		return err
+	}
+
+	cancelReq := &payment.CancelSubscriptionRequest{
+		SubscriptionNo: sub.SubscriptionNo,
+		SubscriptionID: sub.SubscriptionID,
+		Reason:         reason,
+		Config:         config.ConfigData,
+	}
+
+	if err := subscriber.CancelSubscription(ctx, cancelReq); err != nil {
+		s.logPayment(ctx, 0, sub.SubscriptionNo, "subscription_cancel", sub.Provider, cancelReq, nil, "failed", err.Error())
+		return err
+	}
+
+	s.logPayment(ctx, 0, sub.SubscriptionNo, "subscription_cancel", sub.Provider, cancelReq, nil, "success", "")
+	return nil
+}
+
+// markSubscriptionCanceled 将订阅标记为已取消，并通知商户
+func (s *Service) markSubscriptionCanceled(ctx context.Context, sub *entity.Subscription, reason string) error {
+	now := time.Now()
+	sub.Status = entity.SubscriptionStatusCanceled
+	sub.CanceledAt = &now
+	sub.NextBillingTime = nil
+	if reason != "" {
+		sub.FailReason = truncate(reason, 256)
+	}
+
+	if err := s.subscriptionRepo.Update(ctx, sub); err != nil {
+		logger.Error("failed to update subscription", zap.String("subscription_no", sub.SubscriptionNo), zap.Error(err))
+		return err
+	}
+
+	s.notifySubscription(ctx, sub, "subscription.canceled")
+	return nil
+}
+
+// deferSubscription 推迟订阅的下次处理时间，用于等待签约、扣款结果或查询失败后重试
+func (s *Service) deferSubscription(ctx context.Context, sub *entity.Subscription) {
+	next := time.Now().Add(subscriptionPendingCheck)
+	sub.NextBillingTime = &next
+	if err := s.subscriptionRepo.Update(ctx, sub); err != nil {
+		logger.Error("failed to update subscription", zap.String("subscription_no", sub.SubscriptionNo), zap.Error(err))
+	}
+}
+
+// periodStatus 试用期内为 trialing，否则为 active
+func (s *Service) periodStatus(sub *entity.Subscription, now time.Time) string {
+	if sub.TrialEnd != nil && now.Before(*sub.TrialEnd) {
+		return entity.SubscriptionStatusTrialing
+	}
+	return entity.SubscriptionStatusActive
+}
+
+// notifySubscription 订阅有通知URL时，添加 subscription.* 通知任务
+func (s *Service) notifySubscription(ctx context.Context, sub *entity.Subscription, event string) {
+	if sub.NotifyURL == "" {
+		return
+	}
+
+	notifyData := map[string]interface{}{
+		"event":                event,
+		"subscription_no":      sub.SubscriptionNo,
+		"out_subscription_no":  sub.OutSubscriptionNo,
+		"plan_no":              sub.PlanNo,
+		"provider":             sub.Provider,
+		"amount":               sub.Amount,
+		"currency":             sub.Currency,
+		"status":               sub.Status,
+		"cycles":               sub.Cycles,
+		"current_period_start": sub.CurrentPeriodStart,
+		"current_period_end":   sub.CurrentPeriodEnd,
+		"next_billing_time":    sub.NextBillingTime,
+		"fail_reason":          sub.FailReason,
+	}
+
+	if err := s.notifyService.AddNotify(ctx, 0, sub.SubscriptionNo, sub.NotifyURL, notifyData); err != nil {
+		logger.Error("failed to add subscription notify task",
+			zap.String("subscription_no", sub.SubscriptionNo),
+			zap.Error(err))
+	}
+}
+
+// addInterval 计算下一个计费周期的开始时间
+// 按月、按年计费时下一期为 billingDay 日（为0时取 t 的日期），目标月份没有该日期时取该月最后一天，
+// 如账单日为31日时1月31日的下一期为2月28日（闰年29日），再下一期为3月31日
+func addInterval(t time.Time, interval string, count, billingDay int) time.Time {
+	if count <= 0 {
+		count = 1
+	}
+	switch interval {
+	case payment.IntervalDay:
+		return t.AddDate(0, 0, count)
+	case payment.IntervalWeek:
+		return t.AddDate(0, 0, 7*count)
+	case payment.IntervalYear:
+		return addMonths(t, 12*count, billingDay)
+	default:
+		return addMonths(t, count, billingDay)
+	}
+}
+
+// addMonths 增加月数，日期为目标月份的 day 日，超过该月天数时取该月最后一天
+// time.AddDate 会将溢出的日期顺延到下个月（1月31日加一个月为3月3日）
+func addMonths(t time.Time, months, day int) time.Time {
+	if day <= 0 {
+		day = t.Day()
+	}
+	year, month, _ := t.Date()
+	firstDay := time.Date(year, month+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	if lastDay := firstDay.AddDate(0, 1, -1).Day(); day > lastDay {
+		day = lastDay
+	}
+	return time.Date(firstDay.Year(), firstDay.Month(), day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
@@ -0,0 +1,283 @@
+package payment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zqdfound/go-uni-pay/internal/domain/entity"
+	"github.com/zqdfound/go-uni-pay/internal/payment"
+)
+
+// mockSubscriber 自行按周期扣款的订阅提供商（如 Stripe、PayPal）
+type mockSubscriber struct {
+	*mockProvider
+}
+
+func (p *mockSubscriber) CreateSubscription(ctx context.Context, req *payment.SubscriptionRequest) (*payment.SubscriptionResponse, error) {
+	args := p.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*payment.SubscriptionResponse), args.Error(1)
+}
+
+func (p *mockSubscriber) QuerySubscription(ctx context.Context, req *payment.QuerySubscriptionRequest) (*payment.SubscriptionResponse, error) {
+	args := p.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*payment.SubscriptionResponse), args.Error(1)
+}
+
+func (p *mockSubscriber) CancelSubscription(ctx context.Context, req *payment.CancelSubscriptionRequest) error {
+	return p.Called(ctx, req).Error(0)
+}
+
+// mockCharger 签约后由本服务发起扣款的订阅提供商（如支付宝周期扣款）
+type mockCharger struct {
+	*mockSubscriber
+}
+
+func (p *mockCharger) ChargeSubscription(ctx context.Context, req *payment.ChargeSubscriptionRequest) (*payment.ChargeSubscriptionResponse, error) {
+	args := p.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*payment.ChargeSubscriptionResponse), args.Error(1)
+}
+
+// createTestSubscription 创建月度计划并发起订阅，提供商返回等待签约
+func createTestSubscription(t *testing.T, env *testEnv, prov *mockSubscriber) *entity.Subscription {
+	t.Helper()
+	ctx := context.Background()
+
+	plan, err := env.svc.CreatePlan(ctx, &CreatePlanRequest{
+		UserID:    testUserID,
+		OutPlanNo: "PLAN1",
+		Name:      "Pro",
+		Amount:    9.99,
+		Currency:  "USD",
+		Interval:  payment.IntervalMonth,
+	})
+	require.NoError(t, err)
+
+	prov.On("CreateSubscription", mock.Anything, mock.MatchedBy(func(req *payment.SubscriptionRequest) bool {
+		return req.Amount == 9.99 && req.Interval == payment.IntervalMonth && req.NotifyURL != ""
+	})).Return(&payment.SubscriptionResponse{
+		SubscriptionID: "I-SUB1",
+		PaymentURL:     "https://provider.example.com/approve",
+		Status:         entity.SubscriptionStatusPending,
+	}, nil).Once()
+
+	sub, err := env.svc.CreateSubscription(ctx, &CreateSubscriptionRequest{
+		UserID:            testUserID,
+		Provider:          prov.name,
+		PlanNo:            plan.PlanNo,
+		OutSubscriptionNo: "OUTSUB1",
+		NotifyURL:         "https://merchant.example.com/notify",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, entity.SubscriptionStatusPending, sub.Status)
+	assert.Equal(t, "https://provider.example.com/approve", sub.PaymentURL)
+	return sub
+}
+
+// subscriptionNotify 模拟提供商发送订阅通知并交给服务处理
+func subscriptionNotify(t *testing.T, env *testEnv, prov *mockSubscriber, info *payment.SubscriptionInfo) *entity.Subscription {
+	t.Helper()
+	ctx := context.Background()
+
+	prov.On("HandleNotify", mock.Anything, mock.Anything).Return(&payment.NotifyResponse{
+		Event:        payment.EventSubscription,
+		Subscription: info,
+	}, nil).Once()
+	_, err := env.svc.HandleNotify(ctx, prov.name, &payment.NotifyRequest{})
+	require.NoError(t, err)
+
+	sub, err := env.subs.GetByUserAndOutSubscriptionNo(ctx, testUserID, "OUTSUB1")
+	require.NoError(t, err)
+	return sub
+}
+
+// TestSubscriptionLifecycle 测试提供商自行扣款时，订阅按通知签约、续费、扣款失败和取消
+func TestSubscriptionLifecycle(t *testing.T) {
+	env := newTestEnv(t)
+	prov := &mockSubscriber{newMockProvider(t)}
+	payment.Register(prov)
+	env.addConfig(prov.name, entity.ConfigData{})
+
+	sub := createTestSubscription(t, env, prov)
+
+	// 签约完成
+	firstEnd := time.Now().AddDate(0, 1, 0).Truncate(time.Second)
+	sub = subscriptionNotify(t, env, prov, &payment.SubscriptionInfo{
+		SubscriptionNo:   sub.SubscriptionNo,
+		Status:           entity.SubscriptionStatusActive,
+		CurrentPeriodEnd: &firstEnd,
+	})
+	assert.Equal(t, entity.SubscriptionStatusActive, sub.Status)
+	assert.Equal(t, 1, sub.Cycles)
+	assert.True(t, firstEnd.Equal(*sub.CurrentPeriodEnd))
+
+	// 续费失败，通知只带第三方订阅ID
+	sub = subscriptionNotify(t, env, prov, &payment.SubscriptionInfo{
+		SubscriptionID: "I-SUB1",
+		Status:         entity.SubscriptionStatusPastDue,
+		FailReason:     "card declined",
+	})
+	assert.Equal(t, entity.SubscriptionStatusPastDue, sub.Status)
+	assert.Equal(t, "card declined", sub.FailReason)
+
+	// 重试扣款成功，周期结束时间推后
+	secondEnd := firstEnd.AddDate(0, 1, 0)
+	sub = subscriptionNotify(t, env, prov, &payment.SubscriptionInfo{
+		SubscriptionID:   "I-SUB1",
+		Status:           entity.SubscriptionStatusActive,
+		CurrentPeriodEnd: &secondEnd,
+	})
+	assert.Equal(t, entity.SubscriptionStatusActive, sub.Status)
+	assert.Equal(t, 2, sub.Cycles)
+	assert.Equal(t, 0, sub.RetryCount)
+	assert.Empty(t, sub.FailReason)
+	assert.True(t, firstEnd.Equal(*sub.CurrentPeriodStart))
+
+	// 在提供商取消
+	sub = subscriptionNotify(t, env, prov, &payment.SubscriptionInfo{
+		SubscriptionID: "I-SUB1",
+		Status:         entity.SubscriptionStatusCanceled,
+	})
+	assert.Equal(t, entity.SubscriptionStatusCanceled, sub.Status)
+	assert.NotNil(t, sub.CanceledAt)
+	assert.Nil(t, sub.NextBillingTime)
+
+	assert.Equal(t, []string{
+		"subscription.activated",
+		"subscription.payment_failed",
+		"subscription.renewed",
+		"subscription.canceled",
+	}, env.notifier.events())
+	prov.AssertExpectations(t)
+}
+
+// TestSubscriptionCharge 测试本服务发起扣款时，账单日扣款成功续费，失败后进入重试
+func TestSubscriptionCharge(t *testing.T) {
+	env := newTestEnv(t)
+	prov := &mockCharger{&mockSubscriber{newMockProvider(t)}}
+	payment.Register(prov)
+	env.addConfig(prov.name, entity.ConfigData{})
+
+	sub := createTestSubscription(t, env, prov.mockSubscriber)
+
+	// 签约完成后立即扣第一期
+	sub = subscriptionNotify(t, env, prov.mockSubscriber, &payment.SubscriptionInfo{
+		SubscriptionNo: sub.SubscriptionNo,
+		Status:         entity.SubscriptionStatusActive,
+	})
+	assert.Equal(t, entity.SubscriptionStatusActive, sub.Status)
+	assert.Equal(t, 0, sub.Cycles)
+
+	ctx := context.Background()
+	prov.On("ChargeSubscription", mock.Anything, mock.MatchedBy(func(req *payment.ChargeSubscriptionRequest) bool {
+		return req.SubscriptionID == "I-SUB1" && req.Amount == 9.99 && req.OutTradeNo == sub.SubscriptionNo+"_1_0"
+	})).Return(&payment.ChargeSubscriptionResponse{TradeNo: "TRADE1", Status: payment.StatusSuccess}, nil).Once()
+	require.NoError(t, env.svc.processSubscription(ctx, sub))
+
+	sub, err := env.subs.GetBySubscriptionNo(ctx, sub.SubscriptionNo)
+	require.NoError(t, err)
+	assert.Equal(t, entity.SubscriptionStatusActive, sub.Status)
+	assert.Equal(t, 1, sub.Cycles)
+	order, err := env.orders.GetByOutTradeNo(ctx, sub.SubscriptionNo+"_1_0")
+	require.NoError(t, err)
+	assert.Equal(t, entity.OrderStatusSuccess, order.Status)
+
+	// 到达账单日扣款失败
+	due := time.Now().Add(-time.Second)
+	sub.NextBillingTime = &due
+	require.NoError(t, env.subs.Update(ctx, sub))
+	prov.On("ChargeSubscription", mock.Anything, mock.MatchedBy(func(req *payment.ChargeSubscriptionRequest) bool {
+		return req.OutTradeNo == sub.SubscriptionNo+"_2_0"
+	})).Return(&payment.ChargeSubscriptionResponse{Status: payment.StatusFailed, FailReason: "insufficient balance"}, nil).Once()
+	require.NoError(t, env.svc.processSubscription(ctx, sub))
+
+	sub, err = env.subs.GetBySubscriptionNo(ctx, sub.SubscriptionNo)
+	require.NoError(t, err)
+	assert.Equal(t, entity.SubscriptionStatusPastDue, sub.Status)
+	assert.Equal(t, 1, sub.RetryCount)
+	assert.Equal(t, "insufficient balance", sub.FailReason)
+	assert.True(t, sub.NextBillingTime.After(time.Now().Add(23*time.Hour)))
+
+	assert.Equal(t, []string{
+		"subscription.activated",
+		"subscription.renewed",
+		"subscription.payment_failed",
+	}, env.notifier.events())
+	prov.AssertExpectations(t)
+}
+
+// TestAddInterval 测试按月、按年计费时目标月份没有账单日的取该月最后一天
+func TestAddInterval(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 12, 30, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name       string
+		start      time.Time
+		interval   string
+		count      int
+		billingDay int
+		want       time.Time
+	}{
+		{"month", date(2026, 1, 15), payment.IntervalMonth, 1, 0, date(2026, 2, 15)},
+		{"month end to february", date(2026, 1, 31), payment.IntervalMonth, 1, 0, date(2026, 2, 28)},
+		{"month end to leap february", date(2028, 1, 31), payment.IntervalMonth, 1, 0, date(2028, 2, 29)},
+		{"month end to april", date(2026, 3, 31), payment.IntervalMonth, 1, 0, date(2026, 4, 30)},
+		{"back to billing day", date(2026, 2, 28), payment.IntervalMonth, 1, 31, date(2026, 3, 31)},
+		{"billing day clamped again", date(2026, 3, 31), payment.IntervalMonth, 1, 31, date(2026, 4, 30)},
+		{"multiple months across year", date(2026, 8, 31), payment.IntervalMonth, 6, 0, date(2027, 2, 28)},
+		{"default interval count", date(2026, 12, 31), payment.IntervalMonth, 0, 0, date(2027, 1, 31)},
+		{"leap day yearly", date(2028, 2, 29), payment.IntervalYear, 1, 0, date(2029, 2, 28)},
+		{"yearly back to leap day", date(2031, 2, 28), payment.IntervalYear, 1, 29, date(2032, 2, 29)},
+		{"day", date(2026, 1, 31), payment.IntervalDay, 3, 0, date(2026, 2, 3)},
+		{"week", date(2026, 1, 31), payment.IntervalWeek, 2, 31, date(2026, 2, 14)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, addInterval(tt.start, tt.interval, tt.count, tt.billingDay))
+		})
+	}
+}
+
+// TestRenewSubscription_BillingDay 测试续费周期在短月取月末后仍回到原账单日
+func TestRenewSubscription_BillingDay(t *testing.T) {
+	env := newTestEnv(t)
+	periodEnd := time.Date(2026, 1, 31, 10, 0, 0, 0, time.Local)
+	sub := &entity.Subscription{
+		SubscriptionNo:   "SUB1",
+		UserID:           testUserID,
+		Interval:         payment.IntervalMonth,
+		IntervalCount:    1,
+		Status:           entity.SubscriptionStatusActive,
+		CurrentPeriodEnd: &periodEnd,
+	}
+	env.subs.create(sub)
+
+	ctx := context.Background()
+	for _, want := range []time.Time{
+		time.Date(2026, 2, 28, 10, 0, 0, 0, time.Local),
+		time.Date(2026, 3, 31, 10, 0, 0, 0, time.Local),
+		time.Date(2026, 4, 30, 10, 0, 0, 0, time.Local),
+	} {
+		require.NoError(t, env.svc.renewSubscription(ctx, sub))
+		stored, err := env.subs.GetBySubscriptionNo(ctx, sub.SubscriptionNo)
+		require.NoError(t, err)
+		assert.Equal(t, 31, stored.BillingDay)
+		assert.True(t, want.Equal(*stored.CurrentPeriodEnd), "period end %s, want %s", stored.CurrentPeriodEnd, want)
+		assert.True(t, want.Equal(*stored.NextBillingTime))
+		sub = stored
+	}
+}
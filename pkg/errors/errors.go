@@ -31,6 +31,14 @@ const (
 	ErrOrderNotFound    ErrorCode = 2007
 	ErrOrderStatus      ErrorCode = 2008
 	ErrAmountInvalid    ErrorCode = 2009
+	ErrPaymentCapture   ErrorCode = 2010
+	ErrPaymentVoid      ErrorCode = 2011
+	ErrNotSupported     ErrorCode = 2012
+	ErrPaymentBill      ErrorCode = 2013
+	ErrPayout           ErrorCode = 2014
+	ErrProfitSharing    ErrorCode = 2015
+	ErrSubscription     ErrorCode = 2016
+	ErrPaymentMethod    ErrorCode = 2017
 
 	// 数据库错误码 3000-3999
 	ErrDatabaseQuery  ErrorCode = 3000
@@ -104,6 +112,14 @@ var errorMessages = map[ErrorCode]string{
 	ErrOrderNotFound:      "Order not found",
 	ErrOrderStatus:        "Invalid order status",
 	ErrAmountInvalid:      "Invalid amount",
+	ErrPaymentCapture:     "Failed to capture payment",
+	ErrPaymentVoid:        "Failed to void authorization",
+	ErrNotSupported:       "Operation not supported by provider",
+	ErrPaymentBill:        "Failed to download bill",
+	ErrPayout:             "Failed to create payout",
+	ErrProfitSharing:      "Failed to share profit",
+	ErrSubscription:       "Failed to process subscription",
+	ErrPaymentMethod:      "Failed to process payment method",
 	ErrDatabaseQuery:      "Database query error",
 	ErrDatabaseInsert:     "Database insert error",
 	ErrDatabaseUpdate:     "Database update error",
@@ -0,0 +1,128 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+
+	goqrcode "github.com/skip2/go-qrcode"
+	apperrors "github.com/zqdfound/go-uni-pay/pkg/errors"
+)
+
+// 二维码图片参数范围
+const (
+	DefaultSize   = 256
+	MaxSize       = 1024
+	DefaultMargin = 4
+	MaxMargin     = 16
+	DefaultLevel  = "M"
+)
+
+// Options 二维码图片选项
+// Size 为图片边长（像素），Margin 为四周留白（模块数），Level 为纠错级别 L/M/Q/H
+type Options struct {
+	Size   int
+	Margin int
+	Level  string
+}
+
+// PNG 生成 PNG 格式的二维码图片
+func PNG(content string, opts Options) ([]byte, error) {
+	bitmap, opts, err := encode(content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	modules := len(bitmap) + 2*opts.Margin
+	scale := opts.Size / modules
+	if scale < 1 {
+		return nil, apperrors.New(apperrors.ErrInvalidParam, fmt.Sprintf("size must be at least %d for this qr code", modules))
+	}
+	// 图片边长不能被模块数整除时，剩余像素平均分配到四周
+	offset := (opts.Size-scale*modules)/2 + opts.Margin*scale
+
+	img := image.NewPaletted(image.Rect(0, 0, opts.Size, opts.Size), color.Palette{color.White, color.Black})
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetColorIndex(offset+x*scale+dx, offset+y*scale+dy, 1)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrInternalServer, "failed to encode qr code png", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG 生成 SVG 格式的二维码图片，以模块为坐标单位，可任意缩放
+func SVG(content string, opts Options) ([]byte, error) {
+	bitmap, opts, err := encode(content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	modules := len(bitmap) + 2*opts.Margin
+	var path strings.Builder
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&path, "M%d %dh1v1h-1z", x+opts.Margin, y+opts.Margin)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		opts.Size, opts.Size, modules, modules)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="%s"/></svg>`, modules, modules, path.String())
+	return buf.Bytes(), nil
+}
+
+// encode 校验选项并生成不含留白的二维码矩阵
+func encode(content string, opts Options) ([][]bool, Options, error) {
+	if opts.Size == 0 {
+		opts.Size = DefaultSize
+	}
+	if opts.Size < 0 || opts.Size > MaxSize {
+		return nil, opts, apperrors.New(apperrors.ErrInvalidParam, fmt.Sprintf("size must be between 1 and %d", MaxSize))
+	}
+	if opts.Margin < 0 || opts.Margin > MaxMargin {
+		return nil, opts, apperrors.New(apperrors.ErrInvalidParam, fmt.Sprintf("margin must be between 0 and %d", MaxMargin))
+	}
+	if opts.Level == "" {
+		opts.Level = DefaultLevel
+	}
+
+	var level goqrcode.RecoveryLevel
+	switch strings.ToUpper(opts.Level) {
+	case "L":
+		level = goqrcode.Low
+	case "M":
+		level = goqrcode.Medium
+	case "Q":
+		level = goqrcode.High
+	case "H":
+		level = goqrcode.Highest
+	default:
+		return nil, opts, apperrors.New(apperrors.ErrInvalidParam, "level must be one of L, M, Q, H")
+	}
+
+	q, err := goqrcode.New(content, level)
+	if err != nil {
+		return nil, opts, apperrors.Wrap(apperrors.ErrInvalidParam, "failed to encode qr code", err)
+	}
+	q.DisableBorder = true
+
+	return q.Bitmap(), opts, nil
+}
@@ -1,7 +1,10 @@
 package utils
 
 import (
+	"crypto/hmac"
 	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"time"
@@ -19,6 +22,20 @@ func MD5(s string) string {
 	return hex.EncodeToString(h.Sum(nil))
 }
 
+// HMACSHA256 计算HMAC-SHA256，返回十六进制字符串
+func HMACSHA256(key, data string) string {
+	h := hmac.New(sha256.New, []byte(key))
+	h.Write([]byte(data))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// RandomHex 生成 n 字节的随机数，返回十六进制字符串
+func RandomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
 // InArray 判断元素是否在数组中
 func InArray(needle string, haystack []string) bool {
 	for _, v := range haystack {
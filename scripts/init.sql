@@ -11,6 +11,7 @@ CREATE TABLE IF NOT EXISTS `users` (
     `email` VARCHAR(100) NOT NULL UNIQUE COMMENT '邮箱',
     `api_key` VARCHAR(64) NOT NULL UNIQUE COMMENT 'API密钥',
     `api_secret` VARCHAR(128) NOT NULL COMMENT 'API秘钥',
+    `sign_key` VARCHAR(64) COMMENT '跳转参数签名密钥',
     `status` TINYINT NOT NULL DEFAULT 1 COMMENT '状态：1-启用 0-禁用',
     `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
     `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
@@ -25,11 +26,13 @@ CREATE TABLE IF NOT EXISTS `payment_configs` (
     `provider` VARCHAR(20) NOT NULL COMMENT '支付提供商：alipay/wechat/stripe/paypal',
     `config_name` VARCHAR(50) NOT NULL COMMENT '配置名称',
     `config_data` JSON NOT NULL COMMENT '配置数据（JSON格式）',
+    `account_id` VARCHAR(128) DEFAULT NULL COMMENT '提供商账号，用于识别不带配置令牌的通知',
     `status` TINYINT NOT NULL DEFAULT 1 COMMENT '状态：1-启用 0-禁用',
     `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
     `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
     INDEX `idx_user_id` (`user_id`),
     INDEX `idx_provider` (`provider`),
+    INDEX `idx_provider_account` (`provider`, `account_id`),
     INDEX `idx_status` (`status`),
     UNIQUE KEY `uk_user_provider_name` (`user_id`, `provider`, `config_name`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='支付配置表';
@@ -51,7 +54,15 @@ CREATE TABLE IF NOT EXISTS `payment_orders` (
     `notify_url` VARCHAR(512) COMMENT '异步通知URL',
     `return_url` VARCHAR(512) COMMENT '同步跳转URL',
     `client_ip` VARCHAR(45) COMMENT '客户端IP',
+    `qr_code` TEXT COMMENT '扫码支付的二维码内容',
+    `payment_data` JSON COMMENT '提供商返回的支付信息（支付链接、表单、额外数据、过期时间）',
     `extra_data` JSON COMMENT '额外数据',
+    `items` JSON COMMENT '商品明细',
+    `buyer` JSON COMMENT '买家信息',
+    `shipping` JSON COMMENT '收货信息',
+    `profit_sharing` JSON COMMENT '分账计划',
+    `payment_method_no` VARCHAR(64) DEFAULT NULL COMMENT '扣款使用的已保存支付方式单号',
+    `payment_link_no` VARCHAR(64) DEFAULT NULL COMMENT '创建订单的支付链接单号',
     `payment_time` TIMESTAMP NULL COMMENT '支付时间',
     `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
     `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
@@ -61,9 +72,298 @@ CREATE TABLE IF NOT EXISTS `payment_orders` (
     INDEX `idx_trade_no` (`trade_no`),
     INDEX `idx_status` (`status`),
     INDEX `idx_provider` (`provider`),
+    INDEX `idx_payment_method_no` (`payment_method_no`),
+    INDEX `idx_payment_link_no` (`payment_link_no`),
     INDEX `idx_created_at` (`created_at`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='支付订单表';
 
+-- 退款表
+CREATE TABLE IF NOT EXISTS `refunds` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY COMMENT '退款ID',
+    `refund_no` VARCHAR(64) NOT NULL UNIQUE COMMENT '退款单号',
+    `order_id` BIGINT UNSIGNED NOT NULL COMMENT '订单ID',
+    `order_no` VARCHAR(64) NOT NULL COMMENT '订单号',
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+    `provider` VARCHAR(20) NOT NULL COMMENT '支付提供商',
+    `config_id` BIGINT UNSIGNED NOT NULL COMMENT '支付配置ID',
+    `refund_id` VARCHAR(64) DEFAULT NULL COMMENT '第三方退款单号',
+    `amount` DECIMAL(10, 2) NOT NULL COMMENT '退款金额',
+    `currency` VARCHAR(10) NOT NULL COMMENT '货币类型',
+    `reason` VARCHAR(256) COMMENT '退款原因',
+    `status` VARCHAR(20) NOT NULL DEFAULT 'processing' COMMENT '状态：processing/success/failed',
+    `refund_time` TIMESTAMP NULL COMMENT '退款成功时间',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    INDEX `idx_order_id` (`order_id`),
+    INDEX `idx_order_no` (`order_no`),
+    INDEX `idx_user_id` (`user_id`),
+    INDEX `idx_refund_id` (`refund_id`),
+    INDEX `idx_status` (`status`),
+    INDEX `idx_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='退款表';
+
+-- 付款表
+CREATE TABLE IF NOT EXISTS `payouts` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY COMMENT '付款ID',
+    `payout_no` VARCHAR(64) NOT NULL UNIQUE COMMENT '付款单号',
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+    `out_payout_no` VARCHAR(64) NOT NULL COMMENT '商户付款单号',
+    `provider` VARCHAR(20) NOT NULL COMMENT '支付提供商',
+    `config_id` BIGINT UNSIGNED NOT NULL COMMENT '支付配置ID',
+    `payout_id` VARCHAR(64) DEFAULT NULL COMMENT '第三方付款单号',
+    `amount` DECIMAL(10, 2) NOT NULL COMMENT '付款金额',
+    `currency` VARCHAR(10) NOT NULL COMMENT '货币类型',
+    `payee_type` VARCHAR(20) COMMENT '收款方账户类型',
+    `payee_account` VARCHAR(128) NOT NULL COMMENT '收款方账户',
+    `payee_name` VARCHAR(64) COMMENT '收款方姓名',
+    `remark` VARCHAR(256) COMMENT '付款备注',
+    `notify_url` VARCHAR(512) COMMENT '商户通知URL',
+    `status` VARCHAR(20) NOT NULL DEFAULT 'processing' COMMENT '状态：processing/success/failed',
+    `fail_reason` VARCHAR(256) COMMENT '失败原因',
+    `finish_time` TIMESTAMP NULL COMMENT '付款成功时间',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    UNIQUE KEY `idx_user_out_payout` (`user_id`, `out_payout_no`),
+    INDEX `idx_user_id` (`user_id`),
+    INDEX `idx_provider` (`provider`),
+    INDEX `idx_payout_id` (`payout_id`),
+    INDEX `idx_status` (`status`),
+    INDEX `idx_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='付款表';
+
+-- 分账表
+CREATE TABLE IF NOT EXISTS `profit_sharings` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY COMMENT '分账ID',
+    `sharing_no` VARCHAR(64) NOT NULL UNIQUE COMMENT '分账单号',
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+    `out_sharing_no` VARCHAR(64) NOT NULL COMMENT '商户分账单号',
+    `order_id` BIGINT UNSIGNED NOT NULL COMMENT '订单ID',
+    `order_no` VARCHAR(64) NOT NULL COMMENT '订单号',
+    `provider` VARCHAR(20) NOT NULL COMMENT '支付提供商',
+    `config_id` BIGINT UNSIGNED NOT NULL COMMENT '支付配置ID',
+    `sharing_id` VARCHAR(64) DEFAULT NULL COMMENT '第三方分账单号',
+    `amount` DECIMAL(10, 2) NOT NULL COMMENT '分账总金额',
+    `currency` VARCHAR(10) NOT NULL COMMENT '货币类型',
+    `receivers` JSON NOT NULL COMMENT '分账接收方及结果',
+    `finish` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否完结分账（解冻剩余资金）',
+    `status` VARCHAR(20) NOT NULL DEFAULT 'processing' COMMENT '状态：processing/success/failed',
+    `fail_reason` VARCHAR(256) COMMENT '失败原因',
+    `finish_time` TIMESTAMP NULL COMMENT '分账完成时间',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    UNIQUE KEY `idx_user_out_sharing` (`user_id`, `out_sharing_no`),
+    INDEX `idx_user_id` (`user_id`),
+    INDEX `idx_order_id` (`order_id`),
+    INDEX `idx_order_no` (`order_no`),
+    INDEX `idx_status` (`status`),
+    INDEX `idx_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='分账表';
+
+-- 分账回退表
+CREATE TABLE IF NOT EXISTS `profit_sharing_returns` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY COMMENT '分账回退ID',
+    `return_no` VARCHAR(64) NOT NULL UNIQUE COMMENT '回退单号',
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+    `out_return_no` VARCHAR(64) NOT NULL COMMENT '商户回退单号',
+    `sharing_no` VARCHAR(64) NOT NULL COMMENT '分账单号',
+    `order_no` VARCHAR(64) NOT NULL COMMENT '订单号',
+    `provider` VARCHAR(20) NOT NULL COMMENT '支付提供商',
+    `config_id` BIGINT UNSIGNED NOT NULL COMMENT '支付配置ID',
+    `return_id` VARCHAR(64) DEFAULT NULL COMMENT '第三方回退单号',
+    `account` VARCHAR(128) NOT NULL COMMENT '回退的分账接收方账户',
+    `amount` DECIMAL(10, 2) NOT NULL COMMENT '回退金额',
+    `currency` VARCHAR(10) NOT NULL COMMENT '货币类型',
+    `description` VARCHAR(256) COMMENT '回退描述',
+    `status` VARCHAR(20) NOT NULL DEFAULT 'processing' COMMENT '状态：processing/success/failed',
+    `fail_reason` VARCHAR(256) COMMENT '失败原因',
+    `finish_time` TIMESTAMP NULL COMMENT '回退完成时间',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    UNIQUE KEY `idx_user_out_return` (`user_id`, `out_return_no`),
+    INDEX `idx_user_id` (`user_id`),
+    INDEX `idx_sharing_no` (`sharing_no`),
+    INDEX `idx_status` (`status`),
+    INDEX `idx_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='分账回退表';
+
+-- 订阅计划表
+CREATE TABLE IF NOT EXISTS `plans` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY COMMENT '计划ID',
+    `plan_no` VARCHAR(64) NOT NULL UNIQUE COMMENT '计划号',
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+    `out_plan_no` VARCHAR(64) NOT NULL COMMENT '商户计划号',
+    `name` VARCHAR(128) NOT NULL COMMENT '计划名称',
+    `description` VARCHAR(256) COMMENT '计划描述',
+    `amount` DECIMAL(10, 2) NOT NULL COMMENT '每期金额',
+    `currency` VARCHAR(10) NOT NULL COMMENT '货币类型',
+    `billing_interval` VARCHAR(10) NOT NULL COMMENT '计费周期：day/week/month/year',
+    `interval_count` INT NOT NULL DEFAULT 1 COMMENT '每期包含的周期数',
+    `trial_days` INT NOT NULL DEFAULT 0 COMMENT '试用天数',
+    `status` VARCHAR(20) NOT NULL DEFAULT 'active' COMMENT '状态：active/inactive',
+    `provider_plans` JSON COMMENT '提供商侧计划ID，键为 provider:config_id',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    UNIQUE KEY `idx_user_out_plan` (`user_id`, `out_plan_no`),
+    INDEX `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='订阅计划表';
+
+-- 订阅表
+CREATE TABLE IF NOT EXISTS `subscriptions` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY COMMENT '订阅ID',
+    `subscription_no` VARCHAR(64) NOT NULL UNIQUE COMMENT '订阅单号',
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+    `out_subscription_no` VARCHAR(64) NOT NULL COMMENT '商户订阅号',
+    `plan_id` BIGINT UNSIGNED NOT NULL COMMENT '计划ID',
+    `plan_no` VARCHAR(64) NOT NULL COMMENT '计划号',
+    `provider` VARCHAR(20) NOT NULL COMMENT '支付提供商',
+    `config_id` BIGINT UNSIGNED NOT NULL COMMENT '支付配置ID',
+    `subscription_id` VARCHAR(128) DEFAULT NULL COMMENT '第三方订阅ID（支付宝为签约协议号）',
+    `amount` DECIMAL(10, 2) NOT NULL COMMENT '每期金额',
+    `currency` VARCHAR(10) NOT NULL COMMENT '货币类型',
+    `billing_interval` VARCHAR(10) NOT NULL COMMENT '计费周期：day/week/month/year',
+    `interval_count` INT NOT NULL DEFAULT 1 COMMENT '每期包含的周期数',
+    `trial_days` INT NOT NULL DEFAULT 0 COMMENT '试用天数',
+    `status` VARCHAR(20) NOT NULL DEFAULT 'pending' COMMENT '状态：pending/trialing/active/past_due/canceled',
+    `payment_url` TEXT COMMENT '买家签约或授权页面',
+    `trial_end` TIMESTAMP NULL COMMENT '试用结束时间',
+    `current_period_start` TIMESTAMP NULL COMMENT '当前周期开始时间',
+    `current_period_end` TIMESTAMP NULL COMMENT '当前周期结束时间',
+    `next_billing_time` TIMESTAMP NULL COMMENT '下次处理时间（扣款、重试或同步）',
+    `billing_day` INT NOT NULL DEFAULT 0 COMMENT '按月、按年计费的账单日，0 表示尚未开始续费',
+    `cycles` INT NOT NULL DEFAULT 0 COMMENT '已扣款期数',
+    `retry_count` INT NOT NULL DEFAULT 0 COMMENT '本期扣款失败次数',
+    `last_order_no` VARCHAR(64) COMMENT '处理中的续费订单号',
+    `notify_url` VARCHAR(512) COMMENT '商户通知URL',
+    `return_url` VARCHAR(512) COMMENT '签约完成后的跳转地址',
+    `fail_reason` VARCHAR(256) COMMENT '失败或取消原因',
+    `canceled_at` TIMESTAMP NULL COMMENT '取消时间',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    UNIQUE KEY `idx_user_out_subscription` (`user_id`, `out_subscription_no`),
+    INDEX `idx_user_id` (`user_id`),
+    INDEX `idx_plan_id` (`plan_id`),
+    INDEX `idx_subscription_id` (`subscription_id`),
+    INDEX `idx_status_next_billing` (`status`, `next_billing_time`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='订阅表';
+
+-- 客户表
+CREATE TABLE IF NOT EXISTS `customers` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY COMMENT '客户ID',
+    `customer_no` VARCHAR(64) NOT NULL UNIQUE COMMENT '客户单号',
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+    `out_customer_no` VARCHAR(64) NOT NULL COMMENT '商户客户号',
+    `name` VARCHAR(128) COMMENT '客户姓名',
+    `email` VARCHAR(128) COMMENT '客户邮箱',
+    `phone` VARCHAR(32) COMMENT '客户手机号',
+    `provider_customers` JSON DEFAULT NULL COMMENT '第三方客户ID，键为 provider:config_id',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    UNIQUE KEY `idx_user_out_customer` (`user_id`, `out_customer_no`),
+    INDEX `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='客户表';
+
+-- 支付方式表
+CREATE TABLE IF NOT EXISTS `payment_methods` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY COMMENT '支付方式ID',
+    `method_no` VARCHAR(64) NOT NULL UNIQUE COMMENT '支付方式单号',
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+    `customer_id` BIGINT UNSIGNED NOT NULL COMMENT '客户ID',
+    `customer_no` VARCHAR(64) NOT NULL COMMENT '客户单号',
+    `provider` VARCHAR(20) NOT NULL COMMENT '支付提供商',
+    `config_id` BIGINT UNSIGNED NOT NULL COMMENT '支付配置ID',
+    `setup_id` VARCHAR(128) COMMENT '第三方绑定流程ID',
+    `method_id` VARCHAR(128) COMMENT '第三方支付方式ID（支付宝为代扣协议号）',
+    `type` VARCHAR(20) COMMENT '类型：card/paypal/alipay',
+    `brand` VARCHAR(20) COMMENT '卡组织',
+    `last4` VARCHAR(4) COMMENT '卡号后四位',
+    `exp_month` INT NOT NULL DEFAULT 0 COMMENT '有效期月',
+    `exp_year` INT NOT NULL DEFAULT 0 COMMENT '有效期年',
+    `account` VARCHAR(128) COMMENT '账户标识（PayPal 邮箱、支付宝登录号）',
+    `status` VARCHAR(20) NOT NULL DEFAULT 'pending' COMMENT '状态：pending/active/canceled',
+    `setup_url` TEXT COMMENT '买家绑定页面',
+    `notify_url` VARCHAR(512) COMMENT '商户通知URL',
+    `return_url` VARCHAR(512) COMMENT '绑定完成后的跳转地址',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    INDEX `idx_user_id` (`user_id`),
+    INDEX `idx_customer_id` (`customer_id`),
+    INDEX `idx_method_id` (`method_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='支付方式表';
+
+-- 托管收银台表
+CREATE TABLE IF NOT EXISTS `checkouts` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY COMMENT '收银台ID',
+    `checkout_no` VARCHAR(64) NOT NULL UNIQUE COMMENT '收银台单号',
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+    `out_trade_no` VARCHAR(64) NOT NULL COMMENT '商户订单号，买家选择后创建的订单使用同一订单号',
+    `subject` VARCHAR(256) NOT NULL COMMENT '订单标题',
+    `body` TEXT COMMENT '订单描述',
+    `amount` DECIMAL(10,2) NOT NULL COMMENT '金额',
+    `currency` VARCHAR(10) NOT NULL DEFAULT 'CNY' COMMENT '货币',
+    `providers` JSON COMMENT '允许的提供商，为空时为所有启用的提供商',
+    `provider` VARCHAR(20) COMMENT '买家选择的提供商',
+    `order_no` VARCHAR(64) COMMENT '买家选择后创建的订单号',
+    `status` VARCHAR(20) NOT NULL DEFAULT 'open' COMMENT '状态：open/paying/expired',
+    `checkout_url` VARCHAR(512) COMMENT '收银台地址',
+    `payment_url` TEXT COMMENT '所选提供商的支付链接',
+    `qr_code` TEXT COMMENT '所选提供商的二维码内容',
+    `notify_url` VARCHAR(512) COMMENT '商户通知URL',
+    `return_url` VARCHAR(512) COMMENT '支付成功后的跳转地址',
+    `expire_at` TIMESTAMP NULL COMMENT '过期时间',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    UNIQUE KEY `idx_user_out_checkout` (`user_id`, `out_trade_no`),
+    INDEX `idx_user_id` (`user_id`),
+    INDEX `idx_order_no` (`order_no`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='托管收银台表';
+
+-- 支付链接表
+CREATE TABLE IF NOT EXISTS `payment_links` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY COMMENT '支付链接ID',
+    `link_no` VARCHAR(64) NOT NULL UNIQUE COMMENT '支付链接单号',
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+    `subject` VARCHAR(256) NOT NULL COMMENT '标题',
+    `description` TEXT COMMENT '描述',
+    `amount` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '固定金额，买家填写金额时为0',
+    `custom_amount` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否由买家填写金额',
+    `currency` VARCHAR(10) NOT NULL DEFAULT 'CNY' COMMENT '货币',
+    `providers` JSON COMMENT '允许的提供商，为空时为所有启用的提供商',
+    `expire_at` TIMESTAMP NULL COMMENT '过期时间',
+    `max_uses` INT NOT NULL DEFAULT 0 COMMENT '最大支付次数，0为不限',
+    `paid_count` INT NOT NULL DEFAULT 0 COMMENT '支付成功次数',
+    `paid_amount` DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT '支付成功总金额',
+    `status` VARCHAR(20) NOT NULL DEFAULT 'active' COMMENT '状态：active/inactive/completed',
+    `link_url` VARCHAR(512) COMMENT '支付链接地址',
+    `notify_url` VARCHAR(512) COMMENT '商户通知URL',
+    `return_url` VARCHAR(512) COMMENT '支付完成后的跳转地址',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    INDEX `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='支付链接表';
+
+-- 争议（拒付）表
+CREATE TABLE IF NOT EXISTS `disputes` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY COMMENT '争议ID',
+    `order_id` BIGINT UNSIGNED NOT NULL COMMENT '订单ID',
+    `order_no` VARCHAR(64) NOT NULL COMMENT '订单号',
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+    `provider` VARCHAR(20) NOT NULL COMMENT '支付提供商',
+    `dispute_id` VARCHAR(64) NOT NULL COMMENT '第三方争议ID',
+    `reason` VARCHAR(64) COMMENT '争议原因',
+    `amount` DECIMAL(10, 2) NOT NULL COMMENT '争议金额',
+    `currency` VARCHAR(10) NOT NULL COMMENT '货币类型',
+    `status` VARCHAR(20) NOT NULL COMMENT '状态：needs_response/under_review/won/lost/closed',
+    `evidence_due_by` TIMESTAMP NULL COMMENT '提交证据截止时间',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    UNIQUE KEY `idx_provider_dispute` (`provider`, `dispute_id`),
+    INDEX `idx_order_id` (`order_id`),
+    INDEX `idx_order_no` (`order_no`),
+    INDEX `idx_user_id` (`user_id`),
+    INDEX `idx_status` (`status`),
+    INDEX `idx_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='争议表';
+
 -- 支付日志表
 CREATE TABLE IF NOT EXISTS `payment_logs` (
     `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY COMMENT '日志ID',